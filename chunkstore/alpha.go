@@ -20,6 +20,9 @@ type chunkStoreAlpha struct {
 func newChunkStoreAlpha(worldPath string, dimension DimensionId) (s *chunkStoreAlpha, err error) {
 	// Don't know the dimension directory structure for alpha, but it's likely
 	// not worth writing support for.
+	if dimension != DimensionNormal {
+		return nil, fmt.Errorf("dimension %d is not supported in alpha worlds", dimension)
+	}
 
 	s = &chunkStoreAlpha{
 		worldPath: worldPath,
@@ -21,16 +21,18 @@ type IChunkStoreForeground interface {
 // ChunkService adapts an IChunkStoreForeground (which can only be accessed
 // from one goroutine) to an IChunkStore.
 type ChunkService struct {
-	store  IChunkStoreForeground
-	reads  chan readRequest
-	writes chan IChunkWriter
+	store   IChunkStoreForeground
+	reads   chan readRequest
+	writes  chan IChunkWriter
+	flushes chan chan bool
 }
 
 func NewChunkService(store IChunkStoreForeground) (s *ChunkService) {
 	return &ChunkService{
-		store:  store,
-		reads:  make(chan readRequest),
-		writes: make(chan IChunkWriter),
+		store:   store,
+		reads:   make(chan readRequest),
+		writes:  make(chan IChunkWriter),
+		flushes: make(chan chan bool),
 	}
 }
 
@@ -44,6 +46,13 @@ func (s *ChunkService) Serve() {
 			if err := s.store.WriteChunk(writer); err != nil {
 				log.Printf("Could not write chunk at %#v: %v", writer.ChunkLoc(), err)
 			}
+		case done := <-s.flushes:
+			// Writes are performed in order, so all writes submitted before the
+			// flush have been performed by now.
+			if flusher, ok := s.store.(IChunkStoreFlusher); ok {
+				flusher.Flush()
+			}
+			close(done)
 		}
 	}
 }
@@ -70,3 +79,9 @@ func (s *ChunkService) Writer() IChunkWriter {
 func (s *ChunkService) WriteChunk(writer IChunkWriter) {
 	s.writes <- writer
 }
+
+func (s *ChunkService) Flush() {
+	done := make(chan bool)
+	s.flushes <- done
+	<-done
+}
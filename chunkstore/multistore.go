@@ -60,3 +60,11 @@ func (s *MultiStore) WriteChunk(writer IChunkWriter) error {
 	s.writeStore.WriteChunk(writer)
 	return nil
 }
+
+// Flush implements IChunkStoreFlusher, as writes are submitted to the write
+// store, which might not have performed them yet.
+func (s *MultiStore) Flush() {
+	if s.writeStore != nil {
+		s.writeStore.Flush()
+	}
+}
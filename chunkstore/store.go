@@ -24,6 +24,18 @@ type IChunkStore interface {
 	// Submits the set chunk data for writing. The chunk writer must not be
 	// altered any further after calling this.
 	WriteChunk(writer IChunkWriter)
+
+	// Flush returns once all chunks submitted by WriteChunk beforehand have
+	// been written.
+	Flush()
+}
+
+// IChunkStoreFlusher is implemented by IChunkStoreForeground implementations
+// whose WriteChunk returns before the chunk has been written.
+type IChunkStoreFlusher interface {
+	// Flush returns once all chunks passed to WriteChunk beforehand have been
+	// written.
+	Flush()
 }
 
 type IChunkReader interface {
@@ -8,9 +8,12 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/huin/chunkymonkey/game"
 	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/shardserver"
 	"github.com/huin/chunkymonkey/worldstore"
 )
 
@@ -54,6 +57,14 @@ var groupDefs = flag.String(
 	"groups", "groups.json",
 	"The JSON file containing group permissions.")
 
+var shardServerAddr = flag.String(
+	"shard_server_addr", "",
+	"If set, chunk shards are served by the shardserver at the given address:port instead of in this process.")
+
+var shardLookupUrl = flag.String(
+	"shard_lookup_url", "",
+	"If set, chunk shards are served by the shardservers given by the shardlookup service at this URL.")
+
 // TODO Implement max player count enforcement. Probably would have to be
 // implemented atomically at the game level.
 var maxPlayerCount = flag.Int(
@@ -114,7 +125,14 @@ func main() {
 		log.Fatal(err)
 	}
 
-	game, err := chunkymonkey.NewGame(worldPath, listener, *serverDesc, *maintenanceMsg, *maxPlayerCount)
+	var shardConnecter gamerules.IShardConnecter
+	if *shardLookupUrl != "" {
+		shardConnecter = shardserver.NewLookupShardConnecter(*shardLookupUrl, "", nil)
+	} else if *shardServerAddr != "" {
+		shardConnecter = shardserver.NewRemoteShardConnecter(*shardServerAddr)
+	}
+
+	game, err := chunkymonkey.NewGame(worldPath, listener, *serverDesc, *maintenanceMsg, *maxPlayerCount, shardConnecter)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -124,5 +142,20 @@ func main() {
 		log.Fatal(err)
 	}
 
+	go shutdownOnSignal(game)
+
 	game.Serve()
 }
+
+// shutdownOnSignal saves the game upon receiving an interrupt or termination
+// signal, and exits.
+func shutdownOnSignal(game *chunkymonkey.Game) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	log.Print("Shutting down")
+	game.Shutdown()
+
+	os.Exit(0)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	_ "expvar"
+	"flag"
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"time"
+
+	"github.com/huin/chunkymonkey/shardserver"
+)
+
+var addr = flag.String(
+	"addr", ":25569",
+	"Serves the lookup service and HTTP diagnostics on the given address:port.")
+
+var hostTimeoutSecs = flag.Int(
+	"host_timeout_secs", 30,
+	"Shard hosts that have not re-registered for this many seconds have their shards reassigned.")
+
+func usage() {
+	os.Stderr.WriteString("usage: " + os.Args[0] + " [flags]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	lookup := shardserver.NewLookupServer(time.Duration(*hostTimeoutSecs) * time.Second)
+	http.Handle("/register", lookup)
+	http.Handle("/leave", lookup)
+	http.Handle("/left", lookup)
+	http.Handle("/lookup", lookup)
+	http.Handle("/assignments", lookup)
+
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	_ "expvar"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/huin/chunkymonkey/entity"
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/shardserver"
+	"github.com/huin/chunkymonkey/worldstore"
+)
+
+var addr = flag.String(
+	"addr", ":25567",
+	"Serves shards on the given address:port.")
+
+var httpAddr = flag.String(
+	"http_addr", ":25568",
+	"Serves HTTP diagnostics on the given address:port.")
+
+var lookupUrl = flag.String(
+	"lookup_url", "",
+	"If set, registers with the shardlookup service at this URL, and serves only the shards it assigns.")
+
+var advertiseAddr = flag.String(
+	"advertise_addr", "",
+	"The address:port that other servers connect to this server on. Required with lookup_url.")
+
+var entityIdBlock = flag.Int(
+	"entity_id_block", 1,
+	"The block of entity IDs that this server allocates from. The frontend uses block 0, and each shard server must use a different block.")
+
+var blockDefs = flag.String(
+	"blocks", "blocks.json",
+	"The JSON file containing block type definitions.")
+
+var itemDefs = flag.String(
+	"items", "items.json",
+	"The JSON file containing item type definitions.")
+
+var recipeDefs = flag.String(
+	"recipes", "recipes.json",
+	"The JSON file containing recipe definitions.")
+
+var furnaceDefs = flag.String(
+	"furnace", "furnace.json",
+	"The JSON file containing furnace fuel and reaction definitions.")
+
+var userDefs = flag.String(
+	"users", "users.json",
+	"The JSON file container user permissions.")
+
+var groupDefs = flag.String(
+	"groups", "groups.json",
+	"The JSON file containing group permissions.")
+
+func usage() {
+	os.Stderr.WriteString("usage: " + os.Args[0] + " [flags] <world>\n")
+	flag.PrintDefaults()
+}
+
+func startHttpServer(addr string) (err error) {
+	httpPort, err := net.Listen("tcp", addr)
+	if err != nil {
+		return
+	}
+	go http.Serve(httpPort, nil)
+	return
+}
+
+func main() {
+	var err error
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	err = gamerules.LoadGameRules(*blockDefs, *itemDefs, *recipeDefs, *furnaceDefs, *userDefs, *groupDefs)
+	if err != nil {
+		log.Print("Error loading game rules: ", err)
+		os.Exit(1)
+	}
+
+	worldPath := flag.Arg(0)
+	worldStore, err := worldstore.LoadWorldStore(worldPath)
+	if err != nil {
+		log.Printf("Error loading world %v: %v", worldPath, err)
+		os.Exit(1)
+	}
+
+	if *entityIdBlock < 1 || *entityIdBlock >= entity.NumEntityIdBlocks {
+		log.Printf("entity_id_block must be in the range [1, %d)", entity.NumEntityIdBlocks)
+		os.Exit(1)
+	}
+	var entityManager entity.EntityManager
+	entityManager.InitBlock(*entityIdBlock)
+
+	shardManager := shardserver.NewLocalShardManager(worldStore.ChunkStore, &entityManager)
+	shardManager.SetSeed(worldStore.Seed)
+
+	var registration *shardserver.LookupRegistration
+	if *lookupUrl != "" {
+		if *advertiseAddr == "" {
+			log.Print("advertise_addr is required with lookup_url")
+			os.Exit(1)
+		}
+		shardManager.SetRemoteConnecter(
+			shardserver.NewLookupShardConnecter(*lookupUrl, *advertiseAddr, shardManager))
+		registration = shardserver.NewLookupRegistration(*lookupUrl, *advertiseAddr, shardManager)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = startHttpServer(*httpAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if registration != nil {
+		go registration.Serve()
+	}
+
+	go shutdownOnSignal(listener, shardManager, registration)
+
+	server := shardserver.NewShardServer(listener, shardManager)
+	if err = server.Serve(); err != nil {
+		log.Print(err)
+	}
+
+	// Wait for shutdown to complete.
+	select {}
+}
+
+// shutdownOnSignal stops accepting connections and saves all chunks upon
+// receiving an interrupt or termination signal, handing over the shards to
+// other hosts if registered with a lookup service.
+func shutdownOnSignal(listener net.Listener, shardManager *shardserver.LocalShardManager, registration *shardserver.LookupRegistration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	log.Print("Shutting down")
+	listener.Close()
+
+	if registration != nil {
+		if err := registration.Leave(); err != nil {
+			log.Printf("Error handing over shards: %v", err)
+		}
+	} else {
+		shardManager.Close()
+	}
+
+	os.Exit(0)
+}
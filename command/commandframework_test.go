@@ -1,20 +1,42 @@
 package command
 
 import (
+	"strings"
 	"testing"
 
 	"code.google.com/p/gomock/gomock"
 
 	"github.com/huin/chunkymonkey/gamerules"
 	"github.com/huin/chunkymonkey/gamerules_mock"
+	"github.com/huin/chunkymonkey/permission"
 	"github.com/huin/chunkymonkey/testmatcher"
+	. "github.com/huin/chunkymonkey/types"
 )
 
+const (
+	testUsersJson = `{
+		"admin": {"groups": ["admin"]}
+	}`
+	testGroupsJson = `{
+		"default": {"default": true, "permissions": ["login"]},
+		"admin": {"inheritance": ["default"], "permissions": ["admin.commands.gamemode"]}
+	}`
+)
+
+func setTestPermissions(t *testing.T) {
+	permissions, err := permission.LoadJsonPermission(strings.NewReader(testUsersJson), strings.NewReader(testGroupsJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gamerules.Permissions = permissions
+}
+
 func TestCommandFramework(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
+	setTestPermissions(t)
 
-	itemType1 := gamerules.ItemType{1, "1", 64, 0, 0}
+	itemType1 := gamerules.ItemType{1, "1", 64, 0, 0, 0, 0, 0, 0, 0}
 
 	mockGame := gamerules_mock.NewMockIGame(mockCtrl)
 	mockPlayer := gamerules_mock.NewMockIPlayerClient(mockCtrl)
@@ -45,6 +67,19 @@ func TestCommandFramework(t *testing.T) {
 	mockPlayer.EXPECT().EchoMessage("Cannot give more than 512 items at once")
 	cf.Process(mockPlayer, "/give otherPlayer 1 513", mockGame)
 
+	mockPlayer.EXPECT().GetName().Return("admin").Times(2)
+	mockGame.EXPECT().PlayerByName("otherPlayer").Return(mockOther)
+	mockPlayer.EXPECT().EchoMessage("Setting otherPlayer to creative mode")
+	mockOther.EXPECT().SetGameType(GameTypeCreative)
+	cf.Process(mockPlayer, "/gamemode otherPlayer 1", mockGame)
+
+	mockGame.EXPECT().PlayerByName("otherPlayer").Return(mockOther)
+	mockPlayer.EXPECT().EchoMessage(gameModeUsage)
+	cf.Process(mockPlayer, "/gamemode otherPlayer 2", mockGame)
+
+	mockPlayer.EXPECT().Hurt(killDamage)
+	cf.Process(mockPlayer, "/kill", mockGame)
+
 	mockPlayer.EXPECT().EchoMessage(&testmatcher.StringPrefix{"Commands:"})
 	cf.Process(mockPlayer, "/help", mockGame)
 
@@ -55,3 +90,19 @@ func TestCommandFramework(t *testing.T) {
 	)
 	cf.Process(mockPlayer, "/help help", mockGame)
 }
+
+func TestGameModeRequiresPermission(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	setTestPermissions(t)
+
+	mockGame := gamerules_mock.NewMockIGame(mockCtrl)
+	mockPlayer := gamerules_mock.NewMockIPlayerClient(mockCtrl)
+
+	cf := NewCommandFramework("/")
+
+	// The player is refused without looking up the target.
+	mockPlayer.EXPECT().GetName().Return("someone")
+	mockPlayer.EXPECT().EchoMessage(msgNoPermission)
+	cf.Process(mockPlayer, "/gamemode someone 1", mockGame)
+}
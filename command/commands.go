@@ -17,11 +17,13 @@ func getCommands() map[string]*Command {
 	cmds[killCmd] = NewCommand(killCmd, killDesc, killUsage, cmdKill)
 	cmds[tellCmd] = NewCommand(tellCmd, tellDesc, tellUsage, cmdTell)
 	cmds[giveCmd] = NewCommand(giveCmd, giveDesc, giveUsage, cmdGive)
+	cmds[gameModeCmd] = NewCommand(gameModeCmd, gameModeDesc, gameModeUsage, cmdGameMode)
 	return cmds
 }
 
 const msgNotImplemented = "We are sorry. This command is not yet implemented."
 const msgUnknownItem = "Unknown item ID"
+const msgNoPermission = "You do not have permission to use this command."
 
 // say message
 const sayCmd = "say"
@@ -82,9 +84,11 @@ const killCmd = "kill"
 const killUsage = "kill"
 const killDesc = "Inflicts damage to self. Useful when lost or stuck."
 
+// Enough damage to kill any player.
+const killDamage = Health(1000)
+
 func cmdKill(player gamerules.IPlayerClient, message string, cmdHandler gamerules.IGame) {
-	// TODO inflict damage to player
-	player.EchoMessage(msgNotImplemented)
+	player.Hurt(killDamage)
 }
 
 // /tell player message
@@ -222,3 +226,43 @@ func cmdGive(player gamerules.IPlayerClient, message string, cmdHandler gamerule
 		target.EchoMessage(msg)
 	}
 }
+
+const gameModeCmd = "gamemode"
+const gameModeUsage = "gamemode <player> <0|1>"
+const gameModeDesc = "Sets a player's game mode to survival (0) or creative (1)."
+const gameModePermission = "admin.commands.gamemode"
+
+func cmdGameMode(player gamerules.IPlayerClient, message string, cmdHandler gamerules.IGame) {
+	if !gamerules.Permissions.UserPermissions(player.GetName()).Has(gameModePermission) {
+		player.EchoMessage(msgNoPermission)
+		return
+	}
+
+	args := strings.Split(message, " ")
+	if len(args) != 3 {
+		player.EchoMessage(gameModeUsage)
+		return
+	}
+
+	target := cmdHandler.PlayerByName(args[1])
+	if target == nil {
+		msg := fmt.Sprintf("'%s' is not logged in", args[1])
+		player.EchoMessage(msg)
+		return
+	}
+
+	var gameType GameType
+	var modeName string
+	switch args[2] {
+	case "0":
+		gameType, modeName = GameTypeSurvival, "survival"
+	case "1":
+		gameType, modeName = GameTypeCreative, "creative"
+	default:
+		player.EchoMessage(gameModeUsage)
+		return
+	}
+
+	player.EchoMessage(fmt.Sprintf("Setting %s to %s mode", args[1], modeName))
+	target.SetGameType(gameType)
+}
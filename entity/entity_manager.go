@@ -6,17 +6,43 @@ import (
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// The EntityId space is split into blocks, so that processes which exchange
+// entities (the frontend and each shard server) can allocate IDs without
+// colliding, provided that each allocates from a different block.
+const (
+	entityIdBlockBits = 24
+	entityIdBlockSize = 1 << entityIdBlockBits
+
+	// NumEntityIdBlocks is the number of blocks that the EntityId space is
+	// split into.
+	NumEntityIdBlocks = 1 << (31 - entityIdBlockBits)
+)
+
 type EntityManager struct {
-	nextEntityId EntityId
-	entities     map[EntityId]bool
-	lock         sync.Mutex
+	firstEntityId EntityId
+	nextEntityId  EntityId
+	entities      map[EntityId]bool
+	lock          sync.Mutex
 }
 
+// Init initializes the manager to allocate IDs from the first block, which
+// belongs to the frontend.
 func (mgr *EntityManager) Init() {
+	mgr.InitBlock(0)
+}
+
+// InitBlock initializes the manager to allocate IDs from the given block,
+// which must be in the range [0, NumEntityIdBlocks).
+func (mgr *EntityManager) InitBlock(block int) {
 	mgr.lock.Lock()
 	defer mgr.lock.Unlock()
 
-	mgr.nextEntityId = 0
+	if block < 0 || block >= NumEntityIdBlocks {
+		panic("EntityId block out of range")
+	}
+
+	mgr.firstEntityId = EntityId(block) << entityIdBlockBits
+	mgr.nextEntityId = mgr.firstEntityId
 	mgr.entities = make(map[EntityId]bool)
 }
 
@@ -26,6 +52,9 @@ func (mgr *EntityManager) createEntityId() EntityId {
 	_, exists := mgr.entities[entityId]
 	for exists {
 		entityId++
+		if entityId == mgr.firstEntityId+entityIdBlockSize {
+			entityId = mgr.firstEntityId
+		}
 		if entityId == mgr.nextEntityId {
 			// TODO Better handling of this? It shouldn't happen, realistically - but
 			// neither should it explode.
@@ -34,6 +63,9 @@ func (mgr *EntityManager) createEntityId() EntityId {
 		_, exists = mgr.entities[entityId]
 	}
 	mgr.nextEntityId = entityId + 1
+	if mgr.nextEntityId == mgr.firstEntityId+entityIdBlockSize {
+		mgr.nextEntityId = mgr.firstEntityId
+	}
 
 	return entityId
 }
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestEntityIdBlocksDontOverlap(t *testing.T) {
+	var frontend, shardServer EntityManager
+	frontend.Init()
+	shardServer.InitBlock(1)
+
+	ids := make(map[EntityId]bool)
+	for i := 0; i < 100; i++ {
+		for _, mgr := range []*EntityManager{&frontend, &shardServer} {
+			id := mgr.NewEntity()
+			if ids[id] {
+				t.Fatalf("EntityId %d allocated twice", id)
+			}
+			ids[id] = true
+		}
+	}
+}
+
+func TestEntityIdsWrapWithinBlock(t *testing.T) {
+	var mgr EntityManager
+	mgr.InitBlock(NumEntityIdBlocks - 1)
+
+	first := mgr.NewEntity()
+	mgr.nextEntityId = first + entityIdBlockSize - 1
+	if id := mgr.NewEntity(); id != first+entityIdBlockSize-1 {
+		t.Errorf("Expected last ID in block %d, got %d", first+entityIdBlockSize-1, id)
+	}
+	// The first ID is still in use, so the next ID wraps around past it.
+	if id := mgr.NewEntity(); id != first+1 {
+		t.Errorf("Expected ID to wrap around to %d, got %d", first+1, id)
+	}
+}
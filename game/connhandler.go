@@ -12,7 +12,6 @@ import (
 	"github.com/huin/chunkymonkey/player"
 	"github.com/huin/chunkymonkey/proto"
 	"github.com/huin/chunkymonkey/server_auth"
-	"github.com/huin/chunkymonkey/shardserver"
 	. "github.com/huin/chunkymonkey/types"
 	"github.com/huin/chunkymonkey/worldstore"
 )
@@ -45,7 +44,7 @@ type GameInfo struct {
 	serverDesc     string
 	maintenanceMsg string
 	serverId       string
-	shardManager   *shardserver.LocalShardManager
+	shardManagers  map[DimensionId]gamerules.IShardConnecter
 	entityManager  *EntityManager
 	worldStore     *worldstore.WorldStore
 	authserver     server_auth.IAuthenticator
@@ -212,7 +211,7 @@ func (l *pktHandler) handleLogin(conn net.Conn) (err, clientErr error) {
 		return
 	}
 
-	player := player.NewPlayer(entityId, l.gameInfo.shardManager, conn, l.username, l.gameInfo.worldStore.SpawnPosition, l.gameInfo.game.playerDisconnect, l.gameInfo.game)
+	player := player.NewPlayer(entityId, l.gameInfo.shardManagers, conn, l.username, l.gameInfo.worldStore.SpawnPosition, l.gameInfo.worldStore.GameType, l.gameInfo.game.playerDisconnect, l.gameInfo.game)
 	if playerData != nil {
 		if err = player.UnmarshalNbt(playerData); err != nil {
 			// Don't let the player log in, as they will only have default inventory
@@ -289,6 +288,9 @@ func (l *pktHandler) PacketWindowClick(windowId WindowId, slot SlotId, rightClic
 
 func (l *pktHandler) PacketWindowTransaction(windowId WindowId, txId TxId, accepted bool) {}
 
+func (l *pktHandler) PacketQuickbarSlotUpdate(slot SlotId, itemId ItemTypeId, count ItemCount, data ItemData) {
+}
+
 func (l *pktHandler) PacketSignUpdate(position *BlockXyz, lines [4]string) {}
 
 func (l *pktHandler) PacketDisconnect(reason string) {}
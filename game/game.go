@@ -2,11 +2,14 @@ package chunkymonkey
 
 import (
 	"bytes"
+	"expvar"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"net"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/huin/chunkymonkey/command"
@@ -25,8 +28,25 @@ import (
 // That is: characters that might be abused in filename components, etc.
 var validPlayerUsername = regexp.MustCompile(`^[\-a-zA-Z0-9_]+$`)
 
+var playerSaveIntervalSecs = flag.Int(
+	"player_save_interval_secs", 60,
+	"Number of seconds between saves of the data of all online players. Zero "+
+		"disables saving other than when players disconnect.")
+
+var (
+	expVarPlayerSaveCount     *expvar.Int
+	expVarPlayerSaveFailCount *expvar.Int
+	expVarPlayerSaveTimeNs    *expvar.Int
+)
+
+func init() {
+	expVarPlayerSaveCount = expvar.NewInt("player-save-count")
+	expVarPlayerSaveFailCount = expvar.NewInt("player-save-fail-count")
+	expVarPlayerSaveTimeNs = expvar.NewInt("player-save-time-ns")
+}
+
 type Game struct {
-	shardManager  *shardserver.LocalShardManager
+	shardManagers map[DimensionId]gamerules.IShardConnecter // One per dimension hosted.
 	entityManager EntityManager
 	worldStore    *worldstore.WorldStore
 	connHandler   *ConnHandler
@@ -40,13 +60,18 @@ type Game struct {
 	playerConnect    chan *player.Player
 	playerDisconnect chan EntityId
 
+	// Held while saving player data, so that an older snapshot of a player
+	// can't overwrite a newer one.
+	playerSaveLock sync.Mutex
+	playerSaves    chan playerSave // Players queued for saveQueuedPlayers.
+
 	// Server information
 	time           Ticks
 	serverId       string
 	maintenanceMsg string // if set, logins are disallowed.
 }
 
-func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg string, maxPlayerCount int) (game *Game, err error) {
+func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg string, maxPlayerCount int, shardConnecter gamerules.IShardConnecter) (game *Game, err error) {
 	worldStore, err := worldstore.LoadWorldStore(worldPath)
 	if err != nil {
 		return nil, err
@@ -65,6 +90,8 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
 		playerDisconnect: make(chan EntityId),
 		time:             worldStore.Time,
 		worldStore:       worldStore,
+		shardManagers:    make(map[DimensionId]gamerules.IShardConnecter),
+		playerSaves:      make(chan playerSave, 256),
 	}
 
 	game.entityManager.Init()
@@ -72,7 +99,19 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
 	game.serverId = fmt.Sprintf("%016x", rand.NewSource(worldStore.Seed).Int63())
 	//game.serverId = "-"
 
-	game.shardManager = shardserver.NewLocalShardManager(worldStore.ChunkStore, &game.entityManager)
+	if shardConnecter != nil {
+		// TODO Host the Nether on remote shard servers.
+		game.shardManagers[DimensionNormal] = shardConnecter
+	} else {
+		normalShardManager := shardserver.NewLocalShardManager(worldStore.ChunkStore, &game.entityManager)
+		normalShardManager.SetSeed(worldStore.Seed)
+		game.shardManagers[DimensionNormal] = normalShardManager
+		if worldStore.NetherChunkStore != nil {
+			netherShardManager := shardserver.NewLocalShardManager(worldStore.NetherChunkStore, &game.entityManager)
+			netherShardManager.SetSeed(worldStore.Seed)
+			game.shardManagers[DimensionNether] = netherShardManager
+		}
+	}
 
 	// TODO: Load the prefix from a config file
 	gamerules.CommandFramework = command.NewCommandFramework("/")
@@ -84,7 +123,7 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
 		serverDesc:     serverDesc,
 		maintenanceMsg: maintenanceMsg,
 		serverId:       game.serverId,
-		shardManager:   game.shardManager,
+		shardManagers:  game.shardManagers,
 		entityManager:  &game.entityManager,
 		worldStore:     game.worldStore,
 		authserver:     authserver,
@@ -93,10 +132,33 @@ func NewGame(worldPath string, listener net.Listener, serverDesc, maintenanceMsg
 	return
 }
 
+// Shutdown saves the game state before the process exits. The data of all
+// online players is saved, and all changed chunks in locally hosted shards
+// are written, regardless of the save schedule.
+func (game *Game) Shutdown() {
+	log.Print("Saving players")
+	done := make(chan bool)
+	game.enqueue(func(_ *Game) {
+		// Saved after the players queued before, such as those that have
+		// disconnected.
+		game.playerSaves <- playerSave{game.onlinePlayers(), done}
+	})
+	<-done
+
+	for dimension, shardManager := range game.shardManagers {
+		if mgr, ok := shardManager.(*shardserver.LocalShardManager); ok {
+			log.Printf("Saving chunks in dimension %d", dimension)
+			mgr.SaveAll()
+		}
+	}
+}
+
 // Fetch external events and respond appropriately.
 func (game *Game) Serve() {
 	defer game.connHandler.Stop()
 
+	go game.saveQueuedPlayers()
+
 	ticker := time.NewTicker(NanosecondsInSecond / TicksPerSecond)
 
 	for {
@@ -126,14 +188,9 @@ func (game *Game) onPlayerDisconnect(entityId EntityId) {
 	delete(game.playerNames, oldPlayer.Name())
 	game.entityManager.RemoveEntityById(entityId)
 
-	playerData := nbt.NewCompound()
-	if err := oldPlayer.MarshalNbt(playerData); err != nil {
-		log.Printf("Failed to marshal player data: %v", err)
-		return
-	}
-
-	if err := game.worldStore.WritePlayerData(oldPlayer.Name(), playerData); err != nil {
-		log.Printf("Failed when writing player data: %v", err)
+	if !game.queuePlayerSaves([]*player.Player{oldPlayer}) {
+		// The player's data must not be lost.
+		go game.savePlayer(oldPlayer)
 	}
 }
 
@@ -142,6 +199,83 @@ func (game *Game) onTick() {
 	if game.time%TicksPerSecond == 0 {
 		game.sendTimeUpdate()
 	}
+	if *playerSaveIntervalSecs > 0 && game.time%(Ticks(*playerSaveIntervalSecs)*TicksPerSecond) == 0 {
+		if !game.queuePlayerSaves(game.onlinePlayers()) {
+			log.Print("Skipping player saves, as too many saves are still queued")
+		}
+	}
+}
+
+// onlinePlayers returns the players currently connected. It must be called
+// from the game loop.
+func (game *Game) onlinePlayers() []*player.Player {
+	players := make([]*player.Player, 0, len(game.players))
+	for _, player := range game.players {
+		players = append(players, player)
+	}
+	return players
+}
+
+// playerSave is a batch of players queued for saving. done, if not nil, is
+// closed once they have been saved.
+type playerSave struct {
+	players []*player.Player
+	done    chan bool
+}
+
+// queuePlayerSaves queues players to be saved by saveQueuedPlayers, as saving
+// waits on each player and must not hold up the game loop. It returns false
+// if the queue is full.
+func (game *Game) queuePlayerSaves(players []*player.Player) bool {
+	select {
+	case game.playerSaves <- playerSave{players, nil}:
+		return true
+	default:
+		return false
+	}
+}
+
+// saveQueuedPlayers saves the players queued by queuePlayerSaves, in order.
+func (game *Game) saveQueuedPlayers() {
+	for save := range game.playerSaves {
+		game.savePlayers(save.players)
+		if save.done != nil {
+			close(save.done)
+		}
+	}
+}
+
+func (game *Game) savePlayers(players []*player.Player) {
+	for _, player := range players {
+		game.savePlayer(player)
+	}
+}
+
+// savePlayer writes the player's data to the world store. It is safe to call
+// while the player is running.
+func (game *Game) savePlayer(player *player.Player) {
+	game.playerSaveLock.Lock()
+	defer game.playerSaveLock.Unlock()
+
+	startTime := time.Now()
+	defer func() {
+		expVarPlayerSaveTimeNs.Add(int64(time.Since(startTime)))
+	}()
+
+	playerData := nbt.NewCompound()
+	if err := player.SnapshotNbt(playerData); err != nil {
+		log.Printf("Failed to marshal player data: %v", err)
+		expVarPlayerSaveFailCount.Add(1)
+		return
+	}
+
+	if err := game.worldStore.WritePlayerData(player.Name(), playerData); err != nil {
+		log.Printf("Failed when writing player data: %v", err)
+		expVarPlayerSaveFailCount.Add(1)
+		return
+	}
+
+	expVarPlayerSaveCount.Add(1)
 }
 
 // Utility functions
@@ -0,0 +1,59 @@
+package gamerules
+
+import (
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Power of the explosion made by TNT.
+const tntExplosionPower = 4
+
+// ActivatedTnt is an object that is TNT which has been set off. It explodes
+// when its fuse burns down.
+type ActivatedTnt struct {
+	Object
+	Fuse Ticks // Ticks left before the TNT explodes.
+}
+
+func newBlankActivatedTnt() *ActivatedTnt {
+	return &ActivatedTnt{
+		Object: *NewObject(ObjTypeIdActivatedTnt),
+	}
+}
+
+func NewActivatedTntAt(position *AbsXyz, fuse Ticks) (tnt *ActivatedTnt) {
+	tnt = newBlankActivatedTnt()
+	tnt.Fuse = fuse
+	tnt.PointObject.Init(position, &AbsVelocity{0, 0.2, 0})
+	return
+}
+
+func (tnt *ActivatedTnt) UnmarshalNbt(tag *nbt.Compound) (err error) {
+	if err = tnt.Object.UnmarshalNbt(tag); err != nil {
+		return
+	}
+
+	if fuse, ok := tag.Lookup("Fuse").(*nbt.Byte); ok {
+		tnt.Fuse = Ticks(fuse.Value)
+	}
+
+	return
+}
+
+func (tnt *ActivatedTnt) MarshalNbt(tag *nbt.Compound) (err error) {
+	if err = tnt.Object.MarshalNbt(tag); err != nil {
+		return
+	}
+	tag.Set("Fuse", &nbt.Byte{int8(tnt.Fuse)})
+	return
+}
+
+func (tnt *ActivatedTnt) BurnFuse(chunk IChunkBlock) (exploded bool) {
+	if tnt.Fuse--; tnt.Fuse > 0 {
+		return false
+	}
+
+	chunk.Explode(tnt.Position(), tntExplosionPower)
+
+	return true
+}
@@ -0,0 +1,73 @@
+package gamerules
+
+import (
+	"io"
+
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Equipment slots of players, as sent to other players.
+const (
+	EquipmentSlotHeld       = SlotId(0)
+	EquipmentSlotBoots      = SlotId(1)
+	EquipmentSlotLeggings   = SlotId(2)
+	EquipmentSlotChestplate = SlotId(3)
+	EquipmentSlotHelmet     = SlotId(4)
+
+	NumEquipmentSlots = 5
+)
+
+// Bits of the flags in entity metadata field 0.
+const (
+	entityFlagsField    = 0
+	entityFlagCrouching = 0x02
+)
+
+// PlayerAppearance describes how other players see a player, other than
+// their position and look.
+type PlayerAppearance struct {
+	// Items that the player is holding and wearing, indexed by equipment slot.
+	Equipment [NumEquipmentSlots]Slot
+	Crouching bool
+}
+
+// SendSpawn writes the packets that show the appearance of a player who has
+// just been spawned with their held item.
+func (a *PlayerAppearance) SendSpawn(writer io.Writer, entityId EntityId) (err error) {
+	var unchanged PlayerAppearance
+	unchanged.Equipment[EquipmentSlotHeld] = a.Equipment[EquipmentSlotHeld]
+	return a.SendChanges(writer, entityId, &unchanged)
+}
+
+// SendChanges writes the packets that show how the appearance of a player has
+// changed since they looked like old.
+func (a *PlayerAppearance) SendChanges(writer io.Writer, entityId EntityId, old *PlayerAppearance) (err error) {
+	for i := range a.Equipment {
+		slot := &a.Equipment[i]
+		if slot.ItemTypeId == old.Equipment[i].ItemTypeId && slot.Data == old.Equipment[i].Data {
+			continue
+		}
+		itemTypeId := slot.ItemTypeId
+		if slot.IsEmpty() {
+			// The protocol uses -1 for nothing.
+			itemTypeId = -1
+		}
+		if err = proto.WriteEntityEquipment(writer, entityId, SlotId(i), itemTypeId, slot.Data); err != nil {
+			return
+		}
+	}
+
+	if a.Crouching != old.Crouching {
+		err = proto.WriteEntityMetadata(writer, entityId, a.metadata())
+	}
+	return
+}
+
+func (a *PlayerAppearance) metadata() []proto.EntityMetadata {
+	var flags byte
+	if a.Crouching {
+		flags |= entityFlagCrouching
+	}
+	return []proto.EntityMetadata{{0, entityFlagsField, flags}}
+}
@@ -26,6 +26,130 @@ type IChunkBlock interface {
 
 	// AddActiveBlockIndex flags a block in the chunk itself as active by index.
 	AddActiveBlockIndex(blockIndex BlockIndex)
+
+	// BlockAt returns the type and data of a block in the chunk or in another
+	// loaded chunk in the same shard. ok is false if the block is not
+	// available.
+	BlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool)
+
+	// BorderBlockAt returns the type and data of a block in another shard
+	// that touches the chunk's shard, as last sent by that shard when the
+	// block changed. ok is false if it hasn't been sent.
+	BorderBlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool)
+
+	// SpreadBlock requests that a block of the given type spreads into the
+	// block at blockXyz, which can be in any chunk. The aspect of the
+	// spreading block type must implement ISpreadingAspect.
+	SpreadBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte)
+
+	// PlaceBlock requests that a block of the given type is placed at
+	// blockXyz, which can be in any chunk, if the block there is replaceable.
+	// The block is placed at the end of the tick.
+	PlaceBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte)
+
+	// LightLevel returns the brightest of the sky light and block light at a
+	// block in the chunk or in another loaded chunk in the same shard. ok is
+	// false if the block is not available.
+	LightLevel(blockXyz *BlockXyz) (level int8, ok bool)
+
+	// CurrentTick returns the number of ticks that the chunk's shard has run
+	// for.
+	CurrentTick() Ticks
+
+	// ScheduleBlockTick requests that ScheduledTick is called for the block
+	// after delay ticks, unless the block already has a tick scheduled. The
+	// block's aspect must implement IScheduledAspect.
+	ScheduleBlockTick(blockIndex BlockIndex, delay Ticks)
+
+	// RedstoneInput returns the redstone power last sent by a block in another
+	// shard to a block in the chunk's shard.
+	RedstoneInput(from, to *BlockXyz) RedstoneInput
+
+	// IsOccupied returns true if a player is within the block.
+	IsOccupied(blockXyz *BlockXyz) bool
+
+	// MulticastPlayers sends a packet to all players subscribed to the chunk
+	// except exclude.
+	MulticastPlayers(exclude EntityId, packet []byte)
+
+	// Explode causes an explosion, which can reach blocks and entities in
+	// other chunks and shards.
+	Explode(center *AbsXyz, power float32)
+
+	// IsRaining returns true if it is raining on the block.
+	IsRaining(blockXyz *BlockXyz) bool
+}
+
+// IScheduledAspect is implemented by the aspects of blocks that use
+// IChunkBlock.ScheduleBlockTick.
+type IScheduledAspect interface {
+	// ScheduledTick is called when the tick scheduled for the block is due.
+	ScheduledTick(instance *BlockInstance)
+}
+
+// IRandomTickAspect is implemented by the aspects of blocks that change slowly
+// by themselves, such as growing crops.
+type IRandomTickAspect interface {
+	// RandomTick is called for the block when the chunk picks it at random,
+	// which happens to each block every so often.
+	RandomTick(instance *BlockInstance)
+}
+
+// IIgnitableAspect is implemented by the aspects of blocks that are set off
+// by fire or explosions, such as TNT.
+type IIgnitableAspect interface {
+	// Ignite sets off the block, which explodes after fuse ticks, or after its
+	// usual fuse if fuse is zero.
+	Ignite(instance *BlockInstance, fuse Ticks)
+}
+
+// IItemUseAspect is implemented by the aspects of blocks that players can use
+// items on, such as farmland that seeds are planted in.
+type IItemUseAspect interface {
+	// CanUseItem returns true if the item can be used on the block.
+	CanUseItem(instance *BlockInstance, item *Slot) bool
+
+	// UseItem uses the item on the block, taking any items that are used up
+	// from the slot.
+	UseItem(instance *BlockInstance, item *Slot)
+}
+
+// ITouchedAspect is implemented by the aspects of blocks that react to
+// entities within them, such as fire.
+type ITouchedAspect interface {
+	// Touched is called by the chunk for each entity within the block after
+	// each tick in which the entity stayed within the chunk.
+	Touched(instance *BlockInstance, entity INonPlayerEntity)
+}
+
+// ISpreadingAspect is implemented by the aspects of blocks that spread into
+// other blocks, such as fluids.
+type ISpreadingAspect interface {
+	// SpreadInto is called within the target block's chunk to spread a block
+	// of the aspect's type with the given data into it.
+	SpreadInto(target *BlockInstance, blockData byte)
+}
+
+// BlockSpread describes a block spreading into a block in another shard.
+type BlockSpread struct {
+	Block       BlockXyz
+	BlockTypeId BlockId
+	BlockData   byte
+}
+
+// BlockPlacement describes a block to be placed in another shard.
+type BlockPlacement struct {
+	Block       BlockXyz
+	BlockTypeId BlockId
+	BlockData   byte
+}
+
+// BorderBlock describes a block at the edge of a shard, as sent to the
+// neighbouring shards.
+type BorderBlock struct {
+	Block       BlockXyz
+	BlockTypeId BlockId
+	BlockData   byte
 }
 
 // IUnsubscribed is the interface by which blocks (and potentially other
@@ -61,8 +185,10 @@ type IBlockAspect interface {
 	// returning nil if it is correct.
 	Check() error
 
-	// Hit is called when the player hits a block.
-	Hit(instance *BlockInstance, player IPlayerClient, digStatus DigStatus) (destroyed bool)
+	// Hit is called when the player hits a block while holding an item.
+	// digTicks is the number of ticks since the player started digging the
+	// block, when they finish digging it. gameType is the player's game mode.
+	Hit(instance *BlockInstance, player IPlayerClient, gameType GameType, held *Slot, digStatus DigStatus, digTicks Ticks) (destroyed bool)
 
 	// Interact is called when a player right-clicks a block.
 	Interact(instance *BlockInstance, player IPlayerClient)
@@ -71,13 +197,19 @@ type IBlockAspect interface {
 	// inventory for the block (assuming it still has one).
 	InventoryClick(instance *BlockInstance, player IPlayerClient, click *Click)
 
+	// InventoryPutItem is called when the player shift-clicks items from their
+	// own inventory into the inventory for the block (assuming it still has
+	// one). Items that don't fit are given back to the player.
+	InventoryPutItem(instance *BlockInstance, player IPlayerClient, item *Slot)
+
 	// InventoryUnsubscribed is called when the player closes the window for the
 	// inventory for the block (assuming it still has one).
 	InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient)
 
-	// Destroy is called when the block is destroyed by a player hitting it.
-	// TODO And in other situations, maybe?
-	Destroy(instance *BlockInstance)
+	// Destroy is called when the block is destroyed, before it is removed from
+	// the chunk. It always releases anything held by the block, but only drops
+	// the block's own items if harvest is true.
+	Destroy(instance *BlockInstance, harvest bool)
 
 	// Tick tells the aspect to run the block for a tick. It should return false
 	// if the block should not tick again.
@@ -0,0 +1,31 @@
+package gamerules
+
+// Bit set in the block data of the head half of a bed.
+const bedHead = 0x8
+
+func makeBedAspect() (aspect IBlockAspect) {
+	return &BedAspect{}
+}
+
+// BedAspect is the behaviour of beds. A player that uses a bed respawns at it
+// after dying. Only the foot half of a bed drops items.
+//
+// TODO Let players sleep through the night, and break both halves of a bed
+// together.
+type BedAspect struct {
+	StandardAspect
+}
+
+func (aspect *BedAspect) Name() string {
+	return "Bed"
+}
+
+func (aspect *BedAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	player.SetBed(instance.BlockLoc)
+}
+
+func (aspect *BedAspect) Destroy(instance *BlockInstance, harvest bool) {
+	if instance.Data&bedHead == 0 {
+		aspect.StandardAspect.Destroy(instance, harvest)
+	}
+}
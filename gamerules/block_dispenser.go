@@ -1,13 +1,21 @@
 package gamerules
 
 import (
+	"bytes"
+
+	"github.com/huin/chunkymonkey/proto"
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// Speed of items thrown out of dispensers, in blocks per tick.
+const dispenserItemSpeed = 0.3
+
 func makeDispenserAspect() (aspect IBlockAspect) {
-	return &InventoryAspect{
-		name:                 "Dispenser",
-		createBlockInventory: createDispenserInventory,
+	return &DispenserAspect{
+		InventoryAspect: InventoryAspect{
+			name:                 "Dispenser",
+			createBlockInventory: createDispenserInventory,
+		},
 	}
 }
 
@@ -24,4 +32,66 @@ func createDispenserInventory(instance *BlockInstance) *blockInventory {
 	)
 }
 
-// TODO behaviours for dispensers.
+// DispenserAspect is the behaviour of dispensers, which throw out an item
+// from their inventory each time they become powered by redstone.
+type DispenserAspect struct {
+	InventoryAspect
+}
+
+func (aspect *DispenserAspect) Tick(instance *BlockInstance) bool {
+	powered := isRedstonePowered(instance.Chunk, &instance.BlockLoc, offsetNone)
+
+	blkInv := aspect.blockInv(instance, powered)
+	if blkInv == nil {
+		return false
+	}
+	inv, ok := blkInv.inv.(*DispenserInventory)
+	if !ok || inv.powered == powered {
+		return false
+	}
+
+	inv.powered = powered
+	if powered {
+		aspect.dispense(instance, inv)
+	}
+
+	return false
+}
+
+// dispense throws an item out of the front of the dispenser.
+func (aspect *DispenserAspect) dispense(instance *BlockInstance, inv *DispenserInventory) {
+	sound := SoundEffectClick1
+
+	if item, ok := inv.TakeRandomItem(instance.Chunk.Rand()); ok {
+		sound = SoundEffectClick2
+
+		var dx, dz AbsCoord
+		switch instance.Data {
+		case 2:
+			dz = -1
+		case 3:
+			dz = 1
+		case 4:
+			dx = -1
+		default:
+			dx = 1
+		}
+
+		position := instance.BlockLoc.ToAbsXyz()
+		position.X += 0.5 + dx*0.6
+		position.Y += 0.5
+		position.Z += 0.5 + dz*0.6
+		velocity := AbsVelocity{
+			AbsVelocityCoord(dx * dispenserItemSpeed),
+			0.1,
+			AbsVelocityCoord(dz * dispenserItemSpeed),
+		}
+
+		instance.Chunk.AddEntity(
+			NewItem(item.ItemTypeId, item.Count, item.Data, position, &velocity, 0))
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteSoundEffect(buf, sound, instance.BlockLoc, 0)
+	instance.Chunk.MulticastPlayers(-1, buf.Bytes())
+}
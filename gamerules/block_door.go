@@ -0,0 +1,123 @@
+package gamerules
+
+import (
+	"bytes"
+
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Bits in the block data of doors.
+const (
+	doorOpenBit = 0x4
+	doorTopBit  = 0x8
+)
+
+func makeDoorAspect() (aspect IBlockAspect) {
+	return &DoorAspect{}
+}
+
+// DoorAspect is the behaviour of doors, which are two blocks high. A door
+// opens when either half of it is powered by redstone. Manual doors are also
+// opened and closed by players, and are only closed by redstone when they are
+// near a redstone component.
+type DoorAspect struct {
+	StandardAspect
+	Manual bool // Players can open and close the door.
+}
+
+func (aspect *DoorAspect) Name() string {
+	return "Door"
+}
+
+func (aspect *DoorAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	if aspect.Manual {
+		aspect.setOpen(instance, instance.Data&doorOpenBit == 0)
+	}
+}
+
+func (aspect *DoorAspect) Destroy(instance *BlockInstance, harvest bool) {
+	if otherLoc, otherIndex, ok := aspect.otherHalf(instance); ok {
+		if blockId, _, ok := instance.Chunk.BlockAt(otherLoc); ok && blockId == instance.BlockType.id {
+			instance.Chunk.SetBlockByIndex(otherIndex, BlockIdAir, 0)
+		}
+	}
+
+	// Only the bottom half drops the door item.
+	if instance.Data&doorTopBit == 0 {
+		aspect.StandardAspect.Destroy(instance, harvest)
+	}
+}
+
+func (aspect *DoorAspect) Tick(instance *BlockInstance) bool {
+	open := instance.Data&doorOpenBit != 0
+	powered := aspect.isPowered(instance)
+
+	if powered && !open {
+		aspect.setOpen(instance, true)
+	} else if !powered && open {
+		if !aspect.Manual || isNearRedstone(instance.Chunk, &instance.BlockLoc) {
+			aspect.setOpen(instance, false)
+		}
+	}
+
+	return false
+}
+
+// isPowered returns true if either half of the door is powered.
+func (aspect *DoorAspect) isPowered(instance *BlockInstance) bool {
+	otherOffset := offsetUp
+	if instance.Data&doorTopBit != 0 {
+		otherOffset = offsetDown
+	}
+
+	if isRedstonePowered(instance.Chunk, &instance.BlockLoc, otherOffset) {
+		return true
+	}
+
+	otherLoc := otherOffset.from(&instance.BlockLoc)
+	return otherLoc != nil && isRedstonePowered(instance.Chunk, otherLoc, otherOffset.reverse())
+}
+
+func (aspect *DoorAspect) setOpen(instance *BlockInstance, open bool) {
+	blockData := instance.Data &^ doorOpenBit
+	if open {
+		blockData |= doorOpenBit
+	}
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, blockData)
+
+	if otherLoc, otherIndex, ok := aspect.otherHalf(instance); ok {
+		blockId, otherData, ok := instance.Chunk.BlockAt(otherLoc)
+		if ok && blockId == instance.BlockType.id {
+			otherData = otherData &^ doorOpenBit
+			if open {
+				otherData |= doorOpenBit
+			}
+			instance.Chunk.SetBlockByIndex(otherIndex, blockId, otherData)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteSoundEffect(buf, SoundEffectDoor, instance.BlockLoc, 0)
+	instance.Chunk.MulticastPlayers(-1, buf.Bytes())
+}
+
+// otherHalf returns the location and index of the other block of the door.
+// Both halves are always in the same chunk.
+func (aspect *DoorAspect) otherHalf(instance *BlockInstance) (otherLoc *BlockXyz, otherIndex BlockIndex, ok bool) {
+	subLoc := instance.SubLoc
+	if instance.Data&doorTopBit != 0 {
+		otherLoc = offsetDown.from(&instance.BlockLoc)
+		subLoc.Y--
+	} else {
+		otherLoc = offsetUp.from(&instance.BlockLoc)
+		subLoc.Y++
+	}
+
+	if otherLoc == nil {
+		return
+	}
+
+	otherIndex, ok = subLoc.BlockIndex()
+	return
+}
@@ -0,0 +1,301 @@
+package gamerules
+
+import (
+	"fmt"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	// Distance from farmland within which water keeps it wet.
+	farmlandWaterRange = 4
+
+	// Block data of wet farmland. The farmland dries out by one each random
+	// tick once away from water.
+	farmlandMaxMoisture = 7
+)
+
+func makeTillableAspect() (aspect IBlockAspect) {
+	return &TillableAspect{}
+}
+
+// TillableAspect is the behaviour of blocks such as dirt and grass, which
+// players can turn into farmland with a hoe.
+type TillableAspect struct {
+	StandardAspect
+	TilledBy ToolTypeId // Type of tool that tills the block.
+	TillsTo  BlockId    // Block type that the block becomes when tilled.
+}
+
+func (aspect *TillableAspect) Name() string {
+	return "Tillable"
+}
+
+func (aspect *TillableAspect) Check() error {
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.TillsTo); err != nil {
+		return err
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *TillableAspect) CanUseItem(instance *BlockInstance, item *Slot) bool {
+	itemType := item.ItemType()
+	return itemType != nil && itemType.ToolType == aspect.TilledBy && isAirAt(instance.Chunk, offsetUp.from(&instance.BlockLoc))
+}
+
+func (aspect *TillableAspect) UseItem(instance *BlockInstance, item *Slot) {
+	instance.Chunk.SetBlockByIndex(instance.Index, aspect.TillsTo, 0)
+}
+
+func makeFarmlandAspect() (aspect IBlockAspect) {
+	return &FarmlandAspect{}
+}
+
+// FarmlandAspect is the behaviour of farmland, which players can plant the
+// seeds of crops in. The block data holds the moisture of the farmland, which
+// blocks of the WateredBy types nearby keep wet, and which otherwise dries out
+// on random ticks. Farmland reverts to another block type when covered by a
+// solid block, or when dry with nothing planted in it.
+type FarmlandAspect struct {
+	StandardAspect
+	Crops     []BlockId // Crop block types that can be planted.
+	RevertsTo BlockId   // Block type that the farmland reverts to.
+	WateredBy []BlockId // Block types that keep the farmland wet.
+}
+
+func (aspect *FarmlandAspect) Name() string {
+	return "Farmland"
+}
+
+func (aspect *FarmlandAspect) Check() error {
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.RevertsTo); err != nil {
+		return err
+	}
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.WateredBy...); err != nil {
+		return err
+	}
+	for _, cropId := range aspect.Crops {
+		cropType, ok := Blocks.Get(cropId)
+		if !ok {
+			return fmt.Errorf("block %q: block type %d does not exist", aspect.blockAttrs.Name, cropId)
+		}
+		if _, ok := cropType.Aspect.(*CropAspect); !ok {
+			return fmt.Errorf("block %q: block type %d is not a crop", aspect.blockAttrs.Name, cropId)
+		}
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *FarmlandAspect) Tick(instance *BlockInstance) bool {
+	if isSolidAt(instance.Chunk, offsetUp.from(&instance.BlockLoc)) {
+		instance.Chunk.SetBlockByIndex(instance.Index, aspect.RevertsTo, 0)
+	}
+	return false
+}
+
+func (aspect *FarmlandAspect) RandomTick(instance *BlockInstance) {
+	chunk := instance.Chunk
+
+	switch {
+	case aspect.isWatered(instance):
+		if instance.Data != farmlandMaxMoisture {
+			chunk.SetBlockByIndex(instance.Index, aspect.blockAttrs.id, farmlandMaxMoisture)
+		}
+	case instance.Data > 0:
+		chunk.SetBlockByIndex(instance.Index, aspect.blockAttrs.id, instance.Data-1)
+	case !aspect.isPlanted(instance):
+		chunk.SetBlockByIndex(instance.Index, aspect.RevertsTo, 0)
+	}
+}
+
+// isPlanted returns true if a crop is growing in the farmland.
+func (aspect *FarmlandAspect) isPlanted(instance *BlockInstance) bool {
+	blockId, _, ok := instance.Chunk.BlockAt(offsetUp.from(&instance.BlockLoc))
+	if !ok {
+		// Assume that the farmland is planted rather than revert it.
+		return true
+	}
+	for _, cropId := range aspect.Crops {
+		if blockId == cropId {
+			return true
+		}
+	}
+	return false
+}
+
+// isWatered returns true if there is water near the farmland.
+func (aspect *FarmlandAspect) isWatered(instance *BlockInstance) bool {
+	for dx := BlockCoord(-farmlandWaterRange); dx <= farmlandWaterRange; dx++ {
+		for dy := BlockYCoord(0); dy <= 1; dy++ {
+			for dz := BlockCoord(-farmlandWaterRange); dz <= farmlandWaterRange; dz++ {
+				blockLoc := instance.BlockLoc.AddXyz(dx, dy, dz)
+				if blockLoc == nil {
+					continue
+				}
+				blockId, _, ok := instance.Chunk.BlockAt(blockLoc)
+				if !ok {
+					continue
+				}
+				for _, waterId := range aspect.WateredBy {
+					if blockId == waterId {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// cropFromSeed returns the crop block type grown from the item.
+func (aspect *FarmlandAspect) cropFromSeed(item *Slot) (cropId BlockId, ok bool) {
+	for _, cropId = range aspect.Crops {
+		if cropType, ok := Blocks.Get(cropId); ok && cropType.Aspect.(*CropAspect).Seed == item.ItemTypeId {
+			return cropId, true
+		}
+	}
+	return 0, false
+}
+
+func (aspect *FarmlandAspect) CanUseItem(instance *BlockInstance, item *Slot) bool {
+	_, ok := aspect.cropFromSeed(item)
+	return ok && isAirAt(instance.Chunk, offsetUp.from(&instance.BlockLoc))
+}
+
+func (aspect *FarmlandAspect) UseItem(instance *BlockInstance, item *Slot) {
+	cropId, ok := aspect.cropFromSeed(item)
+	if !ok || item.Count < 1 {
+		return
+	}
+
+	subLoc := instance.SubLoc
+	subLoc.Y++
+	index, ok := subLoc.BlockIndex()
+	if !ok || !isAirAt(instance.Chunk, offsetUp.from(&instance.BlockLoc)) {
+		return
+	}
+
+	instance.Chunk.SetBlockByIndex(index, cropId, 0)
+	item.Decrement()
+}
+
+func makeCropAspect() (aspect IBlockAspect) {
+	return &CropAspect{}
+}
+
+// CropAspect is the behaviour of crops such as wheat. The block data holds the
+// stage of growth of the crop. Each random tick, the crop has a GrowthChance in
+// 100 of growing a stage, or a WateredGrowthChance in 100 if its soil is wet.
+// Fertilizer makes it grow a few stages at once.
+//
+// Crops drop DroppedItems until they are fully grown, and then drop each of
+// the MatureDrops according to its probability. They break when their soil is
+// removed.
+type CropAspect struct {
+	StandardAspect
+	Seed                ItemTypeId // Item that is planted to grow the crop.
+	Soil                BlockId    // Block type that the crop grows on.
+	MatureData          byte       // Block data of the fully grown crop.
+	MatureDrops         []blockDropItem
+	GrowthChance        byte
+	WateredGrowthChance byte
+	FertilizerItem      ItemTypeId
+	FertilizerData      ItemData
+}
+
+func (aspect *CropAspect) Name() string {
+	return "Crop"
+}
+
+func (aspect *CropAspect) Check() error {
+	if _, ok := Items[aspect.Seed]; !ok {
+		return fmt.Errorf("block %q: seed item type %d does not exist", aspect.blockAttrs.Name, aspect.Seed)
+	}
+	if _, ok := Items[aspect.FertilizerItem]; !ok {
+		return fmt.Errorf("block %q: fertilizer item type %d does not exist", aspect.blockAttrs.Name, aspect.FertilizerItem)
+	}
+	if aspect.WateredGrowthChance > 100 || aspect.GrowthChance > aspect.WateredGrowthChance {
+		return fmt.Errorf("block %q: crop must grow faster when watered", aspect.blockAttrs.Name)
+	}
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.Soil); err != nil {
+		return err
+	}
+	for i := range aspect.MatureDrops {
+		if err := aspect.MatureDrops[i].check(); err != nil {
+			return fmt.Errorf("block %q: %v", aspect.blockAttrs.Name, err)
+		}
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *CropAspect) Tick(instance *BlockInstance) bool {
+	chunk := instance.Chunk
+
+	if blockId, _, ok := chunk.BlockAt(offsetDown.from(&instance.BlockLoc)); ok && blockId != aspect.Soil {
+		aspect.Destroy(instance, true)
+		chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+	}
+
+	return false
+}
+
+func (aspect *CropAspect) RandomTick(instance *BlockInstance) {
+	if instance.Data >= aspect.MatureData {
+		return
+	}
+
+	chance := aspect.GrowthChance
+	if _, soilData, ok := instance.Chunk.BlockAt(offsetDown.from(&instance.BlockLoc)); ok && soilData > 0 {
+		chance = aspect.WateredGrowthChance
+	}
+
+	if byte(instance.Chunk.Rand().Intn(100)) < chance {
+		aspect.grow(instance, 1)
+	}
+}
+
+func (aspect *CropAspect) Destroy(instance *BlockInstance, harvest bool) {
+	if !harvest || instance.Data < aspect.MatureData {
+		aspect.StandardAspect.Destroy(instance, harvest)
+		return
+	}
+
+	rand := instance.Chunk.Rand()
+	for i := range aspect.MatureDrops {
+		dropItem := &aspect.MatureDrops[i]
+		if dropItem.Probability > byte(rand.Intn(100)) {
+			dropItem.drop(instance.Chunk, instance.BlockLoc, instance.Data)
+		}
+	}
+}
+
+func (aspect *CropAspect) CanUseItem(instance *BlockInstance, item *Slot) bool {
+	return item.ItemTypeId == aspect.FertilizerItem && item.Data == aspect.FertilizerData && instance.Data < aspect.MatureData
+}
+
+func (aspect *CropAspect) UseItem(instance *BlockInstance, item *Slot) {
+	if item.Count < 1 || !aspect.CanUseItem(instance, item) {
+		return
+	}
+
+	aspect.grow(instance, byte(2+instance.Chunk.Rand().Intn(4)))
+	item.Decrement()
+}
+
+// grow grows the crop by a number of stages.
+func (aspect *CropAspect) grow(instance *BlockInstance, stages byte) {
+	data := instance.Data + stages
+	if data > aspect.MatureData {
+		data = aspect.MatureData
+	}
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, data)
+}
+
+func isAirAt(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	if blockLoc == nil {
+		return false
+	}
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	return ok && blockId == BlockIdAir
+}
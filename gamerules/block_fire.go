@@ -0,0 +1,251 @@
+package gamerules
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	blockIdFire = BlockId(51)
+
+	fireMaxAge = 15 // Age of fire that is about to burn out.
+
+	// The odds of a block burning are BurnOdds in fireBurnRange, and of fire
+	// spreading to air are about Flammability in fireSpreadRange.
+	fireBurnRange   = 300
+	fireSpreadRange = 100
+)
+
+func makeFireAspect() (aspect IBlockAspect) {
+	return &FireAspect{}
+}
+
+// FireAspect is the behaviour of fire. Every TicksPerBurn ticks or so, fire
+// grows older, burns away the blocks touching it according to their BurnOdds,
+// and spreads to air near blocks according to their Flammability. The block
+// data holds the age of the fire. Older fire spreads less, and burns out
+// unless it is on a flammable block.
+//
+// Fire only stays on solid blocks or next to flammable ones, and is put out by
+// rain and by blocks of the ExtinguishedBy types touching it. Entities within
+// fire are set burning.
+type FireAspect struct {
+	StandardAspect
+	TicksPerBurn   Ticks     // Number of ticks between each time fire burns.
+	ExtinguishedBy []BlockId // Blocks that put out fire that they touch.
+}
+
+func (aspect *FireAspect) Name() string {
+	return "Fire"
+}
+
+func (aspect *FireAspect) Check() error {
+	if aspect.TicksPerBurn <= 0 {
+		return fmt.Errorf("block %q: TicksPerBurn must be positive", aspect.blockAttrs.Name)
+	}
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.ExtinguishedBy...); err != nil {
+		return err
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *FireAspect) Tick(instance *BlockInstance) bool {
+	instance.Chunk.ScheduleBlockTick(instance.Index, aspect.TicksPerBurn)
+	return false
+}
+
+func (aspect *FireAspect) ScheduledTick(instance *BlockInstance) {
+	chunk := instance.Chunk
+	rand := chunk.Rand()
+	blockLoc := &instance.BlockLoc
+
+	if aspect.isExtinguished(chunk, blockLoc) || !canHoldFire(chunk, blockLoc) {
+		chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+		return
+	}
+
+	age := instance.Data
+	if age < fireMaxAge {
+		if age += byte(rand.Intn(3) / 2); age != instance.Data {
+			chunk.SetBlockByIndex(instance.Index, aspect.blockAttrs.id, age)
+		}
+	}
+
+	if flammability(chunk, blockLoc) == 0 {
+		if age > 3 || !isSolidAt(chunk, offsetDown.from(blockLoc)) {
+			chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+			return
+		}
+	} else if age == fireMaxAge && rand.Intn(4) == 0 && !isBurnableAt(chunk, offsetDown.from(blockLoc)) {
+		chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+		return
+	}
+
+	aspect.spread(instance, age)
+
+	chunk.ScheduleBlockTick(instance.Index, aspect.TicksPerBurn+Ticks(rand.Intn(10)))
+}
+
+// spread sends fire to the blocks around it that it might burn or spread
+// into. Blocks that the chunk doesn't know of might be either.
+func (aspect *FireAspect) spread(instance *BlockInstance, age byte) {
+	chunk := instance.Chunk
+
+	for dx := BlockCoord(-1); dx <= 1; dx++ {
+		for dy := BlockYCoord(-1); dy <= 1; dy++ {
+			for dz := BlockCoord(-1); dz <= 1; dz++ {
+				offset := blockOffset{dx, dy, dz}
+				if offset == offsetNone {
+					continue
+				}
+				targetLoc := offset.from(&instance.BlockLoc)
+				if targetLoc == nil {
+					continue
+				}
+
+				if blockId, _, ok := chunk.BlockAt(targetLoc); ok {
+					blockType, ok := Blocks.Get(blockId)
+					if !ok {
+						continue
+					}
+					burns := offset.isFace() && blockType.BurnOdds > 0
+					if !burns && blockId != BlockIdAir {
+						continue
+					}
+				}
+
+				chunk.SpreadBlock(targetLoc, aspect.blockAttrs.id, age)
+			}
+		}
+	}
+}
+
+func (aspect *FireAspect) SpreadInto(target *BlockInstance, age byte) {
+	chunk := target.Chunk
+	rand := chunk.Rand()
+	blockLoc := &target.BlockLoc
+
+	switch {
+	case target.BlockType.BurnOdds > 0:
+		if rand.Intn(fireBurnRange) >= int(target.BlockType.BurnOdds) {
+			return
+		}
+
+		if ignitable, ok := target.BlockType.Aspect.(IIgnitableAspect); ok {
+			ignitable.Ignite(target, 0)
+		} else if rand.Intn(int(age)+10) < 5 && !aspect.isExtinguished(chunk, blockLoc) {
+			chunk.SetBlockByIndex(target.Index, aspect.blockAttrs.id, spreadFireAge(rand, age))
+		} else {
+			chunk.SetBlockByIndex(target.Index, BlockIdAir, 0)
+		}
+
+	case target.BlockType.id == BlockIdAir:
+		encouragement := int(flammability(chunk, blockLoc))
+		if encouragement == 0 {
+			return
+		}
+		chance := (encouragement + 40) / (int(age) + 30)
+		if chance == 0 || rand.Intn(fireSpreadRange) > chance {
+			return
+		}
+
+		if !aspect.isExtinguished(chunk, blockLoc) {
+			chunk.SetBlockByIndex(target.Index, aspect.blockAttrs.id, spreadFireAge(rand, age))
+		}
+	}
+}
+
+func (aspect *FireAspect) Touched(instance *BlockInstance, entity INonPlayerEntity) {
+	if burnable, ok := entity.(IBurnableEntity); ok {
+		burnable.SetBurning(true)
+	}
+}
+
+// isExtinguished returns true if fire at the block would be put out.
+func (aspect *FireAspect) isExtinguished(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	if chunk.IsRaining(blockLoc) {
+		return true
+	}
+
+	for _, offset := range faceOffsets {
+		neighbourLoc := offset.from(blockLoc)
+		if neighbourLoc == nil {
+			continue
+		}
+		if blockId, _, ok := chunk.BlockAt(neighbourLoc); ok {
+			for _, extinguisherId := range aspect.ExtinguishedBy {
+				if blockId == extinguisherId {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// LightFire sets fire to the block, which must be air with something to hold
+// the fire. It returns true if the fire was lit.
+func LightFire(instance *BlockInstance) (lit bool) {
+	if instance.BlockType.id != BlockIdAir || !canHoldFire(instance.Chunk, &instance.BlockLoc) {
+		return false
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, blockIdFire, 0)
+
+	return true
+}
+
+// spreadFireAge returns the age of fire that has spread from fire of the given
+// age.
+func spreadFireAge(rand *rand.Rand, age byte) byte {
+	if age += byte(rand.Intn(5) / 4); age > fireMaxAge {
+		return fireMaxAge
+	}
+	return age
+}
+
+// canHoldFire returns true if fire can stay at the block, which it does on top
+// of solid blocks and next to flammable ones.
+func canHoldFire(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	return isSolidAt(chunk, offsetDown.from(blockLoc)) || flammability(chunk, blockLoc) > 0
+}
+
+// flammability returns the greatest flammability of the blocks touching the
+// block.
+func flammability(chunk IChunkBlock, blockLoc *BlockXyz) (max byte) {
+	for _, offset := range faceOffsets {
+		neighbourLoc := offset.from(blockLoc)
+		if neighbourLoc == nil {
+			continue
+		}
+		if blockType, ok := blockTypeAt(chunk, neighbourLoc); ok && blockType.Flammability > max {
+			max = blockType.Flammability
+		}
+	}
+	return
+}
+
+func isSolidAt(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	blockType, ok := blockTypeAt(chunk, blockLoc)
+	return ok && blockType.Solid
+}
+
+func isBurnableAt(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	blockType, ok := blockTypeAt(chunk, blockLoc)
+	return ok && blockType.BurnOdds > 0
+}
+
+// blockTypeAt returns the type of the block, if the chunk knows of it.
+func blockTypeAt(chunk IChunkBlock, blockLoc *BlockXyz) (blockType *BlockType, ok bool) {
+	if blockLoc == nil {
+		return
+	}
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	if !ok {
+		return
+	}
+	return Blocks.Get(blockId)
+}
@@ -0,0 +1,336 @@
+package gamerules
+
+import (
+	"fmt"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	fluidSource   = 0   // Block data of a fluid source.
+	fluidMaxLevel = 7   // Level of the fluid furthest from its source.
+	fluidFalling  = 0x8 // Bit set in block data of falling fluid.
+	fluidDry      = 0xff
+)
+
+// Offsets to the blocks that fluid spreads to horizontally.
+var fluidHorizontal = [4]struct{ dx, dz BlockCoord }{
+	{-1, 0}, {1, 0}, {0, -1}, {0, 1},
+}
+
+// Offsets to all the blocks that touch a block.
+var fluidTouching = [6]struct {
+	dx BlockCoord
+	dy BlockYCoord
+	dz BlockCoord
+}{
+	{-1, 0, 0}, {1, 0, 0},
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+}
+
+func makeFluidAspect() (aspect IBlockAspect) {
+	return &FluidAspect{}
+}
+
+// FluidAspect is the behaviour of water and lava. The block data holds the
+// level of the fluid, which is 0 for a source block and increases as the
+// fluid spreads away from it. Fluid that is falling has bit 0x8 set, and
+// spreads as if it were a source.
+//
+// Fluid spreads and dries up a step at a time, every TicksPerFlow ticks.
+type FluidAspect struct {
+	VoidAspect
+	blockAttrs *BlockAttrs
+
+	Flowing         BlockId   // Block type of fluid that is spreading.
+	Still           BlockId   // Block type of fluid at rest.
+	TicksPerFlow    Ticks     // Number of ticks between each step of flow.
+	LevelStep       byte      // Increase in level for each block spread.
+	CreatesSources  bool      // Fluid between two sources becomes a source.
+	HardenedBy      []BlockId // Fluids that harden this fluid on contact.
+	SourceHardensTo BlockId   // Block type that a hardened source becomes.
+	FlowHardensTo   BlockId   // Block type that other hardened fluid becomes.
+}
+
+func (aspect *FluidAspect) setAttrs(blockAttrs *BlockAttrs) {
+	aspect.blockAttrs = blockAttrs
+}
+
+func (aspect *FluidAspect) Name() string {
+	return "Fluid"
+}
+
+func (aspect *FluidAspect) Check() error {
+	if aspect.TicksPerFlow <= 0 {
+		return fmt.Errorf("block %q: TicksPerFlow must be positive", aspect.blockAttrs.Name)
+	}
+	if aspect.LevelStep <= 0 {
+		return fmt.Errorf("block %q: LevelStep must be positive", aspect.blockAttrs.Name)
+	}
+
+	blockIds := []BlockId{aspect.Flowing, aspect.Still}
+	if len(aspect.HardenedBy) > 0 {
+		blockIds = append(blockIds, aspect.SourceHardensTo, aspect.FlowHardensTo)
+		blockIds = append(blockIds, aspect.HardenedBy...)
+	}
+	for _, blockId := range blockIds {
+		if _, ok := Blocks.Get(blockId); !ok {
+			return fmt.Errorf("block %q: block type %d does not exist", aspect.blockAttrs.Name, blockId)
+		}
+	}
+
+	return nil
+}
+
+func (aspect *FluidAspect) Tick(instance *BlockInstance) bool {
+	if instance.Chunk.CurrentTick()%aspect.TicksPerFlow != 0 {
+		// Wait for the next step of the flow.
+		return true
+	}
+
+	if aspect.hardenOnContact(instance) {
+		return false
+	}
+
+	// Fluid whose surroundings aren't all known keeps its level, but still
+	// spreads.
+	if data, ok := aspect.settledData(instance); ok && data != instance.Data {
+		if data == fluidDry {
+			instance.Chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+			return false
+		}
+
+		// Spread at the new level on the next step.
+		instance.Chunk.SetBlockByIndex(instance.Index, aspect.Flowing, data)
+		return true
+	}
+
+	aspect.spread(instance)
+
+	return false
+}
+
+func (aspect *FluidAspect) SpreadInto(target *BlockInstance, blockData byte) {
+	if aspect.isSameFluid(target.BlockType.id) {
+		if !fluidIsWeaker(target.Data, blockData) {
+			return
+		}
+	} else if other, ok := target.BlockType.Aspect.(*FluidAspect); ok {
+		// Different fluids meeting.
+		if other.isHardenedBy(aspect) {
+			other.harden(target)
+		} else if aspect.isHardenedBy(other) {
+			target.Chunk.SetBlockByIndex(target.Index, aspect.FlowHardensTo, 0)
+		}
+		return
+	} else if target.BlockType.Replaceable {
+		target.BlockType.Aspect.Destroy(target, true)
+	} else {
+		return
+	}
+
+	target.Chunk.SetBlockByIndex(target.Index, aspect.Flowing, blockData)
+	target.Chunk.AddActiveBlockIndex(target.Index)
+}
+
+func (aspect *FluidAspect) isSameFluid(blockId BlockId) bool {
+	return blockId == aspect.Flowing || blockId == aspect.Still
+}
+
+func (aspect *FluidAspect) isHardenedBy(other *FluidAspect) bool {
+	for _, blockId := range aspect.HardenedBy {
+		if other.isSameFluid(blockId) {
+			return true
+		}
+	}
+	return false
+}
+
+// harden turns the fluid into a solid block.
+func (aspect *FluidAspect) harden(instance *BlockInstance) {
+	blockId := aspect.FlowHardensTo
+	if instance.Data == fluidSource {
+		blockId = aspect.SourceHardensTo
+	}
+	instance.Chunk.SetBlockByIndex(instance.Index, blockId, 0)
+}
+
+// hardenOnContact hardens the fluid if it touches a fluid that hardens it.
+// Returns true if it did so.
+func (aspect *FluidAspect) hardenOnContact(instance *BlockInstance) bool {
+	if len(aspect.HardenedBy) == 0 {
+		return false
+	}
+
+	for _, offset := range fluidTouching {
+		loc := instance.BlockLoc.AddXyz(offset.dx, offset.dy, offset.dz)
+		if loc == nil {
+			continue
+		}
+
+		blockId, _, ok := instance.Chunk.BlockAt(loc)
+		if !ok {
+			continue
+		}
+
+		if blockType, ok := Blocks.Get(blockId); ok {
+			if other, ok := blockType.Aspect.(*FluidAspect); ok && aspect.isHardenedBy(other) {
+				aspect.harden(instance)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// settledData works out what the block data of the fluid should be from the
+// fluid around it, or fluidDry if the fluid should dry up. ok is false if the
+// surrounding blocks are not all known, such as next to an unloaded chunk.
+func (aspect *FluidAspect) settledData(instance *BlockInstance) (data byte, ok bool) {
+	if instance.Data == fluidSource {
+		return fluidSource, true
+	}
+
+	chunk := instance.Chunk
+	loc := &instance.BlockLoc
+
+	// Fluid that has fluid above it is falling.
+	if aboveLoc := loc.AddXyz(0, 1, 0); aboveLoc != nil {
+		if blockId, _, ok := fluidNeighbourAt(chunk, aboveLoc); !ok {
+			return instance.Data, false
+		} else if aspect.isSameFluid(blockId) {
+			return fluidFalling, true
+		}
+	}
+
+	minLevel := byte(fluidMaxLevel + 1)
+	numSources := 0
+	for _, offset := range fluidHorizontal {
+		neighbourLoc := loc.AddXyz(offset.dx, 0, offset.dz)
+		if neighbourLoc == nil {
+			continue
+		}
+
+		blockId, blockData, ok := fluidNeighbourAt(chunk, neighbourLoc)
+		if !ok {
+			return instance.Data, false
+		} else if !aspect.isSameFluid(blockId) {
+			continue
+		}
+
+		if blockData == fluidSource {
+			numSources++
+		}
+		if level := fluidLevel(blockData); level < minLevel {
+			minLevel = level
+		}
+	}
+
+	if aspect.CreatesSources && numSources >= 2 {
+		if belowLoc := loc.AddXyz(0, -1, 0); belowLoc != nil {
+			blockId, blockData, _ := chunk.BlockAt(belowLoc)
+			if aspect.isSameFluid(blockId) && blockData == fluidSource {
+				return fluidSource, true
+			} else if blockType, ok := Blocks.Get(blockId); ok && blockType.Solid {
+				return fluidSource, true
+			}
+		}
+	}
+
+	if level := minLevel + aspect.LevelStep; level <= fluidMaxLevel {
+		return level, true
+	}
+
+	return fluidDry, true
+}
+
+// fluidNeighbourAt returns the block at blockLoc, which can be just over the
+// edge of the shard.
+func fluidNeighbourAt(chunk IChunkBlock, blockLoc *BlockXyz) (blockId BlockId, blockData byte, ok bool) {
+	if blockId, blockData, ok = chunk.BlockAt(blockLoc); ok {
+		return
+	}
+	return chunk.BorderBlockAt(blockLoc)
+}
+
+// spread spreads the fluid downwards if possible, or otherwise outwards.
+func (aspect *FluidAspect) spread(instance *BlockInstance) {
+	chunk := instance.Chunk
+	loc := &instance.BlockLoc
+	level := fluidLevel(instance.Data)
+
+	if belowLoc := loc.AddXyz(0, -1, 0); belowLoc != nil {
+		// The block below is always in the same chunk.
+		blockId, blockData, _ := chunk.BlockAt(belowLoc)
+		if aspect.canDisplace(blockId, blockData, fluidFalling) {
+			chunk.SpreadBlock(belowLoc, aspect.Flowing, fluidFalling)
+			return
+		}
+
+		if level != fluidSource && aspect.isSameFluid(blockId) {
+			// Flowing onto the surface of the same fluid.
+			return
+		}
+	}
+
+	newLevel := level + aspect.LevelStep
+	if newLevel > fluidMaxLevel {
+		return
+	}
+
+	for _, offset := range fluidHorizontal {
+		neighbourLoc := loc.AddXyz(offset.dx, 0, offset.dz)
+		if neighbourLoc == nil {
+			continue
+		}
+
+		// Avoid requests to blocks that are known to not change.
+		if blockId, blockData, ok := chunk.BlockAt(neighbourLoc); ok && !aspect.canDisplace(blockId, blockData, newLevel) {
+			continue
+		}
+
+		chunk.SpreadBlock(neighbourLoc, aspect.Flowing, newLevel)
+	}
+}
+
+// canDisplace returns true if fluid with the given data would change the
+// block.
+func (aspect *FluidAspect) canDisplace(blockId BlockId, blockData byte, data byte) bool {
+	if aspect.isSameFluid(blockId) {
+		return fluidIsWeaker(blockData, data)
+	}
+
+	blockType, ok := Blocks.Get(blockId)
+	if !ok {
+		return false
+	}
+
+	if other, ok := blockType.Aspect.(*FluidAspect); ok {
+		return other.isHardenedBy(aspect) || aspect.isHardenedBy(other)
+	}
+
+	return blockType.Replaceable
+}
+
+// fluidLevel returns the level that fluid spreads from. Falling fluid spreads
+// as if it were a source.
+func fluidLevel(data byte) byte {
+	if data&fluidFalling != 0 {
+		return fluidSource
+	}
+	return data
+}
+
+// fluidIsWeaker returns true if fluid with data newData would replace fluid
+// with data oldData.
+func fluidIsWeaker(oldData, newData byte) bool {
+	if oldData == fluidSource || oldData&fluidFalling != 0 {
+		return false
+	}
+	if newData&fluidFalling != 0 {
+		return true
+	}
+	return newData < oldData
+}
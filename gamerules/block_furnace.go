@@ -47,6 +47,18 @@ func (aspect *FurnaceAspect) InventoryClick(instance *BlockInstance, player IPla
 	aspect.updateBlock(instance, blockInv, furnaceInv.IsLit())
 }
 
+func (aspect *FurnaceAspect) InventoryPutItem(instance *BlockInstance, player IPlayerClient, item *Slot) {
+	aspect.InventoryAspect.InventoryPutItem(instance, player, item)
+
+	blockInv, furnaceInv := aspect.furnaceInventory(instance)
+	if furnaceInv == nil {
+		// Invalid or missing inventory.
+		return
+	}
+
+	aspect.updateBlock(instance, blockInv, furnaceInv.IsLit())
+}
+
 func (aspect *FurnaceAspect) Tick(instance *BlockInstance) bool {
 	blockInv, furnaceInv := aspect.furnaceInventory(instance)
 	if furnaceInv == nil {
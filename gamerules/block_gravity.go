@@ -0,0 +1,54 @@
+package gamerules
+
+import (
+	"fmt"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func makeGravityAspect() (aspect IBlockAspect) {
+	return &GravityAspect{}
+}
+
+// GravityAspect is the behaviour of blocks such as sand and gravel, which are
+// otherwise StandardAspect blocks. A block that has nothing solid beneath it
+// turns into a FallingBlock object, which becomes a block again when it
+// lands.
+type GravityAspect struct {
+	StandardAspect
+	FallingObject ObjTypeId // Object type that the block falls as.
+}
+
+func (aspect *GravityAspect) Name() string {
+	return "Gravity"
+}
+
+func (aspect *GravityAspect) Check() error {
+	if _, ok := ObjNameByType[aspect.FallingObject]; !ok {
+		return fmt.Errorf("block %q: object type %d does not exist", aspect.blockAttrs.Name, aspect.FallingObject)
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *GravityAspect) Tick(instance *BlockInstance) bool {
+	belowLoc := instance.BlockLoc.AddXyz(0, -1, 0)
+	if belowLoc == nil {
+		return false
+	}
+
+	// The block below is always in the same chunk.
+	blockId, _, _ := instance.Chunk.BlockAt(belowLoc)
+	if blockType, ok := Blocks.Get(blockId); !ok || blockType.Solid {
+		return false
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+
+	position := instance.BlockLoc.ToAbsXyz()
+	position.X += 0.5
+	position.Y += blockItemSpawnFromEdge
+	position.Z += 0.5
+	instance.Chunk.AddEntity(NewFallingBlock(aspect.FallingObject, instance.BlockType.id, position))
+
+	return false
+}
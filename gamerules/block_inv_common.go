@@ -30,6 +30,15 @@ func (aspect *InventoryAspect) InventoryClick(instance *BlockInstance, player IP
 	}
 }
 
+func (aspect *InventoryAspect) InventoryPutItem(instance *BlockInstance, player IPlayerClient, item *Slot) {
+	blkInv := aspect.blockInv(instance, false)
+	if blkInv != nil {
+		blkInv.PutItem(player, item)
+	} else {
+		aspect.StandardAspect.InventoryPutItem(instance, player, item)
+	}
+}
+
 func (aspect *InventoryAspect) InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient) {
 	blkInv := aspect.blockInv(instance, false)
 	if blkInv != nil {
@@ -37,14 +46,14 @@ func (aspect *InventoryAspect) InventoryUnsubscribed(instance *BlockInstance, pl
 	}
 }
 
-func (aspect *InventoryAspect) Destroy(instance *BlockInstance) {
+func (aspect *InventoryAspect) Destroy(instance *BlockInstance, harvest bool) {
 	blkInv := aspect.blockInv(instance, false)
 	if blkInv != nil {
 		blkInv.EjectItems()
 		blkInv.Destroyed()
 	}
 
-	aspect.StandardAspect.Destroy(instance)
+	aspect.StandardAspect.Destroy(instance, harvest)
 }
 
 func (aspect *InventoryAspect) blockInv(instance *BlockInstance, create bool) *blockInventory {
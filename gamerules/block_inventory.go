@@ -60,7 +60,18 @@ func (blkInv *blockInventory) MarshalNbt(tag *nbt.Compound) (err error) {
 }
 
 func (blkInv *blockInventory) Click(player IPlayerClient, click *Click) {
-	txState := blkInv.inv.Click(click)
+	var txState TxState
+	if click.ShiftClick {
+		// The items are moved into a copy of the player's inventory, and then
+		// given to them, so that only as many are taken as they have room for.
+		space := NewTransferSpace(click.Space)
+		txState = blkInv.inv.ShiftClick(click, space)
+		for _, item := range space.Moved {
+			player.GiveItem(item)
+		}
+	} else {
+		txState = blkInv.inv.Click(click)
+	}
 
 	player.InventoryCursorUpdate(blkInv.blockLoc, click.Cursor)
 
@@ -68,6 +79,15 @@ func (blkInv *blockInventory) Click(player IPlayerClient, click *Click) {
 	player.InventoryTxState(blkInv.blockLoc, click.TxId, txState == TxStateAccepted)
 }
 
+// PutItem puts items that the player shift-clicked into the inventory, and
+// gives back any that don't fit.
+func (blkInv *blockInventory) PutItem(player IPlayerClient, item *Slot) {
+	blkInv.inv.PutItem(item)
+	if !item.IsEmpty() {
+		player.GiveItem(*item)
+	}
+}
+
 func (blkInv *blockInventory) SlotUpdate(slot *Slot, slotId SlotId) {
 	for _, subscriber := range blkInv.subscribers {
 		subscriber.InventorySlotUpdate(blkInv.blockLoc, *slot, slotId)
@@ -194,17 +194,33 @@ func LoadBlocksFromFile(filename string) (blockTypes BlockTypeList, err error) {
 
 func init() {
 	aspectMakers = map[string]aspectMakerFn{
-		"Chest":        makeChestAspect,
-		"Dispenser":    makeDispenserAspect,
-		"Furnace":      makeFurnaceAspect,
-		"MobSpawner":   makeMobSpawnerAspect,
-		"Music":        makeMusicAspect,
-		"RecordPlayer": makeRecordPlayerAspect,
-		"Sapling":      makeSaplingAspect,
-		"Sign":         makeSignAspect,
-		"Standard":     makeStandardAspect,
-		"Todo":         makeTodoAspect,
-		"Void":         makeVoidAspect,
-		"Workbench":    makeWorkbenchAspect,
+		"Bed":              makeBedAspect,
+		"Button":           makeButtonAspect,
+		"Chest":            makeChestAspect,
+		"Crop":             makeCropAspect,
+		"Dispenser":        makeDispenserAspect,
+		"Door":             makeDoorAspect,
+		"Farmland":         makeFarmlandAspect,
+		"Fire":             makeFireAspect,
+		"Fluid":            makeFluidAspect,
+		"Furnace":          makeFurnaceAspect,
+		"Gravity":          makeGravityAspect,
+		"Lever":            makeLeverAspect,
+		"MobSpawner":       makeMobSpawnerAspect,
+		"Music":            makeMusicAspect,
+		"Portal":           makePortalAspect,
+		"PressurePlate":    makePressurePlateAspect,
+		"RecordPlayer":     makeRecordPlayerAspect,
+		"RedstoneRepeater": makeRedstoneRepeaterAspect,
+		"RedstoneTorch":    makeRedstoneTorchAspect,
+		"RedstoneWire":     makeRedstoneWireAspect,
+		"Sapling":          makeSaplingAspect,
+		"Sign":             makeSignAspect,
+		"Standard":         makeStandardAspect,
+		"Tillable":         makeTillableAspect,
+		"Tnt":              makeTntAspect,
+		"Todo":             makeTodoAspect,
+		"Void":             makeVoidAspect,
+		"Workbench":        makeWorkbenchAspect,
 	}
 }
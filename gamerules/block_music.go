@@ -1,8 +1,11 @@
 package gamerules
 
 import (
+	"bytes"
 	"errors"
+
 	"github.com/huin/chunkymonkey/nbt"
+	"github.com/huin/chunkymonkey/proto"
 	. "github.com/huin/chunkymonkey/types"
 )
 
@@ -12,7 +15,8 @@ func makeMusicAspect() (aspect IBlockAspect) {
 
 type musicTileEntity struct {
 	tileEntity
-	note NotePitch
+	note    NotePitch
+	powered bool // Powered by redstone when last checked.
 }
 
 func NewMusicTileEntity() ITileEntity {
@@ -44,6 +48,22 @@ func (music *musicTileEntity) MarshalNbt(tag *nbt.Compound) (err error) {
 	return nil
 }
 
+// Instruments played by note blocks, by the type of the block beneath them.
+// Note blocks on other blocks play the harp.
+var musicInstruments = map[BlockId]InstrumentId{
+	1:  InstrumentIdBassDrum,   // Stone.
+	4:  InstrumentIdBassDrum,   // Cobblestone.
+	24: InstrumentIdBassDrum,   // Sandstone.
+	49: InstrumentIdBassDrum,   // Obsidian.
+	5:  InstrumentIdDoubleBass, // Wooden planks.
+	17: InstrumentIdDoubleBass, // Log.
+	12: InstrumentIdSnareDrum,  // Sand.
+	13: InstrumentIdSnareDrum,  // Gravel.
+	20: InstrumentIdSticks,     // Glass.
+}
+
+// MusicAspect is the behaviour of note blocks. Players change the note that
+// they play, and they play it each time they become powered by redstone.
 type MusicAspect struct {
 	StandardAspect
 }
@@ -52,4 +72,50 @@ func (aspect MusicAspect) Name() string {
 	return "Music"
 }
 
-// TODO behaviours for music.
+func (aspect *MusicAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	music := aspect.music(instance, true)
+	music.note = (music.note + 1) % (NotePitchMax + 1)
+	aspect.play(instance, music)
+}
+
+func (aspect *MusicAspect) Tick(instance *BlockInstance) bool {
+	powered := isRedstonePowered(instance.Chunk, &instance.BlockLoc, offsetNone)
+
+	music := aspect.music(instance, powered)
+	if music == nil || music.powered == powered {
+		return false
+	}
+
+	music.powered = powered
+	if powered {
+		aspect.play(instance, music)
+	}
+
+	return false
+}
+
+func (aspect *MusicAspect) music(instance *BlockInstance, create bool) *musicTileEntity {
+	music, ok := instance.Chunk.TileEntity(instance.Index).(*musicTileEntity)
+	if !ok && create {
+		music = &musicTileEntity{}
+		music.chunk = instance.Chunk
+		music.blockLoc = instance.BlockLoc
+		instance.Chunk.SetTileEntity(instance.Index, music)
+	}
+	return music
+}
+
+func (aspect *MusicAspect) play(instance *BlockInstance, music *musicTileEntity) {
+	instrument := InstrumentIdHarp
+	if belowLoc := offsetDown.from(&instance.BlockLoc); belowLoc != nil {
+		if blockId, _, ok := instance.Chunk.BlockAt(belowLoc); ok {
+			if belowInstrument, ok := musicInstruments[blockId]; ok {
+				instrument = belowInstrument
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteNoteBlockPlay(buf, &instance.BlockLoc, instrument, music.note)
+	instance.Chunk.MulticastPlayers(-1, buf.Bytes())
+}
@@ -0,0 +1,307 @@
+package gamerules
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	blockIdObsidian = BlockId(49)
+	blockIdPortal   = BlockId(90)
+
+	// Size of the inside of a portal frame.
+	portalWidth  = 2
+	portalHeight = 3
+
+	// Portal block data for the direction that the portal runs in.
+	portalAlongX = 0
+	portalAlongZ = 1
+
+	// Horizontal coordinates in the Nether are this much smaller than in the
+	// normal world.
+	netherScale = 8
+
+	// Range of heights that players arrive at through a portal.
+	portalMinY = 32
+	portalMaxY = ChunkSizeY - 8
+
+	// Players arriving through a portal use an existing portal up to this
+	// many blocks away horizontally, rather than having one built.
+	portalSearchRadius = 16
+)
+
+// Offsets along a portal for each value of its block data.
+var portalSides = [2]blockOffset{offsetEast, offsetSouth}
+
+func makePortalAspect() (aspect IBlockAspect) {
+	return &PortalAspect{}
+}
+
+// PortalAspect is the behaviour of the blocks inside an obsidian frame that
+// has been lit. Players standing in a portal go to the other dimension. The
+// block data holds the direction that the portal runs in. The portal goes out
+// if its frame is broken.
+type PortalAspect struct {
+	StandardAspect
+}
+
+func (aspect *PortalAspect) Name() string {
+	return "Portal"
+}
+
+func (aspect *PortalAspect) Tick(instance *BlockInstance) bool {
+	if !isPortalFramed(instance.Chunk, &instance.BlockLoc, instance.Data) {
+		instance.Chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+	}
+	return false
+}
+
+// SpreadInto fills the rest of a portal that is being lit.
+func (aspect *PortalAspect) SpreadInto(target *BlockInstance, blockData byte) {
+	if blockId := target.BlockType.id; blockId == BlockIdAir || blockId == blockIdFire {
+		target.Chunk.SetBlockByIndex(target.Index, aspect.blockAttrs.id, blockData)
+	}
+}
+
+// isPortalFramed returns true if the portal block has portal or frame blocks
+// above and below it, and to either side along the portal. Blocks that the
+// chunk doesn't know of are assumed to be in place.
+func isPortalFramed(chunk IChunkBlock, blockLoc *BlockXyz, data byte) bool {
+	side := portalSides[data&1]
+	for _, offset := range [4]blockOffset{offsetUp, offsetDown, side, side.reverse()} {
+		neighbourLoc := offset.from(blockLoc)
+		if neighbourLoc == nil {
+			return false
+		}
+		if blockId, _, ok := chunk.BlockAt(neighbourLoc); ok && blockId != blockIdPortal && blockId != blockIdObsidian {
+			return false
+		}
+	}
+	return true
+}
+
+// LightPortal fills an obsidian frame with portal blocks, if the block is air
+// at the bottom of the inside of the frame. It returns true if the portal was
+// lit. The whole frame must be in chunks that the block's chunk knows of.
+func LightPortal(instance *BlockInstance) (lit bool) {
+	if instance.BlockType.id != BlockIdAir {
+		return false
+	}
+	chunk := instance.Chunk
+
+	for data, side := range portalSides {
+		// The block may be in either column of the frame.
+		for column := 0; column < portalWidth; column++ {
+			corner := portalBlock(&instance.BlockLoc, side, -column, 0)
+			if corner == nil || !isPortalFrame(chunk, corner, side) {
+				continue
+			}
+
+			// The whole portal appears at once, so that none of it goes out
+			// for want of the rest.
+			for w := 0; w < portalWidth; w++ {
+				for h := 0; h < portalHeight; h++ {
+					chunk.SpreadBlock(portalBlock(corner, side, w, h), blockIdPortal, byte(data))
+				}
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPortalFrame returns true if the block at corner is the bottom of the
+// inside of an empty obsidian frame running in the direction of side.
+func isPortalFrame(chunk IChunkBlock, corner *BlockXyz, side blockOffset) bool {
+	isBlockAt := func(w, h int, blockIds ...BlockId) bool {
+		blockLoc := portalBlock(corner, side, w, h)
+		if blockLoc == nil {
+			return false
+		}
+		blockId, _, ok := chunk.BlockAt(blockLoc)
+		if !ok {
+			return false
+		}
+		for _, id := range blockIds {
+			if blockId == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	for w := 0; w < portalWidth; w++ {
+		if !isBlockAt(w, -1, blockIdObsidian) || !isBlockAt(w, portalHeight, blockIdObsidian) {
+			return false
+		}
+		for h := 0; h < portalHeight; h++ {
+			if !isBlockAt(w, h, BlockIdAir, blockIdFire) {
+				return false
+			}
+		}
+	}
+	for h := 0; h < portalHeight; h++ {
+		if !isBlockAt(-1, h, blockIdObsidian) || !isBlockAt(portalWidth, h, blockIdObsidian) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// portalBlock returns the location of the block w blocks along and h blocks
+// up from corner in a portal running in the direction of side.
+func portalBlock(corner *BlockXyz, side blockOffset, w, h int) *BlockXyz {
+	return corner.AddXyz(side.dx*BlockCoord(w), BlockYCoord(h), side.dz*BlockCoord(w))
+}
+
+// PortalDestination returns where a player at pos arrives in the given
+// dimension after going through a portal. They arrive far enough inside a
+// chunk for MakePortal to build a portal around them within it.
+func PortalDestination(pos *AbsXyz, dimension DimensionId) AbsXyz {
+	dest := *pos
+	if dimension == DimensionNether {
+		dest.X /= netherScale
+		dest.Z /= netherScale
+	} else {
+		dest.X *= netherScale
+		dest.Z *= netherScale
+	}
+
+	if dest.Y < portalMinY {
+		dest.Y = portalMinY
+	} else if dest.Y > portalMaxY {
+		dest.Y = portalMaxY
+	}
+
+	chunkLoc, subLoc := dest.ToBlockXyz().ToChunkLocal()
+	subLoc.X = clampSubChunkCoord(subLoc.X, 1, ChunkSizeH-3)
+	subLoc.Z = clampSubChunkCoord(subLoc.Z, 1, ChunkSizeH-2)
+
+	return blockCenter(chunkLoc.ToBlockXyz(subLoc))
+}
+
+func clampSubChunkCoord(c, min, max SubChunkCoord) SubChunkCoord {
+	if c < min {
+		return min
+	} else if c > max {
+		return max
+	}
+	return c
+}
+
+// MakePortal finds a portal near a player who has arrived through a portal
+// from another dimension, or builds one in front of them if there is none. The
+// instance is the block at the player's feet, which must be where
+// PortalDestination put them. It returns where the player should stand, which
+// is in front of the portal. A built portal gives the player space to stand and
+// a floor, but blocks with tile entities are left in place.
+func MakePortal(instance *BlockInstance) (arrival AbsXyz) {
+	feet := &instance.BlockLoc
+	if portal, data, ok := findPortal(instance.Chunk, feet); ok {
+		return portalFront(instance.Chunk, &portal, data)
+	}
+
+	for x := BlockCoord(-1); x <= portalWidth; x++ {
+		for z := BlockCoord(-1); z <= 1; z++ {
+			setBlockInChunk(instance, feet.AddXyz(x, -1, z), blockIdObsidian, 0)
+			for y := BlockYCoord(0); y < portalHeight; y++ {
+				if z == 1 && x >= 0 && x < portalWidth {
+					continue
+				}
+				blockId := BlockIdAir
+				if z == 1 {
+					blockId = blockIdObsidian
+				}
+				setBlockInChunk(instance, feet.AddXyz(x, y, z), blockId, 0)
+			}
+		}
+		setBlockInChunk(instance, feet.AddXyz(x, portalHeight, 1), blockIdObsidian, 0)
+	}
+
+	// The frame is in place before the portal is put in it.
+	for x := BlockCoord(0); x < portalWidth; x++ {
+		for y := BlockYCoord(0); y < portalHeight; y++ {
+			setBlockInChunk(instance, feet.AddXyz(x, y, 1), blockIdPortal, portalAlongX)
+		}
+	}
+
+	return blockCenter(feet)
+}
+
+// findPortal returns the bottom block of the portal nearest to feet, within
+// portalSearchRadius blocks horizontally, and the portal's block data. Only
+// chunks that the chunk knows of are searched.
+func findPortal(chunk IChunkBlock, feet *BlockXyz) (portal BlockXyz, data byte, ok bool) {
+	var bestDistSq int
+	for dx := BlockCoord(-portalSearchRadius); dx <= portalSearchRadius; dx++ {
+		for dz := BlockCoord(-portalSearchRadius); dz <= portalSearchRadius; dz++ {
+			// Blocks below portal blocks are checked as each column is climbed,
+			// to find the bottom of each portal.
+			belowIsPortal := false
+			for y := 0; y < ChunkSizeY; y++ {
+				blockLoc := BlockXyz{feet.X + dx, BlockYCoord(y), feet.Z + dz}
+				blockId, blockData, known := chunk.BlockAt(&blockLoc)
+				isPortal := known && blockId == blockIdPortal
+				if isPortal && !belowIsPortal {
+					dy := y - int(feet.Y)
+					distSq := int(dx)*int(dx) + dy*dy + int(dz)*int(dz)
+					if !ok || distSq < bestDistSq {
+						portal, data, ok = blockLoc, blockData, true
+						bestDistSq = distSq
+					}
+				}
+				belowIsPortal = isPortal
+			}
+		}
+	}
+	return
+}
+
+// portalFront returns where a player should stand to use the portal whose
+// bottom block is at portal. They stand on whichever side of it has space,
+// or in the portal itself if neither does.
+func portalFront(chunk IChunkBlock, portal *BlockXyz, data byte) AbsXyz {
+	side := portalSides[data&1]
+	front := blockOffset{side.dz, 0, side.dx}
+	for _, offset := range [2]blockOffset{front.reverse(), front} {
+		feet := offset.from(portal)
+		if feet != nil && isBlockEmpty(chunk, feet) && isBlockEmpty(chunk, offsetUp.from(feet)) {
+			return blockCenter(feet)
+		}
+	}
+	return blockCenter(portal)
+}
+
+// isBlockEmpty returns true if the block is known to be air.
+func isBlockEmpty(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	if blockLoc == nil {
+		return false
+	}
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	return ok && blockId == BlockIdAir
+}
+
+// blockCenter returns the position of the middle of the bottom of a block.
+func blockCenter(blockLoc *BlockXyz) AbsXyz {
+	return AbsXyz{
+		AbsCoord(blockLoc.X) + 0.5,
+		AbsCoord(blockLoc.Y),
+		AbsCoord(blockLoc.Z) + 0.5,
+	}
+}
+
+// setBlockInChunk sets a block in the same chunk as instance, unless the
+// block has a tile entity, such as a chest.
+func setBlockInChunk(instance *BlockInstance, blockLoc *BlockXyz, blockId BlockId, blockData byte) {
+	if blockLoc == nil {
+		return
+	}
+	chunkLoc, subLoc := blockLoc.ToChunkLocal()
+	if *chunkLoc != *instance.BlockLoc.ToChunkXz() {
+		return
+	}
+	if index, ok := subLoc.BlockIndex(); ok && instance.Chunk.TileEntity(index) == nil {
+		instance.Chunk.SetBlockByIndex(index, blockId, blockData)
+	}
+}
@@ -0,0 +1,341 @@
+package gamerules
+
+import (
+	"fmt"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Maximum number of blocks of wire that are updated together.
+const maxWireNetwork = 1024
+
+func makeRedstoneWireAspect() (aspect IBlockAspect) {
+	return &RedstoneWireAspect{}
+}
+
+// RedstoneWireAspect is the behaviour of redstone wire. The block data holds
+// the power level of the wire. When any wire ticks, the power levels of the
+// whole network of wire connected to it are worked out together.
+//
+// Wire powers the blocks beside and below it, and connects to wire a block
+// up or down from it.
+type RedstoneWireAspect struct {
+	StandardAspect
+}
+
+func (aspect *RedstoneWireAspect) Name() string {
+	return "RedstoneWire"
+}
+
+func (aspect *RedstoneWireAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) (input RedstoneInput) {
+	if blockData == 0 || to.dy > 0 && to.dx == 0 && to.dz == 0 {
+		return
+	}
+
+	input.Power = blockData
+	input.FromWire = true
+
+	if to.isFace() {
+		input.Charge = RedstoneChargeWeak
+	} else if abs(int(to.dx))+abs(int(to.dz)) != 1 || to.dy == 0 {
+		// Not a step up or down to other wire.
+		input = RedstoneInput{}
+	}
+
+	return
+}
+
+type wireNode struct {
+	level, newLevel byte
+	links           []BlockXyz
+}
+
+func (aspect *RedstoneWireAspect) Tick(instance *BlockInstance) bool {
+	chunk := instance.Chunk
+	wireId := instance.BlockType.id
+
+	// Find the network of wire.
+	network := map[BlockXyz]*wireNode{
+		instance.BlockLoc: &wireNode{level: instance.Data},
+	}
+	queue := []BlockXyz{instance.BlockLoc}
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		node := network[loc]
+
+		for _, linkLoc := range aspect.wireLinks(chunk, &loc) {
+			blockId, blockData, ok := chunk.BlockAt(&linkLoc)
+			if !ok || blockId != wireId {
+				continue
+			}
+
+			node.links = append(node.links, linkLoc)
+			if _, ok := network[linkLoc]; !ok && len(network) < maxWireNetwork {
+				network[linkLoc] = &wireNode{level: blockData}
+				queue = append(queue, linkLoc)
+			}
+		}
+	}
+
+	// Spread power from the wire powered by other blocks.
+	for loc, node := range network {
+		node.newLevel = aspect.inputLevel(chunk, &loc, network)
+		if node.newLevel > 1 {
+			queue = append(queue, loc)
+		}
+	}
+	for len(queue) > 0 {
+		node := network[queue[0]]
+		queue = queue[1:]
+
+		for _, linkLoc := range node.links {
+			if other, ok := network[linkLoc]; ok && other.newLevel < node.newLevel-1 {
+				other.newLevel = node.newLevel - 1
+				queue = append(queue, linkLoc)
+			}
+		}
+	}
+
+	chunkLoc := instance.BlockLoc.ToChunkXz()
+	for loc, node := range network {
+		if node.newLevel == node.level {
+			continue
+		}
+
+		nodeChunkLoc, subLoc := loc.ToChunkLocal()
+		if *nodeChunkLoc != *chunkLoc {
+			// Wire in other chunks updates itself.
+			chunk.AddActiveBlock(&loc)
+			continue
+		}
+
+		index, _ := subLoc.BlockIndex()
+		chunk.SetBlockByIndex(index, wireId, node.newLevel)
+		activateRedstoneArea(chunk, &loc)
+	}
+
+	return false
+}
+
+// wireLinks returns the locations that wire connects to from the wire at
+// blockLoc.
+func (aspect *RedstoneWireAspect) wireLinks(chunk IChunkBlock, blockLoc *BlockXyz) (links []BlockXyz) {
+	// Wire can't step up through a solid block above it.
+	canStepUp := true
+	if aboveLoc := offsetUp.from(blockLoc); aboveLoc != nil {
+		canStepUp = !aspect.isConductor(chunk, aboveLoc)
+	}
+
+	for _, offset := range sideOffsets {
+		sideLoc := offset.from(blockLoc)
+		if sideLoc == nil {
+			continue
+		}
+		links = append(links, *sideLoc)
+
+		if canStepUp {
+			if upLoc := offsetUp.from(sideLoc); upLoc != nil {
+				links = append(links, *upLoc)
+			}
+		}
+
+		// Wire can't step down through a solid block beside it.
+		if !aspect.isConductor(chunk, sideLoc) {
+			if downLoc := offsetDown.from(sideLoc); downLoc != nil {
+				links = append(links, *downLoc)
+			}
+		}
+	}
+
+	return
+}
+
+func (aspect *RedstoneWireAspect) isConductor(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	if !ok {
+		return false
+	}
+	blockType, ok := Blocks.Get(blockId)
+	return ok && conductsRedstone(blockType)
+}
+
+// inputLevel returns the power level given to the wire at blockLoc by blocks
+// other than the wire in the network.
+func (aspect *RedstoneWireAspect) inputLevel(chunk IChunkBlock, blockLoc *BlockXyz, network map[BlockXyz]*wireNode) (level byte) {
+	inputLocs := aspect.wireLinks(chunk, blockLoc)
+	for _, offset := range [2]blockOffset{offsetUp, offsetDown} {
+		if loc := offset.from(blockLoc); loc != nil {
+			inputLocs = append(inputLocs, *loc)
+		}
+	}
+
+	for i := range inputLocs {
+		inputLoc := &inputLocs[i]
+		if _, ok := network[*inputLoc]; ok {
+			continue
+		}
+
+		input := RedstoneInputFrom(chunk, inputLoc, blockLoc)
+		if inputLevel := input.wireLevel(); inputLevel > level {
+			level = inputLevel
+		}
+	}
+
+	return
+}
+
+func makeRedstoneTorchAspect() (aspect IBlockAspect) {
+	return &RedstoneTorchAspect{}
+}
+
+// RedstoneTorchAspect is the behaviour of redstone torches. A torch is lit
+// unless the block it is attached to is powered, and changes Delay ticks
+// after that block does. A lit torch powers the blocks around it, and charges
+// the block above it.
+type RedstoneTorchAspect struct {
+	StandardAspect
+	Lit   BlockId // Block type of the torch when lit.
+	Unlit BlockId // Block type of the torch when unlit.
+	Delay Ticks
+}
+
+func (aspect *RedstoneTorchAspect) Name() string {
+	return "RedstoneTorch"
+}
+
+func (aspect *RedstoneTorchAspect) Check() error {
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.Lit, aspect.Unlit); err != nil {
+		return err
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *RedstoneTorchAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) (input RedstoneInput) {
+	if blockId != aspect.Lit || !to.isFace() || to == attachedOffset(blockData) {
+		return
+	}
+
+	input.Power = redstoneMaxPower
+	if to == offsetUp {
+		input.Charge = RedstoneChargeStrong
+	}
+
+	return
+}
+
+func (aspect *RedstoneTorchAspect) Tick(instance *BlockInstance) bool {
+	if aspect.shouldBeLit(instance) != (instance.BlockType.id == aspect.Lit) {
+		instance.Chunk.ScheduleBlockTick(instance.Index, aspect.Delay)
+	}
+	return false
+}
+
+func (aspect *RedstoneTorchAspect) ScheduledTick(instance *BlockInstance) {
+	lit := aspect.shouldBeLit(instance)
+	if lit == (instance.BlockType.id == aspect.Lit) {
+		return
+	}
+
+	blockId := aspect.Unlit
+	if lit {
+		blockId = aspect.Lit
+	}
+	instance.Chunk.SetBlockByIndex(instance.Index, blockId, instance.Data)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
+
+func (aspect *RedstoneTorchAspect) shouldBeLit(instance *BlockInstance) bool {
+	attachedLoc := attachedOffset(instance.Data).from(&instance.BlockLoc)
+	if attachedLoc == nil {
+		return true
+	}
+
+	input := RedstoneInputFrom(instance.Chunk, attachedLoc, &instance.BlockLoc)
+	return input.Power == 0
+}
+
+func makeRedstoneRepeaterAspect() (aspect IBlockAspect) {
+	return &RedstoneRepeaterAspect{}
+}
+
+// Offsets to the block that a repeater powers, from its block data.
+var repeaterFronts = [4]blockOffset{
+	offsetNorth, offsetEast, offsetSouth, offsetWest,
+}
+
+// RedstoneRepeaterAspect is the behaviour of redstone repeaters. A repeater
+// is powered when the block behind it is, after a delay of 1 to 4 redstone
+// ticks (of 2 ticks each) set in its block data. A powered repeater gives
+// full power to the block in front of it.
+type RedstoneRepeaterAspect struct {
+	StandardAspect
+	Powered   BlockId // Block type of the repeater when powered.
+	Unpowered BlockId // Block type of the repeater when unpowered.
+}
+
+func (aspect *RedstoneRepeaterAspect) Name() string {
+	return "RedstoneRepeater"
+}
+
+func (aspect *RedstoneRepeaterAspect) Check() error {
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.Powered, aspect.Unpowered); err != nil {
+		return err
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *RedstoneRepeaterAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) (input RedstoneInput) {
+	if blockId == aspect.Powered && to == repeaterFronts[blockData&0x3] {
+		input.Power = redstoneMaxPower
+		input.Charge = RedstoneChargeStrong
+	}
+	return
+}
+
+func (aspect *RedstoneRepeaterAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	// Change the delay.
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, (instance.Data+0x4)&0xf)
+}
+
+func (aspect *RedstoneRepeaterAspect) Tick(instance *BlockInstance) bool {
+	if aspect.shouldBePowered(instance) != (instance.BlockType.id == aspect.Powered) {
+		delay := Ticks((instance.Data>>2)&0x3+1) * 2
+		instance.Chunk.ScheduleBlockTick(instance.Index, delay)
+	}
+	return false
+}
+
+func (aspect *RedstoneRepeaterAspect) ScheduledTick(instance *BlockInstance) {
+	powered := aspect.shouldBePowered(instance)
+	if powered == (instance.BlockType.id == aspect.Powered) {
+		return
+	}
+
+	blockId := aspect.Unpowered
+	if powered {
+		blockId = aspect.Powered
+	}
+	instance.Chunk.SetBlockByIndex(instance.Index, blockId, instance.Data)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
+
+func (aspect *RedstoneRepeaterAspect) shouldBePowered(instance *BlockInstance) bool {
+	behindLoc := repeaterFronts[instance.Data&0x3].reverse().from(&instance.BlockLoc)
+	if behindLoc == nil {
+		return false
+	}
+
+	input := RedstoneInputFrom(instance.Chunk, behindLoc, &instance.BlockLoc)
+	return input.Power > 0
+}
+
+// checkBlockTypes checks that the block types used by an aspect exist.
+func checkBlockTypes(blockAttrs *BlockAttrs, blockIds ...BlockId) error {
+	for _, blockId := range blockIds {
+		if _, ok := Blocks.Get(blockId); !ok {
+			return fmt.Errorf("block %q: block type %d does not exist", blockAttrs.Name, blockId)
+		}
+	}
+	return nil
+}
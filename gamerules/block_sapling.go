@@ -1,87 +1,229 @@
 package gamerules
 
 import (
-	. "github.com/huin/chunkymonkey/types"
-	"log"
+	"fmt"
 	"math/rand"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Light level that saplings need to grow.
+const saplingMinLight = 9
+
+// Types of tree that saplings grow into, by the sapling's block data.
+const (
+	treeOak = iota
+	treeSpruce
+	treeBirch
+	treeBigOak
+
+	treeTypeMask = 0x3
 )
 
-// Behaviour of a sapling block, takes care of growing or dying depending on
-// world conditions.
+// Block data of the logs and leaves of each type of tree.
+var treeBlockData = [...]byte{
+	treeOak:    0,
+	treeSpruce: 1,
+	treeBirch:  2,
+	treeBigOak: 0,
+}
+
 func makeSaplingAspect() (aspect IBlockAspect) {
 	return &SaplingAspect{}
 }
 
+// SaplingAspect is the behaviour of saplings, which grow into trees of Log and
+// Leaves blocks. The block data of the sapling chooses the type of tree: oak,
+// spruce, birch or big oak. Each random tick, a sapling in enough light has a
+// GrowthChance in 100 of growing, if there is space for the tree's trunk and
+// branches. Logs and leaves are placed wherever there is space for them, even
+// in other chunks.
 type SaplingAspect struct {
 	StandardAspect
+	Log          BlockId
+	Leaves       BlockId
+	GrowthChance byte
 }
 
 func (aspect *SaplingAspect) Name() string {
 	return "Sapling"
 }
 
-func (aspect *SaplingAspect) Tick(instance *BlockInstance) bool {
-	// TODO: Use a random number that is reproduceable from merely the chunk
-	// location so that the world seed produces consistent worlds.
-	if rand.Intn(1e4) >= 1e4-1 {
-		// Turn this block into a tree
-		return aspect.makeTree(instance)
+func (aspect *SaplingAspect) Check() error {
+	if aspect.GrowthChance > 100 {
+		return fmt.Errorf("block %q: GrowthChance must be at most 100", aspect.blockAttrs.Name)
+	}
+	if err := checkBlockTypes(aspect.blockAttrs, aspect.Log, aspect.Leaves); err != nil {
+		return err
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *SaplingAspect) RandomTick(instance *BlockInstance) {
+	chunk := instance.Chunk
+
+	if light, ok := chunk.LightLevel(&instance.BlockLoc); !ok || light < saplingMinLight {
+		return
+	}
+
+	if byte(chunk.Rand().Intn(100)) < aspect.GrowthChance {
+		aspect.grow(instance)
+	}
+}
+
+// grow turns the sapling into a tree. It returns false if there is no space
+// for the tree.
+func (aspect *SaplingAspect) grow(instance *BlockInstance) bool {
+	chunk := instance.Chunk
+	treeType := instance.Data & treeTypeMask
+	shape := newTree(chunk.Rand(), treeType)
+
+	for _, logBlock := range shape.logs {
+		if logBlock != (treeBlock{}) && !logFitsAt(chunk, logBlock.from(&instance.BlockLoc)) {
+			return false
+		}
+	}
+
+	data := treeBlockData[treeType]
+	chunk.SetBlockByIndex(instance.Index, aspect.Log, data)
+
+	// Logs are placed first so that leaves don't take their place.
+	for _, logBlock := range shape.logs {
+		if logBlock != (treeBlock{}) {
+			chunk.PlaceBlock(logBlock.from(&instance.BlockLoc), aspect.Log, data)
+		}
 	}
+	for _, leafBlock := range shape.leaves {
+		if blockLoc := leafBlock.from(&instance.BlockLoc); blockLoc != nil {
+			chunk.PlaceBlock(blockLoc, aspect.Leaves, data)
+		}
+	}
+
 	return true
 }
 
-func (aspect *SaplingAspect) makeTree(instance *BlockInstance) bool {
-	loc := instance.SubLoc
-	minheight := 3
-	maxheight := 6
-	height := minheight + rand.Intn(maxheight-minheight)
-	maxy := loc.Y + SubChunkCoord(height)
-
-	for y := loc.Y; y < maxy; y++ {
-		loc.Y = y
-		index, ok := loc.BlockIndex()
-		if !ok {
-			// TODO: Can't place a block outside chunk boundaries
-			log.Printf("Couldn't place a tree block (%v,%v,%v)", loc.X, loc.Y, loc.Z)
-		} else {
-			instance.Chunk.SetBlockByIndex(index, BlockId(17), byte(0))
+// treeBlock is the location of a block of a tree relative to the sapling that
+// grows it.
+type treeBlock struct {
+	dx BlockCoord
+	dy BlockYCoord
+	dz BlockCoord
+}
+
+// from returns the location of the block of a tree grown by the sapling at
+// saplingLoc, or nil if it is outside of the world.
+func (block treeBlock) from(saplingLoc *BlockXyz) *BlockXyz {
+	return saplingLoc.AddXyz(block.dx, block.dy, block.dz)
+}
+
+// tree holds the shape of a tree that is about to grow.
+type tree struct {
+	logs   []treeBlock
+	leaves []treeBlock
+}
+
+// newTree picks the shape of a tree of the given type.
+func newTree(rand *rand.Rand, treeType byte) (t *tree) {
+	t = new(tree)
+
+	switch treeType {
+	case treeSpruce:
+		height := 6 + rand.Intn(4)
+		t.addTrunk(height)
+		// A cone of alternately wide and narrow layers.
+		bottom := 2 + rand.Intn(2)
+		for dy := height; dy >= bottom; dy-- {
+			switch depth := height - dy; {
+			case depth == 0:
+				t.addLeafLayer(rand, BlockYCoord(dy), 0)
+			case depth%2 == 1:
+				t.addLeafLayer(rand, BlockYCoord(dy), 1)
+			default:
+				t.addLeafLayer(rand, BlockYCoord(dy), 2)
+			}
+		}
+
+	case treeBigOak:
+		height := 8 + rand.Intn(5)
+		t.addTrunk(height)
+		t.addLeafBall(0, BlockYCoord(height-1), 0, 3)
+		for branches := 2 + rand.Intn(3); branches > 0; branches-- {
+			dx, dz := BlockCoord(rand.Intn(3)-1), BlockCoord(rand.Intn(3)-1)
+			if dx == 0 && dz == 0 {
+				continue
+			}
+			y := BlockYCoord(height/2 + rand.Intn(height/2-1))
+			length := BlockCoord(2 + rand.Intn(2))
+			for i := BlockCoord(1); i <= length; i++ {
+				t.logs = append(t.logs, treeBlock{dx * i, y + BlockYCoord(i), dz * i})
+			}
+			t.addLeafBall(dx*length, y+BlockYCoord(length), dz*length, 2)
+		}
+
+	default:
+		// Oak and birch trees, birch being taller.
+		height := 4 + rand.Intn(3)
+		if treeType == treeBirch {
+			height++
+		}
+		t.addTrunk(height)
+		for dy := height - 3; dy <= height; dy++ {
+			radius := BlockCoord(2)
+			if dy >= height-1 {
+				radius = 1
+			}
+			t.addLeafLayer(rand, BlockYCoord(dy), radius)
 		}
 	}
 
-	// Store the location at the top block of the tree
-	treex, treey, treez := int(loc.X), int(loc.Y), int(loc.Z)
-	cradius := height / 2
-
-	// Start one block above the tree and move down
-	for y := treey + 1; y >= treey-1; y-- {
-		var radius int
-
-		// Slightly round out the canopy
-		if y > treey {
-			radius = cradius - 1
-		} else if y == treey {
-			radius = cradius
-		} else if y < treey {
-			radius = cradius - 1
+	return
+}
+
+func (t *tree) addTrunk(height int) {
+	for dy := 0; dy < height; dy++ {
+		t.logs = append(t.logs, treeBlock{0, BlockYCoord(dy), 0})
+	}
+}
+
+// addLeafLayer adds a square layer of leaves around the trunk, leaving out
+// some of its corners.
+func (t *tree) addLeafLayer(rand *rand.Rand, dy BlockYCoord, radius BlockCoord) {
+	for dx := -radius; dx <= radius; dx++ {
+		for dz := -radius; dz <= radius; dz++ {
+			isCorner := radius > 0 && (dx == radius || dx == -radius) && (dz == radius || dz == -radius)
+			if isCorner && rand.Intn(2) == 0 {
+				continue
+			}
+			t.leaves = append(t.leaves, treeBlock{dx, dy, dz})
 		}
+	}
+}
 
-		for x := treex - radius; x <= treex+radius; x++ {
-			for z := treez - radius; z <= treez+radius; z++ {
-				if y > treey || x != treex || z != treez {
-					loc.X = SubChunkCoord(x)
-					loc.Y = SubChunkCoord(y)
-					loc.Z = SubChunkCoord(z)
-					index, ok := loc.BlockIndex()
-					if !ok {
-						// TODO: Can't place a block outside chunk boundaries
-						log.Printf("Couldn't place a leaf block (%v,%v,%v)", loc.X, loc.Y, loc.Z)
-					} else {
-						instance.Chunk.SetBlockByIndex(index, BlockId(18), byte(0))
-					}
+// addLeafBall adds a roughly round ball of leaves.
+func (t *tree) addLeafBall(x BlockCoord, y BlockYCoord, z BlockCoord, radius int) {
+	for dx := -radius; dx <= radius; dx++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dz := -radius; dz <= radius; dz++ {
+				if dx*dx+dy*dy+dz*dz <= radius*radius+radius {
+					t.leaves = append(t.leaves, treeBlock{x + BlockCoord(dx), y + BlockYCoord(dy), z + BlockCoord(dz)})
 				}
 			}
 		}
 	}
+}
 
-	return true
+// logFitsAt returns true unless the block is outside of the world or known
+// not to be replaceable. Blocks that aren't known, such as those in other
+// shards, are pending: the log is placed there later only if the block is
+// replaceable then.
+func logFitsAt(chunk IChunkBlock, blockLoc *BlockXyz) bool {
+	if blockLoc == nil {
+		return false
+	}
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	if !ok {
+		return true
+	}
+	blockType, ok := Blocks.Get(blockId)
+	return ok && blockType.Replaceable
 }
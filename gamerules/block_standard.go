@@ -2,10 +2,14 @@ package gamerules
 
 import (
 	"fmt"
+	"log"
 
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// Percentage of the expected dig time that digs may finish early by.
+const digTimeTolerance = 30
+
 func makeStandardAspect() (aspect IBlockAspect) {
 	return &StandardAspect{}
 }
@@ -34,14 +38,32 @@ func (aspect *StandardAspect) Check() error {
 			return fmt.Errorf("block %q: %v", aspect.blockAttrs.Name, err)
 		}
 	}
+	if aspect.blockAttrs.Hardness > 0 && aspect.BreakOn != DigBlockBroke {
+		return fmt.Errorf("block %q: blocks with hardness must break on DigBlockBroke", aspect.blockAttrs.Name)
+	}
 	return nil
 }
 
-func (aspect *StandardAspect) Hit(instance *BlockInstance, player IPlayerClient, digStatus DigStatus) (destroyed bool) {
+func (aspect *StandardAspect) Hit(instance *BlockInstance, player IPlayerClient, gameType GameType, held *Slot, digStatus DigStatus, digTicks Ticks) (destroyed bool) {
+	if gameType == GameTypeCreative {
+		// Creative players break blocks as soon as they start digging.
+		return digStatus == DigStarted
+	}
+
 	if aspect.BreakOn != digStatus {
 		return
 	}
 
+	if digStatus == DigBlockBroke {
+		// Digs may finish a little early to allow for network delays, but not
+		// so early that the player must be cheating.
+		minTicks := aspect.blockAttrs.DigTicks(held) * (100 - digTimeTolerance) / 100
+		if digTicks < minTicks {
+			log.Printf("StandardAspect.Hit: block %q dug in %d ticks, expected at least %d", aspect.blockAttrs.Name, digTicks, minTicks)
+			return
+		}
+	}
+
 	destroyed = true
 
 	return
@@ -53,11 +75,16 @@ func (aspect *StandardAspect) Interact(instance *BlockInstance, player IPlayerCl
 func (aspect *StandardAspect) InventoryClick(instance *BlockInstance, player IPlayerClient, click *Click) {
 }
 
+func (aspect *StandardAspect) InventoryPutItem(instance *BlockInstance, player IPlayerClient, item *Slot) {
+	// There's no inventory, so the player gets their items back.
+	player.GiveItem(*item)
+}
+
 func (aspect *StandardAspect) InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient) {
 }
 
-func (aspect *StandardAspect) Destroy(instance *BlockInstance) {
-	if len(aspect.DroppedItems) > 0 {
+func (aspect *StandardAspect) Destroy(instance *BlockInstance, harvest bool) {
+	if harvest && len(aspect.DroppedItems) > 0 {
 		rand := instance.Chunk.Rand()
 		// Possibly drop item(s)
 		r := byte(rand.Intn(100))
@@ -0,0 +1,146 @@
+package gamerules
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Bit in the block data of levers and buttons that is set when they are on.
+const switchOnBit = 0x8
+
+// switchOutput returns the redstone power given out by a lever or button.
+// When on, it powers the blocks around it, and charges the block it is
+// attached to.
+func switchOutput(blockData byte, to blockOffset) (input RedstoneInput) {
+	if blockData&switchOnBit == 0 || !to.isFace() {
+		return
+	}
+
+	input.Power = redstoneMaxPower
+	if to == attachedOffset(blockData) {
+		input.Charge = RedstoneChargeStrong
+	}
+
+	return
+}
+
+func makeLeverAspect() (aspect IBlockAspect) {
+	return &LeverAspect{}
+}
+
+// LeverAspect is the behaviour of levers, which are switched on and off by
+// players.
+type LeverAspect struct {
+	StandardAspect
+}
+
+func (aspect *LeverAspect) Name() string {
+	return "Lever"
+}
+
+func (aspect *LeverAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) RedstoneInput {
+	return switchOutput(blockData, to)
+}
+
+func (aspect *LeverAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, instance.Data^switchOnBit)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
+
+func makeButtonAspect() (aspect IBlockAspect) {
+	return &ButtonAspect{}
+}
+
+// ButtonAspect is the behaviour of buttons, which are switched on by players
+// and switch off again after PressTicks.
+type ButtonAspect struct {
+	StandardAspect
+	PressTicks Ticks
+}
+
+func (aspect *ButtonAspect) Name() string {
+	return "Button"
+}
+
+func (aspect *ButtonAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) RedstoneInput {
+	return switchOutput(blockData, to)
+}
+
+func (aspect *ButtonAspect) Interact(instance *BlockInstance, player IPlayerClient) {
+	if instance.Data&switchOnBit != 0 {
+		return
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, instance.Data|switchOnBit)
+	instance.Chunk.ScheduleBlockTick(instance.Index, aspect.PressTicks)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
+
+func (aspect *ButtonAspect) ScheduledTick(instance *BlockInstance) {
+	if instance.Data&switchOnBit == 0 {
+		return
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, instance.Data&^switchOnBit)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
+
+func makePressurePlateAspect() (aspect IBlockAspect) {
+	return &PressurePlateAspect{}
+}
+
+// PressurePlateAspect is the behaviour of pressure plates, which are on while
+// a player stands on them. The block data is 1 while the plate is pressed.
+// The plate is checked every CheckTicks while it is pressed.
+//
+// TODO Mobs and items should also press plates.
+type PressurePlateAspect struct {
+	StandardAspect
+	CheckTicks Ticks
+}
+
+func (aspect *PressurePlateAspect) Name() string {
+	return "PressurePlate"
+}
+
+func (aspect *PressurePlateAspect) redstoneOutput(blockId BlockId, blockData byte, to blockOffset) (input RedstoneInput) {
+	if blockData == 0 || !to.isFace() {
+		return
+	}
+
+	input.Power = redstoneMaxPower
+	if to == offsetDown {
+		input.Charge = RedstoneChargeStrong
+	}
+
+	return
+}
+
+func (aspect *PressurePlateAspect) Tick(instance *BlockInstance) bool {
+	if instance.Data == 0 && instance.Chunk.IsOccupied(&instance.BlockLoc) {
+		aspect.setPressed(instance, true)
+	}
+	return false
+}
+
+func (aspect *PressurePlateAspect) ScheduledTick(instance *BlockInstance) {
+	if instance.Data == 0 {
+		return
+	}
+
+	if instance.Chunk.IsOccupied(&instance.BlockLoc) {
+		instance.Chunk.ScheduleBlockTick(instance.Index, aspect.CheckTicks)
+	} else {
+		aspect.setPressed(instance, false)
+	}
+}
+
+func (aspect *PressurePlateAspect) setPressed(instance *BlockInstance, pressed bool) {
+	var blockData byte
+	if pressed {
+		blockData = 1
+		instance.Chunk.ScheduleBlockTick(instance.Index, aspect.CheckTicks)
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, instance.BlockType.id, blockData)
+	activateRedstoneArea(instance.Chunk, &instance.BlockLoc)
+}
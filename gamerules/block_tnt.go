@@ -0,0 +1,51 @@
+package gamerules
+
+import (
+	"fmt"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func makeTntAspect() (aspect IBlockAspect) {
+	return &TntAspect{}
+}
+
+// TntAspect is the behaviour of TNT. It is set off by redstone power, fire,
+// flint and steel or other explosions, and becomes an ActivatedTnt object
+// that explodes after its fuse burns down.
+type TntAspect struct {
+	StandardAspect
+	Fuse Ticks // Usual number of ticks before the TNT explodes.
+}
+
+func (aspect *TntAspect) Name() string {
+	return "Tnt"
+}
+
+func (aspect *TntAspect) Check() error {
+	if aspect.Fuse <= 0 {
+		return fmt.Errorf("block %q: fuse must be positive", aspect.blockAttrs.Name)
+	}
+	return aspect.StandardAspect.Check()
+}
+
+func (aspect *TntAspect) Tick(instance *BlockInstance) bool {
+	if isRedstonePowered(instance.Chunk, &instance.BlockLoc, offsetNone) {
+		aspect.Ignite(instance, 0)
+	}
+	return false
+}
+
+func (aspect *TntAspect) Ignite(instance *BlockInstance, fuse Ticks) {
+	if fuse == 0 {
+		fuse = aspect.Fuse
+	}
+
+	instance.Chunk.SetBlockByIndex(instance.Index, BlockIdAir, 0)
+
+	position := instance.BlockLoc.ToAbsXyz()
+	position.X += 0.5
+	position.Y += blockItemSpawnFromEdge
+	position.Z += 0.5
+	instance.Chunk.AddEntity(NewActivatedTntAt(position, fuse))
+}
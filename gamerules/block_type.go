@@ -1,18 +1,73 @@
 package gamerules
 
 import (
+	"math"
+
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// Ticks per point of hardness to dig a block by hand, or with the wrong tool
+// for blocks that require one.
+const (
+	digTicksPerHardness          = 30
+	digTicksPerHardnessUnharvest = 100
+)
+
 type BlockAttrs struct {
-	id           BlockId
-	Name         string
-	Opacity      int8
-	defined      bool
-	Destructable bool
-	Solid        bool
-	Replaceable  bool
-	Attachable   bool
+	id              BlockId
+	Name            string
+	Opacity         int8
+	Emission        int8
+	defined         bool
+	Destructable    bool
+	Solid           bool
+	Replaceable     bool
+	Attachable      bool
+	BlastResistance float32
+	Flammability    byte
+	BurnOdds        byte
+	Hardness        float32
+	ToolType        ToolTypeId // The type of tool that digs the block fastest.
+	RequiresTool    bool       // Does the block only drop items when dug with ToolType?
+	ContactDamage   Health     // Damage done to players inside the block.
+	SetsOnFire      bool       // Does the block set players inside it on fire?
+	Drowns          bool       // Do players with their head inside the block drown?
+	Climbable       bool       // Can players inside the block move up without falling?
+	Passable        bool       // Can players move into the block even if it is Solid?
+}
+
+// CanHarvest returns true if digging the block with the held item drops items.
+func (attrs *BlockAttrs) CanHarvest(held *Slot) bool {
+	return !attrs.RequiresTool || attrs.isToolFor(held)
+}
+
+// DigTicks returns the number of ticks that a player takes to dig the block
+// with the held item.
+func (attrs *BlockAttrs) DigTicks(held *Slot) Ticks {
+	if attrs.Hardness <= 0 {
+		return 0
+	}
+
+	speed := float32(1)
+	if attrs.isToolFor(held) && held.ItemType().DigSpeed > 0 {
+		speed = held.ItemType().DigSpeed
+	}
+
+	ticksPerHardness := float32(digTicksPerHardness)
+	if !attrs.CanHarvest(held) {
+		ticksPerHardness = digTicksPerHardnessUnharvest
+	}
+
+	return Ticks(math.Ceil(float64(attrs.Hardness * ticksPerHardness / speed)))
+}
+
+// isToolFor returns true if the held item is the type of tool for the block.
+func (attrs *BlockAttrs) isToolFor(held *Slot) bool {
+	if attrs.ToolType == 0 || held == nil || held.Count < 1 {
+		return false
+	}
+	itemType := held.ItemType()
+	return itemType != nil && itemType.ToolType == attrs.ToolType
 }
 
 // The core information about any block type.
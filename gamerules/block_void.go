@@ -22,7 +22,7 @@ func (aspect *VoidAspect) Check() error {
 	return nil
 }
 
-func (aspect *VoidAspect) Hit(instance *BlockInstance, player IPlayerClient, digStatus DigStatus) (destroyed bool) {
+func (aspect *VoidAspect) Hit(instance *BlockInstance, player IPlayerClient, gameType GameType, held *Slot, digStatus DigStatus, digTicks Ticks) (destroyed bool) {
 	destroyed = false
 	return
 }
@@ -33,10 +33,14 @@ func (aspect *VoidAspect) Interact(instance *BlockInstance, player IPlayerClient
 func (aspect *VoidAspect) InventoryClick(instance *BlockInstance, player IPlayerClient, click *Click) {
 }
 
+func (aspect *VoidAspect) InventoryPutItem(instance *BlockInstance, player IPlayerClient, item *Slot) {
+	player.GiveItem(*item)
+}
+
 func (aspect *VoidAspect) InventoryUnsubscribed(instance *BlockInstance, player IPlayerClient) {
 }
 
-func (aspect *VoidAspect) Destroy(instance *BlockInstance) {
+func (aspect *VoidAspect) Destroy(instance *BlockInstance, harvest bool) {
 }
 
 func (aspect *VoidAspect) Tick(instance *BlockInstance) bool {
@@ -2,6 +2,8 @@ package gamerules
 
 import (
 	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
 )
 
 func TestMergeBlockItems(t *testing.T) {
@@ -102,3 +104,65 @@ func TestMergeBlockItems(t *testing.T) {
 		itemTypes[5],
 	)
 }
+
+func TestBlockDigTicks(t *testing.T) {
+	const (
+		stone         = BlockId(1)
+		dirt          = BlockId(3)
+		sapling       = BlockId(6)
+		ironShovel    = ItemTypeId(256)
+		woodenPickaxe = ItemTypeId(270)
+	)
+
+	tests := []struct {
+		blockId    BlockId
+		held       Slot
+		digTicks   Ticks
+		canHarvest bool
+	}{
+		{stone, Slot{}, 150, false},
+		{stone, Slot{woodenPickaxe, 1, 0}, 23, true},
+		{stone, Slot{ironShovel, 1, 0}, 150, false},
+		{dirt, Slot{}, 15, true},
+		{dirt, Slot{ironShovel, 1, 0}, 3, true},
+		{sapling, Slot{}, 0, true},
+	}
+
+	for _, test := range tests {
+		blockType, _ := Blocks.Get(test.blockId)
+		if digTicks := blockType.DigTicks(&test.held); digTicks != test.digTicks {
+			t.Errorf("Expected block %d to take %d ticks to dig with %+v, got %d", test.blockId, test.digTicks, test.held, digTicks)
+		}
+		if canHarvest := blockType.CanHarvest(&test.held); canHarvest != test.canHarvest {
+			t.Errorf("Expected CanHarvest of block %d with %+v to be %t", test.blockId, test.held, test.canHarvest)
+		}
+	}
+}
+
+func TestStandardAspectHitChecksDigTime(t *testing.T) {
+	chunk := newTestChunk()
+	instance := chunk.instance(BlockXyz{5, 0, 5})
+	pickaxe := &Slot{ItemTypeId(270), 1, 0}
+
+	if instance.BlockType.Aspect.Hit(instance, nil, GameTypeSurvival, pickaxe, DigStarted, 0) {
+		t.Errorf("Expected stone not to break when digging starts")
+	}
+	if instance.BlockType.Aspect.Hit(instance, nil, GameTypeSurvival, pickaxe, DigBlockBroke, 5) {
+		t.Errorf("Expected stone dug too quickly not to break")
+	}
+	if !instance.BlockType.Aspect.Hit(instance, nil, GameTypeSurvival, pickaxe, DigBlockBroke, 20) {
+		t.Errorf("Expected stone dug in time to break")
+	}
+}
+
+func TestStandardAspectHitCreative(t *testing.T) {
+	chunk := newTestChunk()
+	instance := chunk.instance(BlockXyz{5, 0, 5})
+
+	if !instance.BlockType.Aspect.Hit(instance, nil, GameTypeCreative, &Slot{}, DigStarted, 0) {
+		t.Errorf("Expected stone to break as soon as a creative player digs it")
+	}
+	if instance.BlockType.Aspect.Hit(instance, nil, GameTypeCreative, &Slot{}, DigBlockBroke, 0) {
+		t.Errorf("Expected stone not to break twice for a creative player")
+	}
+}
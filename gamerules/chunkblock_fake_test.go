@@ -0,0 +1,288 @@
+package gamerules
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// The block that the fake chunk's floor is made of.
+const testBlockIdStone = BlockId(1)
+
+type testBlock struct {
+	blockId   BlockId
+	blockData byte
+}
+
+// testChunk is a fake IChunkBlock for chunk 0,0 with a stone floor at Y=0.
+// Blocks outside the chunk are unknown, and the power that they give is
+// set in inputs. Blocks placed outside the chunk are recorded in placements.
+type testChunk struct {
+	rand       *rand.Rand
+	blocks     map[BlockXyz]testBlock
+	inputs     map[[2]BlockXyz]RedstoneInput
+	active     map[BlockXyz]bool
+	scheduled  map[BlockIndex]Ticks
+	ticks      Ticks
+	packets    int
+	entities   []INonPlayerEntity
+	explosions int
+	raining    bool
+	spreads    []BlockSpread
+	placements []BlockPlacement
+	light      int8
+}
+
+func newTestChunk() *testChunk {
+	return &testChunk{
+		rand:      rand.New(rand.NewSource(0)),
+		blocks:    make(map[BlockXyz]testBlock),
+		inputs:    make(map[[2]BlockXyz]RedstoneInput),
+		active:    make(map[BlockXyz]bool),
+		scheduled: make(map[BlockIndex]Ticks),
+		light:     15,
+	}
+}
+
+func (chunk *testChunk) inChunk(blockXyz *BlockXyz) bool {
+	return blockXyz.X >= 0 && blockXyz.X < ChunkSizeH && blockXyz.Z >= 0 && blockXyz.Z < ChunkSizeH
+}
+
+func (chunk *testChunk) Rand() *rand.Rand {
+	return chunk.rand
+}
+
+func (chunk *testChunk) ItemType(itemTypeId ItemTypeId) (itemType *ItemType, ok bool) {
+	itemType, ok = Items[itemTypeId]
+	return
+}
+
+func (chunk *testChunk) AddEntity(s INonPlayerEntity) {
+	chunk.entities = append(chunk.entities, s)
+}
+
+func (chunk *testChunk) SetBlockByIndex(blockIndex BlockIndex, blockId BlockId, blockData byte) {
+	subLoc := blockIndex.ToSubChunkXyz()
+	blockLoc := BlockXyz{BlockCoord(subLoc.X), BlockYCoord(subLoc.Y), BlockCoord(subLoc.Z)}
+	chunk.setBlock(blockLoc, blockId, blockData)
+}
+
+func (chunk *testChunk) TileEntity(blockIndex BlockIndex) ITileEntity {
+	return nil
+}
+
+func (chunk *testChunk) SetTileEntity(blockIndex BlockIndex, extra ITileEntity) {
+}
+
+func (chunk *testChunk) AddOnUnsubscribe(entityId EntityId, observer IUnsubscribed) {
+}
+
+func (chunk *testChunk) RemoveOnUnsubscribe(entityId EntityId, observer IUnsubscribed) {
+}
+
+func (chunk *testChunk) AddActiveBlock(blockXyz *BlockXyz) {
+	if chunk.inChunk(blockXyz) {
+		chunk.active[*blockXyz] = true
+	}
+}
+
+func (chunk *testChunk) AddActiveBlockIndex(blockIndex BlockIndex) {
+	subLoc := blockIndex.ToSubChunkXyz()
+	chunk.active[BlockXyz{BlockCoord(subLoc.X), BlockYCoord(subLoc.Y), BlockCoord(subLoc.Z)}] = true
+}
+
+func (chunk *testChunk) BlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool) {
+	if !chunk.inChunk(blockXyz) {
+		return
+	}
+
+	block, ok := chunk.blocks[*blockXyz]
+	if !ok && blockXyz.Y == 0 {
+		return testBlockIdStone, 0, true
+	}
+
+	return block.blockId, block.blockData, true
+}
+
+func (chunk *testChunk) BorderBlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool) {
+	return
+}
+
+func (chunk *testChunk) SpreadBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte) {
+	chunk.spreads = append(chunk.spreads, BlockSpread{*blockXyz, blockTypeId, blockData})
+}
+
+func (chunk *testChunk) PlaceBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte) {
+	if !chunk.inChunk(blockXyz) {
+		chunk.placements = append(chunk.placements, BlockPlacement{*blockXyz, blockTypeId, blockData})
+	} else if blockType, ok := blockTypeAt(chunk, blockXyz); ok && blockType.Replaceable {
+		chunk.setBlock(*blockXyz, blockTypeId, blockData)
+	}
+}
+
+func (chunk *testChunk) LightLevel(blockXyz *BlockXyz) (level int8, ok bool) {
+	return chunk.light, chunk.inChunk(blockXyz)
+}
+
+func (chunk *testChunk) CurrentTick() Ticks {
+	return chunk.ticks
+}
+
+func (chunk *testChunk) ScheduleBlockTick(blockIndex BlockIndex, delay Ticks) {
+	if _, ok := chunk.scheduled[blockIndex]; !ok {
+		chunk.scheduled[blockIndex] = chunk.ticks + delay
+	}
+}
+
+func (chunk *testChunk) RedstoneInput(from, to *BlockXyz) RedstoneInput {
+	return chunk.inputs[[2]BlockXyz{*from, *to}]
+}
+
+func (chunk *testChunk) IsOccupied(blockXyz *BlockXyz) bool {
+	return false
+}
+
+func (chunk *testChunk) MulticastPlayers(exclude EntityId, packet []byte) {
+	chunk.packets++
+}
+
+func (chunk *testChunk) Explode(center *AbsXyz, power float32) {
+	chunk.explosions++
+}
+
+func (chunk *testChunk) IsRaining(blockXyz *BlockXyz) bool {
+	return chunk.raining
+}
+
+// setBlock sets a block and activates it and its neighbours, as a real chunk
+// does.
+func (chunk *testChunk) setBlock(blockLoc BlockXyz, blockId BlockId, blockData byte) {
+	chunk.blocks[blockLoc] = testBlock{blockId, blockData}
+	chunk.AddActiveBlock(&blockLoc)
+	for _, offset := range faceOffsets {
+		if neighbourLoc := offset.from(&blockLoc); neighbourLoc != nil {
+			chunk.AddActiveBlock(neighbourLoc)
+		}
+	}
+}
+
+func (chunk *testChunk) instance(blockLoc BlockXyz) *BlockInstance {
+	blockId, blockData, _ := chunk.BlockAt(&blockLoc)
+	blockType, _ := Blocks.Get(blockId)
+	_, subLoc := blockLoc.ToChunkLocal()
+	index, _ := subLoc.BlockIndex()
+	return &BlockInstance{
+		Chunk:     chunk,
+		BlockLoc:  blockLoc,
+		SubLoc:    *subLoc,
+		Index:     index,
+		BlockType: blockType,
+		Data:      blockData,
+	}
+}
+
+func (chunk *testChunk) interact(blockLoc BlockXyz) {
+	instance := chunk.instance(blockLoc)
+	instance.BlockType.Aspect.Interact(instance, nil)
+}
+
+// tick runs the active blocks, and then the scheduled blocks, in order of
+// location so that each test runs the same each time. Blocks then spread into
+// the chunk.
+func (chunk *testChunk) tick(n int) {
+	for ; n > 0; n-- {
+		chunk.ticks++
+
+		var locs []BlockXyz
+		for blockLoc := range chunk.active {
+			locs = append(locs, blockLoc)
+		}
+		chunk.active = make(map[BlockXyz]bool)
+		sort.Sort(testBlockXyzs(locs))
+
+		for _, blockLoc := range locs {
+			instance := chunk.instance(blockLoc)
+			if instance.BlockType.Aspect.Tick(instance) {
+				chunk.active[blockLoc] = true
+			}
+		}
+
+		var indices []int
+		for index, due := range chunk.scheduled {
+			if due <= chunk.ticks {
+				indices = append(indices, int(index))
+				delete(chunk.scheduled, index)
+			}
+		}
+		sort.Ints(indices)
+
+		for _, index := range indices {
+			subLoc := BlockIndex(index).ToSubChunkXyz()
+			instance := chunk.instance(BlockXyz{BlockCoord(subLoc.X), BlockYCoord(subLoc.Y), BlockCoord(subLoc.Z)})
+			if aspect, ok := instance.BlockType.Aspect.(IScheduledAspect); ok {
+				aspect.ScheduledTick(instance)
+			}
+		}
+
+		spreads := chunk.spreads
+		chunk.spreads = nil
+		for _, spread := range spreads {
+			if !chunk.inChunk(&spread.Block) {
+				continue
+			}
+			spreadType, _ := Blocks.Get(spread.BlockTypeId)
+			spreadType.Aspect.(ISpreadingAspect).SpreadInto(chunk.instance(spread.Block), spread.BlockData)
+		}
+	}
+}
+
+// randomTick gives a random tick to every block that takes them, in order of
+// location, and then ticks the chunk, n times over.
+func (chunk *testChunk) randomTick(n int) {
+	for ; n > 0; n-- {
+		var locs []BlockXyz
+		for blockLoc := range chunk.blocks {
+			locs = append(locs, blockLoc)
+		}
+		sort.Sort(testBlockXyzs(locs))
+
+		for _, blockLoc := range locs {
+			instance := chunk.instance(blockLoc)
+			if aspect, ok := instance.BlockType.Aspect.(IRandomTickAspect); ok {
+				aspect.RandomTick(instance)
+			}
+		}
+
+		chunk.tick(1)
+	}
+}
+
+func (chunk *testChunk) checkBlock(t *testing.T, blockLoc BlockXyz, blockId BlockId, blockData byte) {
+	block, data, _ := chunk.BlockAt(&blockLoc)
+	if block != blockId || data != blockData {
+		t.Errorf("Expected block %d/%d at %v, got %d/%d", blockId, blockData, blockLoc, block, data)
+	}
+}
+
+type testBlockXyzs []BlockXyz
+
+func (locs testBlockXyzs) Len() int {
+	return len(locs)
+}
+
+func (locs testBlockXyzs) Less(i, j int) bool {
+	a, b := &locs[i], &locs[j]
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
+
+func (locs testBlockXyzs) Swap(i, j int) {
+	locs[i], locs[j] = locs[j], locs[i]
+}
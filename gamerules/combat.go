@@ -0,0 +1,69 @@
+package gamerules
+
+import (
+	"math"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	handDamage = Health(1) // Damage done by hitting with anything but a weapon.
+
+	// Velocity with which a hit knocks things away from the attacker, and up.
+	knockbackSpeed = 0.4
+	knockbackLift  = 0.4
+)
+
+// AttackDamage returns the damage done by hitting something with the held
+// item.
+func AttackDamage(held *Slot) Health {
+	if itemType := held.ItemType(); itemType != nil && itemType.Damage > 0 {
+		return itemType.Damage
+	}
+	return handDamage
+}
+
+// AttackWear returns the number of uses of the held tool that hitting
+// something wears away. Swords are made for it, other tools wear twice as
+// fast.
+func AttackWear(held *Slot) ItemData {
+	itemType := held.ItemType()
+	switch {
+	case itemType == nil || itemType.ToolUses == 0:
+		return 0
+	case itemType.ToolType == ToolTypeIdSword:
+		return 1
+	}
+	return 2
+}
+
+// Knockback returns the velocity with which a hit from an attacker at one
+// position knocks back something at the target position.
+func Knockback(attacker, target *AbsXyz) (push AbsVelocity) {
+	dx := float64(target.X - attacker.X)
+	dz := float64(target.Z - attacker.Z)
+	distance := math.Sqrt(dx*dx + dz*dz)
+	if distance > 0 {
+		push.X = AbsVelocityCoord(knockbackSpeed * dx / distance)
+		push.Z = AbsVelocityCoord(knockbackSpeed * dz / distance)
+	}
+	push.Y = knockbackLift
+	return
+}
+
+// HitEntity hits a non-player entity with the item held by an attacker at
+// the given position, knocking it back. It returns true if the entity was
+// hurt, and true for died if it has died and should be removed.
+func HitEntity(entity INonPlayerEntity, held *Slot, attacker *AbsXyz) (hurt, died bool) {
+	damageable, ok := entity.(IDamageableEntity)
+	if !ok {
+		return false, false
+	}
+
+	if pushable, ok := entity.(IPushableEntity); ok {
+		push := Knockback(attacker, entity.Position())
+		pushable.Push(&push)
+	}
+
+	return true, damageable.Damage(AttackDamage(held))
+}
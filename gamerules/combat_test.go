@@ -0,0 +1,75 @@
+package gamerules
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestAttackDamageAndWear(t *testing.T) {
+	tests := []struct {
+		held   Slot
+		damage Health
+		wear   ItemData
+	}{
+		{Slot{}, handDamage, 0},
+		{Slot{ItemTypeId: 4, Count: 1}, handDamage, 0},
+		{Slot{ItemTypeId: 267, Count: 1}, 6, 1},
+		{Slot{ItemTypeId: 257, Count: 1}, 4, 2},
+	}
+
+	for _, test := range tests {
+		if damage := AttackDamage(&test.held); damage != test.damage {
+			t.Errorf("Expected %v to do %d damage, got %d", test.held, test.damage, damage)
+		}
+		if wear := AttackWear(&test.held); wear != test.wear {
+			t.Errorf("Expected %v to wear by %d, got %d", test.held, test.wear, wear)
+		}
+	}
+}
+
+func TestKnockback(t *testing.T) {
+	push := Knockback(&AbsXyz{0, 64, 0}, &AbsXyz{3, 64, 4})
+	if math.Abs(float64(push.X)-0.24) > 1e-6 || math.Abs(float64(push.Z)-0.32) > 1e-6 || push.Y != knockbackLift {
+		t.Errorf("Expected knockback away from the attacker, got %+v", push)
+	}
+}
+
+func TestHitEntity(t *testing.T) {
+	pig := NewPig().(*Pig)
+	pig.PointObject.Init(&AbsXyz{8, 64, 8}, &AbsVelocity{})
+	sword := Slot{ItemTypeId: 267, Count: 1}
+
+	if hurt, died := HitEntity(pig, &sword, &AbsXyz{7, 64, 8}); !hurt || died {
+		t.Errorf("Expected pig to be hurt by the first hit, got hurt=%t died=%t", hurt, died)
+	}
+	if _, died := HitEntity(pig, &sword, &AbsXyz{7, 64, 8}); !died {
+		t.Errorf("Expected pig to die from the second hit")
+	}
+
+	item := NewItem(4, 1, 0, &AbsXyz{8, 64, 8}, &AbsVelocity{}, 0)
+	if hurt, _ := HitEntity(item, &sword, &AbsXyz{7, 64, 8}); hurt {
+		t.Errorf("Expected items not to be hurt")
+	}
+}
+
+func TestMobDrops(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 10; i++ {
+		sheep := NewSheep().(*Sheep)
+		drops := sheep.Drops(rand)
+		if len(drops) != 1 || drops[0].ItemTypeId != 35 || drops[0].Count != 1 {
+			t.Fatalf("Expected sheep to drop one wool, got %v", drops)
+		}
+
+		pig := NewPig().(*Pig)
+		for _, drop := range pig.Drops(rand) {
+			if drop.ItemTypeId != 319 || drop.Count < 1 || drop.Count > 2 {
+				t.Fatalf("Expected pig to drop up to 2 porkchops, got %v", drop)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ package gamerules
 
 import (
 	"io"
+	"math/rand"
 
 	"github.com/huin/chunkymonkey/nbt"
 	"github.com/huin/chunkymonkey/physics"
@@ -53,6 +54,66 @@ type INonPlayerEntity interface {
 	Tick(physics.IBlockQuerier) (leftBlock bool)
 }
 
+// ILandingEntity is implemented by non-player entities that change when they
+// land, such as falling blocks.
+type ILandingEntity interface {
+	// Land is called by the chunk containing the entity after each tick in
+	// which the entity stayed within the chunk. It returns true if the entity
+	// has landed and should be removed from the chunk.
+	Land(chunk IChunkBlock) (landed bool)
+}
+
+// IFusedEntity is implemented by non-player entities that explode, such as
+// activated TNT.
+type IFusedEntity interface {
+	// BurnFuse is called by the chunk containing the entity after each tick in
+	// which the entity stayed within the chunk. It returns true if the entity
+	// has exploded and should be removed from the chunk.
+	BurnFuse(chunk IChunkBlock) (exploded bool)
+}
+
+// IDespawningEntity is implemented by non-player entities that are removed
+// after a while, such as dropped items.
+type IDespawningEntity interface {
+	// AgeTick is called by the chunk containing the entity after each tick in
+	// which the entity stayed within the chunk. It returns true if the entity
+	// has reached the given lifetime and should be removed from the chunk.
+	AgeTick(lifetime Ticks) (despawn bool)
+}
+
+// IPushableEntity is implemented by non-player entities that can be pushed,
+// such as by explosions.
+type IPushableEntity interface {
+	Push(dv *AbsVelocity)
+}
+
+// IDamageableEntity is implemented by non-player entities that can be hurt.
+type IDamageableEntity interface {
+	// Damage hurts the entity. It returns true if the entity has died and
+	// should be removed from the chunk.
+	Damage(amount Health) (died bool)
+}
+
+// IDroppingEntity is implemented by non-player entities that drop items when
+// they die, such as mobs.
+type IDroppingEntity interface {
+	// Drops returns the items that the entity drops.
+	Drops(rand *rand.Rand) []Slot
+}
+
+// IBurnableEntity is implemented by non-player entities that can be set on
+// fire.
+type IBurnableEntity interface {
+	// SetBurning sets the entity on fire for a while, or puts it out.
+	SetBurning(burn bool)
+
+	// BurnTick is called by the chunk containing the entity after each tick in
+	// which the entity stayed within the chunk. It returns true if burning hurt
+	// the entity, and true for died if it has died and should be removed from
+	// the chunk.
+	BurnTick() (hurt, died bool)
+}
+
 // ITileEntity is the interface common to entities that are tile-based.
 type ITileEntity interface {
 	INbtSerializable
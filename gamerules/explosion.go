@@ -0,0 +1,217 @@
+package gamerules
+
+import (
+	"math"
+	"sort"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	// Number of rays along each edge of the cube of rays traced out from an
+	// explosion.
+	explosionRaysPerEdge = 16
+
+	// Distance between the points checked along an explosion ray, in blocks.
+	explosionRayStep = 0.3
+
+	// Range of the fuse given to explosive blocks set off by an explosion.
+	explosionChainFuseMin = 10
+	explosionChainFuseMax = 30
+)
+
+// ExplodedBlock is a block reached by an explosion, with the strength of the
+// explosion that reached it.
+type ExplodedBlock struct {
+	Block    BlockXyz
+	Strength float32
+}
+
+// Explosion describes an explosion to a shard that it reaches. It is passed
+// from shard to shard, so that each player is sent a single explosion packet.
+type Explosion struct {
+	Center    AbsXyz
+	Power     float32
+	Shard     ShardXz         // The shard that the explosion is described to.
+	Blocks    []ExplodedBlock // Blocks reached within the shard.
+	Destroyed []BlockXyz      // Blocks destroyed by the shards passed through.
+	Notified  []EntityId      // Players sent the explosion packet so far.
+	Next      []Explosion     // The explosion in the shards still to pass through.
+}
+
+// explosionCost returns the strength that an explosion loses in passing
+// through a block of the given type.
+func explosionCost(blockType *BlockType) float32 {
+	return (blockType.BlastResistance/5 + 0.3) * explosionRayStep
+}
+
+// ExplosionDestroys returns true if the block type is destroyed by an
+// explosion that reaches it with the given strength.
+func ExplosionDestroys(blockType *BlockType, strength float32) bool {
+	return blockType.Destructable && blockType.id != BlockIdAir && explosionCost(blockType) < strength
+}
+
+// TraceExplosion traces rays out from an explosion, returning the blocks that
+// it destroys. Blocks that chunk doesn't know of are treated as air and are
+// always returned, so that the shards that they are in can decide if they are
+// destroyed using ExplosionDestroys. The blocks are in order of location.
+func TraceExplosion(chunk IChunkBlock, center *AbsXyz, power float32) (blocks []ExplodedBlock) {
+	rand := chunk.Rand()
+	reached := make(map[BlockXyz]float32)
+
+	const edge = explosionRaysPerEdge - 1
+	for i := 0; i <= edge; i++ {
+		for j := 0; j <= edge; j++ {
+			for k := 0; k <= edge; k++ {
+				if i != 0 && i != edge && j != 0 && j != edge && k != 0 && k != edge {
+					// Rays only start from the surface of the cube.
+					continue
+				}
+
+				dx := float64(i)/edge*2 - 1
+				dy := float64(j)/edge*2 - 1
+				dz := float64(k)/edge*2 - 1
+				length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+				dx, dy, dz = dx/length*explosionRayStep, dy/length*explosionRayStep, dz/length*explosionRayStep
+
+				strength := power * (0.7 + rand.Float32()*0.6)
+				x, y, z := float64(center.X), float64(center.Y), float64(center.Z)
+
+				for ; strength > 0; strength -= explosionRayStep * 0.75 {
+					if y < 0 || y >= ChunkSizeY {
+						break
+					}
+					blockLoc := BlockXyz{
+						BlockCoord(math.Floor(x)),
+						BlockYCoord(math.Floor(y)),
+						BlockCoord(math.Floor(z)),
+					}
+					x, y, z = x+dx, y+dy, z+dz
+
+					blockId, _, ok := chunk.BlockAt(&blockLoc)
+					if !ok {
+						if strength > reached[blockLoc] {
+							reached[blockLoc] = strength
+						}
+						strength -= explosionCost(&Blocks[BlockIdAir])
+						continue
+					}
+
+					blockType, ok := Blocks.Get(blockId)
+					if !ok {
+						break
+					}
+					if ExplosionDestroys(blockType, strength) && strength > reached[blockLoc] {
+						reached[blockLoc] = strength
+					}
+					strength -= explosionCost(blockType)
+				}
+			}
+		}
+	}
+
+	blocks = make([]ExplodedBlock, 0, len(reached))
+	for blockLoc, strength := range reached {
+		blocks = append(blocks, ExplodedBlock{blockLoc, strength})
+	}
+	sort.Sort(explodedBlocks(blocks))
+
+	return
+}
+
+type explodedBlocks []ExplodedBlock
+
+func (blocks explodedBlocks) Len() int {
+	return len(blocks)
+}
+
+func (blocks explodedBlocks) Less(i, j int) bool {
+	a, b := &blocks[i].Block, &blocks[j].Block
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.Z < b.Z
+}
+
+func (blocks explodedBlocks) Swap(i, j int) {
+	blocks[i], blocks[j] = blocks[j], blocks[i]
+}
+
+// ExplodeBlock destroys a block in an explosion, possibly dropping items. The
+// caller must then set the block to air unless ExplodeBlock returns true,
+// which it does if it set off an explosive block, which removes itself.
+func ExplodeBlock(instance *BlockInstance, power float32) (ignited bool) {
+	rand := instance.Chunk.Rand()
+
+	if ignitable, ok := instance.BlockType.Aspect.(IIgnitableAspect); ok {
+		fuse := explosionChainFuseMin + rand.Intn(explosionChainFuseMax-explosionChainFuseMin)
+		ignitable.Ignite(instance, Ticks(fuse))
+		return true
+	}
+
+	// Blocks with contents always give them up, but the block itself only
+	// sometimes survives as an item.
+	instance.BlockType.Aspect.Destroy(instance, rand.Float32() < 1/power)
+
+	return false
+}
+
+// ExplodeEntity pushes and hurts an entity caught in an explosion. It returns
+// true if the entity has died and should be removed from its chunk.
+func ExplodeEntity(entity INonPlayerEntity, center *AbsXyz, power float32) (died bool) {
+	impact, push := explosionImpact(entity.Position(), center, power)
+	if impact == 0 {
+		return false
+	}
+
+	if pushable, ok := entity.(IPushableEntity); ok {
+		pushable.Push(&push)
+	}
+
+	if damageable, ok := entity.(IDamageableEntity); ok {
+		died = damageable.Damage(explosionDamage(impact, power))
+	}
+
+	return
+}
+
+// ExplodePlayer returns the damage done by an explosion to a player at the
+// given position, which is 0 if the explosion doesn't reach them.
+func ExplodePlayer(position *AbsXyz, center *AbsXyz, power float32) Health {
+	impact, _ := explosionImpact(position, center, power)
+	if impact == 0 {
+		return 0
+	}
+	return explosionDamage(impact, power)
+}
+
+// explosionImpact returns how hard an explosion hits something at the given
+// position, from 0 (not at all) to 1, and how hard it pushes it away.
+func explosionImpact(position *AbsXyz, center *AbsXyz, power float32) (impact float64, push AbsVelocity) {
+	radius := 2 * float64(power)
+	dx := float64(position.X - center.X)
+	dy := float64(position.Y - center.Y)
+	dz := float64(position.Z - center.Z)
+	distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if distance == 0 || distance >= radius {
+		return 0, push
+	}
+
+	// TODO Blocks between the entity and the explosion should shield it.
+	impact = 1 - distance/radius
+
+	push = AbsVelocity{
+		AbsVelocityCoord(dx / distance * impact),
+		AbsVelocityCoord(dy / distance * impact),
+		AbsVelocityCoord(dz / distance * impact),
+	}
+
+	return
+}
+
+func explosionDamage(impact float64, power float32) Health {
+	return Health((impact*impact+impact)/2*8*float64(power) + 1)
+}
@@ -0,0 +1,134 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdObsidian = BlockId(49)
+	testBlockIdTnt      = BlockId(46)
+)
+
+func testExplodedBlocks(blocks []ExplodedBlock) map[BlockXyz]bool {
+	reached := make(map[BlockXyz]bool)
+	for i := range blocks {
+		reached[blocks[i].Block] = true
+	}
+	return reached
+}
+
+func TestTraceExplosion(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{9, 1, 8}, testBlockIdObsidian, 0)
+
+	blocks := TraceExplosion(chunk, &AbsXyz{8.5, 1.5, 8.5}, tntExplosionPower)
+	reached := testExplodedBlocks(blocks)
+
+	if !reached[BlockXyz{8, 0, 8}] {
+		t.Errorf("Expected stone below explosion to be destroyed")
+	}
+	if reached[BlockXyz{9, 1, 8}] {
+		t.Errorf("Expected obsidian to survive explosion")
+	}
+	if reached[BlockXyz{8, 1, 8}] {
+		t.Errorf("Expected air not to be destroyed")
+	}
+	for i := 1; i < len(blocks); i++ {
+		if !explodedBlocks(blocks).Less(i-1, i) {
+			t.Errorf("Expected blocks in order, got %v before %v", blocks[i-1].Block, blocks[i].Block)
+		}
+	}
+}
+
+func TestTraceExplosionUnknownBlocks(t *testing.T) {
+	chunk := newTestChunk()
+
+	blocks := TraceExplosion(chunk, &AbsXyz{0.5, 1.5, 8.5}, tntExplosionPower)
+	reached := testExplodedBlocks(blocks)
+
+	if !reached[BlockXyz{-1, 1, 8}] {
+		t.Errorf("Expected block outside chunk to be reached")
+	}
+}
+
+func TestTntIgnite(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdTnt, 0)
+
+	instance := chunk.instance(BlockXyz{8, 1, 8})
+	instance.BlockType.Aspect.(IIgnitableAspect).Ignite(instance, 0)
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, BlockIdAir, 0)
+
+	if len(chunk.entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(chunk.entities))
+	}
+	tnt, ok := chunk.entities[0].(*ActivatedTnt)
+	if !ok {
+		t.Fatalf("Expected activated TNT, got %T", chunk.entities[0])
+	}
+	if tnt.Fuse != 80 {
+		t.Errorf("Expected fuse of 80, got %d", tnt.Fuse)
+	}
+
+	for i := 1; i < 80; i++ {
+		if tnt.BurnFuse(chunk) {
+			t.Fatalf("TNT exploded early after %d ticks", i)
+		}
+	}
+	if !tnt.BurnFuse(chunk) {
+		t.Errorf("Expected TNT to explode")
+	}
+	if chunk.explosions != 1 {
+		t.Errorf("Expected 1 explosion, got %d", chunk.explosions)
+	}
+}
+
+func TestTntRedstone(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdTnt, 0)
+	chunk.setBlock(BlockXyz{7, 1, 8}, testBlockIdLever, 5)
+	chunk.tick(5)
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, testBlockIdTnt, 0)
+
+	chunk.interact(BlockXyz{7, 1, 8})
+	chunk.tick(5)
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, BlockIdAir, 0)
+	if len(chunk.entities) != 1 {
+		t.Errorf("Expected 1 entity, got %d", len(chunk.entities))
+	}
+}
+
+func TestExplodeEntity(t *testing.T) {
+	center := &AbsXyz{8.5, 1.5, 8.5}
+
+	near := NewPig().(*Pig)
+	near.PointObject.Init(&AbsXyz{10.5, 1, 8.5}, &AbsVelocity{0, 0, 0})
+	if !ExplodeEntity(near, center, tntExplosionPower) {
+		t.Errorf("Expected pig near explosion to die")
+	}
+
+	far := NewPig().(*Pig)
+	far.PointObject.Init(&AbsXyz{20.5, 1, 8.5}, &AbsVelocity{0, 0, 0})
+	if ExplodeEntity(far, center, tntExplosionPower) {
+		t.Errorf("Expected pig far from explosion to live")
+	}
+	if far.health != mobMaxHealth {
+		t.Errorf("Expected pig far from explosion to be unhurt, has health %d", far.health)
+	}
+}
+
+func TestExplodePlayer(t *testing.T) {
+	center := &AbsXyz{8.5, 1.5, 8.5}
+
+	near := ExplodePlayer(&AbsXyz{9.5, 1, 8.5}, center, tntExplosionPower)
+	further := ExplodePlayer(&AbsXyz{12.5, 1, 8.5}, center, tntExplosionPower)
+	if near <= further || further <= 0 {
+		t.Errorf("Expected damage to fall away from the explosion, got %d then %d", near, further)
+	}
+
+	if damage := ExplodePlayer(&AbsXyz{20.5, 1, 8.5}, center, tntExplosionPower); damage != 0 {
+		t.Errorf("Expected player far from explosion to be unhurt, got damage %d", damage)
+	}
+}
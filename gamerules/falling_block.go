@@ -0,0 +1,75 @@
+package gamerules
+
+import (
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Block types that falling objects are by default.
+const (
+	blockIdSand   = BlockId(12)
+	blockIdGravel = BlockId(13)
+)
+
+// FallingBlock is an object that is a block falling under gravity, such as
+// sand or gravel. It becomes a block again when it lands, or drops as an item
+// if it lands somewhere that the block can't be placed.
+type FallingBlock struct {
+	Object
+	BlockTypeId BlockId
+}
+
+func newBlankFallingBlock(objType ObjTypeId, blockTypeId BlockId) *FallingBlock {
+	return &FallingBlock{
+		Object:      *NewObject(objType),
+		BlockTypeId: blockTypeId,
+	}
+}
+
+func NewFallingBlock(objType ObjTypeId, blockTypeId BlockId, position *AbsXyz) (falling *FallingBlock) {
+	falling = newBlankFallingBlock(objType, blockTypeId)
+	falling.PointObject.Init(position, &AbsVelocity{0, 0, 0})
+	return
+}
+
+func (falling *FallingBlock) UnmarshalNbt(tag *nbt.Compound) (err error) {
+	if err = falling.Object.UnmarshalNbt(tag); err != nil {
+		return
+	}
+
+	if tile, ok := tag.Lookup("Tile").(*nbt.Byte); ok {
+		falling.BlockTypeId = BlockId(tile.Value)
+	}
+
+	return
+}
+
+func (falling *FallingBlock) MarshalNbt(tag *nbt.Compound) (err error) {
+	if err = falling.Object.MarshalNbt(tag); err != nil {
+		return
+	}
+	tag.Set("Tile", &nbt.Byte{int8(falling.BlockTypeId)})
+	return
+}
+
+func (falling *FallingBlock) Land(chunk IChunkBlock) (landed bool) {
+	if !falling.OnGround() {
+		return false
+	}
+
+	blockLoc := falling.Position().ToBlockXyz()
+	_, subLoc := blockLoc.ToChunkLocal()
+
+	blockId, _, ok := chunk.BlockAt(blockLoc)
+	index, indexOk := subLoc.BlockIndex()
+	if ok && indexOk {
+		if blockType, ok := Blocks.Get(blockId); ok && blockType.Replaceable {
+			chunk.SetBlockByIndex(index, falling.BlockTypeId, 0)
+			return true
+		}
+	}
+
+	spawnItemInBlock(chunk, *blockLoc, ItemTypeId(falling.BlockTypeId), 1, 0)
+
+	return true
+}
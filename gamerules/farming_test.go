@@ -0,0 +1,167 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdDirt     = BlockId(3)
+	testBlockIdCrops    = BlockId(59)
+	testBlockIdFarmland = BlockId(60)
+
+	testItemIdWoodenHoe = ItemTypeId(290)
+	testItemIdSeeds     = ItemTypeId(295)
+	testItemIdWheat     = ItemTypeId(296)
+	testItemIdDye       = ItemTypeId(351)
+)
+
+func testUseItem(t *testing.T, chunk *testChunk, blockLoc BlockXyz, item *Slot) {
+	instance := chunk.instance(blockLoc)
+	aspect, ok := instance.BlockType.Aspect.(IItemUseAspect)
+	if !ok || !aspect.CanUseItem(instance, item) {
+		t.Fatalf("Expected to be able to use item %d on block %d", item.ItemTypeId, instance.BlockType.id)
+	}
+	aspect.UseItem(instance, item)
+}
+
+// testWaterPool places water held in place by stone.
+func testWaterPool(chunk *testChunk, blockLoc BlockXyz) {
+	chunk.setBlock(blockLoc, testBlockIdWater, 0)
+	for _, offset := range []BlockXyz{{-1, 0, 0}, {1, 0, 0}, {0, 0, -1}, {0, 0, 1}} {
+		chunk.setBlock(BlockXyz{blockLoc.X + offset.X, blockLoc.Y, blockLoc.Z + offset.Z}, testBlockIdStone, 0)
+	}
+}
+
+func TestTillAndPlant(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdDirt, 0)
+	chunk.setBlock(BlockXyz{6, 1, 5}, testBlockIdDirt, 0)
+	chunk.setBlock(BlockXyz{6, 2, 5}, testBlockIdStone, 0)
+
+	hoe := &Slot{testItemIdWoodenHoe, 1, 0}
+	testUseItem(t, chunk, BlockXyz{5, 1, 5}, hoe)
+	chunk.checkBlock(t, BlockXyz{5, 1, 5}, testBlockIdFarmland, 0)
+	if hoe.Count != 1 {
+		t.Errorf("Expected hoe not to be used up")
+	}
+
+	// Dirt can't be tilled under another block.
+	instance := chunk.instance(BlockXyz{6, 1, 5})
+	if instance.BlockType.Aspect.(IItemUseAspect).CanUseItem(instance, hoe) {
+		t.Errorf("Expected covered dirt not to be tillable")
+	}
+
+	seeds := &Slot{testItemIdSeeds, 3, 0}
+	testUseItem(t, chunk, BlockXyz{5, 1, 5}, seeds)
+	chunk.checkBlock(t, BlockXyz{5, 2, 5}, testBlockIdCrops, 0)
+	if seeds.Count != 2 {
+		t.Errorf("Expected 2 seeds left, got %d", seeds.Count)
+	}
+}
+
+func TestFarmlandMoisture(t *testing.T) {
+	chunk := newTestChunk()
+	testWaterPool(chunk, BlockXyz{1, 1, 3})
+	chunk.setBlock(BlockXyz{3, 1, 3}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{12, 1, 12}, testBlockIdFarmland, farmlandMaxMoisture)
+	chunk.setBlock(BlockXyz{14, 1, 12}, testBlockIdFarmland, farmlandMaxMoisture)
+	chunk.setBlock(BlockXyz{14, 2, 12}, testBlockIdCrops, 0)
+	chunk.setBlock(BlockXyz{14, 3, 12}, testBlockIdStone, 0)
+	chunk.randomTick(1)
+
+	chunk.checkBlock(t, BlockXyz{3, 1, 3}, testBlockIdFarmland, farmlandMaxMoisture)
+	chunk.checkBlock(t, BlockXyz{12, 1, 12}, testBlockIdFarmland, farmlandMaxMoisture-1)
+
+	chunk.randomTick(farmlandMaxMoisture)
+
+	// Dry farmland reverts unless something is planted in it.
+	chunk.checkBlock(t, BlockXyz{12, 1, 12}, testBlockIdDirt, 0)
+	chunk.checkBlock(t, BlockXyz{14, 1, 12}, testBlockIdFarmland, 0)
+}
+
+func TestCropGrowsFasterWatered(t *testing.T) {
+	chunk := newTestChunk()
+	testWaterPool(chunk, BlockXyz{1, 1, 3})
+	chunk.setBlock(BlockXyz{3, 1, 3}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{3, 2, 3}, testBlockIdCrops, 0)
+	chunk.setBlock(BlockXyz{12, 1, 12}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{12, 2, 12}, testBlockIdCrops, 0)
+	chunk.randomTick(30)
+
+	_, wetData, _ := chunk.BlockAt(&BlockXyz{3, 2, 3})
+	_, dryData, _ := chunk.BlockAt(&BlockXyz{12, 2, 12})
+	if wetData <= dryData {
+		t.Errorf("Expected crop on wet farmland to grow more than on dry, got stages %d and %d", wetData, dryData)
+	}
+	if dryData == 0 {
+		t.Errorf("Expected crop on dry farmland to have grown")
+	}
+
+	chunk.randomTick(500)
+	chunk.checkBlock(t, BlockXyz{12, 2, 12}, testBlockIdCrops, 7)
+}
+
+func TestCropFertilizer(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{5, 2, 5}, testBlockIdCrops, 0)
+
+	boneMeal := &Slot{testItemIdDye, 1, 15}
+	testUseItem(t, chunk, BlockXyz{5, 2, 5}, boneMeal)
+	if _, data, _ := chunk.BlockAt(&BlockXyz{5, 2, 5}); data < 2 {
+		t.Errorf("Expected fertilizer to grow the crop, got stage %d", data)
+	}
+	if boneMeal.Count != 0 {
+		t.Errorf("Expected fertilizer to be used up")
+	}
+
+	instance := chunk.instance(BlockXyz{5, 2, 5})
+	if instance.BlockType.Aspect.(IItemUseAspect).CanUseItem(instance, &Slot{testItemIdDye, 1, 1}) {
+		t.Errorf("Expected other dye not to be fertilizer")
+	}
+}
+
+func TestCropDrops(t *testing.T) {
+	countItems := func(chunk *testChunk, itemTypeId ItemTypeId) (count ItemCount) {
+		for _, e := range chunk.entities {
+			if item, ok := e.(*Item); ok && item.ItemTypeId == itemTypeId {
+				count += item.Count
+			}
+		}
+		return
+	}
+
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{5, 2, 5}, testBlockIdCrops, 3)
+	instance := chunk.instance(BlockXyz{5, 2, 5})
+	instance.BlockType.Aspect.Destroy(instance, true)
+	if count := countItems(chunk, testItemIdSeeds); count != 1 || countItems(chunk, testItemIdWheat) != 0 {
+		t.Errorf("Expected growing crop to drop 1 seed and no wheat, got %d seeds", count)
+	}
+
+	chunk = newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{5, 2, 5}, testBlockIdCrops, 7)
+	instance = chunk.instance(BlockXyz{5, 2, 5})
+	instance.BlockType.Aspect.Destroy(instance, true)
+	if count := countItems(chunk, testItemIdWheat); count != 1 {
+		t.Errorf("Expected mature crop to drop 1 wheat, got %d", count)
+	}
+	if count := countItems(chunk, testItemIdSeeds); count < 1 {
+		t.Errorf("Expected mature crop to drop seeds, got %d", count)
+	}
+}
+
+func TestCropBreaksWithoutSoil(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdFarmland, 0)
+	chunk.setBlock(BlockXyz{5, 2, 5}, testBlockIdCrops, 0)
+	chunk.tick(1)
+
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdDirt, 0)
+	chunk.tick(1)
+	chunk.checkBlock(t, BlockXyz{5, 2, 5}, BlockIdAir, 0)
+}
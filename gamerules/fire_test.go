@@ -0,0 +1,102 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdPlank = BlockId(5)
+	testBlockIdWater = BlockId(9)
+)
+
+func TestLightFire(t *testing.T) {
+	chunk := newTestChunk()
+
+	if !LightFire(chunk.instance(BlockXyz{5, 1, 5})) {
+		t.Errorf("Expected fire to be lit on the floor")
+	}
+	chunk.checkBlock(t, BlockXyz{5, 1, 5}, blockIdFire, 0)
+
+	if LightFire(chunk.instance(BlockXyz{5, 5, 5})) {
+		t.Errorf("Expected fire not to be lit in the air")
+	}
+	chunk.checkBlock(t, BlockXyz{5, 5, 5}, BlockIdAir, 0)
+}
+
+func TestFireBurnsOut(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, blockIdFire, 0)
+	chunk.tick(41)
+	chunk.checkBlock(t, BlockXyz{5, 1, 5}, blockIdFire, 0)
+
+	chunk.tick(2000)
+	chunk.checkBlock(t, BlockXyz{5, 1, 5}, BlockIdAir, 0)
+}
+
+func TestFireExtinguished(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, blockIdFire, 0)
+	chunk.setBlock(BlockXyz{6, 1, 5}, testBlockIdWater, 0)
+	chunk.setBlock(BlockXyz{14, 1, 14}, blockIdFire, 0)
+	chunk.tick(41)
+
+	if blockId, _, _ := chunk.BlockAt(&BlockXyz{5, 1, 5}); blockId == blockIdFire {
+		t.Errorf("Expected fire next to water to be put out")
+	}
+	chunk.checkBlock(t, BlockXyz{14, 1, 14}, blockIdFire, 0)
+
+	chunk.raining = true
+	chunk.tick(50)
+	chunk.checkBlock(t, BlockXyz{14, 1, 14}, BlockIdAir, 0)
+}
+
+func TestFireSpreadsAndBurns(t *testing.T) {
+	chunk := newTestChunk()
+	for x := BlockCoord(2); x <= 10; x++ {
+		chunk.setBlock(BlockXyz{x, 1, 5}, testBlockIdPlank, 0)
+	}
+	chunk.setBlock(BlockXyz{2, 2, 5}, blockIdFire, 0)
+	chunk.tick(20000)
+
+	// The fire burned the plank under it, and spread to burn the next one.
+	for x := BlockCoord(2); x <= 3; x++ {
+		if blockId, _, _ := chunk.BlockAt(&BlockXyz{x, 1, 5}); blockId == testBlockIdPlank {
+			t.Errorf("Expected plank at x=%d to have burned", x)
+		}
+	}
+	chunk.checkBlock(t, BlockXyz{10, 1, 5}, testBlockIdPlank, 0)
+}
+
+func TestFireIgnitesTnt(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdTnt, 0)
+	chunk.setBlock(BlockXyz{5, 2, 5}, blockIdFire, 0)
+	chunk.tick(2000)
+
+	chunk.checkBlock(t, BlockXyz{5, 1, 5}, BlockIdAir, 0)
+	if len(chunk.entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(chunk.entities))
+	}
+	if _, ok := chunk.entities[0].(*ActivatedTnt); !ok {
+		t.Errorf("Expected activated TNT, got %T", chunk.entities[0])
+	}
+}
+
+func TestFireSetsMobBurning(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, blockIdFire, 0)
+	instance := chunk.instance(BlockXyz{5, 1, 5})
+
+	pig := NewPig().(*Pig)
+	pig.PointObject.Init(&AbsXyz{5.5, 1, 5.5}, &AbsVelocity{0, 0, 0})
+	instance.BlockType.Aspect.(ITouchedAspect).Touched(instance, pig)
+
+	if pig.metadata[0]&0x01 == 0 {
+		t.Errorf("Expected pig to be burning")
+	}
+	if pig.burnTicks != mobBurnTicks {
+		t.Errorf("Expected pig to burn for %d ticks, got %d", mobBurnTicks, pig.burnTicks)
+	}
+}
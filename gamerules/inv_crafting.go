@@ -59,19 +59,65 @@ func (inv *CraftingInventory) Click(click *Click) (txState TxState) {
 	}
 
 	if click.SlotId == 0 {
-		// Player took items from the output slot. Subtract 1 count from each
-		// non-empty input slot.
-		for i := 1; i < len(inv.slots); i++ {
-			inv.slots[i].Decrement()
-			inv.slotUpdate(&inv.slots[i], SlotId(i))
+		inv.useInputs()
+	}
+
+	inv.match()
+
+	return
+}
+
+// ShiftClick moves the items in an input slot into the target. Shift-clicking
+// the output slot crafts as many times as the target has room for.
+func (inv *CraftingInventory) ShiftClick(click *Click, target ITransferTarget) (txState TxState) {
+	if click.SlotId != 0 {
+		if txState = inv.Inventory.ShiftClick(click, target); txState == TxStateAccepted {
+			inv.match()
+		}
+		return
+	}
+
+	output := &inv.slots[0]
+	if !click.ExpectedSlot.Equals(output) {
+		return TxStateRejected
+	}
+
+	// The target is tried out on a copy first, as only whole outputs can be
+	// taken. Crafting stops when the recipe's output changes.
+	space := NewTransferSpace(target.Space())
+	crafted := *output
+	for !output.IsEmpty() && output.Equals(&crafted) {
+		item := *output
+		if space.PutItem(&item); item.Count > 0 {
+			break
 		}
+		item = *output
+		target.PutItem(&item)
+		inv.useInputs()
+		inv.match()
 	}
 
-	// Match recipe and set output slot.
+	return TxStateAccepted
+}
+
+// PutItem does nothing, as shift-clicked items aren't moved into crafting
+// grids.
+func (inv *CraftingInventory) PutItem(item *Slot) {
+}
+
+// useInputs subtracts 1 count from each non-empty input slot, after the player
+// took items from the output slot.
+func (inv *CraftingInventory) useInputs() {
+	for i := 1; i < len(inv.slots); i++ {
+		inv.slots[i].Decrement()
+		inv.slotUpdate(&inv.slots[i], SlotId(i))
+	}
+}
+
+// match matches the inputs to a recipe and sets the output slot.
+func (inv *CraftingInventory) match() {
 	inv.slots[0] = inv.recipes.Match(inv.width, inv.height, inv.slots[1:])
 	inv.slotUpdate(&inv.slots[0], 0)
-
-	return
 }
 
 // TakeAllItems empties the inventory, and returns all items that were inside
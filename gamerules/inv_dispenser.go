@@ -1,7 +1,10 @@
 package gamerules
 
 import (
+	"math/rand"
+
 	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
 )
 
 const (
@@ -11,6 +14,7 @@ const (
 
 type DispenserInventory struct {
 	Inventory
+	powered bool // Powered by redstone when last checked.
 }
 
 // NewDispenserInventory creates a 3x3 dispenser inventory.
@@ -24,3 +28,21 @@ func (inv *DispenserInventory) MarshalNbt(tag *nbt.Compound) (err error) {
 	tag.Set("id", &nbt.String{"Trap"})
 	return inv.Inventory.MarshalNbt(tag)
 }
+
+// TakeRandomItem takes a single item from a randomly chosen non-empty slot.
+// ok is false if the inventory is empty.
+func (inv *DispenserInventory) TakeRandomItem(rand *rand.Rand) (item Slot, ok bool) {
+	var slotIds []SlotId
+	for i := range inv.slots {
+		if inv.slots[i].Count > 0 {
+			slotIds = append(slotIds, SlotId(i))
+		}
+	}
+
+	if len(slotIds) == 0 {
+		return
+	}
+
+	inv.TakeOneItem(slotIds[rand.Intn(len(slotIds))], &item)
+	return item, true
+}
@@ -77,12 +77,8 @@ func (inv *FurnaceInventory) Click(click *Click) (txState TxState) {
 
 		txState = inv.Inventory.Click(click)
 
-		slotAfter := &inv.slots[furnaceSlotReagent]
-
 		// If the reagent type changes, the reaction restarts.
-		if slotBefore.ItemTypeId != slotAfter.ItemTypeId || slotBefore.Data != slotAfter.Data {
-			inv.cookTime = reactionDuration
-		}
+		inv.reagentChanged(&slotBefore)
 	case furnaceSlotFuel:
 		cursorItemId := click.Cursor.ItemTypeId
 		_, cursorIsFuel := FurnaceReactions.Fuels[cursorItemId]
@@ -101,6 +97,50 @@ func (inv *FurnaceInventory) Click(click *Click) (txState TxState) {
 	return
 }
 
+// ShiftClick moves the items in the clicked slot into the target.
+func (inv *FurnaceInventory) ShiftClick(click *Click, target ITransferTarget) (txState TxState) {
+	reagentBefore := inv.slots[furnaceSlotReagent]
+
+	txState = inv.Inventory.ShiftClick(click, target)
+
+	inv.reagentChanged(&reagentBefore)
+	inv.stateCheck()
+	inv.sendProgressUpdates()
+
+	return
+}
+
+// PutItem puts shift-clicked items that can be smelted into the reagent slot,
+// and fuel into the fuel slot. Nothing is put into the output slot.
+func (inv *FurnaceInventory) PutItem(item *Slot) {
+	slotId := furnaceSlotReagent
+	if _, isReagent := FurnaceReactions.Reactions[item.ItemTypeId]; !isReagent {
+		if _, isFuel := FurnaceReactions.Fuels[item.ItemTypeId]; !isFuel {
+			return
+		}
+		slotId = furnaceSlotFuel
+	}
+
+	slot := &inv.slots[slotId]
+	reagentBefore := inv.slots[furnaceSlotReagent]
+	if slot.Add(item) {
+		inv.slotUpdate(slot, slotId)
+	}
+
+	inv.reagentChanged(&reagentBefore)
+	inv.stateCheck()
+	inv.sendProgressUpdates()
+}
+
+// reagentChanged restarts the reaction if the type of the reagent is no
+// longer reagentBefore.
+func (inv *FurnaceInventory) reagentChanged(reagentBefore *Slot) {
+	reagentAfter := &inv.slots[furnaceSlotReagent]
+	if reagentBefore.ItemTypeId != reagentAfter.ItemTypeId || reagentBefore.Data != reagentAfter.Data {
+		inv.cookTime = reactionDuration
+	}
+}
+
 func (inv *FurnaceInventory) stateCheck() {
 	reagentSlot := &inv.slots[furnaceSlotReagent]
 	fuelSlot := &inv.slots[furnaceSlotFuel]
@@ -94,6 +94,7 @@ func loadedFurnace(t *testing.T, numFuel, numOre ItemCount) (furnace *FurnaceInv
 		false, false,
 		0,
 		emptySlot,
+		nil,
 	}
 	txState = furnace.Click(&click)
 	checkTx(t, TxStateAccepted, txState)
@@ -108,6 +109,7 @@ func loadedFurnace(t *testing.T, numFuel, numOre ItemCount) (furnace *FurnaceInv
 		false, false,
 		0,
 		emptySlot,
+		nil,
 	}
 	txState = furnace.Click(&click)
 	checkTx(t, TxStateAccepted, txState)
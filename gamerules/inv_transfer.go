@@ -0,0 +1,58 @@
+package gamerules
+
+// ITransferTarget is where the items in a shift-clicked slot are moved to.
+type ITransferTarget interface {
+	// PutItem puts as many of the items into the target as it has room for.
+	// The items that don't fit are left in item.
+	PutItem(item *Slot)
+
+	// Space returns a copy of the slots that the target puts items into, or
+	// nil if it isn't known.
+	Space() []Slot
+}
+
+// InventoryGroup is a number of inventories that items are moved into as if
+// they were one, such as the main and holding parts of a player's inventory.
+type InventoryGroup []*Inventory
+
+// PutItem puts the items into the inventories, filling stacks of the same
+// item in any of them before empty slots.
+func (group InventoryGroup) PutItem(item *Slot) {
+	for _, stacks := range [2]bool{true, false} {
+		for _, inv := range group {
+			inv.putItem(item, stacks)
+		}
+	}
+}
+
+func (group InventoryGroup) Space() (space []Slot) {
+	for _, inv := range group {
+		space = append(space, inv.Space()...)
+	}
+	return
+}
+
+// TransferSpace is a copy of the slots in a player's inventory, used to move
+// shift-clicked items out of an inventory that isn't held by the player. It
+// keeps track of what was moved into it, so that it can be given to the
+// player.
+type TransferSpace struct {
+	Inventory
+	Moved []Slot
+}
+
+// NewTransferSpace creates a TransferSpace with a copy of the slots.
+func NewTransferSpace(slots []Slot) *TransferSpace {
+	space := new(TransferSpace)
+	space.Inventory.Init(len(slots))
+	copy(space.slots, slots)
+	return space
+}
+
+func (space *TransferSpace) PutItem(item *Slot) {
+	moved := *item
+	space.Inventory.PutItem(item)
+	if moved.Count -= item.Count; moved.Count > 0 {
+		space.Moved = append(space.Moved, moved)
+	}
+}
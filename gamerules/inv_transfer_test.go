@@ -0,0 +1,127 @@
+package gamerules
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	stoneId = ItemTypeId(1)
+	logId   = ItemTypeId(17)
+)
+
+func TestInventoryPutItemFillsStacksFirst(t *testing.T) {
+	var inv Inventory
+	inv.Init(3)
+	inv.slots[2] = Slot{stoneId, 60, 0}
+
+	inv.PutItem(&Slot{stoneId, 10, 0})
+
+	checkSlot(t, Slot{stoneId, 6, 0}, inv.slots[0])
+	checkSlot(t, emptySlot, inv.slots[1])
+	checkSlot(t, Slot{stoneId, 64, 0}, inv.slots[2])
+}
+
+func TestInventoryShiftClick(t *testing.T) {
+	var inv, main, holding Inventory
+	inv.Init(1)
+	main.Init(1)
+	holding.Init(1)
+	inv.slots[0] = Slot{stoneId, 64, 0}
+	holding.slots[0] = Slot{stoneId, 60, 0}
+
+	click := Click{SlotId: 0, ShiftClick: true, ExpectedSlot: Slot{stoneId, 64, 0}}
+	checkTx(t, TxStateAccepted, inv.ShiftClick(&click, InventoryGroup{&main, &holding}))
+
+	checkSlot(t, emptySlot, inv.slots[0])
+	checkSlot(t, Slot{stoneId, 60, 0}, main.slots[0])
+	checkSlot(t, Slot{stoneId, 64, 0}, holding.slots[0])
+
+	// The client must know what it is shift-clicking.
+	holding.slots[0] = Slot{stoneId, 10, 0}
+	click = Click{SlotId: 0, ShiftClick: true, ExpectedSlot: Slot{stoneId, 64, 0}}
+	checkTx(t, TxStateRejected, holding.ShiftClick(&click, &main))
+	checkSlot(t, Slot{stoneId, 10, 0}, holding.slots[0])
+}
+
+func TestCraftingShiftClickCraftsAll(t *testing.T) {
+	inv := NewWorkbenchInventory()
+	inv.slots[1] = Slot{logId, 3, 0}
+	inv.match()
+	planks := Slot{plankId, 4, 0}
+	checkSlot(t, planks, inv.slots[0])
+
+	var target Inventory
+	target.Init(2)
+	target.slots[0] = Slot{stoneId, 1, 0}
+	target.slots[1] = Slot{plankId, 58, 0}
+
+	// There's room for one lot of planks, but not two.
+	click := Click{SlotId: 0, ShiftClick: true, ExpectedSlot: planks}
+	checkTx(t, TxStateAccepted, inv.ShiftClick(&click, &target))
+	checkSlot(t, Slot{plankId, 62, 0}, target.slots[1])
+	checkSlot(t, Slot{logId, 2, 0}, inv.slots[1])
+	checkSlot(t, planks, inv.slots[0])
+
+	// With room, all of the logs are crafted.
+	target.slots[0].Clear()
+	checkTx(t, TxStateAccepted, inv.ShiftClick(&click, &target))
+	checkSlot(t, Slot{plankId, 6, 0}, target.slots[0])
+	checkSlot(t, Slot{plankId, 64, 0}, target.slots[1])
+	checkSlot(t, emptySlot, inv.slots[1])
+	checkSlot(t, emptySlot, inv.slots[0])
+}
+
+func TestFurnacePutItem(t *testing.T) {
+	furnace := NewFurnaceInventory()
+
+	ingots := Slot{ironIngotId, 5, 0}
+	furnace.PutItem(&ingots)
+	checkSlot(t, Slot{ironIngotId, 5, 0}, ingots)
+	checkSlot(t, emptySlot, furnace.slots[furnaceSlotOutput])
+
+	furnace.PutItem(&Slot{plankId, 5, 0})
+	checkSlot(t, Slot{plankId, 5, 0}, furnace.slots[furnaceSlotFuel])
+	checkLit(t, furnace, false)
+
+	furnace.PutItem(&Slot{ironOreId, 5, 0})
+	checkSlot(t, Slot{ironOreId, 5, 0}, furnace.slots[furnaceSlotReagent])
+	checkLit(t, furnace, true)
+}
+
+func TestBlockInventoryShiftClick(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player := NewMockIPlayerClient(mockCtrl)
+
+	chest := NewChestInventory()
+	chest.slots[0] = Slot{stoneId, 10, 0}
+	blkInv := newBlockInventory(nil, chest, false, InvTypeIdChest)
+
+	// The player's inventory only has room for some of the stone.
+	click := Click{
+		SlotId:       0,
+		ShiftClick:   true,
+		TxId:         1,
+		ExpectedSlot: Slot{stoneId, 10, 0},
+		Space:        []Slot{{stoneId, 60, 0}, {stoneId, 64, 0}},
+	}
+	gomock.InOrder(
+		player.EXPECT().GiveItem(Slot{stoneId, 4, 0}),
+		player.EXPECT().InventoryCursorUpdate(BlockXyz{}, emptySlot),
+		player.EXPECT().InventoryTxState(BlockXyz{}, TxId(1), true),
+	)
+	blkInv.Click(player, &click)
+	checkSlot(t, Slot{stoneId, 6, 0}, chest.slots[0])
+
+	// Items put in from the player's inventory that don't fit are given back.
+	for i := 1; i < len(chest.slots); i++ {
+		chest.slots[i] = Slot{stoneId, 64, 0}
+	}
+	player.EXPECT().GiveItem(Slot{stoneId, 54, 0})
+	blkInv.PutItem(player, &Slot{stoneId, 112, 0})
+	checkSlot(t, Slot{stoneId, 64, 0}, chest.slots[0])
+}
@@ -20,6 +20,8 @@ type IInventorySubscriber interface {
 type IInventory interface {
 	NumSlots() SlotId
 	Click(click *Click) (txState TxState)
+	ShiftClick(click *Click, target ITransferTarget) (txState TxState)
+	PutItem(item *Slot)
 	SetSubscriber(subscriber IInventorySubscriber)
 	MakeProtoSlots() []proto.WindowSlot
 	WriteProtoSlots(slots []proto.WindowSlot)
@@ -36,6 +38,11 @@ type Click struct {
 	ShiftClick   bool
 	TxId         TxId
 	ExpectedSlot Slot
+
+	// Space is a copy of the player's inventory slots that the items in a
+	// shift-clicked slot may be moved into, when the inventory isn't the
+	// player's own.
+	Space []Slot
 }
 
 type Inventory struct {
@@ -124,10 +131,36 @@ func (inv *Inventory) TakeOnlyClick(click *Click) TxState {
 	return TxStateAccepted
 }
 
+// ShiftClick moves the whole stack in the clicked slot into the target, as far
+// as it has room for them.
+func (inv *Inventory) ShiftClick(click *Click, target ITransferTarget) TxState {
+	if click.SlotId < 0 || int(click.SlotId) >= len(inv.slots) {
+		return TxStateRejected
+	}
+
+	clickedSlot := &inv.slots[click.SlotId]
+
+	if !click.ExpectedSlot.Equals(clickedSlot) {
+		return TxStateRejected
+	}
+
+	target.PutItem(clickedSlot)
+	inv.slotUpdate(clickedSlot, click.SlotId)
+
+	return TxStateAccepted
+}
+
 func (inv *Inventory) Slot(slotId SlotId) Slot {
 	return inv.slots[slotId]
 }
 
+// SetSlot replaces the contents of the slot with a copy of the item.
+func (inv *Inventory) SetSlot(slotId SlotId, item *Slot) {
+	slot := &inv.slots[slotId]
+	*slot = *item
+	inv.slotUpdate(slot, slotId)
+}
+
 func (inv *Inventory) TakeOneItem(slotId SlotId, into *Slot) {
 	slot := &inv.slots[slotId]
 	if into.AddOne(slot) {
@@ -135,23 +168,47 @@ func (inv *Inventory) TakeOneItem(slotId SlotId, into *Slot) {
 	}
 }
 
-// PutItem attempts to put the given item into the inventory.
+// WearItem wears down the tool in the slot by a number of uses.
+func (inv *Inventory) WearItem(slotId SlotId, uses ItemData) {
+	slot := &inv.slots[slotId]
+	if slot.Wear(uses) {
+		inv.slotUpdate(slot, slotId)
+	}
+}
+
+// PutItem attempts to put the given item into the inventory. Stacks of the
+// same item are filled before empty slots are used.
 func (inv *Inventory) PutItem(item *Slot) {
+	inv.putItem(item, true)
+	inv.putItem(item, false)
+}
+
+// putItem puts as many of the items as fit into either the stacks of the same
+// item, or the empty slots.
+func (inv *Inventory) putItem(item *Slot, stacks bool) {
 	// TODO optimize this algorithm, maybe by maintaining a map of non-full
-	// slots containing an item of various item type IDs. Additionally, it
-	// should prefer to put stackable items into stacks of the same type,
-	// rather than in empty slots.
+	// slots containing an item of various item type IDs.
 	for slotIndex := range inv.slots {
 		if item.Count <= 0 {
 			break
 		}
 		slot := &inv.slots[slotIndex]
+		if slot.IsEmpty() == stacks {
+			continue
+		}
 		if slot.Add(item) {
 			inv.slotUpdate(slot, SlotId(slotIndex))
 		}
 	}
 }
 
+// Space returns a copy of the inventory's slots.
+func (inv *Inventory) Space() []Slot {
+	space := make([]Slot, len(inv.slots))
+	copy(space, inv.slots)
+	return space
+}
+
 // CanTakeItem returns true if it can take at least one item from the passed
 // Slot.
 func (inv *Inventory) CanTakeItem(item *Slot) bool {
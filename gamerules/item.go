@@ -7,6 +7,7 @@ import (
 	"github.com/huin/chunkymonkey/proto"
 	. "github.com/huin/chunkymonkey/types"
 	"io"
+	"math"
 )
 
 type Item struct {
@@ -15,6 +16,7 @@ type Item struct {
 	physics.PointObject
 	orientation    OrientationBytes
 	PickupImmunity Ticks
+	Age            Ticks // Ticks since the item was dropped.
 }
 
 func NewBlankItem() INonPlayerEntity {
@@ -58,6 +60,10 @@ func (item *Item) UnmarshalNbt(tag *nbt.Compound) (err error) {
 		Data:       ItemData(data.Value),
 	}
 
+	if age, ok := tag.Lookup("Age").(*nbt.Short); ok {
+		item.Age = Ticks(age.Value)
+	}
+
 	return nil
 }
 
@@ -71,9 +77,43 @@ func (item *Item) MarshalNbt(tag *nbt.Compound) (err error) {
 		"Count":  &nbt.Byte{int8(item.Count)},
 		"Damage": &nbt.Short{int16(item.Data)},
 	}})
+
+	age := item.Age
+	if age > math.MaxInt16 {
+		age = math.MaxInt16
+	}
+	tag.Set("Age", &nbt.Short{int16(age)})
+
 	return nil
 }
 
+// AgeTick ages the item by a tick. It returns true if the item is at least
+// lifetime ticks old and should be removed from the chunk. Items never despawn
+// if lifetime is zero.
+func (item *Item) AgeTick(lifetime Ticks) (despawn bool) {
+	item.Age++
+	return lifetime > 0 && item.Age >= lifetime
+}
+
+// Merge moves as many items from other into this item's stack as will fit.
+// The stack keeps the age of the younger of the two items, so that merging
+// doesn't make items despawn any sooner. It returns true if any items moved.
+func (item *Item) Merge(other *Item) (changed bool) {
+	if item.ItemTypeId != other.ItemTypeId || item.Data != other.Data {
+		return false
+	}
+	if !item.Slot.Add(&other.Slot) {
+		return false
+	}
+	if other.Age < item.Age {
+		item.Age = other.Age
+	}
+	if other.PickupImmunity > item.PickupImmunity {
+		item.PickupImmunity = other.PickupImmunity
+	}
+	return true
+}
+
 func (item *Item) GetSlot() *Slot {
 	return &item.Slot
 }
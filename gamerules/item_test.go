@@ -0,0 +1,61 @@
+package gamerules
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestItemMerge(t *testing.T) {
+	position := AbsXyz{0.5, 64, 0.5}
+	item := NewItem(4, 40, 0, &position, &AbsVelocity{}, 0)
+	item.Age = 100
+	other := NewItem(4, 30, 0, &position, &AbsVelocity{}, 10)
+	other.Age = 50
+
+	if !item.Merge(other) {
+		t.Fatalf("Expected items to merge")
+	}
+	if item.Count != 64 || other.Count != 6 {
+		t.Errorf("Expected counts 64 and 6, got %d and %d", item.Count, other.Count)
+	}
+	if item.Age != 50 || item.PickupImmunity != 10 {
+		t.Errorf("Expected merged stack to take age 50 and immunity 10, got %d and %d",
+			item.Age, item.PickupImmunity)
+	}
+
+	if item.Merge(other) {
+		t.Errorf("Expected no merge into a full stack")
+	}
+	if other.Merge(NewItem(4, 1, 1, &position, &AbsVelocity{}, 0)) {
+		t.Errorf("Expected no merge of items with different data")
+	}
+}
+
+func TestItemAgeSaved(t *testing.T) {
+	item := NewItem(4, 1, 0, &AbsXyz{0.5, 64, 0.5}, &AbsVelocity{}, 0)
+	for i := 0; i < 9; i++ {
+		if item.AgeTick(10) {
+			t.Fatalf("Expected item to despawn after 10 ticks, despawned after %d", i+1)
+		}
+	}
+
+	tag := nbt.NewCompound()
+	if err := item.MarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	loaded := new(Item)
+	if err := loaded.UnmarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Age != 9 {
+		t.Errorf("Expected age 9 to be loaded, got %d", loaded.Age)
+	}
+	if !loaded.AgeTick(10) {
+		t.Errorf("Expected loaded item to despawn on its 10th tick")
+	}
+	if loaded.AgeTick(0) {
+		t.Errorf("Expected items not to despawn with no lifetime")
+	}
+}
@@ -10,12 +10,31 @@ const (
 
 type ToolTypeId byte
 
+const (
+	ToolTypeIdShovel        = ToolTypeId(1)
+	ToolTypeIdPickaxe       = ToolTypeId(2)
+	ToolTypeIdAxe           = ToolTypeId(3)
+	ToolTypeIdSword         = ToolTypeId(4)
+	ToolTypeIdFlintAndSteel = ToolTypeId(13)
+)
+
 type ItemType struct {
 	Id       ItemTypeId
 	Name     string
 	MaxStack ItemCount
 	ToolType ToolTypeId
 	ToolUses ItemData
+	DigSpeed float32 // How many times faster the tool digs blocks of its ToolType.
+	Damage   Health  // Damage done by hitting with the item, 0 for the same as a hand.
+
+	Food         FoodUnits  // Food restored by eating the item, 0 if inedible.
+	Saturation   float32    // Food saturation restored by eating the item.
+	FoodLeftover ItemTypeId // Item left after eating the item, such as a bowl.
+}
+
+// IsFood returns true if players can eat the item.
+func (itemType *ItemType) IsFood() bool {
+	return itemType.Food > 0
 }
 
 type ItemTypeMap map[ItemTypeId]*ItemType
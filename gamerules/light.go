@@ -0,0 +1,14 @@
+package gamerules
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// LightUpdate describes a change in the light level of a block that affects
+// a neighbouring block in another shard.
+type LightUpdate struct {
+	Block    BlockXyz // The neighbouring block, within the receiving shard.
+	Level    int8     // New light level of the changed block, or its old level if Decrease.
+	Sky      bool     // SkyLight if true, otherwise BlockLight.
+	Decrease bool     // Whether the light level of the changed block went down.
+}
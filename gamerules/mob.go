@@ -4,6 +4,7 @@ import (
 	"errors"
 	"expvar"
 	"io"
+	"math/rand"
 
 	"github.com/huin/chunkymonkey/nbt"
 	"github.com/huin/chunkymonkey/nbtutil"
@@ -20,6 +21,14 @@ func init() {
 	expVarMobSpawnCount = expvar.NewInt("mob-spawn-count")
 }
 
+// TODO Different mob types should have different health.
+const mobMaxHealth = Health(10)
+
+const (
+	mobBurnTicks  = 8 * TicksPerSecond // Ticks that a mob burns for after being set on fire.
+	mobBurnDamage = Health(1)          // Damage done by each second of burning.
+)
+
 // When using an object of type Mob or a sub-type, the caller must set an
 // EntityId, most likely obtained from the EntityManager.
 type Mob struct {
@@ -27,13 +36,18 @@ type Mob struct {
 	physics.PointObject
 	mobType EntityMobType
 	look    LookDegrees
+	health  Health
+	// Ticks left for the mob to burn for.
+	burnTicks Ticks
 	// TODO(nictuku): Move to a more structured form.
-	metadata map[byte]byte
+	metadata        map[byte]byte
+	metadataChanged bool
 	// TODO: Change to an AABB object when we have that.
 }
 
 func (mob *Mob) Init(id EntityMobType) {
 	mob.mobType = id
+	mob.health = mobMaxHealth
 	mob.metadata = map[byte]byte{
 		0:  byte(0),
 		16: byte(0),
@@ -56,8 +70,11 @@ func (mob *Mob) UnmarshalNbt(tag *nbt.Compound) (err error) {
 	_ = tag.Lookup("AttackTime").(*nbt.Short).Value
 	_ = tag.Lookup("DeathTime").(*nbt.Short).Value
 	_ = tag.Lookup("FallDistance").(*nbt.Float).Value
-	_ = tag.Lookup("Fire").(*nbt.Short).Value
-	_ = tag.Lookup("Health").(*nbt.Short).Value
+	if fire := Ticks(tag.Lookup("Fire").(*nbt.Short).Value); fire > 0 {
+		mob.SetBurning(true)
+		mob.burnTicks = fire
+	}
+	mob.health = Health(tag.Lookup("Health").(*nbt.Short).Value)
 	_ = tag.Lookup("HurtTime").(*nbt.Short).Value
 
 	return nil
@@ -81,8 +98,8 @@ func (mob *Mob) MarshalNbt(tag *nbt.Compound) (err error) {
 	tag.Set("AttackTime", &nbt.Short{0})
 	tag.Set("DeathTime", &nbt.Short{0})
 	tag.Set("FallDistance", &nbt.Float{0})
-	tag.Set("Fire", &nbt.Short{0})
-	tag.Set("Health", &nbt.Short{0})
+	tag.Set("Fire", &nbt.Short{int16(mob.burnTicks)})
+	tag.Set("Health", &nbt.Short{int16(mob.health)})
 	tag.Set("HurtTime", &nbt.Short{0})
 	return nil
 }
@@ -91,14 +108,62 @@ func (mob *Mob) SetLook(look LookDegrees) {
 	mob.look = look
 }
 
+// Damage hurts the mob, returning true if it has died.
+func (mob *Mob) Damage(amount Health) (died bool) {
+	mob.health -= amount
+	return mob.health <= 0
+}
+
+// Drops returns the items that the mob drops when it dies.
+func (mob *Mob) Drops(rand *rand.Rand) (items []Slot) {
+	mobType, ok := Mobs[mob.mobType]
+	if !ok {
+		return
+	}
+
+	for _, drop := range mobType.Drops {
+		count := drop.MinCount + ItemCount(rand.Intn(int(drop.MaxCount-drop.MinCount)+1))
+		if count > 0 {
+			items = append(items, Slot{
+				ItemTypeId: drop.ItemTypeId,
+				Count:      count,
+				Data:       drop.Data,
+			})
+		}
+	}
+	return
+}
+
+// SetBurning sets the mob on fire for mobBurnTicks, or puts it out.
 func (mob *Mob) SetBurning(burn bool) {
+	flags := mob.metadata[0]
 	if burn {
+		mob.burnTicks = mobBurnTicks
 		mob.metadata[0] |= 0x01
 	} else {
-		mob.metadata[0] ^= 0x01
+		mob.burnTicks = 0
+		mob.metadata[0] &^= 0x01
+	}
+	if mob.metadata[0] != flags {
+		mob.metadataChanged = true
 	}
 }
 
+// BurnTick hurts the mob each second that it is burning.
+func (mob *Mob) BurnTick() (hurt, died bool) {
+	if mob.burnTicks == 0 {
+		return false, false
+	}
+
+	if mob.burnTicks--; mob.burnTicks%TicksPerSecond == 0 {
+		hurt, died = true, mob.Damage(mobBurnDamage)
+	}
+	if mob.burnTicks == 0 {
+		mob.SetBurning(false)
+	}
+	return
+}
+
 func (mob *Mob) Tick(blockQuerier physics.IBlockQuerier) (leftBlock bool) {
 	// TODO: Spontaneous mob movement.
 	return mob.PointObject.Tick(blockQuerier)
@@ -119,7 +184,14 @@ func (mob *Mob) SendUpdate(writer io.Writer) (err error) {
 		return
 	}
 
-	err = mob.PointObject.SendUpdate(writer, mob.EntityId, mob.look.ToLookBytes())
+	if err = mob.PointObject.SendUpdate(writer, mob.EntityId, mob.look.ToLookBytes()); err != nil {
+		return
+	}
+
+	if mob.metadataChanged {
+		mob.metadataChanged = false
+		err = proto.WriteEntityMetadata(writer, mob.EntityId, mob.FormatMetadata())
+	}
 
 	return
 }
@@ -167,10 +239,12 @@ func NewCreeper() INonPlayerEntity {
 
 func (c *Creeper) SetNormalStatus() {
 	c.Mob.metadata[17] = creeperNormal
+	c.Mob.metadataChanged = true
 }
 
 func (c *Creeper) CreeperSetBlueAura() {
 	c.Mob.metadata[17] = creeperBlueAura
+	c.Mob.metadataChanged = true
 }
 
 type Skeleton struct {
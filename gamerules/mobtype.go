@@ -5,8 +5,17 @@ import (
 )
 
 type MobType struct {
-	Id   EntityMobType
-	Name string
+	Id    EntityMobType
+	Name  string
+	Drops []MobDrop // Items dropped when the mob dies.
+}
+
+// MobDrop describes an item that a mob drops when it dies.
+type MobDrop struct {
+	ItemTypeId ItemTypeId
+	Data       ItemData
+	MinCount   ItemCount // A random number of items from MinCount to
+	MaxCount   ItemCount // MaxCount drop.
 }
 
 type MobTypeMap map[EntityMobType]*MobType
@@ -29,17 +38,17 @@ var Mobs = MobTypeMap{
 	MobTypeIdWolf:         &WolfType,
 }
 
-var CreeperType = MobType{MobTypeIdCreeper, "creeper"}
-var SkeletonType = MobType{MobTypeIdSkeleton, "skeleton"}
-var SpiderType = MobType{MobTypeIdSpider, "spider"}
-var GiantZombieType = MobType{MobTypeIdGiantZombie, "giantzombie"}
-var ZombieType = MobType{MobTypeIdZombie, "zombie"}
-var SlimeType = MobType{MobTypeIdSlime, "slime"}
-var GhastType = MobType{MobTypeIdGhast, "ghast"}
-var ZombiePigmanType = MobType{MobTypeIdZombiePigman, "zombiepigman"}
-var PigType = MobType{MobTypeIdPig, "pig"}
-var SheepType = MobType{MobTypeIdSheep, "sheep"}
-var CowType = MobType{MobTypeIdCow, "cow"}
-var HenType = MobType{MobTypeIdHen, "hen"}
-var SquidType = MobType{MobTypeIdSquid, "squid"}
-var WolfType = MobType{MobTypeIdWolf, "wolf"}
+var CreeperType = MobType{MobTypeIdCreeper, "creeper", []MobDrop{{289, 0, 0, 2}}}
+var SkeletonType = MobType{MobTypeIdSkeleton, "skeleton", []MobDrop{{262, 0, 0, 2}, {352, 0, 0, 2}}}
+var SpiderType = MobType{MobTypeIdSpider, "spider", []MobDrop{{287, 0, 0, 2}}}
+var GiantZombieType = MobType{MobTypeIdGiantZombie, "giantzombie", []MobDrop{{288, 0, 0, 2}}}
+var ZombieType = MobType{MobTypeIdZombie, "zombie", []MobDrop{{288, 0, 0, 2}}}
+var SlimeType = MobType{MobTypeIdSlime, "slime", []MobDrop{{341, 0, 0, 2}}}
+var GhastType = MobType{MobTypeIdGhast, "ghast", []MobDrop{{289, 0, 0, 2}}}
+var ZombiePigmanType = MobType{MobTypeIdZombiePigman, "zombiepigman", []MobDrop{{320, 0, 0, 2}}}
+var PigType = MobType{MobTypeIdPig, "pig", []MobDrop{{319, 0, 0, 2}}}
+var SheepType = MobType{MobTypeIdSheep, "sheep", []MobDrop{{35, 0, 1, 1}}}
+var CowType = MobType{MobTypeIdCow, "cow", []MobDrop{{334, 0, 0, 2}}}
+var HenType = MobType{MobTypeIdHen, "hen", []MobDrop{{288, 0, 0, 2}}}
+var SquidType = MobType{MobTypeIdSquid, "squid", []MobDrop{{351, 0, 1, 3}}}
+var WolfType = MobType{MobTypeIdWolf, "wolf", nil}
@@ -103,20 +103,20 @@ func (_mr *_MockIPlayerShardClientRecorder) ReqMulticastPlayers(arg0, arg1, arg2
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqMulticastPlayers", arg0, arg1, arg2)
 }
 
-func (_m *MockIPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, held ItemTypeId) {
-	_m.ctrl.Call(_m, "ReqAddPlayerData", chunkLoc, name, position, look, held)
+func (_m *MockIPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, appearance PlayerAppearance) {
+	_m.ctrl.Call(_m, "ReqAddPlayerData", chunkLoc, name, position, look, appearance)
 }
 
 func (_mr *_MockIPlayerShardClientRecorder) ReqAddPlayerData(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqAddPlayerData", arg0, arg1, arg2, arg3, arg4)
 }
 
-func (_m *MockIPlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, isDisconnect bool) {
-	_m.ctrl.Call(_m, "ReqRemovePlayerData", chunkLoc, isDisconnect)
+func (_m *MockIPlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, newChunkLoc ChunkXz, isDisconnect bool) {
+	_m.ctrl.Call(_m, "ReqRemovePlayerData", chunkLoc, newChunkLoc, isDisconnect)
 }
 
-func (_mr *_MockIPlayerShardClientRecorder) ReqRemovePlayerData(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqRemovePlayerData", arg0, arg1)
+func (_mr *_MockIPlayerShardClientRecorder) ReqRemovePlayerData(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqRemovePlayerData", arg0, arg1, arg2)
 }
 
 func (_m *MockIPlayerShardClient) ReqSetPlayerPosition(chunkLoc ChunkXz, position AbsXyz) {
@@ -135,12 +135,20 @@ func (_mr *_MockIPlayerShardClientRecorder) ReqSetPlayerLook(arg0, arg1 interfac
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSetPlayerLook", arg0, arg1)
 }
 
-func (_m *MockIPlayerShardClient) ReqHitBlock(held Slot, target BlockXyz, digStatus DigStatus, face Face) {
-	_m.ctrl.Call(_m, "ReqHitBlock", held, target, digStatus, face)
+func (_m *MockIPlayerShardClient) ReqSetPlayerAppearance(chunkLoc ChunkXz, appearance PlayerAppearance) {
+	_m.ctrl.Call(_m, "ReqSetPlayerAppearance", chunkLoc, appearance)
 }
 
-func (_mr *_MockIPlayerShardClientRecorder) ReqHitBlock(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqHitBlock", arg0, arg1, arg2, arg3)
+func (_mr *_MockIPlayerShardClientRecorder) ReqSetPlayerAppearance(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSetPlayerAppearance", arg0, arg1)
+}
+
+func (_m *MockIPlayerShardClient) ReqHitBlock(held Slot, gameType GameType, target BlockXyz, digStatus DigStatus, face Face) {
+	_m.ctrl.Call(_m, "ReqHitBlock", held, gameType, target, digStatus, face)
+}
+
+func (_mr *_MockIPlayerShardClientRecorder) ReqHitBlock(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqHitBlock", arg0, arg1, arg2, arg3, arg4)
 }
 
 func (_m *MockIPlayerShardClient) ReqInteractBlock(held Slot, target BlockXyz, face Face) {
@@ -151,6 +159,14 @@ func (_mr *_MockIPlayerShardClientRecorder) ReqInteractBlock(arg0, arg1, arg2 in
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqInteractBlock", arg0, arg1, arg2)
 }
 
+func (_m *MockIPlayerShardClient) ReqHitEntity(chunkLoc ChunkXz, target EntityId, held Slot, position AbsXyz, pvp bool) {
+	_m.ctrl.Call(_m, "ReqHitEntity", chunkLoc, target, held, position, pvp)
+}
+
+func (_mr *_MockIPlayerShardClientRecorder) ReqHitEntity(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqHitEntity", arg0, arg1, arg2, arg3, arg4)
+}
+
 func (_m *MockIPlayerShardClient) ReqPlaceItem(target BlockXyz, slot Slot) {
 	_m.ctrl.Call(_m, "ReqPlaceItem", target, slot)
 }
@@ -183,6 +199,14 @@ func (_mr *_MockIPlayerShardClientRecorder) ReqInventoryClick(arg0, arg1 interfa
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqInventoryClick", arg0, arg1)
 }
 
+func (_m *MockIPlayerShardClient) ReqInventoryPutItem(block BlockXyz, item Slot) {
+	_m.ctrl.Call(_m, "ReqInventoryPutItem", block, item)
+}
+
+func (_mr *_MockIPlayerShardClientRecorder) ReqInventoryPutItem(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqInventoryPutItem", arg0, arg1)
+}
+
 func (_m *MockIPlayerShardClient) ReqInventoryUnsubscribed(block BlockXyz) {
 	_m.ctrl.Call(_m, "ReqInventoryUnsubscribed", block)
 }
@@ -191,6 +215,14 @@ func (_mr *_MockIPlayerShardClientRecorder) ReqInventoryUnsubscribed(arg0 interf
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqInventoryUnsubscribed", arg0)
 }
 
+func (_m *MockIPlayerShardClient) ReqMakePortal(position AbsXyz, look LookDegrees) {
+	_m.ctrl.Call(_m, "ReqMakePortal", position, look)
+}
+
+func (_mr *_MockIPlayerShardClientRecorder) ReqMakePortal(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqMakePortal", arg0, arg1)
+}
+
 // Mock of IShardShardClient interface
 type MockIShardShardClient struct {
 	ctrl     *gomock.Controller
@@ -228,14 +260,72 @@ func (_mr *_MockIShardShardClientRecorder) ReqSetActiveBlocks(arg0 interface{})
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSetActiveBlocks", arg0)
 }
 
-func (_m *MockIShardShardClient) ReqTransferEntity(loc ChunkXz, entity INonPlayerEntity) {
-	_m.ctrl.Call(_m, "ReqTransferEntity", loc, entity)
+func (_m *MockIShardShardClient) ReqTransferEntity(loc ChunkXz, entity INonPlayerEntity) bool {
+	ret := _m.ctrl.Call(_m, "ReqTransferEntity", loc, entity)
+	ret0, _ := ret[0].(bool)
+	return ret0
 }
 
 func (_mr *_MockIShardShardClientRecorder) ReqTransferEntity(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqTransferEntity", arg0, arg1)
 }
 
+func (_m *MockIShardShardClient) ReqUpdateLight(updates []LightUpdate) {
+	_m.ctrl.Call(_m, "ReqUpdateLight", updates)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqUpdateLight(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqUpdateLight", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqSpreadBlocks(spreads []BlockSpread) {
+	_m.ctrl.Call(_m, "ReqSpreadBlocks", spreads)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqSpreadBlocks(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSpreadBlocks", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqPlaceBlocks(placements []BlockPlacement) {
+	_m.ctrl.Call(_m, "ReqPlaceBlocks", placements)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqPlaceBlocks(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqPlaceBlocks", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqSetRedstonePower(powers []RedstonePower) {
+	_m.ctrl.Call(_m, "ReqSetRedstonePower", powers)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqSetRedstonePower(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSetRedstonePower", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqSetBorderBlocks(blocks []BorderBlock) {
+	_m.ctrl.Call(_m, "ReqSetBorderBlocks", blocks)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqSetBorderBlocks(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqSetBorderBlocks", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqLightFire(blockLoc BlockXyz) {
+	_m.ctrl.Call(_m, "ReqLightFire", blockLoc)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqLightFire(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqLightFire", arg0)
+}
+
+func (_m *MockIShardShardClient) ReqExplode(explosion Explosion) {
+	_m.ctrl.Call(_m, "ReqExplode", explosion)
+}
+
+func (_mr *_MockIShardShardClientRecorder) ReqExplode(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReqExplode", arg0)
+}
+
 // Mock of IGame interface
 type MockIGame struct {
 	ctrl     *gomock.Controller
@@ -335,6 +425,16 @@ func (_mr *_MockIPlayerClientRecorder) GetEntityId() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetEntityId")
 }
 
+func (_m *MockIPlayerClient) GetName() string {
+	ret := _m.ctrl.Call(_m, "GetName")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockIPlayerClientRecorder) GetName() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetName")
+}
+
 func (_m *MockIPlayerClient) TransmitPacket(packet []byte) {
 	_m.ctrl.Call(_m, "TransmitPacket", packet)
 }
@@ -351,6 +451,30 @@ func (_mr *_MockIPlayerClientRecorder) NotifyChunkLoad() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "NotifyChunkLoad")
 }
 
+func (_m *MockIPlayerClient) EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte) {
+	_m.ctrl.Call(_m, "EntitySpawn", chunkLoc, entityId, spawnPacket)
+}
+
+func (_mr *_MockIPlayerClientRecorder) EntitySpawn(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "EntitySpawn", arg0, arg1, arg2)
+}
+
+func (_m *MockIPlayerClient) EntityMoved(fromChunkLoc ChunkXz, toChunkLoc ChunkXz, entityId EntityId) {
+	_m.ctrl.Call(_m, "EntityMoved", fromChunkLoc, toChunkLoc, entityId)
+}
+
+func (_mr *_MockIPlayerClientRecorder) EntityMoved(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "EntityMoved", arg0, arg1, arg2)
+}
+
+func (_m *MockIPlayerClient) EntityDestroy(chunkLoc ChunkXz, entityId EntityId) {
+	_m.ctrl.Call(_m, "EntityDestroy", chunkLoc, entityId)
+}
+
+func (_mr *_MockIPlayerClientRecorder) EntityDestroy(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "EntityDestroy", arg0, arg1)
+}
+
 func (_m *MockIPlayerClient) InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots []proto.WindowSlot) {
 	_m.ctrl.Call(_m, "InventorySubscribed", block, invTypeId, slots)
 }
@@ -407,6 +531,14 @@ func (_mr *_MockIPlayerClientRecorder) PlaceHeldItem(arg0, arg1 interface{}) *go
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "PlaceHeldItem", arg0, arg1)
 }
 
+func (_m *MockIPlayerClient) WearHeldTool(wasHeld Slot, uses ItemData) {
+	_m.ctrl.Call(_m, "WearHeldTool", wasHeld, uses)
+}
+
+func (_mr *_MockIPlayerClientRecorder) WearHeldTool(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "WearHeldTool", arg0, arg1)
+}
+
 func (_m *MockIPlayerClient) OfferItem(fromChunk ChunkXz, entityId EntityId, item Slot) {
 	_m.ctrl.Call(_m, "OfferItem", fromChunk, entityId, item)
 }
@@ -431,6 +563,38 @@ func (_mr *_MockIPlayerClientRecorder) GiveItem(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GiveItem", arg0)
 }
 
+func (_m *MockIPlayerClient) Hurt(amount Health) {
+	_m.ctrl.Call(_m, "Hurt", amount)
+}
+
+func (_mr *_MockIPlayerClientRecorder) Hurt(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Hurt", arg0)
+}
+
+func (_m *MockIPlayerClient) SetSurroundings(surroundings Surroundings) {
+	_m.ctrl.Call(_m, "SetSurroundings", surroundings)
+}
+
+func (_mr *_MockIPlayerClientRecorder) SetSurroundings(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetSurroundings", arg0)
+}
+
+func (_m *MockIPlayerClient) RejectMove(position AbsXyz) {
+	_m.ctrl.Call(_m, "RejectMove", position)
+}
+
+func (_mr *_MockIPlayerClientRecorder) RejectMove(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RejectMove", arg0)
+}
+
+func (_m *MockIPlayerClient) SetBed(bed BlockXyz) {
+	_m.ctrl.Call(_m, "SetBed", bed)
+}
+
+func (_mr *_MockIPlayerClientRecorder) SetBed(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetBed", arg0)
+}
+
 func (_m *MockIPlayerClient) PositionLook() (AbsXyz, LookDegrees) {
 	ret := _m.ctrl.Call(_m, "PositionLook")
 	ret0, _ := ret[0].(AbsXyz)
@@ -450,6 +614,14 @@ func (_mr *_MockIPlayerClientRecorder) SetPositionLook(arg0, arg1 interface{}) *
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetPositionLook", arg0, arg1)
 }
 
+func (_m *MockIPlayerClient) SetGameType(gameType GameType) {
+	_m.ctrl.Call(_m, "SetGameType", gameType)
+}
+
+func (_mr *_MockIPlayerClientRecorder) SetGameType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetGameType", arg0)
+}
+
 func (_m *MockIPlayerClient) EchoMessage(msg string) {
 	_m.ctrl.Call(_m, "EchoMessage", msg)
 }
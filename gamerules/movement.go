@@ -0,0 +1,69 @@
+package gamerules
+
+import (
+	"math"
+
+	"github.com/huin/chunkymonkey/physics"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	playerHalfWidth = AbsCoord(0.3) // From the middle of a player to each side.
+
+	// Heights above a player's feet at which they must not move through solid
+	// blocks, about their knees and their eyes. The knees are high enough to
+	// walk on slabs and stairs.
+	playerKneeY = AbsCoord(0.5)
+	playerEyeY  = AbsCoord(1.5)
+
+	// Depths below a player's feet that are looked at for blocks to stand on.
+	// The deeper one finds fences, which are taller than a block.
+	standDepth      = AbsCoord(0.5)
+	fenceStandDepth = AbsCoord(1.0)
+
+	moveCheckStep = 0.25 // Distance between the points checked along a move.
+)
+
+// StandingOnSolid returns true if there is a solid block under any part of a
+// player with their feet at pos, close enough for them to stand on. Blocks
+// that aren't known are assumed to be solid.
+func StandingOnSolid(querier physics.IBlockQuerier, pos *AbsXyz) bool {
+	for _, depth := range [2]AbsCoord{standDepth, fenceStandDepth} {
+		for _, dx := range [2]AbsCoord{-playerHalfWidth, playerHalfWidth} {
+			for _, dz := range [2]AbsCoord{-playerHalfWidth, playerHalfWidth} {
+				under := AbsXyz{pos.X + dx, pos.Y - depth, pos.Z + dz}
+				if isSolid, _ := querier.BlockQuery(*under.ToBlockXyz()); isSolid {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// MovesThroughSolid returns true if a player moving from one position to
+// another passes through a solid block. Only blocks within the querier's chunk
+// are looked at, as others might not be known. Blocks that the player starts
+// in are ignored so that players can get out of blocks that fall on them.
+func MovesThroughSolid(querier physics.IBlockQuerier, from, to *AbsXyz) bool {
+	dx, dy, dz := to.X-from.X, to.Y-from.Y, to.Z-from.Z
+	distance := math.Sqrt(float64(dx*dx + dy*dy + dz*dz))
+	steps := int(math.Ceil(distance / moveCheckStep))
+
+	for _, height := range [2]AbsCoord{playerKneeY, playerEyeY} {
+		start := AbsXyz{from.X, from.Y + height, from.Z}
+		startBlock := *start.ToBlockXyz()
+		for i := 1; i <= steps; i++ {
+			f := AbsCoord(i) / AbsCoord(steps)
+			point := AbsXyz{start.X + dx*f, start.Y + dy*f, start.Z + dz*f}
+			blockLoc := point.ToBlockXyz()
+			if *blockLoc == startBlock {
+				continue
+			}
+			if isSolid, isWithinChunk := querier.BlockQuery(*blockLoc); isSolid && isWithinChunk {
+				return true
+			}
+		}
+	}
+	return false
+}
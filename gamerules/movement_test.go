@@ -0,0 +1,66 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// testBlockQuerier is an IBlockQuerier where the given blocks are solid, and
+// all blocks are within the chunk.
+type testBlockQuerier map[BlockXyz]bool
+
+func (querier testBlockQuerier) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk bool) {
+	return querier[blockLoc], true
+}
+
+func TestStandingOnSolid(t *testing.T) {
+	querier := testBlockQuerier{
+		BlockXyz{8, 63, 8}: true,
+		BlockXyz{9, 63, 8}: true, // A fence.
+	}
+
+	tests := []struct {
+		pos      AbsXyz
+		expected bool
+	}{
+		{AbsXyz{8.5, 64, 8.5}, true},
+		{AbsXyz{7.9, 64, 8.5}, true}, // Standing on the edge.
+		{AbsXyz{6.5, 64, 8.5}, false},
+		{AbsXyz{9.5, 64.5, 8.5}, true},
+		{AbsXyz{8.5, 66, 8.5}, false},
+	}
+
+	for _, test := range tests {
+		if supported := StandingOnSolid(querier, &test.pos); supported != test.expected {
+			t.Errorf("Expected standing on solid at %+v to be %t", test.pos, test.expected)
+		}
+	}
+}
+
+func TestMovesThroughSolid(t *testing.T) {
+	querier := testBlockQuerier{
+		BlockXyz{10, 64, 8}: true, // A wall at knee height.
+		BlockXyz{8, 65, 5}:  true, // A wall at eye height.
+		BlockXyz{8, 64, 8}:  true, // A block that the player is stuck in.
+	}
+
+	tests := []struct {
+		from, to AbsXyz
+		expected bool
+	}{
+		{AbsXyz{8.5, 64, 8.5}, AbsXyz{8.5, 64, 8.5}, false},
+		{AbsXyz{8.5, 64, 8.5}, AbsXyz{9.9, 64, 8.5}, false},
+		{AbsXyz{8.5, 64, 8.5}, AbsXyz{11.5, 64, 8.5}, true},
+		{AbsXyz{8.5, 64, 8.5}, AbsXyz{8.5, 64, 4.5}, true},
+		{AbsXyz{8.5, 64, 8.5}, AbsXyz{8.5, 64, 12.5}, false},
+		{AbsXyz{8.5, 62, 8.5}, AbsXyz{8.5, 63, 8.5}, true},
+	}
+
+	for _, test := range tests {
+		if through := MovesThroughSolid(querier, &test.from, &test.to); through != test.expected {
+			t.Errorf("Expected moving through solid from %+v to %+v to be %t",
+				test.from, test.to, test.expected)
+		}
+	}
+}
@@ -105,7 +105,7 @@ func NewPoweredCart() INonPlayerEntity {
 }
 
 func NewActivatedTnt() INonPlayerEntity {
-	return NewObject(ObjTypeIdActivatedTnt)
+	return newBlankActivatedTnt()
 }
 
 func NewArrow() INonPlayerEntity {
@@ -121,11 +121,11 @@ func NewThrownEgg() INonPlayerEntity {
 }
 
 func NewFallingSand() INonPlayerEntity {
-	return NewObject(ObjTypeIdFallingSand)
+	return newBlankFallingBlock(ObjTypeIdFallingSand, blockIdSand)
 }
 
 func NewFallingGravel() INonPlayerEntity {
-	return NewObject(ObjTypeIdFallingGravel)
+	return newBlankFallingBlock(ObjTypeIdFallingGravel, blockIdGravel)
 }
 
 func NewFishingFloat() INonPlayerEntity {
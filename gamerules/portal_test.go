@@ -0,0 +1,129 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// newTestPortalChunk makes a chunk with an obsidian portal frame running
+// along X, with the inside from (5, 2, 5) to (6, 4, 5).
+func newTestPortalChunk() *testChunk {
+	chunk := newTestChunk()
+	for x := BlockCoord(4); x <= 7; x++ {
+		chunk.setBlock(BlockXyz{x, 1, 5}, blockIdObsidian, 0)
+		chunk.setBlock(BlockXyz{x, 5, 5}, blockIdObsidian, 0)
+	}
+	for y := BlockYCoord(2); y <= 4; y++ {
+		chunk.setBlock(BlockXyz{4, y, 5}, blockIdObsidian, 0)
+		chunk.setBlock(BlockXyz{7, y, 5}, blockIdObsidian, 0)
+	}
+	chunk.tick(1)
+	return chunk
+}
+
+func checkPortal(t *testing.T, chunk *testChunk, blockId BlockId) {
+	for x := BlockCoord(5); x <= 6; x++ {
+		for y := BlockYCoord(2); y <= 4; y++ {
+			chunk.checkBlock(t, BlockXyz{x, y, 5}, blockId, 0)
+		}
+	}
+}
+
+func TestLightPortal(t *testing.T) {
+	chunk := newTestPortalChunk()
+
+	if LightPortal(chunk.instance(BlockXyz{5, 3, 5})) {
+		t.Errorf("Expected portal not to be lit from the middle of the frame")
+	}
+	if !LightPortal(chunk.instance(BlockXyz{6, 2, 5})) {
+		t.Fatalf("Expected portal to be lit")
+	}
+	chunk.tick(1)
+	checkPortal(t, chunk, blockIdPortal)
+
+	// Breaking the frame puts out the portal.
+	chunk.setBlock(BlockXyz{7, 3, 5}, BlockIdAir, 0)
+	chunk.tick(5)
+	checkPortal(t, chunk, BlockIdAir)
+}
+
+func TestLightPortalNeedsWholeFrame(t *testing.T) {
+	chunk := newTestPortalChunk()
+	chunk.setBlock(BlockXyz{5, 5, 5}, BlockIdAir, 0)
+
+	if LightPortal(chunk.instance(BlockXyz{5, 2, 5})) {
+		t.Errorf("Expected portal not to be lit in a broken frame")
+	}
+	if !LightFire(chunk.instance(BlockXyz{5, 2, 5})) {
+		t.Errorf("Expected fire to be lit instead")
+	}
+}
+
+func TestPortalDestination(t *testing.T) {
+	tests := []struct {
+		pos       AbsXyz
+		dimension DimensionId
+		expected  AbsXyz
+	}{
+		{AbsXyz{80.2, 70, -160.7}, DimensionNether, AbsXyz{10.5, 70, -20.5}},
+		{AbsXyz{10.5, 70, -20.5}, DimensionNormal, AbsXyz{84.5, 70, -163.5}},
+		// Players arrive far enough from the edges of chunks and the world to
+		// make a portal.
+		{AbsXyz{-4, 5, 120}, DimensionNether, AbsXyz{-2.5, portalMinY, 14.5}},
+		{AbsXyz{1, 127, 1}, DimensionNormal, AbsXyz{8.5, portalMaxY, 8.5}},
+	}
+
+	for _, test := range tests {
+		if dest := PortalDestination(&test.pos, test.dimension); dest != test.expected {
+			t.Errorf("Expected %v to go to %v in dimension %d, got %v", test.pos, test.expected, test.dimension, dest)
+		}
+	}
+}
+
+func TestMakePortal(t *testing.T) {
+	chunk := newTestChunk()
+	for x := BlockCoord(0); x < ChunkSizeH; x++ {
+		for y := BlockYCoord(1); y < 12; y++ {
+			for z := BlockCoord(0); z < ChunkSizeH; z++ {
+				chunk.setBlock(BlockXyz{x, y, z}, testBlockIdStone, 0)
+			}
+		}
+	}
+
+	arrival := AbsXyz{5.5, 2, 4.5}
+	if pos := MakePortal(chunk.instance(BlockXyz{5, 2, 4})); pos != arrival {
+		t.Errorf("Expected player to stay at %v, got %v", arrival, pos)
+	}
+	chunk.tick(1)
+	checkPortal(t, chunk, blockIdPortal)
+	for x := BlockCoord(4); x <= 7; x++ {
+		for z := BlockCoord(3); z <= 4; z++ {
+			chunk.checkBlock(t, BlockXyz{x, 1, z}, blockIdObsidian, 0)
+			chunk.checkBlock(t, BlockXyz{x, 2, z}, BlockIdAir, 0)
+			chunk.checkBlock(t, BlockXyz{x, 4, z}, BlockIdAir, 0)
+		}
+	}
+
+	// A portal isn't made where there is one already.
+	chunk.setBlock(BlockXyz{4, 2, 4}, testBlockIdStone, 0)
+	if pos := MakePortal(chunk.instance(BlockXyz{5, 2, 4})); pos != arrival {
+		t.Errorf("Expected player to stay at %v, got %v", arrival, pos)
+	}
+	chunk.checkBlock(t, BlockXyz{4, 2, 4}, testBlockIdStone, 0)
+}
+
+func TestMakePortalUsesNearbyPortal(t *testing.T) {
+	chunk := newTestPortalChunk()
+	LightPortal(chunk.instance(BlockXyz{5, 2, 5}))
+	chunk.tick(1)
+
+	// The player is taken to the nearest column of the portal, in front of
+	// it, and no new portal is built.
+	expected := AbsXyz{6.5, 2, 4.5}
+	if pos := MakePortal(chunk.instance(BlockXyz{14, 3, 12})); pos != expected {
+		t.Errorf("Expected player to be moved to %v, got %v", expected, pos)
+	}
+	chunk.checkBlock(t, BlockXyz{14, 2, 12}, BlockIdAir, 0)
+	chunk.checkBlock(t, BlockXyz{14, 3, 13}, BlockIdAir, 0)
+}
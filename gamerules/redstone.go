@@ -0,0 +1,254 @@
+package gamerules
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Redstone power runs from 0 (unpowered) to redstoneMaxPower. Wire loses a
+// level of power for each block that the power travels along it.
+const redstoneMaxPower = 15
+
+// RedstoneCharge is how a solid block is charged by a redstone component
+// next to it.
+type RedstoneCharge byte
+
+const (
+	RedstoneChargeNone   = RedstoneCharge(iota)
+	RedstoneChargeWeak   // Powers redstone components, but not wire.
+	RedstoneChargeStrong // Powers wire as well.
+)
+
+// RedstoneInput is the redstone power that a block gives to another block
+// next to it.
+type RedstoneInput struct {
+	Power    byte           // Power given to redstone components.
+	FromWire bool           // Power is from wire, and so is a level lower in wire.
+	Indirect bool           // Power is from a weakly charged block, and doesn't reach wire.
+	Charge   RedstoneCharge // How the block is charged if it is solid.
+}
+
+// wireLevel returns the level of power that the input gives to redstone wire.
+func (input *RedstoneInput) wireLevel() byte {
+	switch {
+	case input.FromWire:
+		if input.Power > 0 {
+			return input.Power - 1
+		}
+		return 0
+	case input.Indirect:
+		return 0
+	}
+	return input.Power
+}
+
+// RedstonePower is the redstone power given by a block to a block in another
+// shard.
+type RedstonePower struct {
+	From, To BlockXyz
+	RedstoneInput
+}
+
+// redstoneComponent is implemented by the aspects of blocks that give out
+// redstone power.
+type redstoneComponent interface {
+	// redstoneOutput returns the power that the block, with the given type and
+	// data, gives to the block at the given offset from it.
+	redstoneOutput(blockId BlockId, blockData byte, to blockOffset) RedstoneInput
+}
+
+type blockOffset struct {
+	dx BlockCoord
+	dy BlockYCoord
+	dz BlockCoord
+}
+
+var (
+	offsetNone  = blockOffset{0, 0, 0}
+	offsetUp    = blockOffset{0, 1, 0}
+	offsetDown  = blockOffset{0, -1, 0}
+	offsetWest  = blockOffset{-1, 0, 0}
+	offsetEast  = blockOffset{1, 0, 0}
+	offsetNorth = blockOffset{0, 0, -1}
+	offsetSouth = blockOffset{0, 0, 1}
+)
+
+// Offsets to the blocks that touch the faces of a block.
+var faceOffsets = [6]blockOffset{
+	offsetWest, offsetEast, offsetDown, offsetUp, offsetNorth, offsetSouth,
+}
+
+// Offsets to the blocks that touch the sides of a block.
+var sideOffsets = [4]blockOffset{
+	offsetWest, offsetEast, offsetNorth, offsetSouth,
+}
+
+func offsetBetween(from, to *BlockXyz) blockOffset {
+	return blockOffset{to.X - from.X, to.Y - from.Y, to.Z - from.Z}
+}
+
+func (offset blockOffset) from(blockLoc *BlockXyz) *BlockXyz {
+	return blockLoc.AddXyz(offset.dx, offset.dy, offset.dz)
+}
+
+func (offset blockOffset) reverse() blockOffset {
+	return blockOffset{-offset.dx, -offset.dy, -offset.dz}
+}
+
+// isFace returns true if the offset is to a block touching a face.
+func (offset blockOffset) isFace() bool {
+	return abs(int(offset.dx))+abs(int(offset.dy))+abs(int(offset.dz)) == 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// attachedOffset returns the offset to the block that a torch, lever or
+// button is attached to, from the block data.
+func attachedOffset(blockData byte) blockOffset {
+	switch blockData & 0x7 {
+	case 1:
+		return offsetWest
+	case 2:
+		return offsetEast
+	case 3:
+		return offsetNorth
+	case 4:
+		return offsetSouth
+	}
+	return offsetDown
+}
+
+// conductsRedstone returns true if the block type passes on the charge given
+// to it by redstone components.
+func conductsRedstone(blockType *BlockType) bool {
+	return blockType.Solid && blockType.Opacity > 0
+}
+
+// RedstoneInputFrom returns the redstone power given by the block at from to
+// the block at to.
+func RedstoneInputFrom(chunk IChunkBlock, from, to *BlockXyz) (input RedstoneInput) {
+	blockId, blockData, ok := chunk.BlockAt(from)
+	if !ok {
+		return chunk.RedstoneInput(from, to)
+	}
+
+	blockType, ok := Blocks.Get(blockId)
+	if !ok {
+		return
+	}
+
+	offset := offsetBetween(from, to)
+	if component, ok := blockType.Aspect.(redstoneComponent); ok {
+		return component.redstoneOutput(blockId, blockData, offset)
+	}
+
+	if !offset.isFace() || !conductsRedstone(blockType) {
+		return
+	}
+
+	switch redstoneCharge(chunk, from) {
+	case RedstoneChargeWeak:
+		input.Power = redstoneMaxPower
+		input.Indirect = true
+	case RedstoneChargeStrong:
+		input.Power = redstoneMaxPower
+	}
+
+	return
+}
+
+// redstoneCharge returns how the block is charged by the redstone components
+// touching it.
+func redstoneCharge(chunk IChunkBlock, blockLoc *BlockXyz) (charge RedstoneCharge) {
+	for _, offset := range faceOffsets {
+		neighbourLoc := offset.from(blockLoc)
+		if neighbourLoc == nil {
+			continue
+		}
+
+		var input RedstoneInput
+		if blockId, blockData, ok := chunk.BlockAt(neighbourLoc); !ok {
+			input = chunk.RedstoneInput(neighbourLoc, blockLoc)
+		} else if blockType, ok := Blocks.Get(blockId); ok {
+			if component, ok := blockType.Aspect.(redstoneComponent); ok {
+				input = component.redstoneOutput(blockId, blockData, offset.reverse())
+			}
+		}
+
+		if input.Charge > charge {
+			charge = input.Charge
+		}
+	}
+
+	return
+}
+
+// isRedstonePowered returns true if any of the blocks touching the block
+// give it power, other than the block at exclude.
+func isRedstonePowered(chunk IChunkBlock, blockLoc *BlockXyz, exclude blockOffset) bool {
+	for _, offset := range faceOffsets {
+		if offset == exclude {
+			continue
+		}
+
+		neighbourLoc := offset.from(blockLoc)
+		if neighbourLoc == nil {
+			continue
+		}
+
+		if input := RedstoneInputFrom(chunk, neighbourLoc, blockLoc); input.Power > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isNearRedstone returns true if there is a redstone component within two
+// blocks of the block.
+func isNearRedstone(chunk IChunkBlock, blockLoc *BlockXyz) (near bool) {
+	forRedstoneArea(blockLoc, func(loc *BlockXyz) {
+		if blockId, _, ok := chunk.BlockAt(loc); ok {
+			if blockType, ok := Blocks.Get(blockId); ok {
+				if _, ok := blockType.Aspect.(redstoneComponent); ok {
+					near = true
+				}
+			}
+		}
+	})
+	return
+}
+
+// activateRedstoneArea activates the blocks that might be affected by a
+// change in the redstone power given out by the block. These are the blocks
+// within two blocks of it, which includes those powered through a solid
+// block.
+func activateRedstoneArea(chunk IChunkBlock, blockLoc *BlockXyz) {
+	forRedstoneArea(blockLoc, func(loc *BlockXyz) {
+		chunk.AddActiveBlock(loc)
+	})
+}
+
+// forRedstoneArea calls fn for each block within two blocks of the given
+// block, not counting diagonal steps as one block.
+func forRedstoneArea(blockLoc *BlockXyz, fn func(loc *BlockXyz)) {
+	for dx := -2; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			for dz := -2; dz <= 2; dz++ {
+				distance := abs(dx) + abs(dy) + abs(dz)
+				if distance == 0 || distance > 2 {
+					continue
+				}
+
+				loc := blockLoc.AddXyz(BlockCoord(dx), BlockYCoord(dy), BlockCoord(dz))
+				if loc != nil {
+					fn(loc)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdWire       = BlockId(55)
+	testBlockIdIronDoor   = BlockId(71)
+	testBlockIdLever      = BlockId(69)
+	testBlockIdTorchOff   = BlockId(75)
+	testBlockIdTorchOn    = BlockId(76)
+	testBlockIdButton     = BlockId(77)
+	testBlockIdRepeater   = BlockId(93)
+	testBlockIdRepeaterOn = BlockId(94)
+)
+
+func TestRedstoneWireFromLever(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{1, 1, 1}, testBlockIdLever, 5)
+	for x := BlockCoord(2); x < ChunkSizeH; x++ {
+		chunk.setBlock(BlockXyz{x, 1, 1}, testBlockIdWire, 0)
+	}
+	chunk.tick(5)
+
+	chunk.interact(BlockXyz{1, 1, 1})
+	chunk.tick(5)
+	for x := BlockCoord(2); x < ChunkSizeH; x++ {
+		chunk.checkBlock(t, BlockXyz{x, 1, 1}, testBlockIdWire, byte(17-x))
+	}
+
+	chunk.interact(BlockXyz{1, 1, 1})
+	chunk.tick(5)
+	for x := BlockCoord(2); x < ChunkSizeH; x++ {
+		chunk.checkBlock(t, BlockXyz{x, 1, 1}, testBlockIdWire, 0)
+	}
+}
+
+func TestRedstoneWireSteps(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{1, 1, 1}, testBlockIdLever, 5|switchOnBit)
+	chunk.setBlock(BlockXyz{2, 1, 1}, testBlockIdWire, 0)
+	// Up a step onto a block, and down again.
+	chunk.setBlock(BlockXyz{3, 1, 1}, testBlockIdStone, 0)
+	chunk.setBlock(BlockXyz{3, 2, 1}, testBlockIdWire, 0)
+	chunk.setBlock(BlockXyz{4, 1, 1}, testBlockIdWire, 0)
+	chunk.tick(5)
+
+	chunk.checkBlock(t, BlockXyz{2, 1, 1}, testBlockIdWire, 15)
+	chunk.checkBlock(t, BlockXyz{3, 2, 1}, testBlockIdWire, 14)
+	chunk.checkBlock(t, BlockXyz{4, 1, 1}, testBlockIdWire, 13)
+}
+
+func TestRedstoneWireFromOtherShard(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.inputs[[2]BlockXyz{{-1, 1, 3}, {0, 1, 3}}] = RedstoneInput{
+		Power:    10,
+		FromWire: true,
+		Charge:   RedstoneChargeWeak,
+	}
+	chunk.setBlock(BlockXyz{0, 1, 3}, testBlockIdWire, 0)
+	chunk.setBlock(BlockXyz{1, 1, 3}, testBlockIdWire, 0)
+	chunk.tick(5)
+
+	chunk.checkBlock(t, BlockXyz{0, 1, 3}, testBlockIdWire, 9)
+	chunk.checkBlock(t, BlockXyz{1, 1, 3}, testBlockIdWire, 8)
+}
+
+func TestRedstoneTorchInverts(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{5, 1, 5}, testBlockIdStone, 0)
+	// The lever and torch are on opposite sides of the block.
+	chunk.setBlock(BlockXyz{4, 1, 5}, testBlockIdLever, 2)
+	chunk.setBlock(BlockXyz{6, 1, 5}, testBlockIdTorchOn, 1)
+	chunk.setBlock(BlockXyz{7, 1, 5}, testBlockIdWire, 0)
+	chunk.tick(5)
+
+	chunk.checkBlock(t, BlockXyz{6, 1, 5}, testBlockIdTorchOn, 1)
+	chunk.checkBlock(t, BlockXyz{7, 1, 5}, testBlockIdWire, 15)
+
+	chunk.interact(BlockXyz{4, 1, 5})
+	chunk.tick(1)
+	// The torch waits for its delay before changing.
+	chunk.checkBlock(t, BlockXyz{6, 1, 5}, testBlockIdTorchOn, 1)
+	chunk.tick(2)
+	chunk.checkBlock(t, BlockXyz{6, 1, 5}, testBlockIdTorchOff, 1)
+	chunk.tick(2)
+	chunk.checkBlock(t, BlockXyz{7, 1, 5}, testBlockIdWire, 0)
+}
+
+func TestRedstoneRepeaterDelay(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{1, 1, 8}, testBlockIdLever, 5)
+	// Facing east, with a delay of 2 redstone ticks.
+	chunk.setBlock(BlockXyz{2, 1, 8}, testBlockIdRepeater, 1|0x4)
+	chunk.setBlock(BlockXyz{3, 1, 8}, testBlockIdWire, 0)
+	chunk.tick(5)
+
+	chunk.interact(BlockXyz{1, 1, 8})
+	chunk.tick(4)
+	chunk.checkBlock(t, BlockXyz{2, 1, 8}, testBlockIdRepeater, 1|0x4)
+	chunk.tick(1)
+	chunk.checkBlock(t, BlockXyz{2, 1, 8}, testBlockIdRepeaterOn, 1|0x4)
+	chunk.tick(1)
+	chunk.checkBlock(t, BlockXyz{3, 1, 8}, testBlockIdWire, 15)
+}
+
+func TestRedstoneButtonOpensDoor(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{8, 1, 12}, testBlockIdIronDoor, 0)
+	chunk.setBlock(BlockXyz{8, 2, 12}, testBlockIdIronDoor, doorTopBit)
+	chunk.setBlock(BlockXyz{9, 2, 12}, testBlockIdButton, 1)
+	chunk.tick(5)
+
+	chunk.interact(BlockXyz{9, 2, 12})
+	chunk.tick(2)
+	chunk.checkBlock(t, BlockXyz{8, 1, 12}, testBlockIdIronDoor, doorOpenBit)
+	chunk.checkBlock(t, BlockXyz{8, 2, 12}, testBlockIdIronDoor, doorTopBit|doorOpenBit)
+	if chunk.packets != 1 {
+		t.Errorf("Expected 1 door sound, got %d", chunk.packets)
+	}
+
+	// Iron doors can't be opened by hand.
+	chunk.interact(BlockXyz{8, 1, 12})
+	chunk.checkBlock(t, BlockXyz{8, 1, 12}, testBlockIdIronDoor, doorOpenBit)
+
+	chunk.tick(20)
+	chunk.checkBlock(t, BlockXyz{9, 2, 12}, testBlockIdButton, 1)
+	chunk.checkBlock(t, BlockXyz{8, 1, 12}, testBlockIdIronDoor, 0)
+	chunk.checkBlock(t, BlockXyz{8, 2, 12}, testBlockIdIronDoor, doorTopBit)
+}
+
+func TestDoorHalfRemovedWithOtherHalf(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{8, 1, 12}, testBlockIdIronDoor, 0)
+	chunk.setBlock(BlockXyz{8, 2, 12}, testBlockIdIronDoor, doorTopBit)
+
+	// Creative players break doors without harvesting them.
+	instance := chunk.instance(BlockXyz{8, 1, 12})
+	instance.BlockType.Aspect.Destroy(instance, false)
+	chunk.checkBlock(t, BlockXyz{8, 2, 12}, BlockIdAir, 0)
+	if len(chunk.entities) != 0 {
+		t.Errorf("Expected unharvested door to drop nothing, got %d entities", len(chunk.entities))
+	}
+}
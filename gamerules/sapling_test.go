@@ -0,0 +1,116 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdSapling = BlockId(6)
+	testBlockIdLog     = BlockId(17)
+	testBlockIdLeaves  = BlockId(18)
+)
+
+func testGrowSapling(chunk *testChunk, blockLoc BlockXyz) bool {
+	instance := chunk.instance(blockLoc)
+	return instance.BlockType.Aspect.(*SaplingAspect).grow(instance)
+}
+
+func TestSaplingGrowsTreeByType(t *testing.T) {
+	tests := []struct {
+		saplingData byte
+		woodData    byte
+		minHeight   BlockYCoord
+	}{
+		{treeOak, 0, 4},
+		{treeSpruce, 1, 6},
+		{treeBirch, 2, 5},
+		{treeBigOak, 0, 8},
+	}
+
+	for _, test := range tests {
+		chunk := newTestChunk()
+		chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdSapling, test.saplingData)
+		if !testGrowSapling(chunk, BlockXyz{8, 1, 8}) {
+			t.Errorf("Expected sapling with data %d to grow", test.saplingData)
+			continue
+		}
+
+		for y := BlockYCoord(1); y < 1+test.minHeight; y++ {
+			chunk.checkBlock(t, BlockXyz{8, y, 8}, testBlockIdLog, test.woodData)
+		}
+
+		leaves := 0
+		for _, block := range chunk.blocks {
+			if block.blockId == testBlockIdLeaves {
+				leaves++
+				if block.blockData != test.woodData {
+					t.Errorf("Expected leaves with data %d, got %d", test.woodData, block.blockData)
+				}
+			}
+		}
+		if leaves == 0 {
+			t.Errorf("Expected sapling with data %d to grow leaves", test.saplingData)
+		}
+	}
+}
+
+func TestSaplingNeedsSpaceAndLight(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdSapling, 0)
+	chunk.setBlock(BlockXyz{8, 3, 8}, testBlockIdStone, 0)
+	if testGrowSapling(chunk, BlockXyz{8, 1, 8}) {
+		t.Errorf("Expected sapling under stone not to grow")
+	}
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, testBlockIdSapling, 0)
+
+	chunk = newTestChunk()
+	chunk.light = saplingMinLight - 1
+	chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdSapling, 0)
+	chunk.randomTick(200)
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, testBlockIdSapling, 0)
+
+	chunk.light = saplingMinLight
+	chunk.randomTick(200)
+	chunk.checkBlock(t, BlockXyz{8, 1, 8}, testBlockIdLog, 0)
+}
+
+func TestSaplingLeavesCrossChunk(t *testing.T) {
+	chunk := newTestChunk()
+	chunk.setBlock(BlockXyz{15, 1, 8}, testBlockIdSapling, treeOak)
+	if !testGrowSapling(chunk, BlockXyz{15, 1, 8}) {
+		t.Fatalf("Expected sapling to grow")
+	}
+
+	if len(chunk.placements) == 0 {
+		t.Fatalf("Expected leaves to be placed in the next chunk")
+	}
+	for _, placement := range chunk.placements {
+		if placement.BlockTypeId != testBlockIdLeaves || placement.Block.X < 16 || placement.Block.X > 17 {
+			t.Errorf("Expected only leaves at X=16 or X=17 outside the chunk, got %+v", placement)
+		}
+	}
+}
+
+func TestSaplingGrowsTheSameEachTime(t *testing.T) {
+	grow := func() map[BlockXyz]testBlock {
+		chunk := newTestChunk()
+		chunk.setBlock(BlockXyz{8, 1, 8}, testBlockIdSapling, treeBigOak)
+		chunk.randomTick(100)
+		return chunk.blocks
+	}
+
+	a, b := grow(), grow()
+	if a[BlockXyz{8, 1, 8}].blockId != testBlockIdLog {
+		t.Fatalf("Expected sapling to grow")
+	}
+	if len(a) != len(b) {
+		t.Fatalf("Expected the same tree each time, got %d and %d blocks", len(a), len(b))
+	}
+	for blockLoc, block := range a {
+		if b[blockLoc] != block {
+			t.Errorf("Expected the same tree each time, got %v and %v at %v", block, b[blockLoc], blockLoc)
+		}
+	}
+}
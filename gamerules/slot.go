@@ -253,6 +253,23 @@ func (s *Slot) Decrement() (changed bool) {
 	return
 }
 
+// Wear uses up a number of the uses of the tool in the slot, destroying it once
+// it has been used ToolUses times. It does nothing to items that aren't tools.
+func (s *Slot) Wear(uses ItemData) (changed bool) {
+	itemType := s.ItemType()
+	if s.Count <= 0 || itemType == nil || itemType.ToolUses <= 0 {
+		return
+	}
+
+	if s.Data+uses >= itemType.ToolUses {
+		s.Clear()
+	} else {
+		s.Data += uses
+	}
+	changed = true
+	return
+}
+
 func (s *Slot) UnmarshalNbt(tag *nbt.Compound) (err error) {
 	var ok bool
 	var idTag, damageTag *nbt.Short
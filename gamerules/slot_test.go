@@ -384,3 +384,38 @@ func TestSlot_AddOne(t *testing.T) {
 		},
 	)
 }
+
+func TestSlot_Wear(t *testing.T) {
+	Items = make(ItemTypeMap)
+	apple := ItemTypeId(1)
+	makeItemType(apple)
+	pickaxe := ItemTypeId(2)
+	Items[pickaxe] = &ItemType{
+		Id:       pickaxe,
+		MaxStack: 1,
+		ToolType: 2,
+		ToolUses: 3,
+	}
+
+	tests := []struct {
+		desc     string
+		initial  Slot
+		uses     ItemData
+		expected Slot
+		changed  bool
+	}{
+		{"wearing empty slot", Slot{0, 0, 0}, 1, Slot{0, 0, 0}, false},
+		{"wearing non-tool", Slot{apple, 1, 0}, 1, Slot{apple, 1, 0}, false},
+		{"wearing new tool", Slot{pickaxe, 1, 0}, 1, Slot{pickaxe, 1, 1}, true},
+		{"wearing tool with a use left", Slot{pickaxe, 1, 2}, 1, Slot{0, 0, 0}, true},
+		{"wearing tool past its uses", Slot{pickaxe, 1, 1}, 5, Slot{0, 0, 0}, true},
+	}
+
+	for _, test := range tests {
+		slot := test.initial
+		changed := slot.Wear(test.uses)
+		if !slotEq(&test.expected, &slot) || changed != test.changed {
+			t.Errorf("%s: expected %+v (changed=%t), got %+v (changed=%t)", test.desc, test.expected, test.changed, slot, changed)
+		}
+	}
+}
@@ -31,20 +31,32 @@ type IPlayerShardClient interface {
 
 	ReqMulticastPlayers(chunkLoc ChunkXz, exclude EntityId, packet []byte)
 
-	ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, held ItemTypeId)
+	ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, appearance PlayerAppearance)
 
-	ReqRemovePlayerData(chunkLoc ChunkXz, isDisconnect bool)
+	// ReqRemovePlayerData removes the player from the chunk. newChunkLoc is
+	// the chunk that the player has moved to, and is ignored if isDisconnect
+	// is true.
+	ReqRemovePlayerData(chunkLoc ChunkXz, newChunkLoc ChunkXz, isDisconnect bool)
 
 	ReqSetPlayerPosition(chunkLoc ChunkXz, position AbsXyz)
 
 	ReqSetPlayerLook(chunkLoc ChunkXz, look LookBytes)
 
+	// ReqSetPlayerAppearance shows other players the player's new held item,
+	// armour or crouching.
+	ReqSetPlayerAppearance(chunkLoc ChunkXz, appearance PlayerAppearance)
+
 	// ReqHitBlock requests that the targetted block be hit.
-	ReqHitBlock(held Slot, target BlockXyz, digStatus DigStatus, face Face)
+	ReqHitBlock(held Slot, gameType GameType, target BlockXyz, digStatus DigStatus, face Face)
 
 	// ReqHitBlock requests that the targetted block be interacted with.
 	ReqInteractBlock(held Slot, target BlockXyz, face Face)
 
+	// ReqHitEntity requests that the player hits the entity in the chunk with
+	// the held item. The player attacks from the given position, which must be
+	// within reach of the entity. Other players are only hit if pvp is true.
+	ReqHitEntity(chunkLoc ChunkXz, target EntityId, held Slot, position AbsXyz, pvp bool)
+
 	// ReqPlaceItem requests that the item passed be placed at the given target
 	// location. The shard *may* choose not to do this, but if it cannot, then it
 	// *must* account for the item in some way (maybe hand it back to the player
@@ -65,9 +77,19 @@ type IPlayerShardClient interface {
 	// ReqInventorySlotUpdate to all subscribers to the inventory.
 	ReqInventoryClick(block BlockXyz, click Click)
 
+	// ReqInventoryPutItem requests that items that the player shift-clicked in
+	// their own inventory be put into the inventory. Items that don't fit are
+	// given back to the player.
+	ReqInventoryPutItem(block BlockXyz, item Slot)
+
 	// ReqInventoryUnsubscribed requests that the inventory for the block be
 	// unsubscribed to.
 	ReqInventoryUnsubscribed(block BlockXyz)
+
+	// ReqMakePortal requests that a portal is found or built for a player
+	// arriving at position from another dimension, see MakePortal. The player
+	// is moved to the portal, keeping their look, if it is elsewhere.
+	ReqMakePortal(position AbsXyz, look LookDegrees)
 }
 
 // IShardShardClient provides an interface for shards to make requests against
@@ -78,7 +100,38 @@ type IShardShardClient interface {
 
 	ReqSetActiveBlocks(blocks []BlockXyz)
 
-	ReqTransferEntity(loc ChunkXz, entity INonPlayerEntity)
+	// ReqTransferEntity moves the entity into the chunk in this shard. It
+	// returns false if the entity could not be sent, in which case the caller
+	// must keep it.
+	ReqTransferEntity(loc ChunkXz, entity INonPlayerEntity) (sent bool)
+
+	// ReqUpdateLight requests that changes in light level at the edge of
+	// another shard be propagated into this shard.
+	ReqUpdateLight(updates []LightUpdate)
+
+	// ReqSpreadBlocks requests that blocks spread into this shard from
+	// another, as described by ISpreadingAspect.
+	ReqSpreadBlocks(spreads []BlockSpread)
+
+	// ReqPlaceBlocks requests that blocks from another shard are placed in
+	// this shard where the blocks there are replaceable.
+	ReqPlaceBlocks(placements []BlockPlacement)
+
+	// ReqSetRedstonePower sets the redstone power given to blocks in this
+	// shard by blocks in another shard.
+	ReqSetRedstonePower(powers []RedstonePower)
+
+	// ReqSetBorderBlocks tells this shard about blocks in another shard that
+	// touch it, as they change.
+	ReqSetBorderBlocks(blocks []BorderBlock)
+
+	// ReqLightFire requests that fire is lit at a block in this shard, as by
+	// flint and steel used in another shard.
+	ReqLightFire(blockLoc BlockXyz)
+
+	// ReqExplode requests that an explosion in another shard affects the
+	// blocks and entities in this shard.
+	ReqExplode(explosion Explosion)
 }
 
 // IGame provide an interface for interacting with and taking action on the
@@ -106,12 +159,29 @@ type IGame interface {
 type IPlayerClient interface {
 	GetEntityId() EntityId
 
+	// GetName returns the player's name.
+	GetName() string
+
 	TransmitPacket(packet []byte)
 
 	// NotifyChunkLoad informs Player that a chunk subscription request with
 	// notify=true has completed.
 	NotifyChunkLoad()
 
+	// EntitySpawn informs the player that an entity (including another
+	// player) is in a chunk that they are subscribed to. The spawn packet is
+	// only transmitted if the entity is not already visible to the player.
+	EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte)
+
+	// EntityMoved informs the player that an entity has moved from one chunk
+	// to another. The entity stops being visible to the player if they are not
+	// subscribed to the new chunk.
+	EntityMoved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId)
+
+	// EntityDestroy informs the player that an entity has been removed from a
+	// chunk.
+	EntityDestroy(chunkLoc ChunkXz, entityId EntityId)
+
 	// InventorySubscribed informs the player that an inventory has been
 	// opened.
 	InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots []proto.WindowSlot)
@@ -142,6 +212,11 @@ type IPlayerClient interface {
 	// held item).
 	PlaceHeldItem(target BlockXyz, wasHeld Slot)
 
+	// WearHeldTool requests that the player frontend wear down the held tool
+	// by a number of uses, if it is still holding the tool. The tool breaks
+	// once it has been used ToolUses times.
+	WearHeldTool(wasHeld Slot, uses ItemData)
+
 	// OfferItem requests that the player check if it can take the item.  If
 	// it can then it should ReqTakeItem from the chunk.
 	OfferItem(fromChunk ChunkXz, entityId EntityId, item Slot)
@@ -155,12 +230,31 @@ type IPlayerClient interface {
 	// current position as the 'atPosition'.
 	GiveItem(item Slot)
 
+	// Hurt inflicts damage on the player, such as from an explosion.
+	Hurt(amount Health)
+
+	// SetSurroundings informs the player of the blocks that they are in, which
+	// may hurt them. The chunk that the player is in sends this whenever it
+	// changes.
+	SetSurroundings(surroundings Surroundings)
+
+	// RejectMove informs the player that they moved through solid blocks, and
+	// puts them back at the position that they moved from.
+	RejectMove(position AbsXyz)
+
+	// SetBed informs the player that they have used a bed, where they respawn
+	// after dying.
+	SetBed(bed BlockXyz)
+
 	// PositionLook returns the player's current position and look
 	PositionLook() (AbsXyz, LookDegrees)
 
 	// SetPositionLook changes the player's position and look
 	SetPositionLook(AbsXyz, LookDegrees)
 
+	// SetGameType changes the player's game mode, such as to creative.
+	SetGameType(gameType GameType)
+
 	// EchoMessage displays a message to the player
 	EchoMessage(msg string)
 }
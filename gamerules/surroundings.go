@@ -0,0 +1,40 @@
+package gamerules
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Surroundings describes the blocks that a player is in, as far as they
+// affect the player's health and movement. Chunks work it out for the players
+// in them, and tell the player frontend when it changes.
+type Surroundings struct {
+	Damage    Health // Damage done by touching the blocks, such as fire.
+	OnFire    bool   // Do the blocks set the player on fire?
+	Wet       bool   // Do the blocks put out fire?
+	Drowning  bool   // Is the player's head in a block that they can't breathe in?
+	Supported bool   // Is the player standing on or holding onto something?
+	Portal    bool   // Is the player in a portal to another dimension?
+}
+
+// PlayerSurroundings works out the surroundings of a player from the types of
+// the blocks at their feet and head. Either may be nil if the block isn't
+// known, such as below the world. Players are only Supported by climbable
+// blocks that they are in, see StandingOnSolid for the blocks below them.
+func PlayerSurroundings(feet, head *BlockType) (s Surroundings) {
+	for _, blockType := range [2]*BlockType{feet, head} {
+		if blockType == nil {
+			continue
+		}
+		if blockType.ContactDamage > s.Damage {
+			s.Damage = blockType.ContactDamage
+		}
+		s.OnFire = s.OnFire || blockType.SetsOnFire
+		s.Wet = s.Wet || blockType.Drowns
+		s.Supported = s.Supported || blockType.Climbable
+		s.Portal = s.Portal || blockType.id == blockIdPortal
+	}
+
+	s.Drowning = head != nil && head.Drowns
+
+	return
+}
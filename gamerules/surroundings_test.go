@@ -0,0 +1,43 @@
+package gamerules
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestPlayerSurroundings(t *testing.T) {
+	blockType := func(blockId BlockId) *BlockType {
+		blockType, ok := Blocks.Get(blockId)
+		if !ok {
+			t.Fatalf("Block type %d not loaded", blockId)
+		}
+		return blockType
+	}
+	air := blockType(BlockIdAir)
+	water := blockType(testBlockIdWater)
+	fire := blockType(blockIdFire)
+	lava := blockType(BlockId(11))
+	ladder := blockType(BlockId(65))
+	portal := blockType(blockIdPortal)
+
+	tests := []struct {
+		feet, head *BlockType
+		expected   Surroundings
+	}{
+		{air, air, Surroundings{}},
+		{nil, nil, Surroundings{}},
+		{water, air, Surroundings{Wet: true, Supported: true}},
+		{water, water, Surroundings{Wet: true, Drowning: true, Supported: true}},
+		{fire, air, Surroundings{Damage: 1, OnFire: true}},
+		{lava, fire, Surroundings{Damage: 4, OnFire: true, Supported: true}},
+		{ladder, air, Surroundings{Supported: true}},
+		{portal, portal, Surroundings{Portal: true}},
+	}
+
+	for _, test := range tests {
+		if s := PlayerSurroundings(test.feet, test.head); s != test.expected {
+			t.Errorf("Expected surroundings %+v, got %+v", test.expected, s)
+		}
+	}
+}
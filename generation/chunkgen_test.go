@@ -19,3 +19,34 @@ func Benchmark_TestGenerator_generate(b *testing.B) {
 		gen.ReadChunk(loc)
 	}
 }
+
+func TestNetherGenerator(t *testing.T) {
+	gen := NewNetherGenerator(0)
+	reader, err := gen.ReadChunk(ChunkXz{3, -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := reader.Blocks()
+	for column := 0; column < ChunkSizeH*ChunkSizeH; column++ {
+		stack := blocks[column*ChunkSizeY : (column+1)*ChunkSizeY]
+		if stack[0] != blockIdBedrock || stack[ChunkSizeY-1] != blockIdBedrock {
+			t.Fatalf("Expected bedrock floor and roof in column %d, got %d and %d", column, stack[0], stack[ChunkSizeY-1])
+		}
+		air := 0
+		for _, blockId := range stack {
+			if blockId == 0 {
+				air++
+			}
+		}
+		if air < 4 {
+			t.Fatalf("Expected cavern in column %d, got %d air blocks", column, air)
+		}
+	}
+
+	for _, light := range reader.SkyLight() {
+		if light != 0 {
+			t.Fatalf("Expected no sky light in the Nether")
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package generation
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/huin/chunkymonkey/chunkstore"
+	"github.com/huin/chunkymonkey/perlin"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	netherFloorLevel   = 40
+	netherCeilingLevel = 96
+	netherLavaLevel    = 31
+
+	blockIdBedrock    = 7
+	blockIdLava       = 11 // stationary lava
+	blockIdNetherrack = 87
+	blockIdSoulSand   = 88
+	blockIdGlowstone  = 89
+)
+
+// NetherGenerator implements chunkstore.IChunkStoreForeground. It generates
+// a cavern of netherrack between a floor and roof of bedrock, with a sea of
+// lava at the bottom.
+type NetherGenerator struct {
+	floorSource   ISource
+	ceilingSource ISource
+	randGen       *rand.Rand
+}
+
+func NewNetherGenerator(seed int64) *NetherGenerator {
+	perlin := perlin.NewPerlinNoise(seed)
+
+	return &NetherGenerator{
+		randGen: rand.New(rand.NewSource(time.Now().Unix())),
+		floorSource: &Sum{
+			Inputs: []ISource{
+				Const(netherFloorLevel),
+				&Scale{
+					Wavelength: 40,
+					Amplitude:  14,
+					Source:     perlin,
+				},
+				&Scale{
+					Wavelength: 8,
+					Amplitude:  3,
+					Source:     perlin,
+				},
+			},
+		},
+		ceilingSource: &Sum{
+			Inputs: []ISource{
+				Const(netherCeilingLevel),
+				&Scale{
+					Wavelength: 30,
+					Amplitude:  12,
+					Source:     &Offset{100.1, 0, perlin},
+				},
+			},
+		},
+	}
+}
+
+func (gen *NetherGenerator) SupportsWrite() bool {
+	return false
+}
+
+func (gen *NetherGenerator) Writer() chunkstore.IChunkWriter {
+	return nil
+}
+
+func (gen *NetherGenerator) WriteChunk(writer chunkstore.IChunkWriter) error {
+	return errors.New("writes not supported by NetherGenerator")
+}
+
+func (gen *NetherGenerator) ReadChunk(chunkLoc ChunkXz) (reader chunkstore.IChunkReader, err error) {
+	baseBlockXyz := chunkLoc.ChunkCornerBlockXY()
+
+	baseX, baseZ := baseBlockXyz.X, baseBlockXyz.Z
+
+	data := newChunkData(chunkLoc)
+
+	baseIndex := BlockIndex(0)
+	heightMapIndex := 0
+	for x := 0; x < ChunkSizeH; x++ {
+		for z := 0; z < ChunkSizeH; z++ {
+			xf, zf := float64(x)+float64(baseX), float64(z)+float64(baseZ)
+			floor := int(gen.floorSource.At2d(xf, zf))
+			ceiling := int(gen.ceilingSource.At2d(xf, zf))
+
+			gen.setBlockStack(
+				floor, ceiling,
+				data.blocks[baseIndex:baseIndex+ChunkSizeY])
+
+			// There is no sky in the Nether, so no sky light.
+			data.heightMap[heightMapIndex] = ChunkSizeY
+
+			heightMapIndex++
+			baseIndex += ChunkSizeY
+		}
+	}
+
+	return data, nil
+}
+
+func (gen *NetherGenerator) setBlockStack(floor, ceiling int, blocks []byte) {
+	if floor < 1 {
+		floor = 1
+	}
+	if ceiling > ChunkSizeY-2 {
+		ceiling = ChunkSizeY - 2
+	}
+	if ceiling < floor+4 {
+		ceiling = floor + 4
+	}
+
+	blocks[0] = blockIdBedrock
+	for y := 1; y <= floor; y++ {
+		blocks[y] = blockIdNetherrack
+	}
+	for y := floor + 1; y <= netherLavaLevel; y++ {
+		blocks[y] = blockIdLava
+	}
+	for y := ceiling; y < ChunkSizeY-1; y++ {
+		blocks[y] = blockIdNetherrack
+	}
+	blocks[ChunkSizeY-1] = blockIdBedrock
+
+	if floor > netherLavaLevel && gen.randGen.Intn(20) == 0 {
+		blocks[floor] = blockIdSoulSand
+	}
+	if gen.randGen.Intn(50) == 0 {
+		blocks[ceiling-1] = blockIdGlowstone
+	}
+}
@@ -53,6 +53,19 @@ func (obj *PointObject) Position() *AbsXyz {
 	return &obj.position
 }
 
+// OnGround returns true if the object has landed on a solid block.
+func (obj *PointObject) OnGround() bool {
+	return obj.onGround
+}
+
+// Push adds to the velocity of the object, lifting it off the ground.
+func (obj *PointObject) Push(dv *AbsVelocity) {
+	obj.velocity.X += dv.X
+	obj.velocity.Y += dv.Y
+	obj.velocity.Z += dv.Z
+	obj.onGround = false
+}
+
 func (obj *PointObject) Init(position *AbsXyz, velocity *AbsVelocity) {
 	obj.LastSentPosition = *position.ToAbsIntXyz()
 	obj.LastSentVelocity = *velocity.ToVelocity()
@@ -0,0 +1,27 @@
+package player
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+)
+
+// appearance returns how other players see the player.
+func (player *Player) appearance() (appearance gamerules.PlayerAppearance) {
+	appearance.Equipment = player.inventory.Equipment()
+	appearance.Crouching = player.crouching
+	return
+}
+
+// updateAppearance shows other players any change in the player's held item,
+// armour or crouching since it was last shown. It must be called with
+// player.lock held.
+func (player *Player) updateAppearance() {
+	appearance := player.appearance()
+	if appearance == player.sentAppearance {
+		return
+	}
+
+	if shard, ok := player.chunkSubs.CurrentShardClient(); ok {
+		player.sentAppearance = appearance
+		shard.ReqSetPlayerAppearance(player.chunkSubs.curChunkLoc, appearance)
+	}
+}
@@ -0,0 +1,68 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestPlayerCrouches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	player.PacketEntityAction(player.EntityId, EntityActionCrouch)
+	if !player.sentAppearance.Crouching {
+		t.Errorf("Expected other players to be shown crouching")
+	}
+
+	player.PacketEntityAction(player.EntityId, EntityActionUncrouch)
+	if player.sentAppearance.Crouching {
+		t.Errorf("Expected other players to be shown standing")
+	}
+}
+
+func TestPlayerShowsHeldItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	sword := gamerules.Slot{ItemTypeId: 267, Count: 1}
+	player.inventory.PutItem(&sword)
+	tickTestPlayer(player, 1)
+
+	if held := player.sentAppearance.Equipment[gamerules.EquipmentSlotHeld]; held.ItemTypeId != 267 {
+		t.Errorf("Expected other players to be shown the sword, got %v", held)
+	}
+}
+
+func TestPlayerNbtKeepsArmour(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	tag := nbt.NewCompound()
+	if err := player.MarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	inventory := tag.Lookup("Inventory").(*nbt.List)
+	helmet := nbt.NewCompound()
+	helmet.Set("Slot", &nbt.Byte{103})
+	helmet.Set("id", &nbt.Short{306})
+	helmet.Set("Count", &nbt.Byte{1})
+	helmet.Set("Damage", &nbt.Short{0})
+	inventory.Value = append(inventory.Value, helmet)
+
+	loaded, _ := newTestPlayer(mockCtrl)
+	if err := loaded.UnmarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	equipment := loaded.inventory.Equipment()
+	if worn := equipment[gamerules.EquipmentSlotHelmet]; worn.ItemTypeId != 306 {
+		t.Errorf("Expected helmet to be worn on the head, got %v", equipment)
+	}
+}
@@ -0,0 +1,32 @@
+package player
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Permission that players need to hurt other players.
+const pvpPermission = "world.pvp"
+
+// attack hits the target entity with the held item. The shard with the chunk
+// that the entity is in works out the damage, and checks that it is in reach.
+// It must be called with player.lock held.
+func (player *Player) attack(target EntityId) {
+	if !player.spawnComplete || player.isDead() {
+		return
+	}
+
+	chunkLoc, ok := player.chunkSubs.entities.chunkOf(target)
+	if !ok {
+		return
+	}
+	shardClient, ok := player.chunkSubs.ShardClientForChunkXz(&chunkLoc)
+	if !ok {
+		return
+	}
+
+	held, _ := player.inventory.HeldItem()
+	pvp := gamerules.Permissions.UserPermissions(player.name).Has(pvpPermission)
+	shardClient.ReqHitEntity(chunkLoc, target, held, player.position, pvp)
+	player.exhaust(attackExhaustion)
+}
@@ -0,0 +1,29 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestPlayerAttacksOnEntityShard(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	player.chunkSubs.entities.Init()
+	player.chunkSubs.entities.spawn(ChunkXz{1, 2}, 50)
+
+	sword := gamerules.Slot{ItemTypeId: 267, Count: 1}
+	player.inventory.PutItem(&sword)
+	held, _ := player.inventory.HeldItem()
+
+	shard.EXPECT().ReqHitEntity(ChunkXz{1, 2}, EntityId(50), held, player.position, true)
+	player.PacketUseEntity(player.EntityId, 50, true)
+
+	// Right clicks and unknown entities don't attack.
+	player.PacketUseEntity(player.EntityId, 50, false)
+	player.PacketUseEntity(player.EntityId, 51, true)
+}
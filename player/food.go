@@ -0,0 +1,189 @@
+package player
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	eatTicks = 32 // Ticks that it takes to eat an item.
+
+	initialSaturation = 5
+
+	// Exhaustion that uses up a unit of food saturation, or a unit of food once
+	// the player has no saturation left.
+	exhaustionPerFood = 4
+	maxExhaustion     = 40
+
+	// Exhaustion caused by the player's activity.
+	walkExhaustion   = 0.01  // Per block walked.
+	swimExhaustion   = 0.015 // Per block swum.
+	jumpExhaustion   = 0.2
+	digExhaustion    = 0.025 // Per block dug.
+	hurtExhaustion   = 0.3
+	attackExhaustion = 0.3
+	regenExhaustion  = 3
+
+	foodTickTicks = 80 // Ticks between each regeneration or starvation.
+
+	regenFood   = FoodUnits(18) // Food at and above which players regenerate.
+	regenHealth = Health(1)
+
+	starveDamage = Health(1)
+	// Starvation doesn't kill, as on normal difficulty.
+	starveMinHealth = Health(1)
+)
+
+// foodTick runs the player's hunger for a single server tick. It must be
+// called with player.lock held.
+func (player *Player) foodTick() {
+	if player.eating > 0 {
+		if player.eating--; player.eating == 0 {
+			player.finishEating()
+		}
+	}
+
+	if player.exhaustion >= exhaustionPerFood {
+		player.exhaustion -= exhaustionPerFood
+		if player.saturation > 0 {
+			player.saturation--
+			if player.saturation < 0 {
+				player.saturation = 0
+			}
+		} else if player.food > 0 {
+			player.food--
+		}
+		player.sendHealth()
+	}
+
+	switch {
+	case player.food >= regenFood && player.health < MaxHealth:
+		if player.foodTimer++; player.foodTimer >= foodTickTicks {
+			player.foodTimer = 0
+			player.heal(regenHealth)
+			player.exhaust(regenExhaustion)
+		}
+	case player.food <= 0 && player.health > starveMinHealth:
+		if player.foodTimer++; player.foodTimer >= foodTickTicks {
+			player.foodTimer = 0
+			player.hurt(starveDamage)
+		}
+	default:
+		player.foodTimer = 0
+	}
+}
+
+// exhaust adds to the player's exhaustion from their activity, which
+// eventually makes them hungry. Creative players don't get hungry. It must be
+// called with player.lock held.
+func (player *Player) exhaust(amount float32) {
+	if player.isCreative() {
+		return
+	}
+	player.exhaustion += amount
+	if player.exhaustion > maxExhaustion {
+		player.exhaustion = maxExhaustion
+	}
+}
+
+// walk exhausts the player for moving from their current position to newPos.
+// It must be called with player.lock held.
+func (player *Player) walk(newPos *AbsXyz, onGround bool) {
+	dx := float64(newPos.X - player.position.X)
+	dz := float64(newPos.Z - player.position.Z)
+	if distance := float32(math.Sqrt(dx*dx + dz*dz)); distance > 0 {
+		if player.surroundings.Wet {
+			player.exhaust(distance * swimExhaustion)
+		} else {
+			player.exhaust(distance * walkExhaustion)
+		}
+	}
+
+	if player.onGround != 0 && !onGround && newPos.Y > player.position.Y {
+		player.exhaust(jumpExhaustion)
+	}
+}
+
+// useHeldItem uses the item that the player is holding without a target,
+// which starts eating it if it is food. It must be called with player.lock
+// held.
+func (player *Player) useHeldItem() {
+	held, _ := player.inventory.HeldItem()
+	itemType := held.ItemType()
+	if itemType == nil || !itemType.IsFood() || player.food >= MaxFoodUnits {
+		return
+	}
+
+	player.eating = eatTicks
+	player.eatingItem = held.ItemTypeId
+}
+
+// stopEating stops the player eating before they finish. It must be called
+// with player.lock held.
+func (player *Player) stopEating() {
+	player.eating = 0
+}
+
+// finishEating consumes one of the food item that the player is eating, if
+// they are still holding it. It must be called with player.lock held.
+func (player *Player) finishEating() {
+	held, _ := player.inventory.HeldItem()
+	if held.ItemTypeId != player.eatingItem {
+		return
+	}
+	itemType := held.ItemType()
+	if itemType == nil || !itemType.IsFood() {
+		return
+	}
+
+	var eaten gamerules.Slot
+	player.inventory.TakeOneHeldItem(&eaten)
+	if eaten.IsEmpty() {
+		return
+	}
+
+	player.food += itemType.Food
+	if player.food > MaxFoodUnits {
+		player.food = MaxFoodUnits
+	}
+	// Saturation can't be more than the food that the player has.
+	player.saturation += itemType.Saturation
+	if player.saturation > float32(player.food) {
+		player.saturation = float32(player.food)
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteEntityStatus(buf, player.EntityId, EntityStatusEatingAccepted)
+	proto.WriteUpdateHealth(buf, player.health, player.food, player.saturation)
+	player.TransmitPacket(buf.Bytes())
+
+	if itemType.FoodLeftover != 0 {
+		leftover := gamerules.Slot{ItemTypeId: itemType.FoodLeftover, Count: 1}
+		player.giveItem(&player.position, &leftover)
+	}
+}
+
+// heal restores some of the player's health. It must be called with
+// player.lock held.
+func (player *Player) heal(amount Health) {
+	if player.isDead() {
+		return
+	}
+
+	player.health += amount
+	if player.health > MaxHealth {
+		player.health = MaxHealth
+	}
+	player.sendHealth()
+}
+
+// sendHealth tells the player their health and food.
+func (player *Player) sendHealth() {
+	buf := new(bytes.Buffer)
+	proto.WriteUpdateHealth(buf, player.health, player.food, player.saturation)
+	player.TransmitPacket(buf.Bytes())
+}
@@ -0,0 +1,159 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testItemIdBread = ItemTypeId(297)
+	testItemIdBowl  = ItemTypeId(281)
+	testItemIdSoup  = ItemTypeId(282)
+)
+
+func checkFood(t *testing.T, player *Player, expected FoodUnits) {
+	if player.food != expected {
+		t.Errorf("Expected food %d, got %d", expected, player.food)
+	}
+}
+
+func TestPlayerEats(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.food = 10
+	player.saturation = 0
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: testItemIdBread, Count: 2})
+
+	player.useHeldItem()
+	tickTestPlayer(player, eatTicks-1)
+	checkFood(t, player, 10)
+
+	tickTestPlayer(player, 1)
+	checkFood(t, player, 15)
+	if player.saturation != 6 {
+		t.Errorf("Expected saturation 6, got %f", player.saturation)
+	}
+	if held, _ := player.inventory.HeldItem(); held.Count != 1 {
+		t.Errorf("Expected one bread to be eaten, holding %v", held)
+	}
+
+	// Changing the held item stops the player eating.
+	player.useHeldItem()
+	player.PacketHoldingChange(1)
+	tickTestPlayer(player, eatTicks)
+	checkFood(t, player, 15)
+}
+
+func TestPlayerEatsSoupFromBowl(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.food = 5
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: testItemIdSoup, Count: 1})
+
+	player.useHeldItem()
+	tickTestPlayer(player, eatTicks)
+	checkFood(t, player, 15)
+	if held, _ := player.inventory.HeldItem(); held.ItemTypeId != testItemIdBowl || held.Count != 1 {
+		t.Errorf("Expected a bowl to be left, holding %v", held)
+	}
+}
+
+func TestPlayerCannotEatWhenFull(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: testItemIdBread, Count: 1})
+
+	player.useHeldItem()
+	tickTestPlayer(player, eatTicks)
+	if held, _ := player.inventory.HeldItem(); held.Count != 1 {
+		t.Errorf("Expected full player not to eat, holding %v", held)
+	}
+}
+
+func TestPlayerGetsHungry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.saturation = 1
+
+	// Saturation is used up before food.
+	player.exhaust(exhaustionPerFood)
+	tickTestPlayer(player, 1)
+	checkFood(t, player, MaxFoodUnits)
+	if player.saturation != 0 {
+		t.Errorf("Expected saturation to be used up, got %f", player.saturation)
+	}
+
+	// Walking 200 blocks uses up half a unit of exhaustion each time.
+	player.walk(&AbsXyz{player.position.X + 200, player.position.Y, player.position.Z}, true)
+	tickTestPlayer(player, 1)
+	checkFood(t, player, MaxFoodUnits)
+	player.walk(&AbsXyz{player.position.X + 200, player.position.Y, player.position.Z}, true)
+	tickTestPlayer(player, 1)
+	checkFood(t, player, MaxFoodUnits-1)
+}
+
+func TestPlayerRegenerates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.health = MaxHealth - 2
+	player.food = regenFood
+
+	tickTestPlayer(player, foodTickTicks)
+	checkHealth(t, player, MaxHealth-1)
+	if player.exhaustion < regenExhaustion {
+		t.Errorf("Expected regeneration to exhaust the player, exhaustion is %f", player.exhaustion)
+	}
+
+	player.food = regenFood - 1
+	tickTestPlayer(player, foodTickTicks)
+	checkHealth(t, player, MaxHealth-1)
+}
+
+func TestPlayerStarves(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.food = 0
+	player.health = 3
+
+	tickTestPlayer(player, foodTickTicks)
+	checkHealth(t, player, 2)
+
+	tickTestPlayer(player, 10*foodTickTicks)
+	checkHealth(t, player, starveMinHealth)
+}
+
+func TestPlayerNbtKeepsFood(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.food = 7
+	player.saturation = 2.5
+	player.exhaustion = 1.5
+	player.foodTimer = 30
+
+	tag := nbt.NewCompound()
+	if err := player.MarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := newTestPlayer(mockCtrl)
+	if err := loaded.UnmarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	checkFood(t, loaded, 7)
+	if loaded.saturation != 2.5 || loaded.exhaustion != 1.5 || loaded.foodTimer != 30 {
+		t.Errorf("Expected saturation 2.5, exhaustion 1.5 and timer 30, got %f, %f and %d",
+			loaded.saturation, loaded.exhaustion, loaded.foodTimer)
+	}
+}
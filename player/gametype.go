@@ -0,0 +1,47 @@
+package player
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Reason sent in a state packet to change the client's game mode.
+const stateReasonChangeGameType = 3
+
+func (player *Player) isCreative() bool {
+	return player.gameType == GameTypeCreative
+}
+
+// setGameType changes the player's game mode, and tells their client. It must
+// be called with player.lock held.
+func (player *Player) setGameType(gameType GameType) {
+	if gameType != GameTypeSurvival && gameType != GameTypeCreative {
+		log.Printf("%v: ignoring unknown game type %d", player, gameType)
+		return
+	}
+
+	player.gameType = gameType
+	player.inventory.SetGameType(gameType)
+	player.resetMovement()
+
+	buf := new(bytes.Buffer)
+	proto.WriteState(buf, stateReasonChangeGameType, byte(gameType))
+	player.TransmitPacket(buf.Bytes())
+}
+
+// creativeSetSlot puts an item that a creative player took from the creative
+// inventory into a slot of their inventory. It must be called with
+// player.lock held.
+func (player *Player) creativeSetSlot(slotId SlotId, item *gamerules.Slot) {
+	if !player.inventory.CreativeSetSlot(slotId, *item) {
+		log.Printf("%v: rejected creative inventory item %+v in slot %d", player, *item, slotId)
+		// Tell the client what is really in its inventory.
+		buf := new(bytes.Buffer)
+		player.inventory.WriteWindowItems(buf)
+		player.TransmitPacket(buf.Bytes())
+	}
+}
@@ -0,0 +1,80 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testSlotIdHolding = SlotId(36) // First holding slot in the window.
+
+func TestCreativePlayerIsNotHurt(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.setGameType(GameTypeCreative)
+
+	player.hurt(10)
+	player.exhaust(maxExhaustion)
+	tickTestPlayer(player, 1)
+	checkHealth(t, player, MaxHealth)
+	checkFood(t, player, MaxFoodUnits)
+
+	player.setGameType(GameTypeSurvival)
+	player.hurt(10)
+	checkHealth(t, player, MaxHealth-10)
+}
+
+func TestCreativePlayerSetsSlots(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	// Survival players can't take items from the creative inventory.
+	player.PacketQuickbarSlotUpdate(testSlotIdHolding, 1, 64, 0)
+	if held, _ := player.inventory.HeldItem(); !held.IsEmpty() {
+		t.Fatalf("Expected survival player not to get items, got %v", held)
+	}
+
+	player.setGameType(GameTypeCreative)
+	player.PacketQuickbarSlotUpdate(testSlotIdHolding, 1, 100, 0)
+	if held, _ := player.inventory.HeldItem(); held != (gamerules.Slot{1, 64, 0}) {
+		t.Errorf("Expected creative player to get a stack of stone, got %v", held)
+	}
+
+	// Placing blocks doesn't use them up.
+	var placed gamerules.Slot
+	player.inventory.TakeOneHeldItem(&placed)
+	if held, _ := player.inventory.HeldItem(); placed.Count != 1 || held.Count != 64 {
+		t.Errorf("Expected creative player to keep held stone, got %v", held)
+	}
+
+	player.PacketQuickbarSlotUpdate(testSlotIdHolding, -1, 0, 0)
+	if held, _ := player.inventory.HeldItem(); !held.IsEmpty() {
+		t.Errorf("Expected creative player to clear the slot, got %v", held)
+	}
+}
+
+func TestPlayerNbtKeepsGameType(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.setGameType(GameTypeCreative)
+
+	tag := nbt.NewCompound()
+	if err := player.MarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := newTestPlayer(mockCtrl)
+	if err := loaded.UnmarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.isCreative() {
+		t.Errorf("Expected player to still be in creative mode")
+	}
+}
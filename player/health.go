@@ -0,0 +1,233 @@
+package player
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	// Ticks after being hurt for which a player is only hurt again by greater
+	// damage.
+	hurtCooldownTicks = 10
+
+	maxAir      = 300 // Ticks that a player can hold their breath for.
+	drownDamage = Health(2)
+
+	burnTicks  = 8 * TicksPerSecond // Ticks that a player burns for after leaving fire.
+	burnDamage = Health(1)
+
+	safeFallDistance = 3 // Distance that a player can fall without being hurt.
+
+	voidY      = AbsCoord(-64) // Height below which the void hurts players.
+	voidDamage = Health(4)
+)
+
+// tick runs the player's health for a single server tick, hurting them if
+// they are in dangerous surroundings. It must be called with player.lock held.
+func (player *Player) tick() {
+	if !player.spawnComplete || player.isDead() {
+		return
+	}
+
+	player.foodTick()
+	player.updateAppearance()
+	player.movementTick()
+
+	if player.hurtTime > 0 {
+		player.hurtTime--
+	}
+
+	if player.surroundings.Damage > 0 {
+		player.hurt(player.surroundings.Damage)
+	}
+
+	switch {
+	case player.surroundings.Wet:
+		player.fire = 0
+		player.fallDistance = 0
+	case player.surroundings.OnFire:
+		player.fire = burnTicks
+	}
+	if player.fire > 0 {
+		player.fire--
+		if player.fire%TicksPerSecond == 0 {
+			player.hurt(burnDamage)
+		}
+	}
+
+	if player.surroundings.Drowning {
+		// Each second without air hurts the player.
+		if player.air--; player.air <= -TicksPerSecond {
+			player.air = 0
+			player.hurt(drownDamage)
+		}
+	} else {
+		player.air = maxAir
+	}
+
+	if player.position.Y < voidY {
+		player.hurt(voidDamage)
+	}
+
+	player.portalTick()
+}
+
+// fall keeps track of how far the player has fallen as they move to the
+// height newY, and hurts them when they land. It must be called with
+// player.lock held.
+func (player *Player) fall(newY AbsCoord, onGround bool) {
+	if dy := player.position.Y - newY; dy > 0 {
+		player.fallDistance += float32(dy)
+	}
+
+	if !onGround {
+		player.onGround = 0
+		return
+	}
+
+	player.onGround = 1
+	if player.fallDistance > safeFallDistance {
+		player.hurt(Health(math.Ceil(float64(player.fallDistance - safeFallDistance))))
+	}
+	player.fallDistance = 0
+}
+
+func (player *Player) isDead() bool {
+	return player.health <= 0
+}
+
+// hurt inflicts damage on the player. A player that has just been hurt is
+// only hurt again by greater damage, and then only by the difference. Creative
+// players aren't hurt. It must be called with player.lock held.
+func (player *Player) hurt(amount Health) {
+	if player.isDead() || amount <= 0 || player.isCreative() {
+		return
+	}
+
+	if player.hurtTime > 0 {
+		if amount <= player.lastDamage {
+			return
+		}
+		amount, player.lastDamage = amount-player.lastDamage, amount
+	} else {
+		player.lastDamage = amount
+		player.hurtTime = hurtCooldownTicks
+	}
+
+	player.health -= amount
+	if player.health < 0 {
+		player.health = 0
+	}
+	player.exhaust(hurtExhaustion)
+	player.sendHealth()
+
+	if player.isDead() {
+		player.die()
+	} else {
+		player.multicastStatus(EntityStatusHurt)
+	}
+}
+
+// die drops everything that the player carries where they died. The client
+// shows the death screen until the player chooses to respawn.
+func (player *Player) die() {
+	player.fire = 0
+	player.eating = 0
+	player.fallDistance = 0
+	player.multicastStatus(EntityStatusDead)
+
+	player.closeCurrentWindow(true)
+	items := player.inventory.TakeAllItems()
+	if !player.cursor.IsEmpty() {
+		items = append(items, player.cursor)
+		player.cursor.Clear()
+		buf := new(bytes.Buffer)
+		player.cursor.SendUpdate(buf, WindowIdCursor, SlotIdCursor)
+		player.TransmitPacket(buf.Bytes())
+	}
+
+	chunkLoc := player.position.ToChunkXz()
+	shardClient, ok := player.chunkSubs.ShardClientForChunkXz(&chunkLoc)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		// Scatter the items a little.
+		velocity := AbsVelocity{
+			AbsVelocityCoord(rand.Float64()*0.2 - 0.1),
+			0.2,
+			AbsVelocityCoord(rand.Float64()*0.2 - 0.1),
+		}
+		shardClient.ReqDropItem(item, player.position, velocity, TicksPerSecond)
+	}
+}
+
+// respawn brings a dead player back to life at their bed, or at the world's
+// spawn point if they have not used a bed. It must be called with
+// player.lock held.
+func (player *Player) respawn() {
+	player.health = MaxHealth
+	player.food = MaxFoodUnits
+	player.saturation = initialSaturation
+	player.exhaustion = 0
+	player.foodTimer = 0
+	player.eating = 0
+	player.air = maxAir
+	player.fire = 0
+	player.hurtTime = 0
+	player.fallDistance = 0
+	player.surroundings = gamerules.Surroundings{}
+
+	spawnBlock := player.spawnBlock
+	if player.bed != nil {
+		// TODO Check that the bed is still there.
+		spawnBlock = BlockXyz{player.bed.X, player.bed.Y + 1, player.bed.Z}
+	}
+	player.position = AbsXyz{
+		X: AbsCoord(spawnBlock.X),
+		Y: AbsCoord(spawnBlock.Y),
+		Z: AbsCoord(spawnBlock.Z),
+	}
+	player.height = StanceNormal
+	player.resetMovement()
+
+	if player.dimension != DimensionNormal {
+		// Players always come back to life in the normal world.
+		player.changeDimension(DimensionNormal, &player.position)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	// TODO pass proper map seed.
+	proto.WriteRespawn(buf, player.dimension, GameDifficultyNormal, player.gameType, MaxYCoord+1, 0)
+	player.TransmitPacket(buf.Bytes())
+
+	// notifyChunkLoad sends the player's new position and health once the
+	// chunk they respawn in is loaded.
+	player.spawnComplete = false
+	if !player.chunkSubs.Respawn(&player.position) {
+		player.notifyChunkLoad()
+	}
+}
+
+// multicastStatus shows other players nearby a change in the player's status,
+// such as being hurt.
+func (player *Player) multicastStatus(status EntityStatus) {
+	shardClient, ok := player.chunkSubs.CurrentShardClient()
+	if !ok {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteEntityStatus(buf, player.EntityId, status)
+	shardClient.ReqMulticastPlayers(
+		player.chunkSubs.curChunkLoc,
+		player.EntityId,
+		buf.Bytes(),
+	)
+}
@@ -0,0 +1,226 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/gamerules_mock"
+	"github.com/huin/chunkymonkey/nbt"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func init() {
+	if err := gamerules.LoadGameRules("../blocks.json", "../items.json", "../recipes.json", "../furnace.json", "../users.json", "../groups.json"); err != nil {
+		panic(err)
+	}
+}
+
+// newTestPlayer creates a player that has spawned at Y=64 in chunk 0,0,
+// hosted by a mock shard.
+func newTestPlayer(mockCtrl *gomock.Controller) (*Player, *gamerules_mock.MockIPlayerShardClient) {
+	shard := gamerules_mock.NewMockIPlayerShardClient(mockCtrl)
+	shard.EXPECT().ReqMulticastPlayers(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	shard.EXPECT().ReqSetPlayerAppearance(gomock.Any(), gomock.Any()).AnyTimes()
+
+	player := NewPlayer(1, nil, nil, "test", BlockXyz{8, 64, 8}, GameTypeSurvival, nil, nil)
+	player.txQueue = make(chan []byte, 1024)
+	player.spawnComplete = true
+	player.surroundings.Supported = true
+	player.chunkSubs.player = player
+	player.chunkSubs.curShard = shard
+	shardLoc := ShardXz{0, 0}
+	player.chunkSubs.shardClients = map[uint64]*shardRef{
+		shardLoc.Key(): &shardRef{shard, 1},
+	}
+
+	return player, shard
+}
+
+func checkHealth(t *testing.T, player *Player, expected Health) {
+	if player.health != expected {
+		t.Errorf("Expected health %d, got %d", expected, player.health)
+	}
+}
+
+func tickTestPlayer(player *Player, ticks int) {
+	for i := 0; i < ticks; i++ {
+		player.tick()
+	}
+}
+
+func TestPlayerFallDamage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	player.fall(62, false)
+	player.position.Y = 62
+	player.fall(61, true)
+	player.position.Y = 61
+	checkHealth(t, player, MaxHealth)
+
+	player.fall(58, false)
+	player.position.Y = 58
+	player.fall(55, true)
+	checkHealth(t, player, MaxHealth-3)
+	if player.fallDistance != 0 {
+		t.Errorf("Expected fall distance to be reset on landing, got %f", player.fallDistance)
+	}
+}
+
+func TestPlayerHurtCooldown(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	player.hurt(4)
+	player.hurt(3)
+	checkHealth(t, player, MaxHealth-4)
+
+	player.hurt(6)
+	checkHealth(t, player, MaxHealth-6)
+
+	tickTestPlayer(player, hurtCooldownTicks)
+	player.hurt(3)
+	checkHealth(t, player, MaxHealth-9)
+}
+
+func TestPlayerDrowns(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	player.surroundings = gamerules.Surroundings{Wet: true, Drowning: true, Supported: true}
+	tickTestPlayer(player, maxAir)
+	checkHealth(t, player, MaxHealth)
+
+	tickTestPlayer(player, TicksPerSecond)
+	checkHealth(t, player, MaxHealth-drownDamage)
+
+	player.surroundings = gamerules.Surroundings{Supported: true}
+	tickTestPlayer(player, 1)
+	if player.air != maxAir {
+		t.Errorf("Expected player to get their breath back, has %d air", player.air)
+	}
+}
+
+func TestPlayerBurns(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	// Stop the player regenerating while they burn.
+	player.food = regenFood - 1
+
+	player.surroundings = gamerules.Surroundings{Damage: 1, OnFire: true, Supported: true}
+	tickTestPlayer(player, 1)
+	checkHealth(t, player, MaxHealth-1)
+
+	// The player keeps burning after leaving the fire.
+	player.surroundings = gamerules.Surroundings{Supported: true}
+	tickTestPlayer(player, burnTicks)
+	checkHealth(t, player, MaxHealth-1-burnTicks/TicksPerSecond)
+
+	player.surroundings = gamerules.Surroundings{OnFire: true, Supported: true}
+	tickTestPlayer(player, 1)
+	player.surroundings = gamerules.Surroundings{Wet: true, Supported: true}
+	tickTestPlayer(player, 1)
+	if player.fire != 0 {
+		t.Errorf("Expected water to put out the player, still burning for %d ticks", player.fire)
+	}
+}
+
+func TestPlayerVoidDamage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	player.position.Y = voidY - 1
+	tickTestPlayer(player, 1)
+	checkHealth(t, player, MaxHealth-voidDamage)
+}
+
+func TestPlayerDiesAndRespawns(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: 1, Count: 10})
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: 4, Count: 20})
+	shard.EXPECT().ReqDropItem(gamerules.Slot{1, 10, 0}, player.position, gomock.Any(), gomock.Any())
+	shard.EXPECT().ReqDropItem(gamerules.Slot{4, 20, 0}, player.position, gomock.Any(), gomock.Any())
+
+	player.hurt(MaxHealth)
+	if !player.isDead() {
+		t.Fatalf("Expected player to die")
+	}
+	if held, _ := player.inventory.HeldItem(); !held.IsEmpty() {
+		t.Errorf("Expected dead player to have dropped their items, holding %v", held)
+	}
+
+	player.bed = &BlockXyz{4, 63, 4}
+	shard.EXPECT().ReqRemovePlayerData(ChunkXz{0, 0}, ChunkXz{0, 0}, true)
+	shard.EXPECT().ReqAddPlayerData(ChunkXz{0, 0}, "test", AbsXyz{4, 64, 4}, gomock.Any(), gomock.Any())
+	player.respawn()
+	checkHealth(t, player, MaxHealth)
+	if !player.spawnComplete {
+		t.Errorf("Expected player to respawn in a loaded chunk")
+	}
+}
+
+func TestDeadPlayerIgnoresActions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+
+	shard.EXPECT().ReqDropItem(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	player.hurt(MaxHealth)
+	if !player.isDead() {
+		t.Fatalf("Expected player to die")
+	}
+
+	// The mock shard fails the test if the player digs or interacts.
+	position := player.position
+	player.PacketPlayerPosition(&AbsXyz{9, 64, 8}, 65.62, true)
+	if player.position != position {
+		t.Errorf("Expected dead player to stay at %v, moved to %v", position, player.position)
+	}
+
+	target := BlockXyz{8, 63, 8}
+	player.PacketPlayerBlockHit(DigStarted, &target, FaceTop)
+	player.PacketPlayerBlockInteract(1, &target, FaceTop, 1, 0)
+
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: 1, Count: 10})
+	_, slotId := player.inventory.HeldItem()
+	player.PacketWindowClick(WindowIdInventory, slotId, false, 1, false, &proto.WindowSlot{})
+	if !player.cursor.IsEmpty() {
+		t.Errorf("Expected dead player not to pick up items, cursor holds %v", player.cursor)
+	}
+	if held, _ := player.inventory.HeldItem(); held.Count != 10 {
+		t.Errorf("Expected held item to be untouched, got %v", held)
+	}
+}
+
+func TestPlayerNbtKeepsBed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+	player.bed = &BlockXyz{-10, 70, 300}
+	player.hurt(5)
+
+	tag := nbt.NewCompound()
+	if err := player.MarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := newTestPlayer(mockCtrl)
+	if err := loaded.UnmarshalNbt(tag); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.bed == nil || *loaded.bed != *player.bed {
+		t.Errorf("Expected bed %v, got %v", player.bed, loaded.bed)
+	}
+	checkHealth(t, loaded, MaxHealth-5)
+}
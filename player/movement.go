@@ -0,0 +1,199 @@
+package player
+
+import (
+	"bytes"
+	"expvar"
+	"flag"
+	"log"
+	"math"
+
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// moveViolation is a way in which a player has moved that they shouldn't be
+// able to.
+type moveViolation byte
+
+const (
+	moveViolationSpeed = moveViolation(iota)
+	moveViolationFlight
+	moveViolationNoclip
+	moveViolationStance
+	numMoveViolations
+)
+
+var moveViolationNames = [numMoveViolations]string{
+	"speed",
+	"flight",
+	"noclip",
+	"stance",
+}
+
+func (v moveViolation) String() string {
+	return moveViolationNames[v]
+}
+
+const (
+	// Distance that a player may move each tick, and in a burst of moves such
+	// as after lag. Moving down isn't counted, as players fall faster.
+	moveSpeedPerTick         = AbsCoord(0.5)
+	creativeMoveSpeedPerTick = AbsCoord(1) // Creative players fly faster.
+	maxMoveAllowance         = AbsCoord(10)
+
+	// Ticks that a player may stay up without anything supporting them, which
+	// is longer than a jump takes. A player that falls by less than
+	// minFallPerTick isn't falling.
+	maxFlightTicks = 40
+	minFallPerTick = AbsCoord(0.05)
+
+	// Range of the height of a player's eyes above their feet.
+	minStanceHeight = AbsCoord(0.1)
+	maxStanceHeight = AbsCoord(1.65)
+
+	// Distance from the position that a player was put back at within which
+	// their client is taken to have moved there.
+	snapEchoDistance = AbsCoord(0.1)
+
+	moveViolationDecayTicks = 600 // Ticks until a violation is forgiven.
+)
+
+var (
+	expVarMoveViolationCount [numMoveViolations]*expvar.Int
+	expVarMoveKickCount      *expvar.Int
+
+	playerMoveKickViolations = flag.Int(
+		"player_move_kick_violations", 20,
+		"Number of recent movement violations after which a player is kicked. "+
+			"0 never kicks players.")
+)
+
+func init() {
+	for v := range expVarMoveViolationCount {
+		expVarMoveViolationCount[v] = expvar.NewInt(
+			"player-move-" + moveViolation(v).String() + "-violation-count")
+	}
+	expVarMoveKickCount = expvar.NewInt("player-move-kick-count")
+}
+
+// validateMove checks that the player can move to newPos with the given
+// stance. It returns ok=false with the violation if not. It must be called
+// with player.lock held.
+func (player *Player) validateMove(newPos *AbsXyz, stance AbsCoord) (violation moveViolation, ok bool) {
+	if height := stance - newPos.Y; height < minStanceHeight || height > maxStanceHeight {
+		return moveViolationStance, false
+	}
+
+	dx := float64(newPos.X - player.position.X)
+	dy := math.Max(float64(newPos.Y-player.position.Y), 0)
+	dz := float64(newPos.Z - player.position.Z)
+	distance := AbsCoord(math.Sqrt(dx*dx + dy*dy + dz*dz))
+	if distance > player.moveAllowance {
+		return moveViolationSpeed, false
+	}
+	player.moveAllowance -= distance
+
+	return 0, true
+}
+
+// movementTick keeps track of how the player moves over time, and catches
+// them flying. Creative players may fly. It must be called with player.lock
+// held.
+func (player *Player) movementTick() {
+	speed := moveSpeedPerTick
+	if player.isCreative() {
+		speed = creativeMoveSpeedPerTick
+	}
+	if player.moveAllowance += speed; player.moveAllowance > maxMoveAllowance {
+		player.moveAllowance = maxMoveAllowance
+	}
+
+	if player.moveViolations > 0 {
+		if player.violationTimer++; player.violationTimer >= moveViolationDecayTicks {
+			player.violationTimer = 0
+			player.moveViolations--
+		}
+	}
+
+	fell := player.flightY-player.position.Y >= minFallPerTick
+	player.flightY = player.position.Y
+	switch {
+	case player.surroundings.Supported || player.isCreative():
+		player.flightTicks = 0
+		player.lastSupported = player.position
+	case !fell:
+		if player.flightTicks++; player.flightTicks > maxFlightTicks {
+			player.rejectMoveFor(moveViolationFlight, &player.lastSupported)
+		}
+	}
+}
+
+// rejectMove puts the player back at position after they moved through solid
+// blocks. It must be called with player.lock held.
+func (player *Player) rejectMove(position *AbsXyz) {
+	if player.snapping {
+		// Moves that arrive before the client is put back are also rejected.
+		return
+	}
+	player.rejectMoveFor(moveViolationNoclip, position)
+}
+
+// rejectMoveFor puts the player back at position after a move that broke the
+// rules, and kicks them if they keep doing so. It must be called with
+// player.lock held.
+func (player *Player) rejectMoveFor(violation moveViolation, position *AbsXyz) {
+	log.Printf("%v: rejected move for %v violation", player, violation)
+	expVarMoveViolationCount[violation].Add(1)
+
+	player.moveViolations++
+	if *playerMoveKickViolations > 0 && player.moveViolations >= *playerMoveKickViolations {
+		expVarMoveKickCount.Add(1)
+		player.kick("Moved in ways that aren't allowed")
+		return
+	}
+
+	player.snapBack(position)
+}
+
+// snapBack moves the player to position, and ignores their moves until their
+// client has moved there too. It must be called with player.lock held.
+func (player *Player) snapBack(position *AbsXyz) {
+	player.position = *position
+	player.fallDistance = 0
+	player.resetMovement()
+	player.snapping = true
+	player.snapTo = *position
+
+	if player.chunkSubs.Move(&player.position) {
+		// The chunk isn't loaded. notifyChunkLoad sends the position when it is.
+		player.spawnComplete = false
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	proto.ServerWritePlayerPositionLook(
+		buf,
+		&player.position, player.position.Y+player.height,
+		&player.look, false)
+	player.TransmitPacket(buf.Bytes())
+}
+
+// resetMovement forgets how the player has been moving, after they have been
+// moved somewhere else by the server. It must be called with player.lock held.
+func (player *Player) resetMovement() {
+	player.snapping = false
+	player.moveAllowance = maxMoveAllowance
+	player.flightY = player.position.Y
+	player.flightTicks = 0
+	player.lastSupported = player.position
+}
+
+// kick disconnects the player, telling them why.
+func (player *Player) kick(reason string) {
+	log.Printf("%v: kicked: %s", player, reason)
+
+	buf := new(bytes.Buffer)
+	proto.WriteDisconnect(buf, reason)
+	player.TransmitPacket(buf.Bytes())
+	player.Stop()
+}
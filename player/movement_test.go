@@ -0,0 +1,139 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func checkPosition(t *testing.T, player *Player, expected AbsXyz) {
+	if player.position != expected {
+		t.Errorf("Expected player at %+v, got %+v", expected, player.position)
+	}
+}
+
+// checkSnappedBack checks that the player's client has been told to move
+// back.
+func checkSnappedBack(t *testing.T, player *Player) {
+	for len(player.txQueue) > 0 {
+		if packet := <-player.txQueue; packet[0] == proto.PacketIdPlayerPositionLook {
+			return
+		}
+	}
+	t.Errorf("Expected player to be put back")
+}
+
+func TestPlayerMovesTooFast(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	shard.EXPECT().ReqSetPlayerPosition(ChunkXz{0, 0}, gomock.Any()).AnyTimes()
+
+	// Falling doesn't count.
+	player.PacketPlayerPosition(&AbsXyz{8, 40, 8}, 41.62, false)
+	checkPosition(t, player, AbsXyz{8, 40, 8})
+
+	// A burst of movement is allowed, but not more.
+	player.PacketPlayerPosition(&AbsXyz{14, 40, 8}, 41.62, false)
+	checkPosition(t, player, AbsXyz{14, 40, 8})
+	player.PacketPlayerPosition(&AbsXyz{14, 40, 14}, 41.62, false)
+	checkPosition(t, player, AbsXyz{14, 40, 8})
+	checkSnappedBack(t, player)
+
+	// Moves are ignored until the client has been put back.
+	tickTestPlayer(player, 10)
+	player.PacketPlayerPosition(&AbsXyz{14, 40, 10}, 41.62, false)
+	checkPosition(t, player, AbsXyz{14, 40, 8})
+	player.PacketPlayerPosition(&AbsXyz{14, 40, 8}, 41.62, false)
+	player.PacketPlayerPosition(&AbsXyz{14, 40, 10}, 41.62, false)
+	checkPosition(t, player, AbsXyz{14, 40, 10})
+
+	if player.moveViolations != 1 {
+		t.Errorf("Expected 1 violation, got %d", player.moveViolations)
+	}
+}
+
+func TestPlayerBadStance(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	shard.EXPECT().ReqSetPlayerPosition(ChunkXz{0, 0}, gomock.Any()).AnyTimes()
+
+	player.PacketPlayerPosition(&AbsXyz{8, 64, 9}, 66, false)
+	checkPosition(t, player, AbsXyz{8, 64, 8})
+	checkSnappedBack(t, player)
+}
+
+func TestPlayerFlies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	shard.EXPECT().ReqSetPlayerPosition(ChunkXz{0, 0}, gomock.Any()).AnyTimes()
+
+	player.surroundings.Supported = false
+	player.PacketPlayerPosition(&AbsXyz{8, 66, 8}, 67.62, false)
+	tickTestPlayer(player, maxFlightTicks)
+	checkPosition(t, player, AbsXyz{8, 66, 8})
+
+	tickTestPlayer(player, 1)
+	checkPosition(t, player, AbsXyz{8, 64, 8})
+	checkSnappedBack(t, player)
+
+	// Falling isn't flying.
+	player.PacketPlayerPosition(&AbsXyz{8, 64, 8}, 65.62, false)
+	for y := AbsCoord(64); y > 64-maxFlightTicks; y-- {
+		player.PacketPlayerPosition(&AbsXyz{8, y, 8}, y+StanceNormal, false)
+		tickTestPlayer(player, 1)
+	}
+	if player.moveViolations != 1 {
+		t.Errorf("Expected 1 violation, got %d", player.moveViolations)
+	}
+}
+
+func TestPlayerMovesThroughBlocks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	shard.EXPECT().ReqSetPlayerPosition(ChunkXz{0, 0}, gomock.Any()).AnyTimes()
+
+	player.PacketPlayerPosition(&AbsXyz{8, 64, 10}, 65.62, false)
+	player.PacketPlayerPosition(&AbsXyz{8, 64, 11}, 65.62, false)
+
+	// The shard rejects both moves.
+	player.rejectMove(&AbsXyz{8, 64, 8})
+	player.rejectMove(&AbsXyz{8, 64, 8})
+	checkPosition(t, player, AbsXyz{8, 64, 8})
+	checkSnappedBack(t, player)
+	if player.moveViolations != 1 {
+		t.Errorf("Expected 1 violation, got %d", player.moveViolations)
+	}
+}
+
+func TestPlayerKickedForViolations(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+	shard.EXPECT().ReqSetPlayerPosition(ChunkXz{0, 0}, gomock.Any()).AnyTimes()
+	player.stopPlayer = make(chan bool, 1)
+
+	player.moveViolations = *playerMoveKickViolations - 2
+	player.rejectMoveFor(moveViolationSpeed, &player.position)
+	if len(player.stopPlayer) != 0 {
+		t.Fatalf("Expected player not to be kicked yet")
+	}
+
+	// Violations are forgiven over time.
+	tickTestPlayer(player, moveViolationDecayTicks)
+	player.rejectMoveFor(moveViolationSpeed, &player.position)
+	if len(player.stopPlayer) != 0 {
+		t.Fatalf("Expected player not to be kicked after being forgiven")
+	}
+
+	player.rejectMoveFor(moveViolationSpeed, &player.position)
+	if len(player.stopPlayer) != 1 {
+		t.Errorf("Expected player to be kicked")
+	}
+}
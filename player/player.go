@@ -51,12 +51,13 @@ type Player struct {
 	// First attributes are for housekeeping etc.
 
 	EntityId
-	playerClient   playerClient
-	shardConnecter gamerules.IShardConnecter
-	conn           net.Conn
-	name           string
-	loginComplete  bool
-	spawnComplete  bool
+	playerClient    playerClient
+	shardConnecter  gamerules.IShardConnecter // Hosts the player's dimension.
+	shardConnecters map[DimensionId]gamerules.IShardConnecter
+	conn            net.Conn
+	name            string
+	loginComplete   bool
+	spawnComplete   bool
 
 	game gamerules.IGame
 
@@ -91,19 +92,49 @@ type Player struct {
 	chunkSubs  chunkSubscriptions
 	health     Health
 	food       FoodUnits
+	gameType   GameType
+	dimension  DimensionId
 
-	// The following data fields are loaded, but not used yet
-	dimension    int32
+	// Ticks that the player has stood in a portal for, see portal.go.
+	portalTicks int16
+
+	// Health related data, see health.go.
 	onGround     int8
-	sleeping     int8
 	fallDistance float32
-	sleepTimer   int16
-	attackTime   int16
-	deathTime    int16
-	hurtTime     int16
-	motion       AbsVelocity
-	air          int16
-	fire         int16
+	hurtTime     int16  // Ticks until the player can be hurt again.
+	lastDamage   Health // Damage done when the player was last hurt.
+	air          int16  // Ticks of breath left.
+	fire         int16  // Ticks left for the player to burn for.
+	surroundings gamerules.Surroundings
+	bed          *BlockXyz // Bed that the player respawns at, if any.
+
+	// How other players see the player, see appearance.go.
+	crouching      bool
+	sentAppearance gamerules.PlayerAppearance
+
+	// Movement validation data, see movement.go.
+	moveAllowance  AbsCoord // Distance that the player may move without a break.
+	flightY        AbsCoord // Height of the player at the last tick.
+	flightTicks    int16    // Ticks that the player has stayed up unsupported.
+	lastSupported  AbsXyz   // Last position at which the player was supported.
+	snapping       bool     // Is the player being put back at snapTo?
+	snapTo         AbsXyz
+	moveViolations int   // Recent violations, which eventually get the player kicked.
+	violationTimer int16 // Ticks until the oldest violation is forgiven.
+
+	// Food related data, see food.go.
+	saturation float32    // Food that is used up before the player gets hungry.
+	exhaustion float32    // Activity that will eventually use up food.
+	foodTimer  int16      // Ticks until the player next regenerates or starves.
+	eating     int16      // Ticks until the player finishes eating.
+	eatingItem ItemTypeId // Item that the player is eating.
+
+	// The following data fields are loaded, but not used yet
+	sleeping   int8
+	sleepTimer int16
+	attackTime int16
+	deathTime  int16
+	motion     AbsVelocity
 
 	cursor       gamerules.Slot // Item being moved by mouse cursor.
 	inventory    window.PlayerInventory
@@ -112,13 +143,16 @@ type Player struct {
 	remoteInv    *RemoteInventory
 }
 
-func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn net.Conn, name string, spawnBlock BlockXyz, onDisconnect chan<- EntityId, game gamerules.IGame) *Player {
+// NewPlayer creates a player who starts in the normal world. shardConnecters
+// host each of the dimensions that the player can go to.
+func NewPlayer(entityId EntityId, shardConnecters map[DimensionId]gamerules.IShardConnecter, conn net.Conn, name string, spawnBlock BlockXyz, gameType GameType, onDisconnect chan<- EntityId, game gamerules.IGame) *Player {
 	player := &Player{
-		EntityId:       entityId,
-		shardConnecter: shardConnecter,
-		conn:           conn,
-		name:           name,
-		spawnBlock:     spawnBlock,
+		EntityId:        entityId,
+		shardConnecter:  shardConnecters[DimensionNormal],
+		shardConnecters: shardConnecters,
+		conn:            conn,
+		name:            name,
+		spawnBlock:      spawnBlock,
 		position: AbsXyz{
 			X: AbsCoord(spawnBlock.X),
 			Y: AbsCoord(spawnBlock.Y),
@@ -127,8 +161,11 @@ func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn
 		height: StanceNormal,
 		look:   LookDegrees{0, 0},
 
-		health: MaxHealth,
-		food:   MaxFoodUnits, // TODO: Check what initial level should be.
+		health:     MaxHealth,
+		food:       MaxFoodUnits,
+		saturation: initialSaturation,
+		air:        maxAir,
+		gameType:   gameType,
 
 		curWindow:    nil,
 		nextWindowId: WindowIdFreeMin,
@@ -146,6 +183,8 @@ func NewPlayer(entityId EntityId, shardConnecter gamerules.IShardConnecter, conn
 
 	player.playerClient.Init(player)
 	player.inventory.Init(player.EntityId, player)
+	player.inventory.SetGameType(gameType)
+	player.resetMovement()
 
 	return player
 }
@@ -180,6 +219,7 @@ func (player *Player) UnmarshalNbt(tag *nbt.Compound) (err error) {
 	if player.position, err = nbtutil.ReadAbsXyz(tag, "Pos"); err != nil {
 		return
 	}
+	player.resetMovement()
 
 	if player.look, err = nbtutil.ReadLookDegrees(tag, "Rotation"); err != nil {
 		return
@@ -199,7 +239,8 @@ func (player *Player) UnmarshalNbt(tag *nbt.Compound) (err error) {
 		return
 	}
 
-	if player.dimension, err = nbtutil.ReadInt(tag, "Dimension"); err != nil {
+	var dimension int32
+	if dimension, err = nbtutil.ReadInt(tag, "Dimension"); err != nil {
 		return
 	}
 
@@ -239,6 +280,62 @@ func (player *Player) UnmarshalNbt(tag *nbt.Compound) (err error) {
 		return
 	}
 
+	// The player's bed is only present if they have used one.
+	if tag.Lookup("SpawnX") != nil {
+		var x, y, z int32
+		if x, err = nbtutil.ReadInt(tag, "SpawnX"); err != nil {
+			return
+		}
+		if y, err = nbtutil.ReadInt(tag, "SpawnY"); err != nil {
+			return
+		}
+		if z, err = nbtutil.ReadInt(tag, "SpawnZ"); err != nil {
+			return
+		}
+		player.bed = &BlockXyz{BlockCoord(x), BlockYCoord(y), BlockCoord(z)}
+	}
+
+	// Food is missing from players saved by older versions.
+	if tag.Lookup("foodLevel") != nil {
+		var food int32
+		if food, err = nbtutil.ReadInt(tag, "foodLevel"); err != nil {
+			return
+		}
+		player.food = FoodUnits(food)
+
+		if player.saturation, err = nbtutil.ReadFloat(tag, "foodSaturationLevel"); err != nil {
+			return
+		}
+
+		if player.exhaustion, err = nbtutil.ReadFloat(tag, "foodExhaustionLevel"); err != nil {
+			return
+		}
+
+		var foodTimer int32
+		if foodTimer, err = nbtutil.ReadInt(tag, "foodTickTimer"); err != nil {
+			return
+		}
+		player.foodTimer = int16(foodTimer)
+	}
+
+	// Players keep the world's game type until they are given their own.
+	if tag.Lookup("playerGameType") != nil {
+		var gameType int32
+		if gameType, err = nbtutil.ReadInt(tag, "playerGameType"); err != nil {
+			return
+		}
+		player.gameType = GameType(gameType)
+		player.inventory.SetGameType(player.gameType)
+	}
+
+	if shardConnecter, ok := player.shardConnecters[DimensionId(dimension)]; ok {
+		player.dimension = DimensionId(dimension)
+		player.shardConnecter = shardConnecter
+	} else {
+		log.Printf("%v: dimension %d is not hosted, moving player to spawn", player, dimension)
+		player.position = *player.spawnBlock.ToAbsXyz()
+	}
+
 	return nil
 }
 
@@ -250,7 +347,7 @@ func (player *Player) MarshalNbt(tag *nbt.Compound) (err error) {
 	}
 
 	tag.Set("OnGround", &nbt.Byte{player.onGround})
-	tag.Set("Dimension", &nbt.Int{player.dimension})
+	tag.Set("Dimension", &nbt.Int{int32(player.dimension)})
 	tag.Set("Sleeping", &nbt.Byte{player.sleeping})
 	tag.Set("FallDistance", &nbt.Float{player.fallDistance})
 	tag.Set("SleepTimer", &nbt.Short{player.sleepTimer})
@@ -274,27 +371,35 @@ func (player *Player) MarshalNbt(tag *nbt.Compound) (err error) {
 	}})
 	tag.Set("Fire", &nbt.Short{player.fire})
 	tag.Set("Health", &nbt.Short{int16(player.health)})
+	if player.bed != nil {
+		tag.Set("SpawnX", &nbt.Int{int32(player.bed.X)})
+		tag.Set("SpawnY", &nbt.Int{int32(player.bed.Y)})
+		tag.Set("SpawnZ", &nbt.Int{int32(player.bed.Z)})
+	}
+	tag.Set("foodLevel", &nbt.Int{int32(player.food)})
+	tag.Set("foodSaturationLevel", &nbt.Float{player.saturation})
+	tag.Set("foodExhaustionLevel", &nbt.Float{player.exhaustion})
+	tag.Set("foodTickTimer", &nbt.Int{int32(player.foodTimer)})
+	tag.Set("playerGameType", &nbt.Int{int32(player.gameType)})
 
 	return nil
 }
 
-func (player *Player) getHeldItemTypeId() ItemTypeId {
-	heldSlot, _ := player.inventory.HeldItem()
-	heldItemId := heldSlot.ItemTypeId
-	if heldItemId < 0 {
-		return 0
-	}
-	return heldItemId
+// SnapshotNbt is like MarshalNbt, but can be called while the player is
+// running.
+func (player *Player) SnapshotNbt(tag *nbt.Compound) (err error) {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	return player.MarshalNbt(tag)
 }
 
 func (player *Player) Run() {
 	buf := &bytes.Buffer{}
-	// TODO pass proper dimension. This is low priority, because we don't yet
-	// support multiple dimensions.
 	// TODO pass proper map seed.
 	// TODO pass proper values for the difficulty.
 	// TODO proper max number of players.
-	proto.ServerWriteLogin(buf, player.EntityId, 0, 0, DimensionNormal, GameDifficultyNormal, MaxYCoord+1, 8)
+	proto.ServerWriteLogin(buf, player.EntityId, 0, int32(player.gameType), player.dimension, GameDifficultyNormal, MaxYCoord+1, 8)
 	proto.WriteSpawnPosition(buf, &player.spawnBlock)
 	player.TransmitPacket(buf.Bytes())
 
@@ -342,41 +447,86 @@ func (player *Player) PacketChatMessage(message string) {
 }
 
 func (player *Player) PacketEntityAction(entityId EntityId, action EntityAction) {
+	if entityId != player.EntityId {
+		return
+	}
+
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	switch action {
+	case EntityActionCrouch:
+		player.crouching = true
+	case EntityActionUncrouch:
+		player.crouching = false
+	default:
+		return
+	}
+	player.updateAppearance()
 }
 
 func (player *Player) PacketUseEntity(user EntityId, target EntityId, leftClick bool) {
+	// TODO Right clicks interact with entities, such as saddling pigs.
+	if !leftClick || user != player.EntityId {
+		return
+	}
+
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	player.attack(target)
 }
 
 func (player *Player) PacketRespawn(dimension DimensionId, unknown int8, gameType GameType, worldHeight int16, mapSeed RandomSeed) {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	if player.isDead() {
+		player.respawn()
+	}
 }
 
 func (player *Player) PacketPlayer(onGround bool) {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	if player.spawnComplete {
+		player.fall(player.position.Y, onGround)
+	}
 }
 
 func (player *Player) PacketPlayerPosition(position *AbsXyz, stance AbsCoord, onGround bool) {
 	player.lock.Lock()
 	defer player.lock.Unlock()
 
+	if player.isDead() {
+		// Dead players can't act until they respawn.
+		return
+	}
+
 	if !player.spawnComplete {
 		// Ignore position packets from player until spawned at initial position
 		// with chunk loaded.
 		return
 	}
 
-	if !player.position.IsWithinDistanceOf(position, 10) {
-		log.Printf("Discarding player position that is too far removed (%.2f, %.2f, %.2f)",
-			position.X, position.Y, position.Z)
+	if player.snapping {
+		if !position.IsWithinDistanceOf(&player.snapTo, snapEchoDistance) {
+			// The client hasn't been put back yet.
+			return
+		}
+		player.snapping = false
+	}
+
+	if violation, ok := player.validateMove(position, stance); !ok {
+		player.rejectMoveFor(violation, &player.position)
 		return
 	}
+	player.walk(position, onGround)
+	player.fall(position.Y, onGround)
 	player.position = *position
 	player.height = stance - position.Y
 	player.chunkSubs.Move(position)
-
-	// TODO: Should keep track of when players enter/leave their mutual radius
-	// of "awareness". I.e a client should receive a RemoveEntity packet when
-	// the player walks out of range, and no longer receive WriteEntityTeleport
-	// packets for them. The converse should happen when players come in range
-	// of each other.
 }
 
 func (player *Player) PacketPlayerLook(look *LookDegrees, onGround bool) {
@@ -386,6 +536,10 @@ func (player *Player) PacketPlayerLook(look *LookDegrees, onGround bool) {
 	// TODO input validation
 	player.look = *look
 
+	if player.spawnComplete {
+		player.fall(player.position.Y, onGround)
+	}
+
 	// Update playerData on current chunk.
 	if shard, ok := player.chunkSubs.CurrentShardClient(); ok {
 		shard.ReqSetPlayerLook(player.chunkSubs.curChunkLoc, *look.ToLookBytes())
@@ -396,6 +550,11 @@ func (player *Player) PacketPlayerBlockHit(status DigStatus, target *BlockXyz, f
 	player.lock.Lock()
 	defer player.lock.Unlock()
 
+	if player.isDead() {
+		// Dead players can't act until they respawn.
+		return
+	}
+
 	// This packet handles 'throwing' an item as well, with status = 4, and
 	// the zero values for target and face, so check for that.
 	if status == DigDropItem && target.IsZero() && face == 0 {
@@ -416,6 +575,11 @@ func (player *Player) PacketPlayerBlockHit(status DigStatus, target *BlockXyz, f
 		return
 	}
 
+	if status == DigReleaseUse {
+		player.stopEating()
+		return
+	}
+
 	// Validate that the player is actually somewhere near the block.
 	targetAbsPos := target.MidPointToAbsXyz()
 	if !targetAbsPos.IsWithinDistanceOf(&player.position, MaxInteractDistance) {
@@ -423,25 +587,35 @@ func (player *Player) PacketPlayerBlockHit(status DigStatus, target *BlockXyz, f
 		return
 	}
 
-	// TODO measure the dig time on the target block and relay to the shard to
-	// stop speed hacking (based on block type and tool used - non-trivial).
-
+	// The shard measures the dig time to stop speed hacking.
 	shardClient, _, ok := player.chunkSubs.ShardClientForBlockXyz(target)
 	if ok {
 		held, _ := player.inventory.HeldItem()
-		shardClient.ReqHitBlock(held, *target, status, face)
+		shardClient.ReqHitBlock(held, player.gameType, *target, status, face)
+	}
+
+	if status == DigBlockBroke {
+		player.exhaust(digExhaustion)
 	}
 }
 
 func (player *Player) PacketPlayerBlockInteract(itemId ItemTypeId, target *BlockXyz, face Face, amount ItemCount, uses ItemData) {
-	if face < FaceMinValid || face > FaceMaxValid {
-		// TODO sometimes FaceNull means something. This case should be covered.
-		log.Printf("Player/PacketPlayerBlockInteract: invalid face %d", face)
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	if player.isDead() {
+		// Dead players can't act until they respawn.
 		return
 	}
 
-	player.lock.Lock()
-	defer player.lock.Unlock()
+	if face == FaceNull {
+		// The player used their held item without pointing at a block.
+		player.useHeldItem()
+		return
+	} else if face < FaceMinValid || face > FaceMaxValid {
+		log.Printf("Player/PacketPlayerBlockInteract: invalid face %d", face)
+		return
+	}
 
 	// Validate that the player is actually somewhere near the block.
 	targetAbsPos := target.MidPointToAbsXyz()
@@ -460,10 +634,32 @@ func (player *Player) PacketPlayerBlockInteract(itemId ItemTypeId, target *Block
 func (player *Player) PacketHoldingChange(slotId SlotId) {
 	player.lock.Lock()
 	defer player.lock.Unlock()
+	player.stopEating()
 	player.inventory.SetHolding(slotId)
+	player.updateAppearance()
 }
 
 func (player *Player) PacketEntityAnimation(entityId EntityId, animation EntityAnimation) {
+	// Other animations are the server's to send.
+	if entityId != player.EntityId || animation != EntityAnimationSwingArm {
+		return
+	}
+
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	shardClient, ok := player.chunkSubs.CurrentShardClient()
+	if !ok {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	proto.WriteEntityAnimation(buf, player.EntityId, animation)
+	shardClient.ReqMulticastPlayers(
+		player.chunkSubs.curChunkLoc,
+		player.EntityId,
+		buf.Bytes(),
+	)
 }
 
 func (player *Player) PacketWindowClose(windowId WindowId) {
@@ -477,6 +673,11 @@ func (player *Player) PacketWindowClick(windowId WindowId, slotId SlotId, rightC
 	player.lock.Lock()
 	defer player.lock.Unlock()
 
+	if player.isDead() {
+		// Dead players can't act until they respawn.
+		return
+	}
+
 	// Note that the expectedSlot parameter is currently ignored. The item(s)
 	// involved are worked out from the server-side data.
 	// TODO use the expectedSlot as a conditions for the click, and base the
@@ -540,6 +741,13 @@ func (player *Player) PacketWindowTransaction(windowId WindowId, txId TxId, acce
 		player.name, windowId, txId, accepted)
 }
 
+func (player *Player) PacketQuickbarSlotUpdate(slotId SlotId, itemId ItemTypeId, count ItemCount, data ItemData) {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	player.creativeSetSlot(slotId, &gamerules.Slot{itemId, count, data})
+}
+
 func (player *Player) PacketSignUpdate(position *BlockXyz, lines [4]string) {
 }
 
@@ -704,6 +912,9 @@ func (player *Player) mainLoop() {
 	// Start the keep-alive/latency pings.
 	player.pingNew()
 
+	ticker := time.NewTicker(NanosecondsInSecond / TicksPerSecond)
+	defer ticker.Stop()
+
 	player.sendChatMessage(fmt.Sprintf("%s has joined", player.name), false)
 
 MAINLOOP:
@@ -721,6 +932,9 @@ MAINLOOP:
 		case _ = <-player.ping.timer.C:
 			player.pingTimeout()
 
+		case _ = <-ticker.C:
+			player.runQueuedCall((*Player).tick)
+
 		case err := <-player.rxErrChan:
 			log.Printf("%v: receive loop failed: %v", player, err)
 			player.Stop()
@@ -746,7 +960,7 @@ func (player *Player) notifyChunkLoad() {
 			&player.position, player.position.Y+player.height,
 			&player.look, false)
 		player.inventory.WriteWindowItems(buf)
-		proto.WriteUpdateHealth(buf, player.health, player.food, 0)
+		proto.WriteUpdateHealth(buf, player.health, player.food, player.saturation)
 
 		player.TransmitPacket(buf.Bytes())
 	}
@@ -844,6 +1058,19 @@ func (player *Player) placeHeldItem(target *BlockXyz, wasHeld *gamerules.Slot) {
 	}
 }
 
+// wearHeldTool wears down the held tool, if it is still the tool that the
+// chunk saw.
+func (player *Player) wearHeldTool(wasHeld *gamerules.Slot, uses ItemData) {
+	curHeld, _ := player.inventory.HeldItem()
+
+	// The tool's data changes as it wears, so only its type is compared.
+	if curHeld.ItemTypeId != wasHeld.ItemTypeId {
+		return
+	}
+
+	player.inventory.WearHeldItem(uses)
+}
+
 // Used to receive items picked up from chunks. It is synchronous so that the
 // passed item can be looked at by the caller afterwards to see if it has been
 // consumed.
@@ -922,8 +1149,12 @@ func (player *Player) setPositionLook(pos AbsXyz, look LookDegrees) {
 	player.position = pos
 	player.look = look
 	player.height = StanceNormal - pos.Y
+	player.fallDistance = 0
+	player.resetMovement()
 
-	if player.chunkSubs.Move(&player.position) {
+	// Moving the player through blocks isn't allowed, so they are spawned
+	// again at the new position.
+	if player.chunkSubs.Respawn(&player.position) {
 		// The destination chunk isn't loaded. Wait for it.
 		player.spawnComplete = false
 	} else {
@@ -21,6 +21,10 @@ func (p *playerClient) GetEntityId() EntityId {
 	return p.player.EntityId
 }
 
+func (p *playerClient) GetName() string {
+	return p.player.Name()
+}
+
 func (p *playerClient) TransmitPacket(packet []byte) {
 	p.player.TransmitPacket(packet)
 }
@@ -31,6 +35,24 @@ func (p *playerClient) NotifyChunkLoad() {
 	})
 }
 
+func (p *playerClient) EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.chunkSubs.EntitySpawn(chunkLoc, entityId, spawnPacket)
+	})
+}
+
+func (p *playerClient) EntityMoved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.chunkSubs.EntityMoved(fromChunkLoc, toChunkLoc, entityId)
+	})
+}
+
+func (p *playerClient) EntityDestroy(chunkLoc ChunkXz, entityId EntityId) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.chunkSubs.EntityDestroy(chunkLoc, entityId)
+	})
+}
+
 func (p *playerClient) InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots []proto.WindowSlot) {
 	p.player.Enqueue(func(_ *Player) {
 		p.player.inventorySubscribed(&block, invTypeId, slots)
@@ -73,6 +95,12 @@ func (p *playerClient) PlaceHeldItem(target BlockXyz, wasHeld gamerules.Slot) {
 	})
 }
 
+func (p *playerClient) WearHeldTool(wasHeld gamerules.Slot, uses ItemData) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.wearHeldTool(&wasHeld, uses)
+	})
+}
+
 func (p *playerClient) OfferItem(fromChunk ChunkXz, entityId EntityId, item gamerules.Slot) {
 	p.player.Enqueue(func(_ *Player) {
 		p.player.offerItem(&fromChunk, entityId, &item)
@@ -91,6 +119,30 @@ func (p *playerClient) GiveItem(item gamerules.Slot) {
 	})
 }
 
+func (p *playerClient) Hurt(amount Health) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.hurt(amount)
+	})
+}
+
+func (p *playerClient) SetSurroundings(surroundings gamerules.Surroundings) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.surroundings = surroundings
+	})
+}
+
+func (p *playerClient) RejectMove(position AbsXyz) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.rejectMove(&position)
+	})
+}
+
+func (p *playerClient) SetBed(bed BlockXyz) {
+	p.player.Enqueue(func(_ *Player) {
+		p.player.bed = &bed
+	})
+}
+
 func (p *playerClient) EchoMessage(msg string) {
 	p.player.Enqueue(func(_ *Player) {
 		buf := new(bytes.Buffer)
@@ -121,3 +173,9 @@ func (p *playerClient) SetPositionLook(pos AbsXyz, look LookDegrees) {
 		player.setPositionLook(pos, look)
 	})
 }
+
+func (p *playerClient) SetGameType(gameType GameType) {
+	p.player.Enqueue(func(player *Player) {
+		player.setGameType(gameType)
+	})
+}
@@ -1,9 +1,11 @@
 package player
 
 import (
+	"bytes"
 	"log"
 
 	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
 	. "github.com/huin/chunkymonkey/types"
 )
 
@@ -27,6 +29,7 @@ type chunkSubscriptions struct {
 	curChunkLoc    ChunkXz                      // Chunk the player is currently in.
 	curShard       gamerules.IPlayerShardClient // Shard the player is hosted on.
 	shardClients   map[uint64]*shardRef         // Connections to shards.
+	entities       visibleEntities              // Entities spawned on the client.
 }
 
 func (sub *chunkSubscriptions) Init(player *Player) {
@@ -37,6 +40,7 @@ func (sub *chunkSubscriptions) Init(player *Player) {
 	sub.curShardLoc = player.position.ToShardXz()
 	sub.curChunkLoc = player.position.ToChunkXz()
 	sub.shardClients = make(map[uint64]*shardRef)
+	sub.entities.Init()
 
 	initialChunkLocs := orderedChunkSquare(sub.curChunkLoc, ChunkRadius)
 	sub.subscribeToChunks(sub.curChunkLoc, initialChunkLocs)
@@ -47,7 +51,7 @@ func (sub *chunkSubscriptions) Init(player *Player) {
 		player.name,
 		player.position,
 		*player.look.ToLookBytes(),
-		player.getHeldItemTypeId(),
+		player.appearance(),
 	)
 }
 
@@ -72,12 +76,41 @@ func (sub *chunkSubscriptions) Move(newLoc *AbsXyz) (notify bool) {
 	return
 }
 
+// Respawn moves the player to newLoc after they have died or been teleported.
+// Other players see the player's body disappear, and the player spawn again at
+// newLoc. Returns true under the same conditions as Move.
+func (sub *chunkSubscriptions) Respawn(newLoc *AbsXyz) (notify bool) {
+	sub.curShard.ReqRemovePlayerData(sub.curChunkLoc, sub.curChunkLoc, true)
+
+	newChunkLoc := newLoc.ToChunkXz()
+	if newChunkLoc.X != sub.curChunkLoc.X || newChunkLoc.Z != sub.curChunkLoc.Z {
+		addChunkLocs := squareDifference(newChunkLoc, sub.curChunkLoc, ChunkRadius)
+		notify = sub.subscribeToChunks(newChunkLoc, addChunkLocs)
+
+		delChunkLocs := squareDifference(sub.curChunkLoc, newChunkLoc, ChunkRadius)
+		sub.unsubscribeFromChunks(delChunkLocs)
+
+		sub.curChunkLoc = newChunkLoc
+		sub.moveToShard(newLoc.ToShardXz())
+	}
+
+	sub.curShard.ReqAddPlayerData(
+		sub.curChunkLoc,
+		sub.player.name,
+		*newLoc,
+		*sub.player.look.ToLookBytes(),
+		sub.player.appearance(),
+	)
+
+	return
+}
+
 // Close closes down all shard connections. Use when the player is
 // disconnected.
 func (sub *chunkSubscriptions) Close() {
 	curShardLoc := sub.curChunkLoc.ToShardXz()
 	if ref, ok := sub.shardClients[curShardLoc.Key()]; ok {
-		ref.shard.ReqRemovePlayerData(sub.curChunkLoc, true)
+		ref.shard.ReqRemovePlayerData(sub.curChunkLoc, sub.curChunkLoc, true)
 	}
 
 	for key, ref := range sub.shardClients {
@@ -86,13 +119,56 @@ func (sub *chunkSubscriptions) Close() {
 	}
 }
 
+// IsSubscribed returns true if the chunk is within the player's subscribed
+// radius.
+func (sub *chunkSubscriptions) IsSubscribed(chunkLoc ChunkXz) bool {
+	return chunkLoc.X >= sub.curChunkLoc.X-ChunkRadius && chunkLoc.X <= sub.curChunkLoc.X+ChunkRadius &&
+		chunkLoc.Z >= sub.curChunkLoc.Z-ChunkRadius && chunkLoc.Z <= sub.curChunkLoc.Z+ChunkRadius
+}
+
+// EntitySpawn transmits the spawn packet for an entity in a subscribed chunk,
+// unless the client already knows about the entity.
+func (sub *chunkSubscriptions) EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte) {
+	if entityId == sub.entityId || !sub.IsSubscribed(chunkLoc) {
+		return
+	}
+
+	if sub.entities.spawn(chunkLoc, entityId) {
+		sub.player.TransmitPacket(spawnPacket)
+	}
+}
+
+// EntityMoved follows an entity between chunks, destroying it on the client
+// if it moves out of the subscribed chunks.
+func (sub *chunkSubscriptions) EntityMoved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId) {
+	if sub.entities.moved(fromChunkLoc, toChunkLoc, entityId, sub.IsSubscribed(toChunkLoc)) {
+		sub.destroyEntity(entityId)
+	}
+}
+
+// EntityDestroy destroys an entity on the client that was removed from a
+// chunk.
+func (sub *chunkSubscriptions) EntityDestroy(chunkLoc ChunkXz, entityId EntityId) {
+	if sub.entities.destroy(chunkLoc, entityId) {
+		sub.destroyEntity(entityId)
+	}
+}
+
+func (sub *chunkSubscriptions) destroyEntity(entityId EntityId) {
+	buf := new(bytes.Buffer)
+	proto.WriteEntityDestroy(buf, entityId)
+	sub.player.TransmitPacket(buf.Bytes())
+}
+
 // CurrentShardClient is a convenience function to get a client shard
 // connection for the player's current shard. ok=false if no such connection
 // exists.
 func (sub *chunkSubscriptions) CurrentShardClient() (conn gamerules.IPlayerShardClient, ok bool) {
 	curShardLoc := sub.curChunkLoc.ToShardXz()
-	shardRef, ok := sub.shardClients[curShardLoc.Key()]
-	return shardRef.shard, ok
+	if shardRef, ok := sub.shardClients[curShardLoc.Key()]; ok {
+		return shardRef.shard, true
+	}
+	return nil, false
 }
 
 // ShardClientForBlockXyz is a convenience function to get the correct shard
@@ -165,6 +241,9 @@ func (sub *chunkSubscriptions) unsubscribeFromChunks(chunkLocs []ChunkXz) {
 		shardKey := shardLoc.Key()
 		if ref, ok := sub.shardClients[shardKey]; ok {
 			ref.shard.ReqUnsubscribeChunk(chunkLoc)
+			for _, entityId := range sub.entities.removeChunk(chunkLoc) {
+				sub.destroyEntity(entityId)
+			}
 			ref.count--
 			if ref.count <= 0 {
 				ref.shard.Disconnect()
@@ -191,13 +270,13 @@ func (sub *chunkSubscriptions) moveToChunk(newChunkLoc ChunkXz, newLoc *AbsXyz)
 			sub.player.name,
 			sub.player.position,
 			*sub.player.look.ToLookBytes(),
-			sub.player.getHeldItemTypeId(),
+			sub.player.appearance(),
 		)
 	}
 
 	curShardLoc := sub.curChunkLoc.ToShardXz()
 	if ref, ok := sub.shardClients[curShardLoc.Key()]; ok {
-		ref.shard.ReqRemovePlayerData(sub.curChunkLoc, false)
+		ref.shard.ReqRemovePlayerData(sub.curChunkLoc, newChunkLoc, false)
 	}
 
 	delChunkLocs := squareDifference(sub.curChunkLoc, newChunkLoc, ChunkRadius)
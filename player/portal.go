@@ -0,0 +1,67 @@
+package player
+
+import (
+	"bytes"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Ticks that a player must stand in a portal for before it takes them to the
+// other dimension.
+const ticksInPortalToTravel = 4 * TicksPerSecond
+
+// portalTick takes the player through a portal that they have stood in for
+// long enough. A portal is made for them to come back through. It must be
+// called with player.lock held.
+func (player *Player) portalTick() {
+	if !player.surroundings.Portal || player.isDead() {
+		player.portalTicks = 0
+		return
+	}
+
+	if player.portalTicks++; player.portalTicks < ticksInPortalToTravel {
+		return
+	}
+
+	dimension := DimensionNether
+	if player.dimension == DimensionNether {
+		dimension = DimensionNormal
+	}
+	if _, ok := player.shardConnecters[dimension]; !ok {
+		return
+	}
+
+	position := gamerules.PortalDestination(&player.position, dimension)
+	player.changeDimension(dimension, &position)
+	if shardClient, ok := player.chunkSubs.CurrentShardClient(); ok {
+		shardClient.ReqMakePortal(position, player.look)
+	}
+}
+
+// changeDimension moves the player to a position in another dimension. It
+// must be called with player.lock held.
+func (player *Player) changeDimension(dimension DimensionId, position *AbsXyz) {
+	player.closeCurrentWindow(true)
+	player.chunkSubs.Close()
+
+	player.dimension = dimension
+	player.shardConnecter = player.shardConnecters[dimension]
+	player.position = *position
+	player.height = StanceNormal
+	player.fallDistance = 0
+	player.surroundings = gamerules.Surroundings{}
+	player.portalTicks = 0
+	player.resetMovement()
+
+	buf := new(bytes.Buffer)
+	// TODO pass proper map seed.
+	proto.WriteRespawn(buf, dimension, GameDifficultyNormal, player.gameType, MaxYCoord+1, 0)
+	player.TransmitPacket(buf.Bytes())
+
+	// notifyChunkLoad sends the player's new position once the chunk they
+	// arrive in is loaded.
+	player.spawnComplete = false
+	player.chunkSubs.Init(player)
+}
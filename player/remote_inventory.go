@@ -62,6 +62,41 @@ func (inv *RemoteInventory) Click(click *gamerules.Click) (txState TxState) {
 	return TxStateDeferred
 }
 
+// ShiftClick asks the shard to move the items in the clicked slot into the
+// target. The shard only knows how much room the target has from a copy of it,
+// and gives the items that it moves to the player.
+func (inv *RemoteInventory) ShiftClick(click *gamerules.Click, target gamerules.ITransferTarget) (txState TxState) {
+	shard, _, ok := inv.chunkSubs.ShardClientForBlockXyz(&inv.blockLoc)
+
+	if ok {
+		click.Space = target.Space()
+		shard.ReqInventoryClick(inv.blockLoc, *click)
+	}
+
+	return TxStateDeferred
+}
+
+// The following methods are to implement gamerules.ITransferTarget.
+
+// PutItem sends the items to the shard, which gives back any that don't fit.
+func (inv *RemoteInventory) PutItem(item *gamerules.Slot) {
+	if item.IsEmpty() {
+		return
+	}
+
+	shard, _, ok := inv.chunkSubs.ShardClientForBlockXyz(&inv.blockLoc)
+
+	if ok {
+		shard.ReqInventoryPutItem(inv.blockLoc, *item)
+		item.Clear()
+	}
+}
+
+// Space returns nil, as the slots are on the shard.
+func (inv *RemoteInventory) Space() []gamerules.Slot {
+	return nil
+}
+
 func (inv *RemoteInventory) SetSubscriber(subscriber gamerules.IInventorySubscriber) {
 	inv.subscriber = subscriber
 }
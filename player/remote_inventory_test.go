@@ -0,0 +1,56 @@
+package player
+
+import (
+	"testing"
+
+	"code.google.com/p/gomock/gomock"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestPlayerShiftClicksInventory(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, _ := newTestPlayer(mockCtrl)
+
+	stone := gamerules.Slot{ItemTypeId: 1, Count: 20}
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: 1, Count: 20})
+
+	// From the first holding slot to the main inventory, and back.
+	player.PacketWindowClick(WindowIdInventory, 36, false, 1, true, &proto.WindowSlot{1, 20, 0})
+	if held, _ := player.inventory.HeldItem(); !held.IsEmpty() {
+		t.Fatalf("Expected stone to be moved out of the held slot, got %v", held)
+	}
+	player.PacketWindowClick(WindowIdInventory, 9, false, 2, true, &proto.WindowSlot{1, 20, 0})
+	if held, _ := player.inventory.HeldItem(); held != stone {
+		t.Errorf("Expected stone to be moved back to the held slot, got %v", held)
+	}
+}
+
+func TestPlayerShiftClicksChest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	player, shard := newTestPlayer(mockCtrl)
+
+	chestLoc := BlockXyz{8, 64, 9}
+	player.inventory.PutItem(&gamerules.Slot{ItemTypeId: 1, Count: 20})
+	player.inventorySubscribed(&chestLoc, InvTypeIdChest, make([]proto.WindowSlot, 27))
+	windowId := player.curWindow.WindowId()
+
+	// Items taken out of the chest must fit in the player's inventory.
+	shard.EXPECT().ReqInventoryClick(chestLoc, gomock.Any()).Do(func(block BlockXyz, click gamerules.Click) {
+		if !click.ShiftClick || click.SlotId != 0 || len(click.Space) != 36 {
+			t.Errorf("Expected shift-click with room in the player's inventory, got %+v", click)
+		}
+	})
+	player.PacketWindowClick(windowId, 0, false, 1, true, &proto.WindowSlot{1, 5, 0})
+
+	// Items put into the chest leave the player's inventory.
+	shard.EXPECT().ReqInventoryPutItem(chestLoc, gamerules.Slot{ItemTypeId: 1, Count: 20})
+	player.PacketWindowClick(windowId, 54, false, 2, true, &proto.WindowSlot{1, 20, 0})
+	if held, _ := player.inventory.HeldItem(); !held.IsEmpty() {
+		t.Errorf("Expected stone to be put in the chest, got %v", held)
+	}
+}
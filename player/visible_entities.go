@@ -0,0 +1,71 @@
+package player
+
+import (
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// visibleEntities tracks the entities (other players, mobs, items, etc.) that
+// the client has been sent spawns for, and the chunk that each was last known
+// to be in. Notifications about an entity can arrive from different shards in
+// any order as it moves between chunks, so notifications from a chunk that the
+// entity is no longer known to be in are ignored.
+type visibleEntities struct {
+	entities map[EntityId]ChunkXz
+}
+
+func (ve *visibleEntities) Init() {
+	ve.entities = make(map[EntityId]ChunkXz)
+}
+
+// spawn records that the entity is in the given chunk. Returns true if the
+// entity was not already visible, and so should be spawned on the client.
+func (ve *visibleEntities) spawn(chunkLoc ChunkXz, entityId EntityId) (isNew bool) {
+	_, visible := ve.entities[entityId]
+	ve.entities[entityId] = chunkLoc
+	return !visible
+}
+
+// chunkOf returns the chunk that the entity was last known to be in.
+func (ve *visibleEntities) chunkOf(entityId EntityId) (chunkLoc ChunkXz, ok bool) {
+	chunkLoc, ok = ve.entities[entityId]
+	return
+}
+
+// moved records that the entity moved between chunks. Returns true if the
+// entity was visible and should now be destroyed on the client because the
+// new chunk is not subscribed to.
+func (ve *visibleEntities) moved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId, toSubscribed bool) (destroy bool) {
+	if chunkLoc, ok := ve.entities[entityId]; !ok || chunkLoc != fromChunkLoc {
+		return false
+	}
+
+	if toSubscribed {
+		ve.entities[entityId] = toChunkLoc
+		return false
+	}
+
+	delete(ve.entities, entityId)
+	return true
+}
+
+// destroy records that the entity was removed from the chunk. Returns true if
+// the entity was visible, and so should be destroyed on the client.
+func (ve *visibleEntities) destroy(chunkLoc ChunkXz, entityId EntityId) bool {
+	if curChunkLoc, ok := ve.entities[entityId]; !ok || curChunkLoc != chunkLoc {
+		return false
+	}
+
+	delete(ve.entities, entityId)
+	return true
+}
+
+// removeChunk forgets all entities in the chunk, and returns their IDs.
+func (ve *visibleEntities) removeChunk(chunkLoc ChunkXz) (entityIds []EntityId) {
+	for entityId, curChunkLoc := range ve.entities {
+		if curChunkLoc == chunkLoc {
+			entityIds = append(entityIds, entityId)
+			delete(ve.entities, entityId)
+		}
+	}
+	return
+}
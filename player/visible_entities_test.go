@@ -0,0 +1,79 @@
+package player
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestVisibleEntitiesSpawnOnce(t *testing.T) {
+	var ve visibleEntities
+	ve.Init()
+
+	if !ve.spawn(ChunkXz{0, 0}, 5) {
+		t.Errorf("Expected first spawn to be new")
+	}
+	if ve.spawn(ChunkXz{0, 1}, 5) {
+		t.Errorf("Expected second spawn to not be new")
+	}
+	if chunkLoc := ve.entities[5]; chunkLoc != (ChunkXz{0, 1}) {
+		t.Errorf("Expected entity in chunk {0, 1}, got %v", chunkLoc)
+	}
+}
+
+func TestVisibleEntitiesMoved(t *testing.T) {
+	var ve visibleEntities
+	ve.Init()
+	ve.spawn(ChunkXz{0, 0}, 5)
+
+	// Stale notification from a chunk the entity is not known to be in.
+	if ve.moved(ChunkXz{3, 3}, ChunkXz{4, 4}, 5, false) {
+		t.Errorf("Expected stale move to be ignored")
+	}
+
+	if ve.moved(ChunkXz{0, 0}, ChunkXz{1, 0}, 5, true) {
+		t.Errorf("Expected move to subscribed chunk to not destroy")
+	}
+
+	if !ve.moved(ChunkXz{1, 0}, ChunkXz{2, 0}, 5, false) {
+		t.Errorf("Expected move to unsubscribed chunk to destroy")
+	}
+	if _, ok := ve.entities[5]; ok {
+		t.Errorf("Expected entity to be forgotten")
+	}
+}
+
+func TestVisibleEntitiesDestroy(t *testing.T) {
+	var ve visibleEntities
+	ve.Init()
+	ve.spawn(ChunkXz{0, 0}, 5)
+
+	// The spawn in the new chunk can arrive before the destroy from the old.
+	ve.spawn(ChunkXz{1, 0}, 5)
+	if ve.destroy(ChunkXz{0, 0}, 5) {
+		t.Errorf("Expected destroy from old chunk to be ignored")
+	}
+
+	if !ve.destroy(ChunkXz{1, 0}, 5) {
+		t.Errorf("Expected destroy from current chunk")
+	}
+	if ve.destroy(ChunkXz{1, 0}, 5) {
+		t.Errorf("Expected repeated destroy to be ignored")
+	}
+}
+
+func TestVisibleEntitiesRemoveChunk(t *testing.T) {
+	var ve visibleEntities
+	ve.Init()
+	ve.spawn(ChunkXz{0, 0}, 5)
+	ve.spawn(ChunkXz{0, 0}, 6)
+	ve.spawn(ChunkXz{1, 0}, 7)
+
+	entityIds := ve.removeChunk(ChunkXz{0, 0})
+	if len(entityIds) != 2 {
+		t.Errorf("Expected 2 entities removed, got %v", entityIds)
+	}
+	if len(ve.entities) != 1 {
+		t.Errorf("Expected 1 entity remaining, got %v", ve.entities)
+	}
+}
@@ -118,6 +118,7 @@ type IPacketHandler interface {
 	PacketPlayerBlockInteract(itemTypeId ItemTypeId, blockLoc *BlockXyz, face Face, amount ItemCount, data ItemData)
 	PacketEntityAnimation(entityId EntityId, animation EntityAnimation)
 	PacketWindowTransaction(windowId WindowId, txId TxId, accepted bool)
+	PacketQuickbarSlotUpdate(slot SlotId, itemId ItemTypeId, count ItemCount, data ItemData)
 	PacketSignUpdate(position *BlockXyz, lines [4]string)
 	PacketDisconnect(reason string)
 }
@@ -180,7 +181,6 @@ type IClientPacketHandler interface {
 	PacketWindowSetSlot(windowId WindowId, slot SlotId, itemTypeId ItemTypeId, amount ItemCount, data ItemData)
 	PacketWindowItems(windowId WindowId, items []WindowSlot)
 	PacketWindowProgressBar(windowId WindowId, prgBarId PrgBarId, value PrgBarValue)
-	PacketQuickbarSlotUpdate(slot SlotId, itemId ItemTypeId, count ItemCount, data ItemData)
 	PacketItemData(itemTypeId ItemTypeId, itemDataId ItemData, data []byte)
 	PacketIncrementStatistic(statisticId StatisticId, delta int8)
 	PacketUserListItem(username string, unknown bool, ping int16)
@@ -2845,30 +2845,31 @@ func readWindowTransaction(reader io.Reader, handler IPacketHandler) (err error)
 }
 
 // PacketIdQuickbarSlotUpdate
+// Creative mode clients send this to set the contents of an inventory slot.
 
 func WriteQuickbarSlotUpdate(writer io.Writer, slot SlotId, itemId ItemTypeId, count ItemCount, data ItemData) (err error) {
 	var packet = struct {
 		PacketId byte
 		Slot     SlotId
 		ItemId   ItemTypeId
-		Count    ItemCount
+		Count    int16
 		Data     ItemData
 	}{
 		PacketIdQuickbarSlotUpdate,
 		slot,
 		itemId,
-		count,
+		int16(count),
 		data,
 	}
 
 	return binary.Write(writer, binary.BigEndian, &packet)
 }
 
-func readQuickbarSlotUpdate(reader io.Reader, handler IClientPacketHandler) (err error) {
+func readQuickbarSlotUpdate(reader io.Reader, handler IPacketHandler) (err error) {
 	var packet struct {
 		Slot   SlotId
 		ItemId ItemTypeId
-		Count  ItemCount
+		Count  int16
 		Data   ItemData
 	}
 
@@ -2876,7 +2877,7 @@ func readQuickbarSlotUpdate(reader io.Reader, handler IClientPacketHandler) (err
 		return
 	}
 
-	handler.PacketQuickbarSlotUpdate(packet.Slot, packet.ItemId, packet.Count, packet.Data)
+	handler.PacketQuickbarSlotUpdate(packet.Slot, packet.ItemId, ItemCount(packet.Count), packet.Data)
 
 	return
 }
@@ -3104,6 +3105,7 @@ var commonReadFns = commonPacketReaderMap{
 	PacketIdPlayerBlockInteract: readPlayerBlockInteract,
 	PacketIdEntityAnimation:     readEntityAnimation,
 	PacketIdWindowTransaction:   readWindowTransaction,
+	PacketIdQuickbarSlotUpdate:  readQuickbarSlotUpdate,
 	PacketIdSignUpdate:          readSignUpdate,
 	PacketIdDisconnect:          readDisconnect,
 }
@@ -3162,7 +3164,6 @@ var clientReadFns = clientPacketReaderMap{
 	PacketIdWindowSetSlot:        readWindowSetSlot,
 	PacketIdWindowItems:          readWindowItems,
 	PacketIdWindowProgressBar:    readWindowProgressBar,
-	PacketIdQuickbarSlotUpdate:   readQuickbarSlotUpdate,
 	PacketIdItemData:             readItemData,
 	PacketIdIncrementStatistic:   readIncrementStatistic,
 }
@@ -0,0 +1,58 @@
+package shardserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testItemIdIronHelmet = ItemTypeId(306)
+
+func TestPlayerAppearance(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	viewer := newTestPlayerClient(1)
+	chunk.reqSubscribeChunk(1, viewer, false)
+
+	var appearance gamerules.PlayerAppearance
+	appearance.Equipment[gamerules.EquipmentSlotHelmet] = gamerules.Slot{ItemTypeId: testItemIdIronHelmet, Count: 1}
+	appearance.Crouching = true
+	chunk.reqAddPlayerData(2, "other", AbsXyz{8, 64, 8}, LookBytes{}, appearance)
+
+	expected := new(bytes.Buffer)
+	proto.WriteEntityEquipment(expected, 2, gamerules.EquipmentSlotHelmet, testItemIdIronHelmet, 0)
+	packet := viewer.waitForPacket(proto.PacketIdNamedEntitySpawn)
+	if packet == nil {
+		t.Fatalf("Expected player to be spawned")
+	}
+	if !bytes.Contains(packet, expected.Bytes()) {
+		t.Errorf("Expected spawned player to wear a helmet, got % x", packet)
+	}
+	if !bytes.Contains(packet, []byte{proto.PacketIdEntityMetadata}) {
+		t.Errorf("Expected spawned player to be crouching, got % x", packet)
+	}
+
+	// Draw a sword and stand up.
+	appearance.Equipment[gamerules.EquipmentSlotHeld] = gamerules.Slot{ItemTypeId: testItemIdIronSword, Count: 1}
+	appearance.Crouching = false
+	chunk.reqSetPlayerAppearance(2, appearance)
+
+	expected.Reset()
+	proto.WriteEntityEquipment(expected, 2, gamerules.EquipmentSlotHeld, testItemIdIronSword, 0)
+	proto.WriteEntityMetadata(expected, 2, []proto.EntityMetadata{{0, 0, byte(0)}})
+	packet = viewer.waitForPacket(proto.PacketIdEntityEquipment)
+	if !bytes.Equal(packet, expected.Bytes()) {
+		t.Errorf("Expected only the changes to be sent, got % x", packet)
+	}
+
+	// Nothing is sent when nothing changes.
+	chunk.reqSetPlayerAppearance(2, appearance)
+	for len(viewer.events) > 0 {
+		if e, ok := (<-viewer.events).(testPacketEvent); ok {
+			t.Errorf("Expected no packets for an unchanged appearance, got % x", e.packet)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package shardserver
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// borderBlock is the type and data of a block in another shard.
+type borderBlock struct {
+	blockId   BlockId
+	blockData byte
+}
+
+type destBorderShard struct {
+	loc    ShardXz
+	blocks []gamerules.BorderBlock
+}
+
+// markBorderBlock marks the block to be sent to the neighbouring shards by
+// transferBorderBlocks if it is at the edge of the shard. It is called when
+// the block changes.
+func (shard *ChunkShard) markBorderBlock(blockLoc *BlockXyz) {
+	for _, offset := range neighbourOffsets {
+		neighbourLoc := blockLoc.AddXyz(offset.dx, offset.dy, offset.dz)
+		if neighbourLoc == nil {
+			continue
+		}
+		if _, _, _, ok := shard.chunkIndexAndRelLoc(*neighbourLoc.ToChunkXz()); !ok {
+			shard.newBorderBlocks[*blockLoc] = true
+			return
+		}
+	}
+}
+
+// transferBorderBlocks sends the blocks marked by markBorderBlock to the
+// shards that they touch.
+func (shard *ChunkShard) transferBorderBlocks() {
+	if len(shard.newBorderBlocks) == 0 {
+		return
+	}
+
+	destShards := make(map[uint64]*destBorderShard)
+
+	for blockLoc := range shard.newBorderBlocks {
+		delete(shard.newBorderBlocks, blockLoc)
+
+		chunk, index, _ := shard.loadedBlock(&blockLoc)
+		if chunk == nil {
+			continue
+		}
+		block := gamerules.BorderBlock{
+			Block:       blockLoc,
+			BlockTypeId: index.BlockId(chunk.blocks),
+			BlockData:   index.BlockData(chunk.blockData),
+		}
+
+		for _, offset := range neighbourOffsets {
+			neighbourLoc := blockLoc.AddXyz(offset.dx, offset.dy, offset.dz)
+			if neighbourLoc == nil {
+				continue
+			}
+
+			shardLoc := neighbourLoc.ToChunkXz().ToShardXz()
+			if shardLoc == shard.loc {
+				continue
+			}
+			shardKey := shardLoc.Key()
+			destShard, ok := destShards[shardKey]
+			if !ok {
+				destShard = &destBorderShard{loc: shardLoc}
+				destShards[shardKey] = destShard
+			}
+			destShard.blocks = append(destShard.blocks, block)
+		}
+	}
+
+	for _, destShard := range destShards {
+		if client := shard.clientForShard(destShard.loc); client != nil {
+			client.ReqSetBorderBlocks(destShard.blocks)
+		}
+	}
+}
+
+// reqSetBorderBlocks records blocks in other shards that touch this shard,
+// and activates the blocks that they touch.
+func (shard *ChunkShard) reqSetBorderBlocks(blocks []gamerules.BorderBlock) {
+	for i := range blocks {
+		block := &blocks[i]
+
+		if _, _, _, ok := shard.chunkIndexAndRelLoc(*block.Block.ToChunkXz()); ok {
+			continue
+		}
+
+		newBlock := borderBlock{block.BlockTypeId, block.BlockData}
+		if oldBlock, ok := shard.borderBlocks[block.Block]; ok && oldBlock == newBlock {
+			continue
+		}
+		shard.borderBlocks[block.Block] = newBlock
+
+		for _, offset := range neighbourOffsets {
+			neighbourLoc := block.Block.AddXyz(offset.dx, offset.dy, offset.dz)
+			if neighbourLoc == nil {
+				continue
+			}
+			if _, _, _, ok := shard.chunkIndexAndRelLoc(*neighbourLoc.ToChunkXz()); ok {
+				shard.addActiveBlock(neighbourLoc)
+			}
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"time"
+	"sort"
 
 	"github.com/huin/chunkymonkey/chunkstore"
 	"github.com/huin/chunkymonkey/gamerules"
@@ -13,6 +13,17 @@ import (
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// Offsets to the six blocks that share a face with a block.
+var neighbourOffsets = [6]struct {
+	dx BlockCoord
+	dy BlockYCoord
+	dz BlockCoord
+}{
+	{-1, 0, 0}, {1, 0, 0},
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+}
+
 // A chunk is slice of the world map.
 type Chunk struct {
 	shard        *ChunkShard
@@ -30,10 +41,19 @@ type Chunk struct {
 	playersData  map[EntityId]*playerData               // Some player data for player(s) in the chunk.
 	onUnsub      map[EntityId][]gamerules.IUnsubscribed // Functions to be called when unsubscribed.
 	storeDirty   bool                                   // Is the chunk store copy of this chunk dirty?
+	idleTicks    Ticks                                  // Number of ticks that the chunk has been idle.
+	digs         map[EntityId]blockDig                  // Blocks that players have started digging.
 
-	activeBlocks    map[BlockIndex]bool // Blocks that need to "tick".
-	newActiveBlocks map[BlockIndex]bool // Blocks added as active for next "tick".
-	tickAll         bool                // Whether or not all blocks should be allowed to "tick" once
+	activeBlocks    map[BlockIndex]bool  // Blocks that need to "tick".
+	newActiveBlocks map[BlockIndex]bool  // Blocks added as active for next "tick".
+	scheduledTicks  map[BlockIndex]Ticks // Shard tick at which blocks have a scheduled "tick".
+	tickAll         bool                 // Whether or not all blocks should be allowed to "tick" once
+}
+
+// blockDig records when a player started digging a block.
+type blockDig struct {
+	block   BlockXyz
+	started Ticks // Shard tick at which the dig started.
 }
 
 func newChunkFromReader(reader chunkstore.IChunkReader, shard *ChunkShard) (chunk *Chunk) {
@@ -47,14 +67,16 @@ func newChunkFromReader(reader chunkstore.IChunkReader, shard *ChunkShard) (chun
 		heightMap:    reader.HeightMap(),
 		entities:     make(map[EntityId]gamerules.INonPlayerEntity),
 		tileEntities: make(map[BlockIndex]gamerules.ITileEntity),
-		rand:         rand.New(rand.NewSource(time.Now().Unix())),
+		rand:         rand.New(rand.NewSource(chunkRandSeed(shard.seed, reader.ChunkLoc(), shard.ticks))),
 		subscribers:  make(map[EntityId]gamerules.IPlayerClient),
 		playersData:  make(map[EntityId]*playerData),
 		onUnsub:      make(map[EntityId][]gamerules.IUnsubscribed),
 		storeDirty:   false,
+		digs:         make(map[EntityId]blockDig),
 
 		activeBlocks:    make(map[BlockIndex]bool),
 		newActiveBlocks: make(map[BlockIndex]bool),
+		scheduledTicks:  make(map[BlockIndex]Ticks),
 		tickAll:         true,
 	}
 
@@ -83,6 +105,14 @@ func newChunkFromReader(reader chunkstore.IChunkReader, shard *ChunkShard) (chun
 	return
 }
 
+// chunkRandSeed returns the seed for the random numbers of the chunk at the
+// location in the world with the given seed. The shard's tick count when the
+// chunk is loaded is mixed in, so that random ticks don't repeat each time the
+// chunk is reloaded.
+func chunkRandSeed(worldSeed int64, chunkLoc ChunkXz, ticks Ticks) int64 {
+	return worldSeed ^ (int64(chunkLoc.X)*341873128712 + int64(chunkLoc.Z)*132897987541 + int64(ticks)*42317861)
+}
+
 func (chunk *Chunk) save(chunkStore chunkstore.IChunkStore) {
 	if chunk.storeDirty {
 		writer := chunkStore.Writer()
@@ -99,13 +129,36 @@ func (chunk *Chunk) save(chunkStore chunkstore.IChunkStore) {
 	}
 }
 
+// isIdle returns true if nothing is currently happening in the chunk that
+// would require it to stay loaded.
+func (chunk *Chunk) isIdle() bool {
+	return (len(chunk.subscribers) == 0 &&
+		len(chunk.playersData) == 0 &&
+		len(chunk.onUnsub) == 0 &&
+		len(chunk.entities) == 0 &&
+		len(chunk.digs) == 0 &&
+		len(chunk.activeBlocks) == 0 &&
+		len(chunk.newActiveBlocks) == 0 &&
+		len(chunk.scheduledTicks) == 0)
+}
+
 func (chunk *Chunk) String() string {
 	return fmt.Sprintf("Chunk[%d,%d]", chunk.loc.X, chunk.loc.Z)
 }
 
 // Sets a block and its data. Returns true if the block was not changed.
 func (chunk *Chunk) setBlock(blockLoc *BlockXyz, subLoc *SubChunkXyz, index BlockIndex, blockType BlockId, blockData byte) {
+	chunk.changeBlock(blockLoc, subLoc, index, blockType, blockData)
 
+	// Tell players that the block changed.
+	packet := new(bytes.Buffer)
+	proto.WriteBlockChange(packet, blockLoc, blockType, blockData)
+	chunk.reqMulticastPlayers(-1, packet.Bytes())
+}
+
+// changeBlock is as setBlock, but leaves telling players about the change to
+// the caller.
+func (chunk *Chunk) changeBlock(blockLoc *BlockXyz, subLoc *SubChunkXyz, index BlockIndex, blockType BlockId, blockData byte) {
 	// Invalidate cached packet.
 	chunk.cachedPacket = nil
 
@@ -117,16 +170,21 @@ func (chunk *Chunk) setBlock(blockLoc *BlockXyz, subLoc *SubChunkXyz, index Bloc
 
 	delete(chunk.tileEntities, index)
 
-	// Tell players that the block changed.
-	packet := new(bytes.Buffer)
-	proto.WriteBlockChange(packet, blockLoc, blockType, blockData)
-	chunk.reqMulticastPlayers(-1, packet.Bytes())
+	chunk.updateBlockLight(blockLoc, subLoc, index)
 
-	return
+	// The block and its neighbours might react to the change.
+	chunk.AddActiveBlockIndex(index)
+	chunk.activateNeighbours(blockLoc)
+
+	// Blocks in other shards might be powered differently.
+	chunk.shard.markRedstoneBorder(blockLoc)
+
+	// Blocks in other shards might react to the change.
+	chunk.shard.markBorderBlock(blockLoc)
 }
 
 func (chunk *Chunk) blockId(index BlockIndex) BlockId {
-	return BlockId(index.BlockData(chunk.blocks))
+	return index.BlockId(chunk.blocks)
 }
 
 func (chunk *Chunk) SetBlockByIndex(blockIndex BlockIndex, blockId BlockId, blockData byte) {
@@ -153,6 +211,10 @@ func (chunk *Chunk) ItemType(itemTypeId ItemTypeId) (itemType *gamerules.ItemTyp
 // Tells the chunk to take posession of the item/mob from another chunk.
 func (chunk *Chunk) transferEntity(s gamerules.INonPlayerEntity) {
 	chunk.entities[s.GetEntityId()] = s
+
+	// Spawn the mob/item for players that could not see it in its old chunk.
+	chunk.multicastEntitySpawn(s)
+
 	chunk.storeDirty = true
 }
 
@@ -164,11 +226,45 @@ func (chunk *Chunk) AddEntity(s gamerules.INonPlayerEntity) {
 	chunk.entities[newEntityId] = s
 
 	// Spawn new item/mob for players.
+	chunk.multicastEntitySpawn(s)
+
+	chunk.storeDirty = true
+}
+
+// multicastEntitySpawn informs all subscribers of the entity in the chunk.
+func (chunk *Chunk) multicastEntitySpawn(s gamerules.INonPlayerEntity) {
+	if len(chunk.subscribers) == 0 {
+		return
+	}
+
 	buf := &bytes.Buffer{}
 	s.SendSpawn(buf)
-	chunk.reqMulticastPlayers(-1, buf.Bytes())
+	packet := buf.Bytes()
 
-	chunk.storeDirty = true
+	entityId := s.GetEntityId()
+	for _, player := range chunk.subscribers {
+		player.EntitySpawn(chunk.loc, entityId, packet)
+	}
+}
+
+// multicastEntityDestroy informs all subscribers (except exclude) that the
+// entity has been removed from the chunk.
+func (chunk *Chunk) multicastEntityDestroy(exclude EntityId, entityId EntityId) {
+	for subscriberId, player := range chunk.subscribers {
+		if subscriberId != exclude {
+			player.EntityDestroy(chunk.loc, entityId)
+		}
+	}
+}
+
+// multicastEntityMoved informs all subscribers (except exclude) that the
+// entity has moved to another chunk.
+func (chunk *Chunk) multicastEntityMoved(exclude EntityId, entityId EntityId, toChunkLoc ChunkXz) {
+	for subscriberId, player := range chunk.subscribers {
+		if subscriberId != exclude {
+			player.EntityMoved(chunk.loc, toChunkLoc, entityId)
+		}
+	}
 }
 
 func (chunk *Chunk) removeEntity(s gamerules.INonPlayerEntity) {
@@ -176,9 +272,7 @@ func (chunk *Chunk) removeEntity(s gamerules.INonPlayerEntity) {
 	chunk.shard.entityMgr.RemoveEntityById(e)
 	delete(chunk.entities, e)
 	// Tell all subscribers that the spawn's entity is destroyed.
-	buf := new(bytes.Buffer)
-	proto.WriteEntityDestroy(buf, e)
-	chunk.reqMulticastPlayers(-1, buf.Bytes())
+	chunk.multicastEntityDestroy(-1, e)
 
 	chunk.storeDirty = true
 }
@@ -258,24 +352,57 @@ func (chunk *Chunk) blockInstanceAndType(blockLoc *BlockXyz) (blockInstance *gam
 	return
 }
 
-func (chunk *Chunk) reqHitBlock(player gamerules.IPlayerClient, held gamerules.Slot, digStatus DigStatus, target *BlockXyz, face Face) {
+func (chunk *Chunk) reqHitBlock(player gamerules.IPlayerClient, held gamerules.Slot, gameType GameType, digStatus DigStatus, target *BlockXyz, face Face) {
 
 	blockInstance, blockType, ok := chunk.blockInstanceAndType(target)
 	if !ok {
 		return
 	}
 
-	if blockType.Destructable && blockType.Aspect.Hit(blockInstance, player, digStatus) {
-		blockType.Aspect.Destroy(blockInstance)
+	// Time the dig, so that blocks can't be dug faster than they should be.
+	var digTicks Ticks
+	entityId := player.GetEntityId()
+	switch digStatus {
+	case DigStarted:
+		chunk.digs[entityId] = blockDig{*target, chunk.shard.ticks}
+	case DigAborted:
+		delete(chunk.digs, entityId)
+		return
+	case DigBlockBroke:
+		if dig, ok := chunk.digs[entityId]; ok && dig.block == *target {
+			digTicks = chunk.shard.ticks - dig.started
+		}
+		delete(chunk.digs, entityId)
+	}
+
+	if blockType.Destructable && blockType.Aspect.Hit(blockInstance, player, gameType, &held, digStatus, digTicks) {
+		// Creative players have all the items that they want already.
+		harvest := gameType != GameTypeCreative && blockType.CanHarvest(&held)
+		blockType.Aspect.Destroy(blockInstance, harvest)
 		chunk.setBlock(target, &blockInstance.SubLoc, blockInstance.Index, BlockIdAir, 0)
+		if blockType.Hardness > 0 && gameType != GameTypeCreative {
+			chunk.wearHeldTool(player, &held)
+		}
+	} else if digStatus == DigBlockBroke {
+		// The player's client thinks that the block is gone.
+		buf := new(bytes.Buffer)
+		proto.WriteBlockChange(buf, target, blockInstance.Index.BlockId(chunk.blocks), blockInstance.Data)
+		player.TransmitPacket(buf.Bytes())
 	}
 
 	return
 }
 
+// wearHeldTool wears down the player's held item if it is a tool.
+func (chunk *Chunk) wearHeldTool(player gamerules.IPlayerClient, held *gamerules.Slot) {
+	if itemType, ok := chunk.ItemType(held.ItemTypeId); ok && held.Count > 0 && itemType.ToolUses > 0 {
+		player.WearHeldTool(*held, 1)
+	}
+}
+
 func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gamerules.Slot, target *BlockXyz, againstFace Face) {
 	// TODO use held item to better check of if the player is trying to place a
-	// block vs. perform some other interaction (e.g hoeing dirt). This is
+	// block vs. perform some other interaction (e.g filling a bucket). This is
 	// perhaps best solved by sending held item type and the face to
 	// blockType.Aspect.Interact()
 
@@ -284,7 +411,16 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
 		return
 	}
 
-	if _, isBlockHeld := held.ItemTypeId.ToBlockId(); isBlockHeld && blockType.Attachable {
+	if aspect, ok := blockType.Aspect.(gamerules.IItemUseAspect); ok && held.Count > 0 && aspect.CanUseItem(blockInstance, &held) {
+		if itemType, ok := chunk.ItemType(held.ItemTypeId); ok && itemType.ToolUses > 0 {
+			// Tools aren't used up, so are used where they are held.
+			aspect.UseItem(blockInstance, &held)
+			chunk.wearHeldTool(player, &held)
+		} else {
+			// The item comes back to the block in reqPlaceItem.
+			player.PlaceHeldItem(*target, held)
+		}
+	} else if _, isBlockHeld := held.ItemTypeId.ToBlockId(); isBlockHeld && blockType.Attachable {
 		// The player is interacting with a block that can be attached to.
 
 		// Work out the position to put the block at.
@@ -296,6 +432,13 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
 		}
 
 		player.PlaceHeldItem(*destLoc, held)
+	} else if chunk.isHeldToolType(&held, gamerules.ToolTypeIdFlintAndSteel) {
+		chunk.wearHeldTool(player, &held)
+		if ignitable, ok := blockType.Aspect.(gamerules.IIgnitableAspect); ok {
+			ignitable.Ignite(blockInstance, 0)
+		} else if destLoc := target.AddXyz(againstFace.Dxyz()); destLoc != nil {
+			chunk.shard.lightFire(destLoc)
+		}
 	} else {
 		// Player is otherwise interacting with the block.
 		blockType.Aspect.Interact(blockInstance, player)
@@ -304,6 +447,12 @@ func (chunk *Chunk) reqInteractBlock(player gamerules.IPlayerClient, held gameru
 	return
 }
 
+// isHeldToolType returns true if the held item is a tool of the given type.
+func (chunk *Chunk) isHeldToolType(held *gamerules.Slot, toolType gamerules.ToolTypeId) bool {
+	itemType, ok := chunk.ItemType(held.ItemTypeId)
+	return ok && held.Count > 0 && itemType.ToolType == toolType
+}
+
 // placeBlock attempts to place a block. This is called by PlayerBlockInteract
 // in the situation where the player interacts with an attachable block
 // (potentially in a different chunk to the one where the block gets placed).
@@ -311,10 +460,14 @@ func (chunk *Chunk) reqPlaceItem(player gamerules.IPlayerClient, target *BlockXy
 	// TODO defer a check for remaining items in slot, and do something with them
 	// (send to player or drop on the ground).
 
-	// TODO more flexible item checking for block placement (e.g placing seed
-	// items on farmland doesn't fit this current simplistic model). The block
-	// type for the block being placed against should probably contain this logic
-	// (i.e farmland block should know about the seed item).
+	// Items such as seeds are used on the block itself.
+	if instance, blockType, ok := chunk.blockInstanceAndType(target); ok {
+		if aspect, ok := blockType.Aspect.(gamerules.IItemUseAspect); ok && aspect.CanUseItem(instance, slot) {
+			aspect.UseItem(instance, slot)
+			return
+		}
+	}
+
 	heldBlockType, ok := slot.ItemTypeId.ToBlockId()
 	if !ok || slot.Count < 1 {
 		// Not a placeable item.
@@ -378,6 +531,17 @@ func (chunk *Chunk) reqInventoryClick(player gamerules.IPlayerClient, blockLoc *
 	blockType.Aspect.InventoryClick(blockInstance, player, click)
 }
 
+func (chunk *Chunk) reqInventoryPutItem(player gamerules.IPlayerClient, blockLoc *BlockXyz, item *gamerules.Slot) {
+	blockInstance, blockType, ok := chunk.blockInstanceAndType(blockLoc)
+	if !ok {
+		// The player still gets their items back.
+		player.GiveItem(*item)
+		return
+	}
+
+	blockType.Aspect.InventoryPutItem(blockInstance, player, item)
+}
+
 func (chunk *Chunk) reqInventoryUnsubscribed(player gamerules.IPlayerClient, blockLoc *BlockXyz) {
 	blockInstance, blockType, ok := chunk.blockInstanceAndType(blockLoc)
 	if !ok {
@@ -387,11 +551,27 @@ func (chunk *Chunk) reqInventoryUnsubscribed(player gamerules.IPlayerClient, blo
 	blockType.Aspect.InventoryUnsubscribed(blockInstance, player)
 }
 
+func (chunk *Chunk) reqMakePortal(player gamerules.IPlayerClient, position *AbsXyz, look LookDegrees) {
+	if instance, _, ok := chunk.blockInstanceAndType(position.ToBlockXyz()); ok {
+		if arrival := gamerules.MakePortal(instance); arrival != *position {
+			player.SetPositionLook(arrival, look)
+		}
+	}
+}
+
 // Used to read the BlockId of a block that's either in the chunk, or
 // immediately adjoining it in a neighbouring chunk. In cases where the block
 // type can't be determined we assume that the block asked about is solid
 // (this way objects don't fly off the side of the map needlessly).
 func (chunk *Chunk) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk bool) {
+	blockType, isWithinChunk := chunk.blockTypeQuery(blockLoc)
+	isSolid = blockType == nil || blockType.Solid
+	return
+}
+
+// blockTypeQuery looks up the type of a block for BlockQuery. It returns nil
+// if the block type can't be determined.
+func (chunk *Chunk) blockTypeQuery(blockLoc BlockXyz) (blockType *gamerules.BlockType, isWithinChunk bool) {
 	chunkLoc, subLoc := blockLoc.ToChunkLocal()
 
 	var blockTypeId BlockId
@@ -402,7 +582,6 @@ func (chunk *Chunk) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk b
 		index, ok := subLoc.BlockIndex()
 		if !ok {
 			log.Printf("%s.PhysicsBlockQuery(%#v) got bad block index", chunk, blockLoc)
-			isSolid = true
 			return
 		}
 
@@ -416,24 +595,31 @@ func (chunk *Chunk) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk b
 
 		if !ok {
 			// The block isn't known.
-			isSolid = true
 			return
 		}
 	}
 
-	if blockType, ok := gamerules.Blocks.Get(blockTypeId); ok {
-		isSolid = blockType.Solid
-	} else {
+	if blockType, ok = gamerules.Blocks.Get(blockTypeId); !ok {
 		log.Printf(
 			"%s.PhysicsBlockQuery found unknown block type Id %d at %+v",
 			chunk, blockTypeId, blockLoc)
 		// The block type isn't known.
-		isSolid = true
+		return nil, isWithinChunk
 	}
 
 	return
 }
 
+// playerBlockQuerier answers block queries about how players move around a
+// chunk, where blocks that players can pass through aren't solid.
+type playerBlockQuerier Chunk
+
+func (querier *playerBlockQuerier) BlockQuery(blockLoc BlockXyz) (isSolid bool, isWithinChunk bool) {
+	blockType, isWithinChunk := (*Chunk)(querier).blockTypeQuery(blockLoc)
+	isSolid = blockType == nil || (blockType.Solid && !blockType.Passable)
+	return
+}
+
 func (chunk *Chunk) tick() {
 	chunk.spawnTick()
 	if chunk.tickAll {
@@ -442,6 +628,9 @@ func (chunk *Chunk) tick() {
 	} else {
 		chunk.blockTick()
 	}
+	chunk.randomTick()
+	chunk.scheduledTick()
+	chunk.playerTick()
 }
 
 // spawnTick runs all spawns for a tick.
@@ -452,6 +641,7 @@ func (chunk *Chunk) spawnTick() {
 	}
 
 	outgoingEntities := []gamerules.INonPlayerEntity{}
+	lifetime := Ticks(*itemLifetimeSecs) * TicksPerSecond
 
 	for _, e := range chunk.entities {
 		if e.Tick(chunk) {
@@ -461,14 +651,29 @@ func (chunk *Chunk) spawnTick() {
 			} else {
 				outgoingEntities = append(outgoingEntities, e)
 			}
+		} else if e.Position().ToChunkXz() != chunk.loc {
+			// An earlier transfer to the chunk that it moved into failed.
+			outgoingEntities = append(outgoingEntities, e)
+		} else if landing, ok := e.(gamerules.ILandingEntity); ok && landing.Land(chunk) {
+			chunk.removeEntity(e)
+		} else if fused, ok := e.(gamerules.IFusedEntity); ok && fused.BurnFuse(chunk) {
+			chunk.removeEntity(e)
+		} else if aging, ok := e.(gamerules.IDespawningEntity); ok && aging.AgeTick(lifetime) {
+			chunk.removeEntity(e)
+		} else if burnable, ok := e.(gamerules.IBurnableEntity); ok && chunk.burnEntity(e, burnable) {
+			chunk.removeEntity(e)
+		} else {
+			chunk.touchBlock(e)
 		}
 	}
 
 	if len(outgoingEntities) > 0 {
 		// Transfer spawns to new chunk.
 		for _, e := range outgoingEntities {
-			// Remove mob/items from this chunk.
-			delete(chunk.entities, e.GetEntityId())
+			if _, ok := chunk.entities[e.GetEntityId()]; !ok {
+				// Removed by a later entity, such as by an explosion.
+				continue
+			}
 
 			// Transfer to other chunk.
 			chunkLoc := e.Position().ToChunkXz()
@@ -477,15 +682,135 @@ func (chunk *Chunk) spawnTick() {
 			// TODO Batch spawns up into a request per shard if there are efficiency
 			// concerns in sending them individually.
 			shardClient := chunk.shard.clientForShard(shardLoc)
-			if shardClient != nil {
-				shardClient.ReqTransferEntity(chunkLoc, e)
+			if shardClient == nil || !shardClient.ReqTransferEntity(chunkLoc, e) {
+				// Keep the mob/item here, and try again next tick.
+				continue
 			}
+
+			// Remove mob/items from this chunk.
+			delete(chunk.entities, e.GetEntityId())
+			chunk.multicastEntityMoved(-1, e.GetEntityId(), chunkLoc)
 		}
 	}
 
+	chunk.mergeItems()
+
 	chunk.storeDirty = true
 }
 
+// itemMergeDistance is how close items must be to be merged into one stack.
+const itemMergeDistance = 0.5
+
+// itemMergeKey identifies items that can be merged into one stack.
+type itemMergeKey struct {
+	itemTypeId ItemTypeId
+	data       ItemData
+}
+
+// mergeItems merges items of the same type and data that are within
+// itemMergeDistance of each other into as few stacks as they fit in.
+func (chunk *Chunk) mergeItems() {
+	stacks := make(map[itemMergeKey][]*gamerules.Item)
+	var changed []*gamerules.Item
+
+	for _, e := range chunk.entities {
+		item, ok := e.(*gamerules.Item)
+		if !ok {
+			continue
+		}
+
+		key := itemMergeKey{item.ItemTypeId, item.Data}
+		merged := false
+		for _, stack := range stacks[key] {
+			if !stack.Position().IsWithinDistanceOf(item.Position(), itemMergeDistance) || !stack.Merge(item) {
+				continue
+			}
+			merged = true
+			changed = append(changed, stack)
+			if item.Count == 0 {
+				break
+			}
+		}
+
+		if item.Count == 0 {
+			chunk.removeEntity(item)
+			continue
+		}
+		// Items that didn't fit in a nearby stack start a new one.
+		stacks[key] = append(stacks[key], item)
+		if merged {
+			changed = append(changed, item)
+		}
+	}
+
+	// Clients only learn the size of an item's stack when it spawns.
+	sent := make(map[EntityId]bool)
+	for _, item := range changed {
+		entityId := item.GetEntityId()
+		if _, ok := chunk.entities[entityId]; !ok || sent[entityId] {
+			continue
+		}
+		sent[entityId] = true
+		chunk.multicastEntityDestroy(-1, entityId)
+		chunk.multicastEntitySpawn(item)
+	}
+}
+
+// playerTick tells the players in the chunk about changes to the blocks that
+// they are in and standing on.
+func (chunk *Chunk) playerTick() {
+	for entityId, data := range chunk.playersData {
+		player, ok := chunk.subscribers[entityId]
+		if !ok {
+			continue
+		}
+
+		headPos := data.position
+		headPos.Y += playerHeadY
+		surroundings := gamerules.PlayerSurroundings(
+			chunk.blockTypeAt(&data.position), chunk.blockTypeAt(&headPos))
+		surroundings.Supported = surroundings.Supported ||
+			gamerules.StandingOnSolid(chunk, &data.position)
+
+		if data.surroundingsSent && surroundings == data.surroundings {
+			continue
+		}
+		data.surroundings = surroundings
+		data.surroundingsSent = true
+		player.SetSurroundings(surroundings)
+	}
+}
+
+// blockTypeAt returns the type of the block at the position, or nil if it is
+// outside of the world or not known.
+func (chunk *Chunk) blockTypeAt(pos *AbsXyz) *gamerules.BlockType {
+	if pos.Y < 0 || pos.Y >= ChunkSizeY {
+		return nil
+	}
+	index, _, ok := chunk.getBlockIndexByBlockXyz(pos.ToBlockXyz())
+	if !ok {
+		return nil
+	}
+	blockType, _, ok := chunk.blockTypeAndData(index)
+	if !ok {
+		return nil
+	}
+	return blockType
+}
+
+// touchBlock tells the block that an entity is within that it is touched by
+// the entity.
+func (chunk *Chunk) touchBlock(e gamerules.INonPlayerEntity) {
+	instance, blockType, ok := chunk.blockInstanceAndType(e.Position().ToBlockXyz())
+	if !ok {
+		return
+	}
+
+	if aspect, ok := blockType.Aspect.(gamerules.ITouchedAspect); ok {
+		aspect.Touched(instance, e)
+	}
+}
+
 // blockTick runs any blocks that need to do something each tick.
 func (chunk *Chunk) blockTick() {
 	if len(chunk.activeBlocks) == 0 && len(chunk.newActiveBlocks) == 0 {
@@ -506,6 +831,7 @@ func (chunk *Chunk) blockTick() {
 		if !ok {
 			// Invalid block.
 			delete(chunk.activeBlocks, blockIndex)
+			continue
 		}
 
 		blockInstance.SubLoc = blockIndex.ToSubChunkXyz()
@@ -550,12 +876,98 @@ func (chunk *Chunk) blockTickAll() {
 	chunk.storeDirty = true
 }
 
+// randomTick gives a "RandomTick" to blocks picked at random from each 16 block
+// high section of the chunk, for blocks that change slowly by themselves.
+func (chunk *Chunk) randomTick() {
+	var blockInstance gamerules.BlockInstance
+	blockInstance.Chunk = chunk
+
+	for sectionY := 0; sectionY < ChunkSizeY; sectionY += ChunkSizeH {
+		for i := 0; i < chunk.shard.randomTicks; i++ {
+			r := chunk.rand.Intn(ChunkSizeH * ChunkSizeH * ChunkSizeH)
+			blockInstance.SubLoc = SubChunkXyz{
+				X: SubChunkCoord(r & ChunkHMask),
+				Y: SubChunkCoord(sectionY + ((r >> 4) & ChunkHMask)),
+				Z: SubChunkCoord((r >> 8) & ChunkHMask),
+			}
+			blockIndex, ok := blockInstance.SubLoc.BlockIndex()
+			if !ok {
+				continue
+			}
+
+			blockInstance.BlockType, blockInstance.Data, ok = chunk.blockTypeAndData(blockIndex)
+			if !ok {
+				continue
+			}
+			aspect, ok := blockInstance.BlockType.Aspect.(gamerules.IRandomTickAspect)
+			if !ok {
+				continue
+			}
+
+			blockInstance.Index = blockIndex
+			blockInstance.BlockLoc = *chunk.loc.ToBlockXyz(&blockInstance.SubLoc)
+			aspect.RandomTick(&blockInstance)
+		}
+	}
+}
+
+// scheduledTick runs the blocks whose ticks scheduled by ScheduleBlockTick are
+// due. They are run in order of index so that they behave the same each time.
+func (chunk *Chunk) scheduledTick() {
+	if len(chunk.scheduledTicks) == 0 {
+		return
+	}
+
+	var dueBlocks []int
+	for blockIndex, dueTick := range chunk.scheduledTicks {
+		if dueTick <= chunk.shard.ticks {
+			dueBlocks = append(dueBlocks, int(blockIndex))
+			delete(chunk.scheduledTicks, blockIndex)
+		}
+	}
+	sort.Ints(dueBlocks)
+
+	var ok bool
+	var blockInstance gamerules.BlockInstance
+	blockInstance.Chunk = chunk
+
+	for _, i := range dueBlocks {
+		blockIndex := BlockIndex(i)
+		blockInstance.BlockType, blockInstance.Data, ok = chunk.blockTypeAndData(blockIndex)
+		if !ok {
+			continue
+		}
+
+		aspect, ok := blockInstance.BlockType.Aspect.(gamerules.IScheduledAspect)
+		if !ok {
+			// The block has changed to a type that doesn't schedule ticks.
+			continue
+		}
+
+		blockInstance.SubLoc = blockIndex.ToSubChunkXyz()
+		blockInstance.Index = blockIndex
+		blockInstance.BlockLoc = *chunk.loc.ToBlockXyz(&blockInstance.SubLoc)
+
+		aspect.ScheduledTick(&blockInstance)
+	}
+
+	chunk.storeDirty = true
+}
+
+func (chunk *Chunk) ScheduleBlockTick(blockIndex BlockIndex, delay Ticks) {
+	if _, ok := chunk.scheduledTicks[blockIndex]; !ok {
+		chunk.scheduledTicks[blockIndex] = chunk.shard.ticks + delay
+	}
+}
+
 func (chunk *Chunk) AddActiveBlock(blockXyz *BlockXyz) {
 	chunkXz, subLoc := blockXyz.ToChunkLocal()
 	if chunk.isSameChunk(chunkXz) {
 		if index, ok := subLoc.BlockIndex(); ok {
 			chunk.newActiveBlocks[index] = true
 		}
+	} else {
+		chunk.shard.addActiveBlock(blockXyz)
 	}
 }
 
@@ -563,6 +975,103 @@ func (chunk *Chunk) AddActiveBlockIndex(blockIndex BlockIndex) {
 	chunk.newActiveBlocks[blockIndex] = true
 }
 
+// activateNeighbours flags the blocks touching the given block as active.
+func (chunk *Chunk) activateNeighbours(blockXyz *BlockXyz) {
+	for _, offset := range neighbourOffsets {
+		if neighbour := blockXyz.AddXyz(offset.dx, offset.dy, offset.dz); neighbour != nil {
+			chunk.AddActiveBlock(neighbour)
+		}
+	}
+}
+
+func (chunk *Chunk) BlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool) {
+	target, index, _ := chunk.shard.loadedBlock(blockXyz)
+	if target == nil {
+		return
+	}
+
+	return index.BlockId(target.blocks), index.BlockData(target.blockData), true
+}
+
+func (chunk *Chunk) BorderBlockAt(blockXyz *BlockXyz) (blockTypeId BlockId, blockData byte, ok bool) {
+	block, ok := chunk.shard.borderBlocks[*blockXyz]
+	return block.blockId, block.blockData, ok
+}
+
+func (chunk *Chunk) SpreadBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte) {
+	chunk.shard.queueSpread(gamerules.BlockSpread{
+		Block:       *blockXyz,
+		BlockTypeId: blockTypeId,
+		BlockData:   blockData,
+	})
+}
+
+func (chunk *Chunk) PlaceBlock(blockXyz *BlockXyz, blockTypeId BlockId, blockData byte) {
+	chunk.shard.queuePlacement(gamerules.BlockPlacement{
+		Block:       *blockXyz,
+		BlockTypeId: blockTypeId,
+		BlockData:   blockData,
+	})
+}
+
+func (chunk *Chunk) LightLevel(blockXyz *BlockXyz) (level int8, ok bool) {
+	target, index, _ := chunk.shard.loadedBlock(blockXyz)
+	if target == nil {
+		return
+	}
+
+	level = target.lightLevel(index, true)
+	if blockLevel := target.lightLevel(index, false); blockLevel > level {
+		level = blockLevel
+	}
+	return level, true
+}
+
+func (chunk *Chunk) CurrentTick() Ticks {
+	return chunk.shard.ticks
+}
+
+func (chunk *Chunk) RedstoneInput(from, to *BlockXyz) gamerules.RedstoneInput {
+	return chunk.shard.redstoneInputs[redstoneLink{*from, *to}]
+}
+
+func (chunk *Chunk) IsOccupied(blockXyz *BlockXyz) bool {
+	target, _, _ := chunk.shard.loadedBlock(blockXyz)
+	if target == nil {
+		return false
+	}
+
+	for _, data := range target.playersData {
+		if *data.position.ToBlockXyz() == *blockXyz {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (chunk *Chunk) MulticastPlayers(exclude EntityId, packet []byte) {
+	chunk.reqMulticastPlayers(exclude, packet)
+}
+
+func (chunk *Chunk) Explode(center *AbsXyz, power float32) {
+	chunk.shard.explode(chunk, center, power)
+}
+
+// IsRaining returns true if it is raining in the world, and rain falls on the
+// block because it is open to the sky.
+func (chunk *Chunk) IsRaining(blockXyz *BlockXyz) bool {
+	if !chunk.shard.raining {
+		return false
+	}
+	_, subLoc, ok := chunk.getBlockIndexByBlockXyz(blockXyz)
+	if !ok {
+		return false
+	}
+	height := chunk.heightMap[int(subLoc.X)*ChunkSizeH+int(subLoc.Z)]
+	return byte(subLoc.Y) >= height
+}
+
 func (chunk *Chunk) mobs() (s []*gamerules.Mob) {
 	s = make([]*gamerules.Mob, 0, 3)
 	for _, e := range chunk.entities {
@@ -602,30 +1111,27 @@ func (chunk *Chunk) reqSubscribeChunk(entityId EntityId, player gamerules.IPlaye
 		player.NotifyChunkLoad()
 	}
 
-	// Send spawns packets for all entities in the chunk.
-	if len(chunk.entities) > 0 {
+	// Send spawns for all entities in the chunk.
+	for _, e := range chunk.entities {
 		buf := new(bytes.Buffer)
-		for _, e := range chunk.entities {
-			e.SendSpawn(buf)
-		}
-		player.TransmitPacket(buf.Bytes())
+		e.SendSpawn(buf)
+		player.EntitySpawn(chunk.loc, e.GetEntityId(), buf.Bytes())
 	}
 
 	// Spawn existing players for new player.
-	if len(chunk.playersData) > 0 {
-		playersPacket := new(bytes.Buffer)
-		for _, existing := range chunk.playersData {
-			if existing.entityId != entityId {
-				existing.sendSpawn(playersPacket)
-			}
+	for _, existing := range chunk.playersData {
+		if existing.entityId != entityId {
+			buf := new(bytes.Buffer)
+			existing.sendSpawn(buf)
+			player.EntitySpawn(chunk.loc, existing.entityId, buf.Bytes())
 		}
-		player.TransmitPacket(playersPacket.Bytes())
 	}
 }
 
 func (chunk *Chunk) reqUnsubscribeChunk(entityId EntityId, sendPacket bool) {
 	if player, ok := chunk.subscribers[entityId]; ok {
 		delete(chunk.subscribers, entityId)
+		delete(chunk.digs, entityId)
 
 		// Call any observers registered with AddOnUnsubscribe.
 		if observers, ok := chunk.onUnsub[entityId]; ok {
@@ -635,10 +1141,11 @@ func (chunk *Chunk) reqUnsubscribeChunk(entityId EntityId, sendPacket bool) {
 			}
 		}
 
+		// The player frontend destroys the entities in the chunk for the client,
+		// as it tracks which entities are visible to it.
 		if sendPacket {
 			buf := new(bytes.Buffer)
 			proto.WritePreChunk(buf, &chunk.loc, ChunkUnload)
-			// TODO send PacketEntityDestroy packets for spawns in this chunk.
 			player.TransmitPacket(buf.Bytes())
 		}
 	}
@@ -687,30 +1194,37 @@ func (chunk *Chunk) reqMulticastPlayers(exclude EntityId, packet []byte) {
 	}
 }
 
-func (chunk *Chunk) reqAddPlayerData(entityId EntityId, name string, pos AbsXyz, look LookBytes, held ItemTypeId) {
+func (chunk *Chunk) reqAddPlayerData(entityId EntityId, name string, pos AbsXyz, look LookBytes, appearance gamerules.PlayerAppearance) {
 	// TODO add other initial data in here.
 	newPlayerData := &playerData{
 		entityId:   entityId,
 		name:       name,
 		position:   pos,
 		look:       look,
-		heldItemId: held,
+		appearance: appearance,
 	}
 	chunk.playersData[entityId] = newPlayerData
+	chunk.AddActiveBlock(pos.ToBlockXyz())
 
 	// Spawn new player for existing players.
-	newPlayerPacket := new(bytes.Buffer)
-	newPlayerData.sendSpawn(newPlayerPacket)
-	chunk.reqMulticastPlayers(entityId, newPlayerPacket.Bytes())
+	buf := new(bytes.Buffer)
+	newPlayerData.sendSpawn(buf)
+	packet := buf.Bytes()
+	for subscriberId, player := range chunk.subscribers {
+		if subscriberId != entityId {
+			player.EntitySpawn(chunk.loc, entityId, packet)
+		}
+	}
 }
 
-func (chunk *Chunk) reqRemovePlayerData(entityId EntityId, isDisconnect bool) {
+func (chunk *Chunk) reqRemovePlayerData(entityId EntityId, newChunkLoc ChunkXz, isDisconnect bool) {
 	delete(chunk.playersData, entityId)
+	delete(chunk.digs, entityId)
 
 	if isDisconnect {
-		buf := new(bytes.Buffer)
-		proto.WriteEntityDestroy(buf, entityId)
-		chunk.reqMulticastPlayers(entityId, buf.Bytes())
+		chunk.multicastEntityDestroy(entityId, entityId)
+	} else {
+		chunk.multicastEntityMoved(entityId, entityId, newChunkLoc)
 	}
 }
 
@@ -725,6 +1239,18 @@ func (chunk *Chunk) reqSetPlayerPosition(entityId EntityId, pos AbsXyz) {
 		return
 	}
 
+	if gamerules.MovesThroughSolid((*playerBlockQuerier)(chunk), &data.position, &pos) {
+		if player, ok := chunk.subscribers[entityId]; ok {
+			player.RejectMove(data.position)
+		}
+		return
+	}
+
+	// Blocks such as pressure plates react to players entering them.
+	if blockLoc := pos.ToBlockXyz(); *blockLoc != *data.position.ToBlockXyz() {
+		chunk.AddActiveBlock(blockLoc)
+	}
+
 	data.position = pos
 
 	// Update subscribers.
@@ -769,6 +1295,26 @@ func (chunk *Chunk) reqSetPlayerLook(entityId EntityId, look LookBytes) {
 	chunk.reqMulticastPlayers(entityId, buf.Bytes())
 }
 
+func (chunk *Chunk) reqSetPlayerAppearance(entityId EntityId, appearance gamerules.PlayerAppearance) {
+	data, ok := chunk.playersData[entityId]
+
+	if !ok {
+		log.Printf(
+			"%v.reqSetPlayerAppearance: called for EntityId (%d) not present as playerData.",
+			chunk, entityId,
+		)
+		return
+	}
+
+	// Update subscribers.
+	buf := new(bytes.Buffer)
+	appearance.SendChanges(buf, entityId, &data.appearance)
+	data.appearance = appearance
+	if buf.Len() > 0 {
+		chunk.reqMulticastPlayers(entityId, buf.Bytes())
+	}
+}
+
 func (chunk *Chunk) chunkPacket() []byte {
 	if chunk.cachedPacket == nil {
 		buf := new(bytes.Buffer)
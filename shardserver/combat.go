@@ -0,0 +1,108 @@
+package shardserver
+
+import (
+	"bytes"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// reqHitEntity hits a mob or player in the chunk for a player attacking from
+// the given position.
+func (chunk *Chunk) reqHitEntity(player gamerules.IPlayerClient, target EntityId, held *gamerules.Slot, position *AbsXyz, pvp bool) {
+	if e, ok := chunk.entities[target]; ok {
+		if !e.Position().IsWithinDistanceOf(position, MaxInteractDistance) {
+			return
+		}
+
+		hurt, died := gamerules.HitEntity(e, held, position)
+		if !hurt {
+			return
+		}
+		chunk.wearAttackingTool(player, held)
+		chunk.multicastEntityHurt(target, died)
+
+		if died {
+			chunk.dropEntityLoot(e)
+			chunk.removeEntity(e)
+		}
+		return
+	}
+
+	if data, ok := chunk.playersData[target]; ok {
+		victim, ok := chunk.subscribers[target]
+		if !ok || !pvp || target == player.GetEntityId() {
+			return
+		}
+		if !data.position.IsWithinDistanceOf(position, MaxInteractDistance) {
+			return
+		}
+
+		// The victim's frontend shows them being hurt to other players.
+		victim.Hurt(gamerules.AttackDamage(held))
+		chunk.wearAttackingTool(player, held)
+
+		push := gamerules.Knockback(position, &data.position)
+		buf := new(bytes.Buffer)
+		proto.WriteEntityVelocity(buf, target, push.ToVelocity())
+		victim.TransmitPacket(buf.Bytes())
+	}
+}
+
+// burnEntity hurts an entity that is on fire. It returns true if the entity
+// has died, after dropping its loot.
+func (chunk *Chunk) burnEntity(e gamerules.INonPlayerEntity, burnable gamerules.IBurnableEntity) (died bool) {
+	hurt, died := burnable.BurnTick()
+	if hurt {
+		chunk.multicastEntityHurt(e.GetEntityId(), died)
+	}
+	if died {
+		chunk.dropEntityLoot(e)
+	}
+	return died
+}
+
+// multicastEntityHurt shows subscribers that an entity has been hurt, or
+// has died.
+func (chunk *Chunk) multicastEntityHurt(entityId EntityId, died bool) {
+	buf := new(bytes.Buffer)
+	if died {
+		proto.WriteEntityStatus(buf, entityId, EntityStatusDead)
+	} else {
+		proto.WriteEntityAnimation(buf, entityId, EntityAnimationDamage)
+		proto.WriteEntityStatus(buf, entityId, EntityStatusHurt)
+	}
+	chunk.reqMulticastPlayers(-1, buf.Bytes())
+}
+
+// wearAttackingTool wears down the tool that a player hit something with.
+func (chunk *Chunk) wearAttackingTool(player gamerules.IPlayerClient, held *gamerules.Slot) {
+	if uses := gamerules.AttackWear(held); uses > 0 {
+		player.WearHeldTool(*held, uses)
+	}
+}
+
+// dropEntityLoot drops the items that an entity that has died leaves behind.
+func (chunk *Chunk) dropEntityLoot(e gamerules.INonPlayerEntity) {
+	dropping, ok := e.(gamerules.IDroppingEntity)
+	if !ok {
+		return
+	}
+
+	for _, drop := range dropping.Drops(chunk.rand) {
+		velocity := AbsVelocity{
+			AbsVelocityCoord(chunk.rand.Float64()*0.2 - 0.1),
+			0.2,
+			AbsVelocityCoord(chunk.rand.Float64()*0.2 - 0.1),
+		}
+		chunk.AddEntity(gamerules.NewItem(
+			drop.ItemTypeId,
+			drop.Count,
+			drop.Data,
+			e.Position(),
+			&velocity,
+			0,
+		))
+	}
+}
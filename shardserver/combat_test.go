@@ -0,0 +1,100 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testItemIdIronSword = ItemTypeId(267)
+
+func TestHitMobUntilItDies(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.reqSubscribeChunk(1, player, false)
+
+	pig := gamerules.NewPig()
+	pig.(*gamerules.Pig).PointObject.Init(&AbsXyz{8, 64, 8}, &AbsVelocity{})
+	chunk.AddEntity(pig)
+	pigId := pig.GetEntityId()
+	sword := gamerules.Slot{ItemTypeId: testItemIdIronSword, Count: 1}
+
+	// Too far away to reach.
+	chunk.reqHitEntity(player, pigId, &sword, &AbsXyz{8, 64, 20}, false)
+	chunk.reqHitEntity(player, pigId, &sword, &AbsXyz{8, 64, 20}, false)
+	if _, ok := chunk.entities[pigId]; !ok {
+		t.Fatalf("Expected pig out of reach to survive")
+	}
+
+	chunk.reqHitEntity(player, pigId, &sword, &AbsXyz{7, 64, 8}, false)
+	if _, ok := chunk.entities[pigId]; !ok {
+		t.Fatalf("Expected pig to survive the first hit")
+	}
+	chunk.reqHitEntity(player, pigId, &sword, &AbsXyz{7, 64, 8}, false)
+	if _, ok := chunk.entities[pigId]; ok {
+		t.Fatalf("Expected pig to die from the second hit")
+	}
+
+	for _, e := range chunk.entities {
+		if item, ok := e.(*gamerules.Item); !ok || item.ItemTypeId != 319 {
+			t.Errorf("Expected pig to leave only porkchops, got %#v", e)
+		}
+	}
+}
+
+func TestMobBurnsToDeath(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+
+	pig := gamerules.NewPig()
+	pig.(*gamerules.Pig).PointObject.Init(&AbsXyz{8, 64, 8}, &AbsVelocity{})
+	chunk.AddEntity(pig)
+	pigId := pig.GetEntityId()
+
+	// Pigs survive burning out once.
+	pig.(gamerules.IBurnableEntity).SetBurning(true)
+	tickTestShards([]*ChunkShard{shard}, 10*TicksPerSecond)
+	if _, ok := chunk.entities[pigId]; !ok {
+		t.Fatalf("Expected pig to survive burning once")
+	}
+
+	pig.(gamerules.IBurnableEntity).SetBurning(true)
+	tickTestShards([]*ChunkShard{shard}, 10*TicksPerSecond)
+	if _, ok := chunk.entities[pigId]; ok {
+		t.Fatalf("Expected pig to burn to death")
+	}
+}
+
+func TestHitPlayer(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	attacker := newTestPlayerClient(1)
+	victim := newTestPlayerClient(2)
+	chunk.reqSubscribeChunk(1, attacker, false)
+	chunk.reqSubscribeChunk(2, victim, false)
+	chunk.reqAddPlayerData(2, "victim", AbsXyz{8, 64, 8}, LookBytes{}, gamerules.PlayerAppearance{})
+	sword := gamerules.Slot{ItemTypeId: testItemIdIronSword, Count: 1}
+
+	isHurt := func(event interface{}) bool {
+		_, ok := event.(testHurtEvent)
+		return ok
+	}
+
+	chunk.reqHitEntity(attacker, 2, &sword, &AbsXyz{7, 64, 8}, false)
+	for len(victim.events) > 0 {
+		if isHurt(<-victim.events) {
+			t.Fatalf("Expected player not to be hurt without PvP")
+		}
+	}
+
+	chunk.reqHitEntity(attacker, 2, &sword, &AbsXyz{7, 64, 8}, true)
+	event := victim.waitFor(isHurt)
+	if event == nil {
+		t.Fatalf("Expected player to be hurt with PvP")
+	}
+	if amount := event.(testHurtEvent).amount; amount != 6 {
+		t.Errorf("Expected iron sword to do 6 damage, got %d", amount)
+	}
+}
@@ -0,0 +1,89 @@
+package shardserver
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// deferredLightKey identifies a light update deferred until a chunk loads.
+// Only the latest update to each block is kept.
+type deferredLightKey struct {
+	block BlockXyz
+	sky   bool
+}
+
+// deferredWork holds changes sent to a chunk of the shard while it was not
+// loaded. They are performed when the chunk is next loaded, rather than
+// loading the chunk straight away, as that would in turn send changes to the
+// chunks around it, loading them too.
+type deferredWork struct {
+	activeBlocks map[BlockXyz]bool
+	lightUpdates map[deferredLightKey]gamerules.LightUpdate
+}
+
+// deferActiveBlock makes the block active when its chunk is next loaded.
+func (shard *ChunkShard) deferActiveBlock(chunkIndex int, block *BlockXyz) {
+	shard.deferredWorkFor(chunkIndex).activeBlocks[*block] = true
+}
+
+// deferLightUpdate applies the light update when its block's chunk is next
+// loaded.
+func (shard *ChunkShard) deferLightUpdate(chunkIndex int, update *gamerules.LightUpdate) {
+	key := deferredLightKey{update.Block, update.Sky}
+	shard.deferredWorkFor(chunkIndex).lightUpdates[key] = *update
+}
+
+func (shard *ChunkShard) deferredWorkFor(chunkIndex int) *deferredWork {
+	work, ok := shard.deferredWork[chunkIndex]
+	if !ok {
+		work = &deferredWork{
+			activeBlocks: make(map[BlockXyz]bool),
+			lightUpdates: make(map[deferredLightKey]gamerules.LightUpdate),
+		}
+		shard.deferredWork[chunkIndex] = work
+	}
+	return work
+}
+
+// performDeferredWork performs the changes deferred until the chunk was
+// loaded. It is called by chunkAt once the chunk has been loaded.
+func (shard *ChunkShard) performDeferredWork(chunkIndex int, chunk *Chunk) {
+	work, ok := shard.deferredWork[chunkIndex]
+	if !ok {
+		return
+	}
+	delete(shard.deferredWork, chunkIndex)
+
+	for block := range work.activeBlocks {
+		chunk.AddActiveBlock(&block)
+	}
+
+	if len(work.lightUpdates) > 0 {
+		updates := make([]gamerules.LightUpdate, 0, len(work.lightUpdates))
+		for _, update := range work.lightUpdates {
+			updates = append(updates, update)
+		}
+		shard.reqUpdateLight(updates)
+	}
+}
+
+// loadDeferredChunks loads the chunks that have deferred light updates, so
+// that they are performed before the shard is released. Deferred active blocks
+// are dropped instead, as all blocks in a chunk are ticked when it is loaded.
+// Returns true if any chunks were loaded.
+func (shard *ChunkShard) loadDeferredChunks() (loaded bool) {
+	for chunkIndex, work := range shard.deferredWork {
+		if len(work.lightUpdates) == 0 {
+			delete(shard.deferredWork, chunkIndex)
+			continue
+		}
+
+		if chunk := shard.chunkAt(shard.chunkLocForIndex(chunkIndex)); chunk != nil {
+			loaded = true
+		} else {
+			// The chunk is not available, so the work can never be performed.
+			delete(shard.deferredWork, chunkIndex)
+		}
+	}
+	return
+}
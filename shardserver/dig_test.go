@@ -0,0 +1,144 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestCreativeBreakDropsNothing(t *testing.T) {
+	type digTest struct {
+		gameType GameType
+		items    int
+	}
+
+	tests := []digTest{
+		{GameTypeSurvival, 1},
+		{GameTypeCreative, 0},
+	}
+
+	for _, test := range tests {
+		shard := newTestShards(ShardXz{0, 0})[0]
+		chunk := shard.chunkAt(ChunkXz{0, 0})
+		player := newTestPlayerClient(1)
+		blockLoc := BlockXyz{8, 5, 8}
+
+		// Torches break straight away in survival.
+		testSetBlock(t, shard, blockLoc, testBlockIdTorch)
+		chunk.reqHitBlock(player, gamerules.Slot{}, test.gameType, DigStarted, &blockLoc, FaceTop)
+
+		if blockId := testBlockIdAt(shard, blockLoc); blockId != BlockIdAir {
+			t.Errorf("Game type %d: expected torch to be broken, got block %d", test.gameType, blockId)
+		}
+		if items := chunk.items(); len(items) != test.items {
+			t.Errorf("Game type %d: expected %d items dropped, got %d", test.gameType, test.items, len(items))
+		}
+	}
+}
+
+func TestUnharvestableBlockGivesUpContents(t *testing.T) {
+	const (
+		testBlockIdFurnace = BlockId(61)
+		testItemIdCoal     = ItemTypeId(263)
+	)
+
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	blockLoc := BlockXyz{8, 5, 8}
+
+	testSetBlock(t, shard, blockLoc, testBlockIdFurnace)
+	chunk.reqInteractBlock(player, gamerules.Slot{}, &blockLoc, FaceTop)
+	chunk.reqInventoryClick(player, &blockLoc, &gamerules.Click{
+		SlotId: 1, // Fuel.
+		Cursor: gamerules.Slot{testItemIdCoal, 4, 0},
+	})
+
+	// Furnaces need a pickaxe to harvest, so digging one by hand leaves no
+	// furnace item.
+	chunk.digs[player.GetEntityId()] = blockDig{blockLoc, shard.ticks - 1000}
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeSurvival, DigBlockBroke, &blockLoc, FaceTop)
+
+	if blockId := testBlockIdAt(shard, blockLoc); blockId != BlockIdAir {
+		t.Fatalf("Expected furnace to be broken, got block %d", blockId)
+	}
+	items := chunk.items()
+	if len(items) != 1 || items[0].ItemTypeId != testItemIdCoal || items[0].Count != 4 {
+		t.Errorf("Expected only the 4 coal in the furnace to be dropped, got %d items", len(items))
+	}
+	if event := player.waitFor(func(event interface{}) bool {
+		_, ok := event.(testInventoryUnsubscribedEvent)
+		return ok
+	}); event == nil {
+		t.Errorf("Expected player to be unsubscribed from the furnace inventory")
+	}
+}
+
+func TestCreativeBreakGivesUpContents(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	blockLoc := BlockXyz{8, 5, 8}
+
+	testSetBlock(t, shard, blockLoc, testBlockIdChest)
+	chunk.reqInteractBlock(player, gamerules.Slot{}, &blockLoc, FaceTop)
+	chunk.reqInventoryClick(player, &blockLoc, &gamerules.Click{
+		SlotId: 0,
+		Cursor: gamerules.Slot{4, 10, 0},
+	})
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeCreative, DigStarted, &blockLoc, FaceTop)
+
+	if blockId := testBlockIdAt(shard, blockLoc); blockId != BlockIdAir {
+		t.Fatalf("Expected chest to be broken, got block %d", blockId)
+	}
+	items := chunk.items()
+	if len(items) != 1 || items[0].ItemTypeId != 4 || items[0].Count != 10 {
+		t.Errorf("Expected only the 10 cobblestone in the chest to be dropped, got %d items", len(items))
+	}
+	if event := player.waitFor(func(event interface{}) bool {
+		_, ok := event.(testInventoryUnsubscribedEvent)
+		return ok
+	}); event == nil {
+		t.Errorf("Expected player to be unsubscribed from the chest inventory")
+	}
+}
+
+func TestChunkNotIdleWhileDigging(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+
+	// Let the newly generated chunk settle.
+	tickTestShards([]*ChunkShard{shard}, 100)
+	if !chunk.isIdle() {
+		t.Fatalf("Expected settled chunk to be idle")
+	}
+
+	// Stone takes a while to dig by hand.
+	blockLoc := BlockXyz{8, 5, 8}
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeSurvival, DigStarted, &blockLoc, FaceTop)
+	if chunk.isIdle() {
+		t.Errorf("Expected chunk to be busy while a block in it is being dug")
+	}
+}
+
+func TestDigsClearedWhenAbortedOrPlayerLeaves(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.reqSubscribeChunk(1, player, false)
+	blockLoc := BlockXyz{8, 5, 8}
+
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeSurvival, DigStarted, &blockLoc, FaceTop)
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeSurvival, DigAborted, &blockLoc, FaceTop)
+	if len(chunk.digs) != 0 {
+		t.Errorf("Expected aborted dig to be forgotten")
+	}
+
+	chunk.reqHitBlock(player, gamerules.Slot{}, GameTypeSurvival, DigStarted, &blockLoc, FaceTop)
+	chunk.reqUnsubscribeChunk(1, false)
+	if len(chunk.digs) != 0 {
+		t.Errorf("Expected dig to be forgotten when the player leaves")
+	}
+}
@@ -0,0 +1,213 @@
+package shardserver
+
+import (
+	"bytes"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// explode runs an explosion centered within chunk. Blocks and entities in
+// other shards are left to those shards, which the explosion is passed on to
+// in turn.
+func (shard *ChunkShard) explode(chunk *Chunk, center *AbsXyz, power float32) {
+	parts := make(map[uint64]*gamerules.Explosion)
+	partFor := func(shardLoc ShardXz) *gamerules.Explosion {
+		shardKey := shardLoc.Key()
+		part, ok := parts[shardKey]
+		if !ok {
+			part = &gamerules.Explosion{
+				Center: *center,
+				Power:  power,
+				Shard:  shardLoc,
+			}
+			parts[shardKey] = part
+		}
+		return part
+	}
+
+	local := partFor(shard.loc)
+	for _, exploded := range gamerules.TraceExplosion(chunk, center, power) {
+		part := partFor(exploded.Block.ToChunkXz().ToShardXz())
+		part.Blocks = append(part.Blocks, exploded)
+	}
+
+	// Entities can be caught by the explosion in any chunk within its reach.
+	shard.forExplosionChunks(center, power, func(chunkLoc ChunkXz, target *Chunk) {
+		if target == nil {
+			partFor(chunkLoc.ToShardXz())
+		}
+	})
+
+	for _, part := range parts {
+		if part != local {
+			local.Next = append(local.Next, *part)
+		}
+	}
+
+	shard.reqExplode(local)
+}
+
+// explodeBlocks destroys the blocks in the shard that are reached by an
+// explosion strongly enough, returning the blocks that were set to air. The
+// caller must tell players about the changes to those blocks.
+func (shard *ChunkShard) explodeBlocks(blocks []gamerules.ExplodedBlock, power float32) (destroyed []BlockXyz) {
+	for i := range blocks {
+		exploded := &blocks[i]
+
+		chunk, index, _ := shard.blockToUpdate(&exploded.Block)
+		if chunk == nil {
+			continue
+		}
+
+		instance, blockType, ok := chunk.blockInstanceAndType(&exploded.Block)
+		if !ok || !gamerules.ExplosionDestroys(blockType, exploded.Strength) {
+			continue
+		}
+
+		if gamerules.ExplodeBlock(instance, power) {
+			// The block replaced itself.
+			continue
+		}
+
+		chunk.changeBlock(&exploded.Block, &instance.SubLoc, index, BlockIdAir, 0)
+		destroyed = append(destroyed, exploded.Block)
+	}
+
+	return
+}
+
+// reqExplode runs the part of an explosion that reaches this shard, and then
+// passes the explosion on to the next shard that it reaches.
+func (shard *ChunkShard) reqExplode(explosion *gamerules.Explosion) {
+	center, power := &explosion.Center, explosion.Power
+
+	shard.forExplosionChunks(center, power, func(chunkLoc ChunkXz, chunk *Chunk) {
+		if chunk != nil {
+			chunk.explodeEntities(center, power)
+		}
+	})
+
+	destroyed := shard.explodeBlocks(explosion.Blocks, power)
+	notified := shard.multicastExplosion(explosion, destroyed)
+	allDestroyed := make([]BlockXyz, 0, len(explosion.Destroyed)+len(destroyed))
+	allDestroyed = append(append(allDestroyed, explosion.Destroyed...), destroyed...)
+
+	next := explosion.Next
+	for len(next) > 0 {
+		part := next[0]
+		next = next[1:]
+
+		client := shard.clientForShard(part.Shard)
+		if client == nil {
+			continue
+		}
+
+		part.Destroyed = allDestroyed
+		part.Notified = notified
+		part.Next = next
+		client.ReqExplode(part)
+		break
+	}
+}
+
+// forExplosionChunks calls fn for each chunk within reach of an explosion,
+// which covers all of the blocks and entities that it can affect. Chunks in
+// the shard are loaded if needed, and chunk is nil for chunks outside of it.
+func (shard *ChunkShard) forExplosionChunks(center *AbsXyz, power float32, fn func(chunkLoc ChunkXz, chunk *Chunk)) {
+	reach := AbsCoord(2 * power)
+	minLoc := AbsXyz{center.X - reach, center.Y, center.Z - reach}
+	maxLoc := AbsXyz{center.X + reach, center.Y, center.Z + reach}
+	minChunk, maxChunk := minLoc.ToChunkXz(), maxLoc.ToChunkXz()
+	for x := minChunk.X; x <= maxChunk.X; x++ {
+		for z := minChunk.Z; z <= maxChunk.Z; z++ {
+			chunkLoc := ChunkXz{x, z}
+			var chunk *Chunk
+			if _, _, _, ok := shard.chunkIndexAndRelLoc(chunkLoc); ok {
+				chunk = shard.chunkAt(chunkLoc)
+			}
+			fn(chunkLoc, chunk)
+		}
+	}
+}
+
+// multicastExplosion sends an explosion packet to each player watching any
+// chunk of the shard that the explosion reaches, unless the player was sent
+// one by a shard that the explosion passed through before. The packet has the
+// blocks destroyed in this shard and those before it. Players that were sent
+// a packet before are sent the blocks destroyed in this shard as block
+// changes instead. Returns the players that have been sent the packet so far.
+func (shard *ChunkShard) multicastExplosion(explosion *gamerules.Explosion, destroyed []BlockXyz) (notified []EntityId) {
+	notified = explosion.Notified
+	wasNotified := make(map[EntityId]bool)
+	for _, entityId := range notified {
+		wasNotified[entityId] = true
+	}
+
+	players := make(map[EntityId]gamerules.IPlayerClient)
+	shard.forExplosionChunks(&explosion.Center, explosion.Power, func(chunkLoc ChunkXz, chunk *Chunk) {
+		if chunk == nil {
+			return
+		}
+		for entityId, player := range chunk.subscribers {
+			if !wasNotified[entityId] {
+				players[entityId] = player
+			}
+		}
+	})
+
+	if len(players) > 0 {
+		allDestroyed := make([]BlockXyz, 0, len(explosion.Destroyed)+len(destroyed))
+		allDestroyed = append(append(allDestroyed, explosion.Destroyed...), destroyed...)
+		centerBlock := explosion.Center.ToBlockXyz()
+		offsets := make([]proto.ExplosionOffsetXyz, len(allDestroyed))
+		for i := range allDestroyed {
+			offsets[i] = proto.ExplosionOffsetXyz{
+				int8(allDestroyed[i].X - centerBlock.X),
+				int8(allDestroyed[i].Y - centerBlock.Y),
+				int8(allDestroyed[i].Z - centerBlock.Z),
+			}
+		}
+
+		packet := new(bytes.Buffer)
+		proto.WriteExplosion(packet, &explosion.Center, explosion.Power, offsets)
+		for entityId, player := range players {
+			player.TransmitPacket(packet.Bytes())
+			notified = append(notified, entityId)
+		}
+	}
+
+	for i := range destroyed {
+		chunk, _, _ := shard.loadedBlock(&destroyed[i])
+		if chunk == nil {
+			continue
+		}
+		packet := new(bytes.Buffer)
+		proto.WriteBlockChange(packet, &destroyed[i], BlockIdAir, 0)
+		for entityId, player := range chunk.subscribers {
+			if wasNotified[entityId] {
+				player.TransmitPacket(packet.Bytes())
+			}
+		}
+	}
+
+	return
+}
+
+// explodeEntities pushes and hurts the entities in the chunk that are caught
+// by an explosion, removing any that die. Players in the chunk are hurt too.
+func (chunk *Chunk) explodeEntities(center *AbsXyz, power float32) {
+	for _, e := range chunk.entities {
+		if gamerules.ExplodeEntity(e, center, power) {
+			chunk.removeEntity(e)
+		}
+	}
+
+	for entityId, data := range chunk.playersData {
+		damage := gamerules.ExplodePlayer(&data.position, center, power)
+		if player, ok := chunk.subscribers[entityId]; ok && damage > 0 {
+			player.Hurt(damage)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package shardserver
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testBlockIdTnt = BlockId(46)
+
+func testIgniteBlock(t *testing.T, shard *ChunkShard, blockLoc BlockXyz, fuse Ticks) {
+	chunk := shard.chunkAt(*blockLoc.ToChunkXz())
+	if chunk == nil {
+		t.Fatalf("Chunk for %v not available", blockLoc)
+	}
+	instance, blockType, ok := chunk.blockInstanceAndType(&blockLoc)
+	if !ok {
+		t.Fatalf("Block %v not available", blockLoc)
+	}
+	ignitable, ok := blockType.Aspect.(gamerules.IIgnitableAspect)
+	if !ok {
+		t.Fatalf("Block %v is not ignitable", blockLoc)
+	}
+	ignitable.Ignite(instance, fuse)
+}
+
+func countActivatedTnt(shard *ChunkShard, chunkLoc ChunkXz) (count int) {
+	for _, e := range shard.chunkAt(chunkLoc).entities {
+		if _, ok := e.(*gamerules.ActivatedTnt); ok {
+			count++
+		}
+	}
+	return
+}
+
+func TestExplosionAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Shard B starts at X=256.
+	digTestTunnel(t, shardA, 250, 255)
+	digTestTunnel(t, shardB, 256, 262)
+	testSetBlock(t, shardA, BlockXyz{254, 5, 8}, testBlockIdTnt)
+	testSetBlock(t, shardB, BlockXyz{257, 5, 8}, testBlockIdTnt)
+	tickTestShards(shards, 5)
+
+	testIgniteBlock(t, shardA, BlockXyz{254, 5, 8}, 2)
+	if blockId, _, _ := shardA.chunkAt(ChunkXz{15, 0}).BlockAt(&BlockXyz{254, 5, 8}); blockId != BlockIdAir {
+		t.Errorf("Expected ignited TNT to be removed, got block %d", blockId)
+	}
+	if count := countActivatedTnt(shardA, ChunkXz{15, 0}); count != 1 {
+		t.Fatalf("Expected 1 activated TNT in shard A, got %d", count)
+	}
+
+	tickTestShards(shards, 3)
+
+	if count := countActivatedTnt(shardA, ChunkXz{15, 0}); count != 0 {
+		t.Errorf("Expected activated TNT in shard A to have exploded, got %d", count)
+	}
+	if blockId, _, _ := shardA.chunkAt(ChunkXz{15, 0}).BlockAt(&BlockXyz{254, 4, 8}); blockId != BlockIdAir {
+		t.Errorf("Expected explosion to destroy block below it, got block %d", blockId)
+	}
+
+	// The TNT in shard B was set off by the explosion.
+	if blockId, _, _ := shardB.chunkAt(ChunkXz{16, 0}).BlockAt(&BlockXyz{257, 5, 8}); blockId != BlockIdAir {
+		t.Errorf("Expected TNT in shard B to be set off, got block %d", blockId)
+	}
+	if count := countActivatedTnt(shardB, ChunkXz{16, 0}); count != 1 {
+		t.Fatalf("Expected 1 activated TNT in shard B, got %d", count)
+	}
+
+	tickTestShards(shards, 40)
+
+	if count := countActivatedTnt(shardB, ChunkXz{16, 0}); count != 0 {
+		t.Errorf("Expected activated TNT in shard B to have exploded, got %d", count)
+	}
+}
+
+// drainPackets returns the packets that the player has been sent so far by
+// packet ID, discarding all of its events.
+func drainPackets(player *testPlayerClient) map[byte][][]byte {
+	packets := make(map[byte][][]byte)
+	for {
+		select {
+		case event := <-player.events:
+			if e, ok := event.(testPacketEvent); ok && len(e.packet) > 0 {
+				packets[e.packet[0]] = append(packets[e.packet[0]], e.packet)
+			}
+		default:
+			return packets
+		}
+	}
+}
+
+// explosionBlockCount returns the number of destroyed blocks in an explosion
+// packet.
+func explosionBlockCount(packet []byte) int {
+	// The count follows the packet ID, position and power.
+	return int(binary.BigEndian.Uint32(packet[29:33]))
+}
+
+func TestExplosionPacketSentToWatchers(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	digTestTunnel(t, shardA, 250, 255)
+	digTestTunnel(t, shardB, 256, 262)
+
+	// Player 1 watches a chunk next to the one with the explosion, player 2
+	// watches both, player 3 watches a chunk in the other shard, and player 4
+	// watches chunks in both shards.
+	watching := map[EntityId][]*Chunk{
+		1: {shardA.chunkAt(ChunkXz{15, 1})},
+		2: {shardA.chunkAt(ChunkXz{15, 1}), shardA.chunkAt(ChunkXz{15, 0})},
+		3: {shardB.chunkAt(ChunkXz{16, 0})},
+		4: {shardA.chunkAt(ChunkXz{15, 0}), shardB.chunkAt(ChunkXz{16, 0})},
+	}
+	players := make(map[EntityId]*testPlayerClient)
+	for entityId, chunks := range watching {
+		players[entityId] = newTestPlayerClient(entityId)
+		for _, chunk := range chunks {
+			chunk.subscribers[entityId] = players[entityId]
+		}
+	}
+
+	shardA.chunkAt(ChunkXz{15, 0}).Explode(&AbsXyz{254.5, 5.5, 8.5}, 4)
+
+	blockCounts := make(map[EntityId]int)
+	blockChanges := make(map[EntityId]int)
+	for entityId, player := range players {
+		packets := drainPackets(player)
+		explosions := packets[proto.PacketIdExplosion]
+		if len(explosions) != 1 {
+			t.Errorf("Expected player %d to be sent 1 explosion packet, got %d", entityId, len(explosions))
+			continue
+		}
+		blockCounts[entityId] = explosionBlockCount(explosions[0])
+		blockChanges[entityId] = len(packets[proto.PacketIdBlockChange])
+	}
+
+	// The explosion packet is sent by shard A to players watching it, and then
+	// by shard B to the rest, with the blocks destroyed in both. Players sent
+	// the packet by shard A are told about blocks destroyed in shard B as
+	// block changes.
+	destroyedA, destroyedBoth := blockCounts[1], blockCounts[3]
+	if destroyedA == 0 || destroyedBoth <= destroyedA {
+		t.Errorf("Expected blocks destroyed in both shards, got %d in shard A and %d in total",
+			destroyedA, destroyedBoth)
+	}
+	if blockCounts[4] != destroyedA || blockChanges[4] != destroyedBoth-destroyedA {
+		t.Errorf("Expected player 4 to be sent %d blocks and %d block changes, got %d and %d",
+			destroyedA, destroyedBoth-destroyedA, blockCounts[4], blockChanges[4])
+	}
+	if blockChanges[1] != 0 || blockChanges[3] != 0 {
+		t.Errorf("Expected no block changes for players watching a single shard, got %d and %d",
+			blockChanges[1], blockChanges[3])
+	}
+}
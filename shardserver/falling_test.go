@@ -0,0 +1,100 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testBlockIdSand = BlockId(12)
+
+// digTestShaft digs a vertical shaft at X=8, Z=8, deep underground.
+func digTestShaft(t *testing.T, shard *ChunkShard, fromY, toY BlockYCoord) {
+	for y := fromY; y <= toY; y++ {
+		testSetBlock(t, shard, BlockXyz{8, y, 8}, BlockIdAir)
+	}
+}
+
+func testBlockIdAt(shard *ChunkShard, blockLoc BlockXyz) BlockId {
+	blockId, _, _ := shard.chunkAt(ChunkXz{0, 0}).BlockAt(&blockLoc)
+	return blockId
+}
+
+func TestFallingBlockLands(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	digTestShaft(t, shard, 3, 10)
+	testSetBlock(t, shard, BlockXyz{8, 10, 8}, testBlockIdSand)
+	tickTestShards(shards, 2)
+
+	if blockId := testBlockIdAt(shard, BlockXyz{8, 10, 8}); blockId != BlockIdAir {
+		t.Errorf("Expected sand to have fallen, got block %d", blockId)
+	}
+	if len(shard.chunkAt(ChunkXz{0, 0}).entities) != 1 {
+		t.Errorf("Expected a falling object")
+	}
+
+	tickTestShards(shards, 60)
+
+	if blockId := testBlockIdAt(shard, BlockXyz{8, 3, 8}); blockId != testBlockIdSand {
+		t.Errorf("Expected sand to land at the bottom of the shaft, got block %d", blockId)
+	}
+	if len(shard.chunkAt(ChunkXz{0, 0}).entities) != 0 {
+		t.Errorf("Expected falling object to be removed")
+	}
+}
+
+func TestFallingBlockDropsItem(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	digTestShaft(t, shard, 3, 10)
+	testSetBlock(t, shard, BlockXyz{8, 3, 8}, testBlockIdTorch)
+	testSetBlock(t, shard, BlockXyz{8, 10, 8}, testBlockIdSand)
+	tickTestShards(shards, 60)
+
+	if blockId := testBlockIdAt(shard, BlockXyz{8, 3, 8}); blockId != testBlockIdTorch {
+		t.Errorf("Expected torch to remain, got block %d", blockId)
+	}
+
+	items := shard.chunkAt(ChunkXz{0, 0}).items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if items[0].ItemTypeId != ItemTypeId(testBlockIdSand) || items[0].Count != 1 {
+		t.Errorf("Expected sand item, got %+v", items[0].Slot)
+	}
+}
+
+func TestFallingBlockChangesChunk(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	// Falling object thrown sideways towards chunk 1 in a tall tunnel.
+	for y := BlockYCoord(5); y <= 8; y++ {
+		for x := BlockCoord(12); x <= 22; x++ {
+			testSetBlock(t, shard, BlockXyz{x, y, 8}, BlockIdAir)
+		}
+	}
+	position := AbsXyz{14.5, 8.5, 8.5}
+	falling := gamerules.NewFallingBlock(ObjTypeIdFallingSand, testBlockIdSand, &position)
+	falling.PointObject.Init(&position, &AbsVelocity{1.5, 0, 0})
+	shard.chunkAt(ChunkXz{0, 0}).AddEntity(falling)
+	tickTestShards(shards, 60)
+
+	if len(shard.chunkAt(ChunkXz{0, 0}).entities) != 0 || len(shard.chunkAt(ChunkXz{1, 0}).entities) != 0 {
+		t.Errorf("Expected falling object to have landed")
+	}
+
+	found := false
+	for x := BlockCoord(16); x <= 22; x++ {
+		if testBlockIdAt(shard, BlockXyz{x, 5, 8}) == testBlockIdSand {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected sand to land in chunk 1")
+	}
+}
@@ -0,0 +1,51 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdFire         = BlockId(51)
+	testItemIdFlintAndSteel = ItemTypeId(259)
+)
+
+func TestLightFireAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+	player := newTestPlayerClient(1)
+
+	// Shard B starts at X=256. The fire is lit against the stone at the end
+	// of shard A, on the tunnel floor in shard B.
+	digTestTunnel(t, shardB, 256, 262)
+	target := BlockXyz{255, 5, 8}
+	flintAndSteel := gamerules.Slot{ItemTypeId: testItemIdFlintAndSteel, Count: 1}
+	shardA.chunkAt(*target.ToChunkXz()).reqInteractBlock(player, flintAndSteel, &target, FaceSouth)
+
+	if blockId := testLoadedBlockIdAt(t, shardB, BlockXyz{256, 5, 8}); blockId != testBlockIdFire {
+		t.Errorf("Expected fire in shard B, got block %d", blockId)
+	}
+}
+
+func TestRainFallsOnlyOnBlocksOpenToSky(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	digTestTunnel(t, shard, 0, 4)
+
+	surface := BlockXyz{8, BlockYCoord(chunk.heightMap[8*ChunkSizeH+8]), 8}
+	tunnel := BlockXyz{2, 5, 8}
+	if chunk.IsRaining(&surface) {
+		t.Errorf("Expected no rain at %v without rain in the world", surface)
+	}
+
+	shard.raining = true
+	if !chunk.IsRaining(&surface) {
+		t.Errorf("Expected rain on the surface at %v", surface)
+	}
+	if chunk.IsRaining(&tunnel) {
+		t.Errorf("Expected no rain in the tunnel at %v", tunnel)
+	}
+}
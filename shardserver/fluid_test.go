@@ -0,0 +1,197 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdWater       = BlockId(8)
+	testBlockIdStillWater  = BlockId(9)
+	testBlockIdLava        = BlockId(10)
+	testBlockIdCobblestone = BlockId(4)
+	testBlockIdObsidian    = BlockId(49)
+)
+
+func tickTestShards(shards []*ChunkShard, ticks int) {
+	for i := 0; i < ticks; i++ {
+		for _, shard := range shards {
+			shard.tick()
+		}
+	}
+}
+
+func testPlaceFluid(t *testing.T, shard *ChunkShard, blockLoc BlockXyz, blockId BlockId, blockData byte) {
+	chunkLoc, subLoc := blockLoc.ToChunkLocal()
+	chunk := shard.chunkAt(*chunkLoc)
+	if chunk == nil {
+		t.Fatalf("Chunk %v not available", *chunkLoc)
+	}
+	index, _ := subLoc.BlockIndex()
+	chunk.SetBlockByIndex(index, blockId, blockData)
+	chunk.AddActiveBlockIndex(index)
+}
+
+type testBlock struct {
+	blockId   BlockId
+	blockData byte
+}
+
+// checkTunnelBlocks checks blocks in a tunnel made by digTestTunnel.
+func checkTunnelBlocks(t *testing.T, shard *ChunkShard, expected map[BlockCoord]testBlock) {
+	for x, block := range expected {
+		blockId, blockData, ok := shard.chunkAt(ChunkXz{0, 0}).BlockAt(&BlockXyz{x, 5, 8})
+		if !ok {
+			t.Errorf("Block at x=%d not loaded", x)
+		} else if blockId != block.blockId || blockData != block.blockData {
+			t.Errorf("Expected block %d:%d at x=%d, got %d:%d",
+				block.blockId, block.blockData, x, blockId, blockData)
+		}
+	}
+}
+
+func TestFluidSpreadsAndDries(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	// The tunnel crosses from chunk 0 into chunk 1.
+	digTestTunnel(t, shard, 10, 24)
+	testPlaceFluid(t, shard, BlockXyz{12, 5, 8}, testBlockIdStillWater, 0)
+	tickTestShards(shards, 60)
+
+	checkTunnelBlocks(t, shard, map[BlockCoord]testBlock{
+		10: {testBlockIdWater, 2},
+		11: {testBlockIdWater, 1},
+		12: {testBlockIdStillWater, 0},
+		13: {testBlockIdWater, 1},
+		16: {testBlockIdWater, 4},
+		19: {testBlockIdWater, 7},
+		20: {BlockIdAir, 0},
+	})
+
+	testSetBlock(t, shard, BlockXyz{12, 5, 8}, BlockIdAir)
+	tickTestShards(shards, 400)
+
+	checkTunnelBlocks(t, shard, map[BlockCoord]testBlock{
+		10: {BlockIdAir, 0},
+		13: {BlockIdAir, 0},
+		16: {BlockIdAir, 0},
+		19: {BlockIdAir, 0},
+	})
+}
+
+func TestFluidCreatesSource(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	digTestTunnel(t, shard, 10, 14)
+	testPlaceFluid(t, shard, BlockXyz{11, 5, 8}, testBlockIdStillWater, 0)
+	testPlaceFluid(t, shard, BlockXyz{13, 5, 8}, testBlockIdStillWater, 0)
+	tickTestShards(shards, 20)
+
+	checkTunnelBlocks(t, shard, map[BlockCoord]testBlock{
+		12: {testBlockIdWater, 0},
+	})
+}
+
+func TestFluidsHarden(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+
+	digTestTunnel(t, shard, 10, 20)
+	// Lava source next to where water will flow.
+	testPlaceFluid(t, shard, BlockXyz{10, 5, 8}, testBlockIdLava, 0)
+	testPlaceFluid(t, shard, BlockXyz{12, 5, 8}, testBlockIdStillWater, 0)
+	// Flowing lava next to a water source.
+	testPlaceFluid(t, shard, BlockXyz{17, 5, 8}, testBlockIdLava, 2)
+	testPlaceFluid(t, shard, BlockXyz{18, 5, 8}, testBlockIdStillWater, 0)
+	tickTestShards(shards, 40)
+
+	checkTunnelBlocks(t, shard, map[BlockCoord]testBlock{
+		10: {testBlockIdObsidian, 0},
+		17: {testBlockIdCobblestone, 0},
+	})
+}
+
+func TestFluidAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Shard B starts at X=256.
+	digTestTunnel(t, shardA, 250, 255)
+	digTestTunnel(t, shardB, 256, 262)
+	testPlaceFluid(t, shardA, BlockXyz{252, 5, 8}, testBlockIdStillWater, 0)
+	tickTestShards(shards, 60)
+
+	blockId, blockData, _ := shardB.chunkAt(ChunkXz{16, 0}).BlockAt(&BlockXyz{256, 5, 8})
+	if blockId != testBlockIdWater || blockData != 4 {
+		t.Errorf("Expected water level 4 in shard B, got %d:%d", blockId, blockData)
+	}
+	blockId, blockData, _ = shardB.chunkAt(ChunkXz{16, 0}).BlockAt(&BlockXyz{259, 5, 8})
+	if blockId != testBlockIdWater || blockData != 7 {
+		t.Errorf("Expected water level 7 in shard B, got %d:%d", blockId, blockData)
+	}
+	blockId, _, _ = shardB.chunkAt(ChunkXz{16, 0}).BlockAt(&BlockXyz{260, 5, 8})
+	if blockId != BlockIdAir {
+		t.Errorf("Expected water to stop in shard B, got block %d", blockId)
+	}
+}
+
+func TestFluidDriesAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Shard B starts at X=256.
+	digTestTunnel(t, shardA, 250, 255)
+	digTestTunnel(t, shardB, 256, 262)
+	testPlaceFluid(t, shardA, BlockXyz{252, 5, 8}, testBlockIdStillWater, 0)
+	tickTestShards(shards, 60)
+
+	testSetBlock(t, shardA, BlockXyz{252, 5, 8}, BlockIdAir)
+	tickTestShards(shards, 400)
+
+	for x := BlockCoord(253); x <= 259; x++ {
+		blockLoc := BlockXyz{x, 5, 8}
+		shard := shardA
+		if x >= 256 {
+			shard = shardB
+		}
+		blockId, _, _ := shard.chunkAt(*blockLoc.ToChunkXz()).BlockAt(&blockLoc)
+		if blockId != BlockIdAir {
+			t.Errorf("Expected water at x=%d to dry up, got block %d", x, blockId)
+		}
+	}
+}
+
+func TestPlayerDrowningInWater(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	digTestTunnel(t, shard, 6, 10)
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.reqSubscribeChunk(1, player, false)
+	chunk.reqAddPlayerData(1, "test", AbsXyz{8.5, 5, 8.5}, LookBytes{}, gamerules.PlayerAppearance{})
+
+	waitForSurroundings := func() gamerules.Surroundings {
+		tickTestShards([]*ChunkShard{shard}, 1)
+		event := player.waitFor(func(event interface{}) bool {
+			_, ok := event.(testSurroundingsEvent)
+			return ok
+		})
+		if event == nil {
+			t.Fatal("Expected player to be told their surroundings")
+		}
+		return event.(testSurroundingsEvent).surroundings
+	}
+
+	if s := waitForSurroundings(); s != (gamerules.Surroundings{Supported: true}) {
+		t.Errorf("Expected harmless surroundings on the tunnel floor, got %+v", s)
+	}
+
+	testSetBlock(t, shard, BlockXyz{8, 5, 8}, testBlockIdStillWater)
+	testSetBlock(t, shard, BlockXyz{8, 6, 8}, testBlockIdStillWater)
+	if s := waitForSurroundings(); !s.Wet || !s.Drowning {
+		t.Errorf("Expected player to be drowning in water, got %+v", s)
+	}
+}
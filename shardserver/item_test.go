@@ -0,0 +1,125 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestItemsMerge(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	chunk := shards[0].chunkAt(ChunkXz{0, 0})
+
+	position := AbsXyz{8.5, 120, 8.5}
+	for _, count := range []ItemCount{40, 30, 5} {
+		chunk.AddEntity(gamerules.NewItem(4, count, 0, &position, &AbsVelocity{}, 0))
+	}
+	chunk.AddEntity(gamerules.NewItem(4, 1, 1, &position, &AbsVelocity{}, 0))
+	tickTestShards(shards, 1)
+
+	items := chunk.items()
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	var stacks []ItemCount
+	for _, item := range items {
+		if item.Data == 1 {
+			if item.Count != 1 {
+				t.Errorf("Expected item with other data to be left alone, got %+v", item.Slot)
+			}
+			continue
+		}
+		stacks = append(stacks, item.Count)
+	}
+	if len(stacks) != 2 || stacks[0]+stacks[1] != 75 || (stacks[0] != 64 && stacks[1] != 64) {
+		t.Errorf("Expected stacks of 64 and 11, got %v", stacks)
+	}
+}
+
+func TestItemsMergeAcrossBlocks(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	chunk := shards[0].chunkAt(ChunkXz{0, 0})
+
+	// The first two items are in neighbouring blocks, but close enough to
+	// merge. The third is too far away.
+	for _, x := range []AbsCoord{8.9, 9.1, 10.5} {
+		position := AbsXyz{x, 120, 8.5}
+		chunk.AddEntity(gamerules.NewItem(4, 10, 0, &position, &AbsVelocity{}, 0))
+	}
+	tickTestShards(shards, 1)
+
+	items := chunk.items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	for _, item := range items {
+		if position := item.Position(); position.X < 10 {
+			if item.Count != 20 {
+				t.Errorf("Expected neighbouring items to merge into a stack of 20, got %d", item.Count)
+			}
+		} else if item.Count != 10 {
+			t.Errorf("Expected distant item to be left alone, got %d", item.Count)
+		}
+	}
+}
+
+func TestItemDespawns(t *testing.T) {
+	defer func(secs int) { *itemLifetimeSecs = secs }(*itemLifetimeSecs)
+	*itemLifetimeSecs = 1
+
+	shards := newTestShards(ShardXz{0, 0})
+	chunk := shards[0].chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.subscribers[1] = player
+
+	item := gamerules.NewItem(4, 1, 0, &AbsXyz{8.5, 120, 8.5}, &AbsVelocity{}, 0)
+	chunk.AddEntity(item)
+
+	tickTestShards(shards, TicksPerSecond-1)
+	if len(chunk.items()) != 1 {
+		t.Fatalf("Expected item to remain until its lifetime is up")
+	}
+
+	tickTestShards(shards, 1)
+	if len(chunk.items()) != 0 {
+		t.Fatalf("Expected item to have despawned")
+	}
+	event := player.waitFor(func(event interface{}) bool {
+		e, ok := event.(testEntityDestroyEvent)
+		return ok && e.entityId == item.GetEntityId()
+	})
+	if event == nil {
+		t.Errorf("Expected item to be destroyed for subscribers")
+	}
+}
+
+func TestItemKeptWhenTransferFails(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+	connecter := shardA.shardConnecter.(testShardConnecter)
+
+	// Shard B can't be connected to at first.
+	delete(connecter, shardB.loc.Key())
+
+	fromLoc := ChunkXz{ShardSize - 1, 0}
+	toLoc := ChunkXz{ShardSize, 0}
+	from := shardA.chunkAt(fromLoc)
+	// The item has moved over the edge of the shard.
+	item := gamerules.NewItem(4, 1, 0, &AbsXyz{ShardSize*ChunkSizeH + 0.5, 120, 8.5}, &AbsVelocity{}, 0)
+	from.AddEntity(item)
+
+	tickTestShards(shards, 2)
+	if _, ok := from.entities[item.GetEntityId()]; !ok {
+		t.Fatalf("Expected item to be kept when it can't be transferred")
+	}
+
+	connecter[shardB.loc.Key()] = shardB
+	tickTestShards(shards, 1)
+	if _, ok := from.entities[item.GetEntityId()]; ok {
+		t.Errorf("Expected item to be removed once transferred")
+	}
+	if _, ok := shardB.chunkAt(toLoc).entities[item.GetEntityId()]; !ok {
+		t.Errorf("Expected item to be transferred to chunk %v", toLoc)
+	}
+}
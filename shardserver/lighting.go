@@ -0,0 +1,256 @@
+package shardserver
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Light level of blocks that can see the sky.
+const maxLightLevel = 15
+
+// lightNode is a block queued for light propagation.
+type lightNode struct {
+	loc   BlockXyz
+	level int8 // Old light level when decreasing, otherwise the new level.
+}
+
+// lighting incrementally propagates changes to either SkyLight or BlockLight
+// between the loaded chunks of a shard. Changes that reach the edge of the
+// shard are queued to be sent to the neighbouring shard.
+//
+// Light is removed by a breadth first search from the darkened block, which
+// clears blocks that were lit less brightly than it, and collects the blocks
+// around the edge of the darkened area. Light is then spread back in from
+// those blocks and from any newly lit blocks.
+type lighting struct {
+	shard    *ChunkShard
+	sky      bool
+	decrease []lightNode
+	increase []lightNode
+}
+
+func (l *lighting) Init(shard *ChunkShard, sky bool) {
+	l.shard = shard
+	l.sky = sky
+}
+
+// relight resets the light level of the block to that of its own light
+// source, and queues the change to be propagated.
+func (l *lighting) relight(chunk *Chunk, index BlockIndex, loc *BlockXyz) {
+	oldLevel := chunk.lightLevel(index, l.sky)
+	newLevel := chunk.lightSource(index, l.sky)
+	chunk.setLightLevel(index, l.sky, newLevel)
+
+	// Also causes neighbours to light the block if they are able to.
+	l.decrease = append(l.decrease, lightNode{*loc, oldLevel})
+
+	if newLevel > 0 {
+		l.increase = append(l.increase, lightNode{*loc, newLevel})
+	}
+}
+
+// neighbourDecreased is called when a neighbouring block's light level has
+// gone down from fromLevel.
+func (l *lighting) neighbourDecreased(chunk *Chunk, index BlockIndex, loc *BlockXyz, fromLevel int8) {
+	level := chunk.lightLevel(index, l.sky)
+	if level == 0 {
+		return
+	}
+
+	if level < fromLevel {
+		// The block might have been lit by the neighbour.
+		l.decrease = append(l.decrease, lightNode{*loc, level})
+
+		source := chunk.lightSource(index, l.sky)
+		chunk.setLightLevel(index, l.sky, source)
+		if source > 0 {
+			l.increase = append(l.increase, lightNode{*loc, source})
+		}
+	} else {
+		// The block is lit independently, and can light the darkened area.
+		l.increase = append(l.increase, lightNode{*loc, level})
+	}
+}
+
+// neighbourIncreased is called when a neighbouring block's light level has
+// gone up to fromLevel.
+func (l *lighting) neighbourIncreased(chunk *Chunk, index BlockIndex, loc *BlockXyz, fromLevel int8) {
+	attenuation := chunk.blockOpacity(index)
+	if attenuation < 1 {
+		attenuation = 1
+	}
+
+	level := fromLevel - attenuation
+	if level > chunk.lightLevel(index, l.sky) {
+		chunk.setLightLevel(index, l.sky, level)
+		l.increase = append(l.increase, lightNode{*loc, level})
+	}
+}
+
+// propagate processes the queued changes until there are none left.
+func (l *lighting) propagate() {
+	for len(l.decrease) > 0 {
+		node := l.decrease[0]
+		l.decrease = l.decrease[1:]
+		l.forNeighbours(&node.loc, node.level, true, l.neighbourDecreased)
+	}
+
+	for len(l.increase) > 0 {
+		node := l.increase[0]
+		l.increase = l.increase[1:]
+
+		// The level might have changed since the block was queued.
+		chunk, index, inShard := l.shard.loadedBlock(&node.loc)
+		if !inShard || chunk == nil {
+			continue
+		}
+		level := chunk.lightLevel(index, l.sky)
+
+		l.forNeighbours(&node.loc, level, false, l.neighbourIncreased)
+	}
+
+	l.decrease = l.decrease[:0]
+	l.increase = l.increase[:0]
+}
+
+// forNeighbours calls fn for each neighbour of the block that is in a loaded
+// chunk in the shard. Updates to neighbours in chunks that are not loaded are
+// deferred until they are. Neighbours in other shards are sent a LightUpdate.
+func (l *lighting) forNeighbours(loc *BlockXyz, level int8, decrease bool, fn func(chunk *Chunk, index BlockIndex, loc *BlockXyz, fromLevel int8)) {
+	for _, offset := range neighbourOffsets {
+		neighbourLoc := loc.AddXyz(offset.dx, offset.dy, offset.dz)
+		if neighbourLoc == nil {
+			continue
+		}
+
+		chunk, index, inShard := l.shard.loadedBlock(neighbourLoc)
+		if chunk != nil {
+			fn(chunk, index, neighbourLoc, level)
+			continue
+		}
+
+		update := gamerules.LightUpdate{
+			Block:    *neighbourLoc,
+			Level:    level,
+			Sky:      l.sky,
+			Decrease: decrease,
+		}
+		if inShard {
+			chunkIndex, _, _, _ := l.shard.chunkIndexAndRelLoc(*neighbourLoc.ToChunkXz())
+			l.shard.deferLightUpdate(chunkIndex, &update)
+		} else {
+			l.shard.queueLightUpdate(update)
+		}
+	}
+}
+
+// updateBlockLight recomputes the light of a block that has just been
+// changed, and propagates the results to the blocks around it.
+func (chunk *Chunk) updateBlockLight(blockLoc *BlockXyz, subLoc *SubChunkXyz, index BlockIndex) {
+	sky := &chunk.shard.skyLighting
+
+	// Blocks in the column that have gained or lost sight of the sky.
+	fromY, toY := chunk.updateHeightMap(subLoc)
+	for y := fromY; y < toY; y++ {
+		columnSubLoc := SubChunkXyz{subLoc.X, SubChunkCoord(y), subLoc.Z}
+		columnIndex, _ := columnSubLoc.BlockIndex()
+		sky.relight(chunk, columnIndex, chunk.loc.ToBlockXyz(&columnSubLoc))
+	}
+
+	sky.relight(chunk, index, blockLoc)
+	sky.propagate()
+
+	block := &chunk.shard.blockLighting
+	block.relight(chunk, index, blockLoc)
+	block.propagate()
+}
+
+// updateHeightMap updates the height map for the column containing the given
+// block. The blocks from fromY up to (but not including) toY are those that
+// have changed between seeing the sky and not.
+func (chunk *Chunk) updateHeightMap(subLoc *SubChunkXyz) (fromY, toY int) {
+	heightIndex := int(subLoc.X)*ChunkSizeH + int(subLoc.Z)
+	height := int(chunk.heightMap[heightIndex])
+	y := int(subLoc.Y)
+	index, _ := subLoc.BlockIndex()
+
+	if chunk.blockOpacity(index) > 0 {
+		if y < height {
+			return
+		}
+		// The block now shades those below it.
+		chunk.heightMap[heightIndex] = byte(y + 1)
+		return height, y
+	}
+
+	if y != height-1 {
+		return
+	}
+
+	// The top shading block was removed. Find the next one down.
+	newHeight := y
+	for ; newHeight > 0; newHeight-- {
+		belowSubLoc := SubChunkXyz{subLoc.X, SubChunkCoord(newHeight - 1), subLoc.Z}
+		belowIndex, _ := belowSubLoc.BlockIndex()
+		if chunk.blockOpacity(belowIndex) > 0 {
+			break
+		}
+	}
+	chunk.heightMap[heightIndex] = byte(newHeight)
+
+	return newHeight, y
+}
+
+func (chunk *Chunk) lightData(sky bool) []byte {
+	if sky {
+		return chunk.skyLight
+	}
+	return chunk.blockLight
+}
+
+func (chunk *Chunk) lightLevel(index BlockIndex, sky bool) int8 {
+	return int8(index.BlockData(chunk.lightData(sky)))
+}
+
+func (chunk *Chunk) setLightLevel(index BlockIndex, sky bool, level int8) {
+	if level < 0 {
+		level = 0
+	}
+
+	lightData := chunk.lightData(sky)
+	if int8(index.BlockData(lightData)) == level {
+		return
+	}
+	index.SetBlockData(lightData, byte(level))
+
+	chunk.cachedPacket = nil
+	chunk.storeDirty = true
+}
+
+// lightSource returns the light level that the block has regardless of the
+// blocks around it. For SkyLight this is the full level if the block can see
+// the sky, and for BlockLight this is the light emitted by the block.
+func (chunk *Chunk) lightSource(index BlockIndex, sky bool) int8 {
+	if sky {
+		subLoc := index.ToSubChunkXyz()
+		height := chunk.heightMap[int(subLoc.X)*ChunkSizeH+int(subLoc.Z)]
+		if byte(subLoc.Y) >= height {
+			return maxLightLevel
+		}
+		return 0
+	}
+
+	if blockType, ok := gamerules.Blocks.Get(index.BlockId(chunk.blocks)); ok {
+		return blockType.Emission
+	}
+	return 0
+}
+
+// blockOpacity returns how much light is absorbed by the block. Unknown
+// blocks are assumed to be opaque.
+func (chunk *Chunk) blockOpacity(index BlockIndex) int8 {
+	if blockType, ok := gamerules.Blocks.Get(index.BlockId(chunk.blocks)); ok {
+		return blockType.Opacity
+	}
+	return maxLightLevel
+}
@@ -0,0 +1,179 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/chunkstore"
+	"github.com/huin/chunkymonkey/entity"
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/generation"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdTorch = BlockId(50)
+	testBlockIdStone = BlockId(1)
+)
+
+// testShardConnecter connects test shards directly to each other.
+type testShardConnecter map[uint64]*ChunkShard
+
+func (connecter testShardConnecter) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+	return nil
+}
+
+func (connecter testShardConnecter) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+	if shard, ok := connecter[shardLoc.Key()]; ok {
+		return &shard.selfClient
+	}
+	return nil
+}
+
+// newTestShards creates shards that are not served, so that their methods
+// can be called directly. Requests between the shards are performed
+// immediately.
+func newTestShards(locs ...ShardXz) (shards []*ChunkShard) {
+	store := chunkstore.NewChunkService(generation.NewTestGenerator(0))
+	go store.Serve()
+
+	entityMgr := new(entity.EntityManager)
+	entityMgr.Init()
+	connecter := make(testShardConnecter)
+
+	for _, loc := range locs {
+		shard := NewChunkShard(connecter, store, entityMgr, loc)
+		connecter[loc.Key()] = shard
+		shards = append(shards, shard)
+	}
+	return
+}
+
+func testSetBlock(t *testing.T, shard *ChunkShard, blockLoc BlockXyz, blockId BlockId) {
+	chunkLoc, subLoc := blockLoc.ToChunkLocal()
+	chunk := shard.chunkAt(*chunkLoc)
+	if chunk == nil {
+		t.Fatalf("Chunk %v not available", *chunkLoc)
+	}
+	index, _ := subLoc.BlockIndex()
+	chunk.SetBlockByIndex(index, blockId, 0)
+}
+
+func testLightLevel(t *testing.T, shard *ChunkShard, blockLoc BlockXyz, sky bool) int8 {
+	chunk, index, inShard := shard.loadedBlock(&blockLoc)
+	if !inShard || chunk == nil {
+		t.Fatalf("Block %v not loaded in %v", blockLoc, shard)
+	}
+	return chunk.lightLevel(index, sky)
+}
+
+// digTestTunnel digs a tunnel along the X axis, deep underground.
+func digTestTunnel(t *testing.T, shard *ChunkShard, fromX, toX BlockCoord) {
+	for x := fromX; x <= toX; x++ {
+		testSetBlock(t, shard, BlockXyz{x, 5, 8}, BlockIdAir)
+	}
+}
+
+func checkBlockLight(t *testing.T, shard *ChunkShard, expected map[BlockCoord]int8) {
+	for x, level := range expected {
+		if result := testLightLevel(t, shard, BlockXyz{x, 5, 8}, false); result != level {
+			t.Errorf("Expected BlockLight %d at x=%d, got %d", level, x, result)
+		}
+	}
+}
+
+// transferTestLightUpdates performs the light updates queued in from for to.
+func transferTestLightUpdates(from, to *ChunkShard) {
+	if lightShard, ok := from.newLightUpdates[to.loc.Key()]; ok {
+		delete(from.newLightUpdates, to.loc.Key())
+		to.reqUpdateLight(lightShard.updates)
+	}
+}
+
+func TestLightingTorch(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+
+	// The tunnel crosses from chunk 0 into chunk 1.
+	digTestTunnel(t, shard, 8, 24)
+	checkBlockLight(t, shard, map[BlockCoord]int8{8: 0, 16: 0})
+
+	testSetBlock(t, shard, BlockXyz{8, 5, 8}, testBlockIdTorch)
+	checkBlockLight(t, shard, map[BlockCoord]int8{
+		8: 14, 9: 13, 15: 7, 16: 6, 21: 1, 22: 0,
+	})
+
+	// Light does not pass into the stone around the tunnel.
+	if level := testLightLevel(t, shard, BlockXyz{8, 5, 9}, false); level != 0 {
+		t.Errorf("Expected no BlockLight in stone, got %d", level)
+	}
+
+	// Dig a side passage that is lit from the tunnel.
+	testSetBlock(t, shard, BlockXyz{10, 5, 9}, BlockIdAir)
+	if level := testLightLevel(t, shard, BlockXyz{10, 5, 9}, false); level != 11 {
+		t.Errorf("Expected BlockLight 11 in side passage, got %d", level)
+	}
+
+	testSetBlock(t, shard, BlockXyz{8, 5, 8}, BlockIdAir)
+	checkBlockLight(t, shard, map[BlockCoord]int8{8: 0, 9: 0, 16: 0, 21: 0})
+	if level := testLightLevel(t, shard, BlockXyz{10, 5, 9}, false); level != 0 {
+		t.Errorf("Expected no BlockLight in side passage, got %d", level)
+	}
+}
+
+func TestLightingSky(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	chunk.storeDirty = false
+	chunk.chunkPacket()
+
+	roof := BlockXyz{8, 120, 8}
+	below := BlockXyz{8, 119, 8}
+	heightIndex := 8*ChunkSizeH + 8
+
+	testSetBlock(t, shard, roof, testBlockIdStone)
+
+	if height := chunk.heightMap[heightIndex]; height != 121 {
+		t.Errorf("Expected height 121, got %d", height)
+	}
+	if level := testLightLevel(t, shard, below, true); level != 14 {
+		t.Errorf("Expected SkyLight 14 under roof, got %d", level)
+	}
+	if level := testLightLevel(t, shard, roof, true); level != 0 {
+		t.Errorf("Expected SkyLight 0 in roof, got %d", level)
+	}
+	if chunk.cachedPacket != nil || !chunk.storeDirty {
+		t.Errorf("Expected chunk to need resending and saving")
+	}
+
+	testSetBlock(t, shard, roof, BlockIdAir)
+
+	if height := chunk.heightMap[heightIndex]; height >= 120 {
+		t.Errorf("Expected height to be restored, got %d", height)
+	}
+	if level := testLightLevel(t, shard, below, true); level != maxLightLevel {
+		t.Errorf("Expected SkyLight %d without roof, got %d", maxLightLevel, level)
+	}
+}
+
+func TestLightingAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Shard B starts at X=256.
+	digTestTunnel(t, shardA, 248, 255)
+	digTestTunnel(t, shardB, 256, 264)
+	transferTestLightUpdates(shardA, shardB)
+	transferTestLightUpdates(shardB, shardA)
+
+	testSetBlock(t, shardA, BlockXyz{250, 5, 8}, testBlockIdTorch)
+	transferTestLightUpdates(shardA, shardB)
+
+	checkBlockLight(t, shardA, map[BlockCoord]int8{250: 14, 255: 9})
+	checkBlockLight(t, shardB, map[BlockCoord]int8{256: 8, 260: 4, 264: 0})
+
+	testSetBlock(t, shardA, BlockXyz{250, 5, 8}, BlockIdAir)
+	transferTestLightUpdates(shardA, shardB)
+	transferTestLightUpdates(shardB, shardA)
+
+	checkBlockLight(t, shardA, map[BlockCoord]int8{250: 0, 255: 0})
+	checkBlockLight(t, shardB, map[BlockCoord]int8{256: 0, 260: 0})
+}
@@ -7,13 +7,15 @@ import (
 
 // localPlayerShardClient implements IPlayerShardClient for LocalShardManager.
 type localPlayerShardClient struct {
+	mgr      *LocalShardManager
 	entityId EntityId
 	player   gamerules.IPlayerClient
 	shard    *ChunkShard
 }
 
-func newLocalPlayerShardClient(entityId EntityId, player gamerules.IPlayerClient, shard *ChunkShard) *localPlayerShardClient {
+func newLocalPlayerShardClient(mgr *LocalShardManager, entityId EntityId, player gamerules.IPlayerClient, shard *ChunkShard) *localPlayerShardClient {
 	return &localPlayerShardClient{
+		mgr:      mgr,
 		entityId: entityId,
 		player:   player,
 		shard:    shard,
@@ -26,6 +28,12 @@ func (conn *localPlayerShardClient) Disconnect() {
 	conn.shard.enqueueAllChunks(func(chunk *Chunk) {
 		chunk.reqUnsubscribeChunk(conn.entityId, false)
 	})
+	conn.mgr.disconnect(conn.shard)
+}
+
+// stopped implements iStoppableClient.stopped.
+func (conn *localPlayerShardClient) stopped() <-chan bool {
+	return conn.shard.done
 }
 
 func (conn *localPlayerShardClient) ReqSubscribeChunk(chunkLoc ChunkXz, notify bool) {
@@ -46,15 +54,15 @@ func (conn *localPlayerShardClient) ReqMulticastPlayers(chunkLoc ChunkXz, exclud
 	})
 }
 
-func (conn *localPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, held ItemTypeId) {
+func (conn *localPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, appearance gamerules.PlayerAppearance) {
 	conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
-		chunk.reqAddPlayerData(conn.entityId, name, position, look, held)
+		chunk.reqAddPlayerData(conn.entityId, name, position, look, appearance)
 	})
 }
 
-func (conn *localPlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, isDisconnect bool) {
+func (conn *localPlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, newChunkLoc ChunkXz, isDisconnect bool) {
 	conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
-		chunk.reqRemovePlayerData(conn.entityId, isDisconnect)
+		chunk.reqRemovePlayerData(conn.entityId, newChunkLoc, isDisconnect)
 	})
 }
 
@@ -70,11 +78,17 @@ func (conn *localPlayerShardClient) ReqSetPlayerLook(chunkLoc ChunkXz, look Look
 	})
 }
 
-func (conn *localPlayerShardClient) ReqHitBlock(held gamerules.Slot, target BlockXyz, digStatus DigStatus, face Face) {
+func (conn *localPlayerShardClient) ReqSetPlayerAppearance(chunkLoc ChunkXz, appearance gamerules.PlayerAppearance) {
+	conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+		chunk.reqSetPlayerAppearance(conn.entityId, appearance)
+	})
+}
+
+func (conn *localPlayerShardClient) ReqHitBlock(held gamerules.Slot, gameType GameType, target BlockXyz, digStatus DigStatus, face Face) {
 	chunkLoc := target.ToChunkXz()
 
 	conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
-		chunk.reqHitBlock(conn.player, held, digStatus, &target, face)
+		chunk.reqHitBlock(conn.player, held, gameType, digStatus, &target, face)
 	})
 }
 
@@ -86,6 +100,12 @@ func (conn *localPlayerShardClient) ReqInteractBlock(held gamerules.Slot, target
 	})
 }
 
+func (conn *localPlayerShardClient) ReqHitEntity(chunkLoc ChunkXz, target EntityId, held gamerules.Slot, position AbsXyz, pvp bool) {
+	conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+		chunk.reqHitEntity(conn.player, target, &held, &position, pvp)
+	})
+}
+
 func (conn *localPlayerShardClient) ReqPlaceItem(target BlockXyz, slot gamerules.Slot) {
 	chunkLoc, _ := target.ToChunkLocal()
 
@@ -114,9 +134,23 @@ func (conn *localPlayerShardClient) ReqInventoryClick(block BlockXyz, click game
 	})
 }
 
+func (conn *localPlayerShardClient) ReqInventoryPutItem(block BlockXyz, item gamerules.Slot) {
+	chunkLoc := block.ToChunkXz()
+	conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
+		chunk.reqInventoryPutItem(conn.player, &block, &item)
+	})
+}
+
 func (conn *localPlayerShardClient) ReqInventoryUnsubscribed(block BlockXyz) {
 	chunkLoc := block.ToChunkXz()
 	conn.shard.enqueueOnChunk(*chunkLoc, func(chunk *Chunk) {
 		chunk.reqInventoryUnsubscribed(conn.player, &block)
 	})
 }
+
+func (conn *localPlayerShardClient) ReqMakePortal(position AbsXyz, look LookDegrees) {
+	chunkLoc := position.ToChunkXz()
+	conn.shard.enqueueOnChunk(chunkLoc, func(chunk *Chunk) {
+		chunk.reqMakePortal(conn.player, &position, look)
+	})
+}
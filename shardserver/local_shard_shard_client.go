@@ -1,22 +1,32 @@
 package shardserver
 
 import (
+	"log"
+
 	"github.com/huin/chunkymonkey/gamerules"
 	. "github.com/huin/chunkymonkey/types"
 )
 
 // localShardShardClient implements IShardShardClient for LocalShardManager.
 type localShardShardClient struct {
+	mgr         *LocalShardManager
 	serverShard *ChunkShard
 }
 
-func newLocalShardShardClient(serverShard *ChunkShard) *localShardShardClient {
+func newLocalShardShardClient(mgr *LocalShardManager, serverShard *ChunkShard) *localShardShardClient {
 	return &localShardShardClient{
+		mgr:         mgr,
 		serverShard: serverShard,
 	}
 }
 
 func (client *localShardShardClient) Disconnect() {
+	client.mgr.disconnect(client.serverShard)
+}
+
+// stopped implements iStoppableClient.stopped.
+func (client *localShardShardClient) stopped() <-chan bool {
+	return client.serverShard.done
 }
 
 func (client *localShardShardClient) ReqSetActiveBlocks(blocks []BlockXyz) {
@@ -25,11 +35,53 @@ func (client *localShardShardClient) ReqSetActiveBlocks(blocks []BlockXyz) {
 	})
 }
 
-func (client *localShardShardClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) {
-	client.serverShard.enqueue(func() {
-		chunk := client.serverShard.chunkAt(loc)
-		if chunk != nil {
-			chunk.transferEntity(entity)
+func (client *localShardShardClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) bool {
+	return client.serverShard.enqueue(func() {
+		if !client.serverShard.selfClient.ReqTransferEntity(loc, entity) {
+			log.Printf("%v: lost entity %d transferred to unavailable chunk %v",
+				client.serverShard, entity.GetEntityId(), loc)
 		}
 	})
 }
+
+func (client *localShardShardClient) ReqUpdateLight(updates []gamerules.LightUpdate) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqUpdateLight(updates)
+	})
+}
+
+func (client *localShardShardClient) ReqSpreadBlocks(spreads []gamerules.BlockSpread) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqSpreadBlocks(spreads)
+	})
+}
+
+func (client *localShardShardClient) ReqPlaceBlocks(placements []gamerules.BlockPlacement) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqPlaceBlocks(placements)
+	})
+}
+
+func (client *localShardShardClient) ReqSetRedstonePower(powers []gamerules.RedstonePower) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqSetRedstonePower(powers)
+	})
+}
+
+func (client *localShardShardClient) ReqSetBorderBlocks(blocks []gamerules.BorderBlock) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqSetBorderBlocks(blocks)
+	})
+}
+
+func (client *localShardShardClient) ReqLightFire(blockLoc BlockXyz) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.lightFire(&blockLoc)
+	})
+}
+
+func (client *localShardShardClient) ReqExplode(explosion gamerules.Explosion) {
+	client.serverShard.enqueue(func() {
+		client.serverShard.reqExplode(&explosion)
+	})
+}
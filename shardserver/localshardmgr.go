@@ -1,6 +1,7 @@
 package shardserver
 
 import (
+	"log"
 	"sync"
 
 	"github.com/huin/chunkymonkey/chunkstore"
@@ -9,62 +10,252 @@ import (
 	. "github.com/huin/chunkymonkey/types"
 )
 
+// IShardOwnership tells a LocalShardManager which shards it owns, when other
+// hosts serve shards from the same chunk store. Only owned shards are served,
+// so that no two hosts write the same chunks.
+type IShardOwnership interface {
+	// OwnsShard returns true if the shard is owned. It does not block.
+	OwnsShard(loc ShardXz) bool
+
+	// ConfirmShard returns true if the shard is owned. Unlike OwnsShard, it
+	// might block to find out if the shard has been newly assigned.
+	ConfirmShard(loc ShardXz) bool
+}
+
+// localShardRef holds a shard hosted by LocalShardManager, and the number of
+// open connections to it.
+type localShardRef struct {
+	shard *ChunkShard
+	count int
+}
+
 // LocalShardManager contains all chunk shards and can look them up. It
 // implements IShardConnecter and is for use in hosting all shards in the local
 // process.
 type LocalShardManager struct {
 	entityMgr  *entity.EntityManager
 	chunkStore chunkstore.IChunkStore
-	shards     map[uint64]*ChunkShard
+	shards     map[uint64]*localShardRef
+	closed     bool // Have the shards been stopped by Close?
 	lock       sync.Mutex
+
+	// remote is used by hosted shards to connect to other shards, if set.
+	remote gamerules.IShardConnecter
+
+	// ownership limits the shards that are served, if set.
+	ownership IShardOwnership
+
+	// seed is the world seed that hosted shards use for random numbers.
+	seed int64
 }
 
 func NewLocalShardManager(chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager) *LocalShardManager {
 	return &LocalShardManager{
 		entityMgr:  entityMgr,
 		chunkStore: chunkStore,
-		shards:     make(map[uint64]*ChunkShard),
+		shards:     make(map[uint64]*localShardRef),
 	}
 }
 
-func (mgr *LocalShardManager) getShard(loc ShardXz, create bool) *ChunkShard {
+// SetRemoteConnecter sets the IShardConnecter that hosted shards use to
+// connect to other shards, which might be hosted elsewhere. By default they
+// connect only to shards hosted by mgr. This must be called before any shards
+// are created.
+func (mgr *LocalShardManager) SetRemoteConnecter(remote gamerules.IShardConnecter) {
+	mgr.remote = remote
+}
+
+// SetOwnership limits the shards that mgr serves to those owned according to
+// ownership. By default all shards are served. This must be called before any
+// shards are created.
+func (mgr *LocalShardManager) SetOwnership(ownership IShardOwnership) {
+	mgr.ownership = ownership
+}
+
+// SetSeed sets the world seed that hosted shards mix into the random numbers
+// of their chunks. This must be called before any shards are created.
+func (mgr *LocalShardManager) SetSeed(seed int64) {
+	mgr.seed = seed
+}
+
+// ownsShard returns true if mgr may serve the shard.
+func (mgr *LocalShardManager) ownsShard(loc ShardXz) bool {
+	return mgr.ownership == nil || mgr.ownership.OwnsShard(loc)
+}
+
+// confirmShard is like ownsShard, but might block to find out if the shard has
+// been newly assigned to mgr.
+func (mgr *LocalShardManager) confirmShard(loc ShardXz) bool {
+	return mgr.ownership == nil || mgr.ownership.ConfirmShard(loc)
+}
+
+// getShard returns a reference to the shard at the given location, creating
+// it if it does not already exist. It must be called with mgr.lock held.
+func (mgr *LocalShardManager) getShard(loc ShardXz) *localShardRef {
 	shardKey := loc.Key()
-	if shard, ok := mgr.shards[shardKey]; ok {
+	if ref, ok := mgr.shards[shardKey]; ok {
 		// Shard already exists.
-		return shard
+		return ref
 	}
 
-	if !create {
-		return nil
+	// Create shard.
+	ref := &localShardRef{
+		shard: NewChunkShard(localShardPeers{mgr}, mgr.chunkStore, mgr.entityMgr, loc),
 	}
+	ref.shard.seed = mgr.seed
+	mgr.shards[shardKey] = ref
+	go ref.shard.serve()
 
-	// Create shard.
-	shard := NewChunkShard(mgr, mgr.chunkStore, mgr.entityMgr, loc)
-	mgr.shards[shardKey] = shard
-	go shard.serve()
+	return ref
+}
+
+// connect returns the shard at the given location, creating it if necessary,
+// and counts a new connection to it. It returns nil if mgr has been closed or
+// doesn't own the shard.
+func (mgr *LocalShardManager) connect(loc ShardXz) *ChunkShard {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
 
-	return shard
+	if mgr.closed || !mgr.ownsShard(loc) {
+		return nil
+	}
+
+	ref := mgr.getShard(loc)
+	ref.count++
+	return ref.shard
 }
 
-func (mgr *LocalShardManager) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+// disconnect counts the closing of a connection previously opened by connect.
+func (mgr *LocalShardManager) disconnect(shard *ChunkShard) {
 	mgr.lock.Lock()
 	defer mgr.lock.Unlock()
 
-	shard := mgr.getShard(shardLoc, true)
-	return newLocalPlayerShardClient(entityId, player, shard)
+	if ref, ok := mgr.shards[shard.loc.Key()]; ok && ref.shard == shard {
+		ref.count--
+	}
 }
 
-func (mgr *LocalShardManager) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+// releaseShard implements iShardHost.releaseShard. It is called from the
+// shard's own goroutine.
+func (mgr *LocalShardManager) releaseShard(shard *ChunkShard) bool {
 	mgr.lock.Lock()
 	defer mgr.lock.Unlock()
 
-	shard := mgr.getShard(shardLoc, false)
+	shardKey := shard.loc.Key()
+	ref, ok := mgr.shards[shardKey]
+	if !ok || ref.shard != shard {
+		return false
+	}
+
+	// Nothing else can enqueue requests on the shard without a connection, so
+	// it is safe to stop the shard if there are none now.
+	if ref.count > 0 || len(shard.requests) > 0 {
+		return false
+	}
 
+	delete(mgr.shards, shardKey)
+
+	return true
+}
+
+func (mgr *LocalShardManager) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+	shard := mgr.connect(shardLoc)
 	if shard == nil {
-		return nil
+		// Requests upon the client are dropped.
+		return nullPlayerShardClient{}
 	}
+	return newLocalPlayerShardClient(mgr, entityId, player, shard)
+}
 
-	return newLocalShardShardClient(shard)
+func (mgr *LocalShardManager) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+	// The shard is created if it is not running, so that entities and other
+	// requests are not lost when they cross into a shard that has been
+	// released.
+	shard := mgr.connect(shardLoc)
+	if shard == nil {
+		// Requests upon the client are dropped.
+		return nullShardShardClient{}
+	}
+	return newLocalShardShardClient(mgr, shard)
+}
+
+// dropUnownedShards stops the shards that mgr no longer owns, without saving
+// them, as another host might now be writing their chunks. Connections to the
+// shards are closed by the ShardServer, so that the remote end finds the
+// shard's new host.
+func (mgr *LocalShardManager) dropUnownedShards() {
+	mgr.lock.Lock()
+	shards := make([]*ChunkShard, 0)
+	for shardKey, ref := range mgr.shards {
+		if !mgr.ownsShard(ref.shard.loc) {
+			shards = append(shards, ref.shard)
+			delete(mgr.shards, shardKey)
+		}
+	}
+	mgr.lock.Unlock()
+
+	for _, shard := range shards {
+		log.Printf("Lost ownership of shard %v, stopping it without saving", shard.loc)
+		shard.enqueue(shard.stop)
+	}
+}
+
+// SaveAll writes all loaded chunks in owned shards to the chunk store, and
+// returns once they have been written.
+func (mgr *LocalShardManager) SaveAll() {
+	mgr.lock.Lock()
+	shards := make([]*ChunkShard, 0, len(mgr.shards))
+	for _, ref := range mgr.shards {
+		ref.count++
+		shards = append(shards, ref.shard)
+	}
+	mgr.lock.Unlock()
+
+	for _, shard := range shards {
+		done := make(chan bool)
+		shard.enqueue(func() {
+			if mgr.ownsShard(shard.loc) {
+				shard.saveAll()
+			}
+			done <- true
+		})
+		<-done
+		mgr.disconnect(shard)
+	}
+
+	mgr.chunkStore.Flush()
+}
+
+// Close saves all loaded chunks in owned shards and stops all shards,
+// returning once the chunks have been written. Requests made upon the shards afterwards are
+// dropped, and no more shards are started.
+func (mgr *LocalShardManager) Close() {
+	mgr.lock.Lock()
+	mgr.closed = true
+	shards := make([]*ChunkShard, 0, len(mgr.shards))
+	for shardKey, ref := range mgr.shards {
+		shards = append(shards, ref.shard)
+		delete(mgr.shards, shardKey)
+	}
+	mgr.lock.Unlock()
+
+	for _, shard := range shards {
+		done := make(chan bool, 1)
+		shard.enqueue(func() {
+			if mgr.ownsShard(shard.loc) {
+				shard.saveAll()
+			}
+			shard.stop()
+			done <- true
+		})
+		select {
+		case <-done:
+		case <-shard.done:
+			// The shard stopped itself before the request was performed.
+		}
+	}
+
+	mgr.chunkStore.Flush()
 }
 
 // TODO remove Enqueue* methods
@@ -72,19 +263,40 @@ func (mgr *LocalShardManager) ShardShardConnect(shardLoc ShardXz) gamerules.ISha
 // EnqueueAllChunks runs a given function on all loaded chunks.
 func (mgr *LocalShardManager) EnqueueAllChunks(fn func(chunk *Chunk)) {
 	mgr.lock.Lock()
-	defer mgr.lock.Unlock()
+	shards := make([]*ChunkShard, 0, len(mgr.shards))
+	for _, ref := range mgr.shards {
+		ref.count++
+		shards = append(shards, ref.shard)
+	}
+	mgr.lock.Unlock()
 
-	for _, shard := range mgr.shards {
+	for _, shard := range shards {
 		shard.enqueueAllChunks(fn)
+		mgr.disconnect(shard)
 	}
 }
 
 // EnqueueOnChunk runs a function on the chunk at the given location. If the
 // chunk does not exist, it does nothing.
 func (mgr *LocalShardManager) EnqueueOnChunk(loc ChunkXz, fn func(chunk *Chunk)) {
-	mgr.lock.Lock()
-	defer mgr.lock.Unlock()
-
-	shard := mgr.getShard(loc.ToShardXz(), true)
+	shard := mgr.connect(loc.ToShardXz())
+	if shard == nil {
+		return
+	}
 	shard.enqueueOnChunk(loc, fn)
+	mgr.disconnect(shard)
+}
+
+// localShardPeers is the IShardConnecter given to shards hosted by
+// LocalShardManager. It connects to other shards via the manager's remote
+// connecter if set, and otherwise to shards hosted by the manager.
+type localShardPeers struct {
+	*LocalShardManager
+}
+
+func (peers localShardPeers) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+	if peers.remote != nil {
+		return peers.remote.ShardShardConnect(shardLoc)
+	}
+	return peers.LocalShardManager.ShardShardConnect(shardLoc)
 }
@@ -0,0 +1,198 @@
+package shardserver
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/huin/chunkymonkey/entity"
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestLocalShardManagerClose(t *testing.T) {
+	mgr := newTestShardManager()
+	loc := ChunkXz{0, 0}
+	player := newTestPlayerClient(1)
+	client := mgr.PlayerShardConnect(1, player, loc.ToShardXz())
+	subscribeAndWait(t, player, client, loc)
+
+	mgr.Close()
+
+	if len(mgr.shards) != 0 {
+		t.Errorf("Expected shards to be stopped, got %d", len(mgr.shards))
+	}
+
+	// Requests on existing connections are dropped rather than blocking.
+	for i := 0; i < 1000; i++ {
+		client.ReqUnsubscribeChunk(loc)
+	}
+	client.Disconnect()
+
+	if _, ok := mgr.PlayerShardConnect(2, player, loc.ToShardXz()).(nullPlayerShardClient); !ok {
+		t.Errorf("Expected no new connections to a closed manager")
+	}
+	item := gamerules.NewItem(4, 1, 0, &AbsXyz{8, 120, 8}, &AbsVelocity{}, 0)
+	if mgr.ShardShardConnect(loc.ToShardXz()).ReqTransferEntity(loc, item) {
+		t.Errorf("Expected entities not to be accepted by a closed manager")
+	}
+	if len(mgr.shards) != 0 {
+		t.Errorf("Expected no shards to be started, got %d", len(mgr.shards))
+	}
+}
+
+func TestLocalShardManagerCloseSavesChunks(t *testing.T) {
+	store, worldPath := newTestSavingStore(t)
+	defer os.RemoveAll(worldPath)
+	entityMgr := new(entity.EntityManager)
+	entityMgr.Init()
+	mgr := NewLocalShardManager(store, entityMgr)
+
+	// The chunk is changed just before closing, long before the shard would
+	// otherwise save it.
+	loc := ShardXz{0, 0}
+	client := mgr.ShardShardConnect(loc)
+	shard := client.(*localShardShardClient).serverShard
+	blockLoc := BlockXyz{8, 5, 8}
+	done := make(chan bool)
+	shard.enqueue(func() {
+		testSetBlock(t, shard, blockLoc, testBlockIdTorch)
+		done <- true
+	})
+	<-done
+	client.Disconnect()
+
+	mgr.Close()
+
+	reloaded := NewChunkShard(make(testShardConnecter), store, entityMgr, loc)
+	reloaded.chunkAt(*blockLoc.ToChunkXz())
+	if blockId := testLoadedBlockIdAt(t, reloaded, blockLoc); blockId != testBlockIdTorch {
+		t.Errorf("Expected torch saved on close at %v, got block %d", blockLoc, blockId)
+	}
+}
+
+func TestLocalShardManagerReleasesIdleShard(t *testing.T) {
+	mgr := newTestShardManager()
+	loc := ShardXz{0, 0}
+	client := mgr.ShardShardConnect(loc)
+	shard := client.(*localShardShardClient).serverShard
+
+	done := make(chan bool)
+	shard.enqueue(func() {
+		shard.shardIdleTicks = 1
+		done <- true
+	})
+	<-done
+
+	// The shard is kept while there is a connection to it.
+	time.Sleep(5 * NanosecondsInSecond / TicksPerSecond)
+	mgr.lock.Lock()
+	count := len(mgr.shards)
+	mgr.lock.Unlock()
+	if count != 1 {
+		t.Fatalf("Expected connected shard to be kept, got %d shards", count)
+	}
+
+	client.Disconnect()
+	select {
+	case <-client.(iStoppableClient).stopped():
+	case <-time.After(testEventTimeout):
+		t.Fatal("Expected idle shard to stop once released")
+	}
+	mgr.lock.Lock()
+	count = len(mgr.shards)
+	mgr.lock.Unlock()
+	if count != 0 {
+		t.Errorf("Expected idle shard to be released, got %d shards", count)
+	}
+
+	// The shard is started again when next needed.
+	client = mgr.ShardShardConnect(loc)
+	defer client.Disconnect()
+	if client.(*localShardShardClient).serverShard == shard {
+		t.Errorf("Expected a new shard to be started")
+	}
+}
+
+// testShardOwnership is an IShardOwnership that owns the shards set in it.
+type testShardOwnership struct {
+	lock  sync.Mutex
+	owned map[ShardXz]bool
+}
+
+func newTestShardOwnership() *testShardOwnership {
+	return &testShardOwnership{owned: make(map[ShardXz]bool)}
+}
+
+func (o *testShardOwnership) set(loc ShardXz, owned bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.owned[loc] = owned
+}
+
+func (o *testShardOwnership) OwnsShard(loc ShardXz) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.owned[loc]
+}
+
+func (o *testShardOwnership) ConfirmShard(loc ShardXz) bool {
+	return o.OwnsShard(loc)
+}
+
+func TestLocalShardManagerDropsUnownedShards(t *testing.T) {
+	ownership := newTestShardOwnership()
+	mgr := newTestShardManager()
+	mgr.SetOwnership(ownership)
+
+	loc := ChunkXz{0, 0}
+	ownership.set(loc.ToShardXz(), true)
+	player := newTestPlayerClient(1)
+	client := mgr.PlayerShardConnect(1, player, loc.ToShardXz())
+	defer client.Disconnect()
+	subscribeAndWait(t, player, client, loc)
+
+	ownership.set(loc.ToShardXz(), false)
+	mgr.dropUnownedShards()
+
+	if len(mgr.shards) != 0 {
+		t.Errorf("Expected unowned shard to be dropped, got %d shards", len(mgr.shards))
+	}
+	select {
+	case <-client.(iStoppableClient).stopped():
+	case <-time.After(testEventTimeout):
+		t.Error("Expected unowned shard to stop")
+	}
+}
+
+func TestShardServerRejectsUnownedShards(t *testing.T) {
+	ownership := newTestShardOwnership()
+	mgr := newTestShardManager()
+	mgr.SetOwnership(ownership)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	server := NewShardServer(listener, mgr)
+	go server.Serve()
+
+	conn, sender := dialShard(listener.Addr().String(), &connHello{ShardLoc: ShardXz{0, 0}})
+	if sender == nil {
+		t.Fatal("Expected to connect to the shard server")
+	}
+	defer sender.close()
+
+	conn.SetReadDeadline(time.Now().Add(testEventTimeout))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected connection to be closed, got %v", err)
+	}
+	if len(mgr.shards) != 0 {
+		t.Errorf("Expected no shards to be started, got %d", len(mgr.shards))
+	}
+}
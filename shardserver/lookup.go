@@ -0,0 +1,388 @@
+package shardserver
+
+// Implements the lookup service, which assigns ranges of shards to the shard
+// hosts (shardserver processes) that serve them.
+//
+// Shard hosts register themselves with the lookup service, and must keep
+// re-registering to stay registered. A host that wishes to stop serving its
+// shards does so with a handover:
+//   1. The host POSTs to /leave. Lookups for its shards now fail with
+//      http.StatusServiceUnavailable.
+//   2. The host saves all of its chunks.
+//   3. The host POSTs to /left. Its shard ranges are reassigned to the
+//      remaining hosts.
+// A host that stops re-registering is assumed to have failed, and its ranges
+// are reassigned without waiting for its chunks to be saved.
+//
+// Each registration is answered with the ranges assigned to the host, and the
+// lease for which the host may serve them without registering again. A host
+// stops serving shards that it is no longer assigned, so that no two hosts
+// write the same chunks.
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// lookupRangeSize is the width and depth (in shards) of the square ranges of
+// shards that are assigned to hosts.
+const lookupRangeSize = 4
+
+var errNoHosts = errors.New("no shard hosts available")
+var errHandover = errors.New("shard range is being handed over")
+
+// shardRangeXz identifies a range of lookupRangeSize*lookupRangeSize shards.
+type shardRangeXz struct {
+	X, Z ShardCoord
+}
+
+func rangeForShard(loc *ShardXz) shardRangeXz {
+	return shardRangeXz{
+		X: floorDivShardCoord(loc.X, lookupRangeSize),
+		Z: floorDivShardCoord(loc.Z, lookupRangeSize),
+	}
+}
+
+func floorDivShardCoord(c ShardCoord, d ShardCoord) (r ShardCoord) {
+	r = c / d
+	if c%d < 0 {
+		r--
+	}
+	return
+}
+
+type lookupHost struct {
+	Addr     string
+	LastSeen time.Time
+	Leaving  bool
+	Ranges   int
+}
+
+// registerResponse is the JSON response to /register.
+type registerResponse struct {
+	Ranges []shardRangeXz // Ranges assigned to the host.
+	Lease  time.Duration  // How long the host may serve them for.
+}
+
+// lookupResponse is the JSON response to /lookup.
+type lookupResponse struct {
+	Addr string
+}
+
+// lookupAssignment is an entry in the JSON response to /assignments.
+type lookupAssignment struct {
+	X, Z     ShardCoord // Range coordinates, in shards.
+	Size     ShardCoord
+	Addr     string
+	Handover bool
+}
+
+// lookupReport is the JSON response to /assignments.
+type lookupReport struct {
+	Hosts       []*lookupHost
+	Assignments []*lookupAssignment
+}
+
+// LookupServer is an http.Handler that implements the lookup service.
+type LookupServer struct {
+	hostTimeout time.Duration
+	mux         *http.ServeMux
+
+	lock        sync.Mutex
+	hosts       map[string]*lookupHost
+	assignments map[shardRangeXz]string
+}
+
+func NewLookupServer(hostTimeout time.Duration) *LookupServer {
+	srv := &LookupServer{
+		hostTimeout: hostTimeout,
+		mux:         http.NewServeMux(),
+		hosts:       make(map[string]*lookupHost),
+		assignments: make(map[shardRangeXz]string),
+	}
+
+	srv.mux.HandleFunc("/register", srv.handleRegister)
+	srv.mux.HandleFunc("/leave", srv.handleLeave)
+	srv.mux.HandleFunc("/left", srv.handleLeft)
+	srv.mux.HandleFunc("/lookup", srv.handleLookup)
+	srv.mux.HandleFunc("/assignments", srv.handleAssignments)
+
+	return srv
+}
+
+func (srv *LookupServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// register adds a host, or notes that an existing host is still alive. It
+// returns the ranges assigned to the host.
+func (srv *LookupServer) register(addr string) (ranges []shardRangeXz) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.expireHosts()
+
+	host, ok := srv.hosts[addr]
+	if !ok {
+		log.Printf("LookupServer: host %s registered", addr)
+		host = &lookupHost{Addr: addr}
+		srv.hosts[addr] = host
+	}
+	host.LastSeen = time.Now()
+
+	ranges = make([]shardRangeXz, 0, host.Ranges)
+	for rangeLoc, assignedAddr := range srv.assignments {
+		if assignedAddr == addr {
+			ranges = append(ranges, rangeLoc)
+		}
+	}
+
+	return
+}
+
+// leave starts the handover of a host's shard ranges.
+func (srv *LookupServer) leave(addr string) bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	host, ok := srv.hosts[addr]
+	if !ok {
+		return false
+	}
+
+	log.Printf("LookupServer: host %s leaving", addr)
+	host.Leaving = true
+	host.LastSeen = time.Now()
+
+	return true
+}
+
+// left completes the handover of a host's shard ranges.
+func (srv *LookupServer) left(addr string) bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if _, ok := srv.hosts[addr]; !ok {
+		return false
+	}
+
+	log.Printf("LookupServer: host %s left", addr)
+	srv.removeHost(addr)
+
+	return true
+}
+
+// lookup returns the address of the host serving the given shard, assigning
+// the shard's range to a host if it is not already assigned.
+func (srv *LookupServer) lookup(loc *ShardXz) (addr string, err error) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.expireHosts()
+
+	rangeLoc := rangeForShard(loc)
+	if addr, ok := srv.assignments[rangeLoc]; ok {
+		if srv.hosts[addr].Leaving {
+			return "", errHandover
+		}
+		return addr, nil
+	}
+
+	host := srv.leastLoadedHost()
+	if host == nil {
+		return "", errNoHosts
+	}
+
+	srv.assign(rangeLoc, host)
+
+	return host.Addr, nil
+}
+
+// report returns the current hosts and assignments.
+func (srv *LookupServer) report() *lookupReport {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.expireHosts()
+
+	report := &lookupReport{
+		Hosts:       make([]*lookupHost, 0, len(srv.hosts)),
+		Assignments: make([]*lookupAssignment, 0, len(srv.assignments)),
+	}
+
+	addrs := make([]string, 0, len(srv.hosts))
+	for addr := range srv.hosts {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		host := *srv.hosts[addr]
+		report.Hosts = append(report.Hosts, &host)
+	}
+
+	for rangeLoc, addr := range srv.assignments {
+		report.Assignments = append(report.Assignments, &lookupAssignment{
+			X:        rangeLoc.X * lookupRangeSize,
+			Z:        rangeLoc.Z * lookupRangeSize,
+			Size:     lookupRangeSize,
+			Addr:     addr,
+			Handover: srv.hosts[addr].Leaving,
+		})
+	}
+	sort.Sort(lookupAssignmentsByLoc(report.Assignments))
+
+	return report
+}
+
+// The following methods must be called with srv.lock held.
+
+func (srv *LookupServer) assign(rangeLoc shardRangeXz, host *lookupHost) {
+	srv.assignments[rangeLoc] = host.Addr
+	host.Ranges++
+}
+
+// expireHosts removes hosts that have not registered recently.
+func (srv *LookupServer) expireHosts() {
+	now := time.Now()
+	for addr, host := range srv.hosts {
+		if now.Sub(host.LastSeen) > srv.hostTimeout {
+			log.Printf("LookupServer: host %s timed out, reassigning its shards without handover", addr)
+			srv.removeHost(addr)
+		}
+	}
+}
+
+// removeHost removes a host, and reassigns its ranges to the remaining hosts.
+func (srv *LookupServer) removeHost(addr string) {
+	delete(srv.hosts, addr)
+
+	for rangeLoc, assignedAddr := range srv.assignments {
+		if assignedAddr != addr {
+			continue
+		}
+
+		if host := srv.leastLoadedHost(); host != nil {
+			srv.assign(rangeLoc, host)
+		} else {
+			// Reassigned upon the next lookup, if a host is available then.
+			delete(srv.assignments, rangeLoc)
+		}
+	}
+}
+
+// leastLoadedHost returns the host that is not leaving with the fewest
+// assigned ranges, or nil if there are no such hosts.
+func (srv *LookupServer) leastLoadedHost() (best *lookupHost) {
+	for _, host := range srv.hosts {
+		if host.Leaving {
+			continue
+		}
+		if best == nil || host.Ranges < best.Ranges || (host.Ranges == best.Ranges && host.Addr < best.Addr) {
+			best = host
+		}
+	}
+	return
+}
+
+// HTTP handlers follow.
+
+func (srv *LookupServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	addr, ok := hostAddrFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	writeJson(w, &registerResponse{
+		Ranges: srv.register(addr),
+		Lease:  srv.hostTimeout,
+	})
+}
+
+func (srv *LookupServer) handleLeave(w http.ResponseWriter, r *http.Request) {
+	addr, ok := hostAddrFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if !srv.leave(addr) {
+		http.Error(w, "unknown host", http.StatusNotFound)
+	}
+}
+
+func (srv *LookupServer) handleLeft(w http.ResponseWriter, r *http.Request) {
+	addr, ok := hostAddrFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if !srv.left(addr) {
+		http.Error(w, "unknown host", http.StatusNotFound)
+	}
+}
+
+func (srv *LookupServer) handleLookup(w http.ResponseWriter, r *http.Request) {
+	x, errX := strconv.ParseInt(r.FormValue("x"), 10, 32)
+	z, errZ := strconv.ParseInt(r.FormValue("z"), 10, 32)
+	if errX != nil || errZ != nil {
+		http.Error(w, "bad shard location", http.StatusBadRequest)
+		return
+	}
+
+	addr, err := srv.lookup(&ShardXz{ShardCoord(x), ShardCoord(z)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJson(w, &lookupResponse{addr})
+}
+
+func (srv *LookupServer) handleAssignments(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, srv.report())
+}
+
+func hostAddrFromRequest(w http.ResponseWriter, r *http.Request) (addr string, ok bool) {
+	if r.Method != "POST" {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if addr = r.FormValue("addr"); addr == "" {
+		http.Error(w, "missing addr", http.StatusBadRequest)
+		return
+	}
+
+	return addr, true
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("LookupServer: error writing response: %v", err)
+	}
+}
+
+type lookupAssignmentsByLoc []*lookupAssignment
+
+func (a lookupAssignmentsByLoc) Len() int {
+	return len(a)
+}
+
+func (a lookupAssignmentsByLoc) Less(i, j int) bool {
+	if a[i].X != a[j].X {
+		return a[i].X < a[j].X
+	}
+	return a[i].Z < a[j].Z
+}
+
+func (a lookupAssignmentsByLoc) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
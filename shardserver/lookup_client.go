@@ -0,0 +1,313 @@
+package shardserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	// Number of attempts made to look up a shard that is unavailable, e.g
+	// because it is being handed over.
+	lookupAttempts   = 10
+	lookupRetryDelay = 500 * time.Millisecond
+
+	lookupRegisterInterval = 5 * time.Second
+
+	// Time for which a looked up shard address is used to decide whether to
+	// connect to the shard locally.
+	lookupCacheTime = lookupRegisterInterval
+)
+
+// LookupShardConnecter implements IShardConnecter by asking a LookupServer
+// which shard host serves a shard, and then connecting to it with
+// RemoteShardConnecter. Connecting never waits for the lookup service -
+// unless the shard's address was looked up recently, the shard is looked up
+// in the background by the client.
+type LookupShardConnecter struct {
+	lookupUrl string
+
+	// Shards served at localAddr are connected to via local, if set.
+	localAddr string
+	local     gamerules.IShardConnecter
+
+	lock  sync.Mutex
+	addrs map[ShardXz]cachedShardAddr // Recently looked up addresses.
+}
+
+type cachedShardAddr struct {
+	addr    string
+	expires time.Time
+}
+
+// NewLookupShardConnecter creates a LookupShardConnecter that uses the lookup
+// service at lookupUrl. If local is not nil, then shards that the lookup
+// service says are served at localAddr are connected to via local instead of
+// over the network.
+func NewLookupShardConnecter(lookupUrl string, localAddr string, local gamerules.IShardConnecter) *LookupShardConnecter {
+	return &LookupShardConnecter{
+		lookupUrl: lookupUrl,
+		localAddr: localAddr,
+		local:     local,
+		addrs:     make(map[ShardXz]cachedShardAddr),
+	}
+}
+
+// shardAddr returns the address of the host serving the given shard. It
+// retries for a while if the shard is being handed over between hosts.
+func (lc *LookupShardConnecter) shardAddr(loc ShardXz) (addr string, err error) {
+	query := url.Values{}
+	query.Set("x", fmt.Sprint(loc.X))
+	query.Set("z", fmt.Sprint(loc.Z))
+	lookupUrl := lc.lookupUrl + "/lookup?" + query.Encode()
+
+	for attempt := 0; attempt < lookupAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(lookupRetryDelay)
+		}
+
+		var resp *http.Response
+		if resp, err = http.Get(lookupUrl); err != nil {
+			return
+		}
+
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			err = fmt.Errorf("shard %v unavailable", loc)
+			continue
+		} else if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			err = fmt.Errorf("lookup of shard %v failed: %s", loc, resp.Status)
+			return
+		}
+
+		var result lookupResponse
+		if err = json.NewDecoder(resp.Body).Decode(&result); err == nil {
+			lc.cacheAddr(loc, result.Addr)
+		}
+		resp.Body.Close()
+
+		return result.Addr, err
+	}
+
+	return
+}
+
+func (lc *LookupShardConnecter) cacheAddr(loc ShardXz, addr string) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	lc.addrs[loc] = cachedShardAddr{addr, time.Now().Add(lookupCacheTime)}
+}
+
+// cachedAddr returns the address of the shard if it was looked up recently,
+// otherwise it returns an empty string.
+func (lc *LookupShardConnecter) cachedAddr(loc ShardXz) string {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	cached, ok := lc.addrs[loc]
+	if !ok {
+		return ""
+	} else if time.Now().After(cached.expires) {
+		delete(lc.addrs, loc)
+		return ""
+	}
+
+	return cached.addr
+}
+
+// isLocal returns true if the shard at addr is served by the local shard host.
+// A shard that the local host hasn't yet confirmed that it owns is connected to
+// over the network, where the ShardServer confirms it.
+func (lc *LookupShardConnecter) isLocal(loc ShardXz, addr string) bool {
+	if lc.local == nil || addr == "" || addr != lc.localAddr {
+		return false
+	}
+	if owner, ok := lc.local.(iShardOwner); ok {
+		return owner.ownsShard(loc)
+	}
+	return true
+}
+
+func (lc *LookupShardConnecter) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+	addr := lc.cachedAddr(shardLoc)
+	if lc.isLocal(shardLoc, addr) {
+		return lc.local.PlayerShardConnect(entityId, player, shardLoc)
+	}
+
+	return newRemotePlayerShardClient(addr, lc.shardAddr, entityId, player, shardLoc)
+}
+
+func (lc *LookupShardConnecter) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+	addr := lc.cachedAddr(shardLoc)
+	if lc.isLocal(shardLoc, addr) {
+		return lc.local.ShardShardConnect(shardLoc)
+	}
+
+	return newRemoteShardShardClient(addr, lc.shardAddr, shardLoc)
+}
+
+// LookupRegistration keeps a shard host registered with a lookup service, and
+// performs the handover of its shards when it leaves. It implements
+// IShardOwnership for the host's LocalShardManager, which serves only the
+// shards in the ranges that the lookup service has assigned to the host.
+type LookupRegistration struct {
+	lookupUrl string
+	addr      string
+	mgr       *LocalShardManager
+	client    http.Client
+	stop      chan bool
+	stopped   chan bool
+
+	lock         sync.Mutex
+	ranges       map[shardRangeXz]bool // Ranges assigned to the host.
+	leaseExpires time.Time             // When the assignment of ranges lapses.
+}
+
+// NewLookupRegistration creates a registration for a shard host serving the
+// shards in mgr at addr with the lookup service at lookupUrl. Serve must be
+// called to register.
+func NewLookupRegistration(lookupUrl string, addr string, mgr *LocalShardManager) *LookupRegistration {
+	reg := &LookupRegistration{
+		lookupUrl: lookupUrl,
+		addr:      addr,
+		mgr:       mgr,
+		client:    http.Client{Timeout: lookupRegisterInterval},
+		stop:      make(chan bool),
+		stopped:   make(chan bool),
+		ranges:    make(map[shardRangeXz]bool),
+	}
+
+	mgr.SetOwnership(reg)
+
+	return reg
+}
+
+func (reg *LookupRegistration) post(path string) (resp *http.Response, err error) {
+	resp, err = reg.client.PostForm(reg.lookupUrl+path, url.Values{"addr": {reg.addr}})
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err = fmt.Errorf("POST to %s%s failed: %s", reg.lookupUrl, path, resp.Status)
+	}
+
+	return
+}
+
+// register registers the host, and updates the ranges assigned to it.
+func (reg *LookupRegistration) register() (err error) {
+	// The lease is counted from before the request, as the lookup service
+	// counts it from some time after.
+	sent := time.Now()
+
+	resp, err := reg.post("/register")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var result registerResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return
+	}
+
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	reg.ranges = make(map[shardRangeXz]bool)
+	for _, rangeLoc := range result.Ranges {
+		reg.ranges[rangeLoc] = true
+	}
+	// Shards are only stopped after registering, so the lease is treated as
+	// lapsing an interval early.
+	reg.leaseExpires = sent.Add(result.Lease - lookupRegisterInterval)
+
+	return
+}
+
+// OwnsShard implements IShardOwnership.OwnsShard.
+func (reg *LookupRegistration) OwnsShard(loc ShardXz) bool {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	return reg.ranges[rangeForShard(&loc)] && time.Now().Before(reg.leaseExpires)
+}
+
+// ConfirmShard implements IShardOwnership.ConfirmShard. Ranges are assigned
+// to the host when shards in them are looked up, so the host registers again
+// to find out if the shard was assigned since it last registered.
+func (reg *LookupRegistration) ConfirmShard(loc ShardXz) bool {
+	if reg.OwnsShard(loc) {
+		return true
+	}
+
+	if err := reg.register(); err != nil {
+		log.Printf("Failed to register with lookup service: %v", err)
+		return false
+	}
+
+	return reg.OwnsShard(loc)
+}
+
+// Serve registers the host, and keeps it registered until Leave is called.
+// Shards that the host is no longer assigned, or whose lease has lapsed, are
+// stopped.
+func (reg *LookupRegistration) Serve() {
+	defer close(reg.stopped)
+
+	ticker := time.NewTicker(lookupRegisterInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := reg.register(); err != nil {
+			log.Printf("Failed to register with lookup service: %v", err)
+		}
+
+		reg.mgr.dropUnownedShards()
+
+		select {
+		case <-ticker.C:
+		case <-reg.stop:
+			return
+		}
+	}
+}
+
+// Leave hands over the host's shards to other hosts. The shards are stopped
+// and their chunks saved before the lookup service is told that the host has
+// left. The host keeps re-registering until then, so that the lookup service
+// doesn't time it out while saving. Serve must have been started beforehand.
+func (reg *LookupRegistration) Leave() (err error) {
+	defer func() {
+		close(reg.stop)
+		<-reg.stopped
+	}()
+
+	resp, err := reg.post("/leave")
+	if err != nil {
+		// The chunks are still saved, but the lookup service won't wait for
+		// them.
+		reg.mgr.Close()
+		return
+	}
+	resp.Body.Close()
+
+	reg.mgr.Close()
+
+	if resp, err = reg.post("/left"); err == nil {
+		resp.Body.Close()
+	}
+
+	return
+}
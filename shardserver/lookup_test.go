@@ -0,0 +1,122 @@
+package shardserver
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+func TestLookupAssignsRanges(t *testing.T) {
+	srv := NewLookupServer(time.Minute)
+
+	if _, err := srv.lookup(&ShardXz{0, 0}); err != errNoHosts {
+		t.Errorf("Expected errNoHosts with no hosts, got %v", err)
+	}
+
+	srv.register("a:1")
+	srv.register("b:1")
+
+	addr00, _ := srv.lookup(&ShardXz{0, 0})
+	addr11, _ := srv.lookup(&ShardXz{lookupRangeSize - 1, lookupRangeSize - 1})
+	addrFar, _ := srv.lookup(&ShardXz{-1, 0})
+
+	if addr00 != addr11 {
+		t.Errorf("Expected shards in the same range on the same host, got %q and %q", addr00, addr11)
+	}
+	if addr00 == addrFar {
+		t.Errorf("Expected second range to be assigned to the other host, both on %q", addr00)
+	}
+}
+
+func TestLookupHandover(t *testing.T) {
+	srv := NewLookupServer(time.Minute)
+	srv.register("a:1")
+
+	loc := &ShardXz{5, -3}
+	if addr, err := srv.lookup(loc); addr != "a:1" || err != nil {
+		t.Fatalf("Expected a:1, got %q, %v", addr, err)
+	}
+
+	srv.register("b:1")
+	srv.leave("a:1")
+
+	if _, err := srv.lookup(loc); err != errHandover {
+		t.Errorf("Expected errHandover during handover, got %v", err)
+	}
+
+	srv.left("a:1")
+
+	if addr, err := srv.lookup(loc); addr != "b:1" || err != nil {
+		t.Errorf("Expected reassignment to b:1, got %q, %v", addr, err)
+	}
+}
+
+func TestLookupExpiresHosts(t *testing.T) {
+	srv := NewLookupServer(time.Minute)
+	srv.register("a:1")
+
+	loc := &ShardXz{0, 0}
+	srv.lookup(loc)
+
+	srv.register("b:1")
+	srv.hosts["a:1"].LastSeen = time.Now().Add(-2 * time.Minute)
+
+	if addr, err := srv.lookup(loc); addr != "b:1" || err != nil {
+		t.Errorf("Expected reassignment to b:1, got %q, %v", addr, err)
+	}
+}
+
+func TestLookupRegisterReturnsRanges(t *testing.T) {
+	srv := NewLookupServer(time.Minute)
+	srv.register("a:1")
+
+	loc := &ShardXz{5, -3}
+	srv.lookup(loc)
+
+	ranges := srv.register("a:1")
+	if len(ranges) != 1 || ranges[0] != rangeForShard(loc) {
+		t.Errorf("Expected range %+v, got %+v", rangeForShard(loc), ranges)
+	}
+
+	if ranges := srv.register("b:1"); len(ranges) != 0 {
+		t.Errorf("Expected no ranges for b:1, got %+v", ranges)
+	}
+}
+
+func TestLookupShardConnecter(t *testing.T) {
+	lookupHttp := httptest.NewServer(NewLookupServer(time.Minute))
+	defer lookupHttp.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	mgr := newTestShardManager()
+	server := NewShardServer(listener, mgr)
+	go server.Serve()
+
+	reg := NewLookupRegistration(lookupHttp.URL, listener.Addr().String(), mgr)
+	go reg.Serve()
+	defer reg.Leave()
+
+	// Wait for the registration.
+	connecter := NewLookupShardConnecter(lookupHttp.URL, "", nil)
+	for i := 0; i < 100; i++ {
+		if _, err = connecter.shardAddr(ShardXz{0, 0}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	loc := ChunkXz{0, 0}
+	player := newTestPlayerClient(1)
+	client := connecter.PlayerShardConnect(1, player, loc.ToShardXz())
+	defer client.Disconnect()
+
+	subscribeAndWait(t, player, client, loc)
+}
@@ -0,0 +1,51 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testBlockIdWoodenDoor = BlockId(64)
+
+func TestPlayerMovesThroughBlocks(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	for x := BlockCoord(6); x <= 10; x++ {
+		testSetBlock(t, shard, BlockXyz{x, 5, 8}, BlockIdAir)
+		testSetBlock(t, shard, BlockXyz{x, 6, 8}, BlockIdAir)
+	}
+	testSetBlock(t, shard, BlockXyz{10, 5, 8}, testBlockIdWoodenDoor)
+	testSetBlock(t, shard, BlockXyz{10, 6, 8}, testBlockIdWoodenDoor)
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.reqSubscribeChunk(1, player, false)
+	chunk.reqAddPlayerData(1, "test", AbsXyz{8.5, 5, 8.5}, LookBytes{}, gamerules.PlayerAppearance{})
+
+	isRejected := func(event interface{}) bool {
+		_, ok := event.(testRejectMoveEvent)
+		return ok
+	}
+
+	// Along the tunnel, and through the door.
+	chunk.reqSetPlayerPosition(1, AbsXyz{9.5, 5, 8.5})
+	chunk.reqSetPlayerPosition(1, AbsXyz{10.5, 5, 8.5})
+	for len(player.events) > 0 {
+		if isRejected(<-player.events) {
+			t.Fatalf("Expected player to move along the tunnel")
+		}
+	}
+
+	// Through the tunnel wall.
+	chunk.reqSetPlayerPosition(1, AbsXyz{10.5, 5, 10.5})
+	event := player.waitFor(isRejected)
+	if event == nil {
+		t.Fatalf("Expected player not to move through the tunnel wall")
+	}
+	if position := event.(testRejectMoveEvent).position; position != (AbsXyz{10.5, 5, 8.5}) {
+		t.Errorf("Expected player to be put back in the tunnel, got %+v", position)
+	}
+	if position := chunk.playersData[1].position; position != (AbsXyz{10.5, 5, 8.5}) {
+		t.Errorf("Expected chunk to keep the player in the tunnel, got %+v", position)
+	}
+}
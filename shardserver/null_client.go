@@ -0,0 +1,101 @@
+package shardserver
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// nullClientStopped is closed, as null clients are never connected to a
+// running shard.
+var nullClientStopped = make(chan bool)
+
+func init() {
+	close(nullClientStopped)
+}
+
+// nullPlayerShardClient implements IPlayerShardClient for a shard that can't
+// be served, such as one that is not owned or after its host has been closed.
+// Requests upon it are dropped.
+type nullPlayerShardClient struct{}
+
+// stopped implements iStoppableClient.stopped.
+func (client nullPlayerShardClient) stopped() <-chan bool {
+	return nullClientStopped
+}
+
+func (client nullPlayerShardClient) Disconnect() {}
+
+func (client nullPlayerShardClient) ReqSubscribeChunk(chunkLoc ChunkXz, notify bool) {}
+
+func (client nullPlayerShardClient) ReqUnsubscribeChunk(chunkLoc ChunkXz) {}
+
+func (client nullPlayerShardClient) ReqMulticastPlayers(chunkLoc ChunkXz, exclude EntityId, packet []byte) {
+}
+
+func (client nullPlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, appearance gamerules.PlayerAppearance) {
+}
+
+func (client nullPlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, newChunkLoc ChunkXz, isDisconnect bool) {
+}
+
+func (client nullPlayerShardClient) ReqSetPlayerPosition(chunkLoc ChunkXz, position AbsXyz) {}
+
+func (client nullPlayerShardClient) ReqSetPlayerLook(chunkLoc ChunkXz, look LookBytes) {}
+
+func (client nullPlayerShardClient) ReqSetPlayerAppearance(chunkLoc ChunkXz, appearance gamerules.PlayerAppearance) {
+}
+
+func (client nullPlayerShardClient) ReqHitBlock(held gamerules.Slot, gameType GameType, target BlockXyz, digStatus DigStatus, face Face) {
+}
+
+func (client nullPlayerShardClient) ReqInteractBlock(held gamerules.Slot, target BlockXyz, face Face) {
+}
+
+func (client nullPlayerShardClient) ReqHitEntity(chunkLoc ChunkXz, target EntityId, held gamerules.Slot, position AbsXyz, pvp bool) {
+}
+
+func (client nullPlayerShardClient) ReqPlaceItem(target BlockXyz, slot gamerules.Slot) {}
+
+func (client nullPlayerShardClient) ReqTakeItem(chunkLoc ChunkXz, entityId EntityId) {}
+
+func (client nullPlayerShardClient) ReqDropItem(content gamerules.Slot, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks) {
+}
+
+func (client nullPlayerShardClient) ReqInventoryClick(block BlockXyz, click gamerules.Click) {}
+
+func (client nullPlayerShardClient) ReqInventoryPutItem(block BlockXyz, item gamerules.Slot) {}
+
+func (client nullPlayerShardClient) ReqInventoryUnsubscribed(block BlockXyz) {}
+
+func (client nullPlayerShardClient) ReqMakePortal(position AbsXyz, look LookDegrees) {}
+
+// nullShardShardClient implements IShardShardClient for a shard that can't be
+// served. Requests upon it are dropped, and entities are not accepted.
+type nullShardShardClient struct{}
+
+// stopped implements iStoppableClient.stopped.
+func (client nullShardShardClient) stopped() <-chan bool {
+	return nullClientStopped
+}
+
+func (client nullShardShardClient) Disconnect() {}
+
+func (client nullShardShardClient) ReqSetActiveBlocks(blocks []BlockXyz) {}
+
+func (client nullShardShardClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) bool {
+	return false
+}
+
+func (client nullShardShardClient) ReqUpdateLight(updates []gamerules.LightUpdate) {}
+
+func (client nullShardShardClient) ReqSpreadBlocks(spreads []gamerules.BlockSpread) {}
+
+func (client nullShardShardClient) ReqPlaceBlocks(placements []gamerules.BlockPlacement) {}
+
+func (client nullShardShardClient) ReqSetRedstonePower(powers []gamerules.RedstonePower) {}
+
+func (client nullShardShardClient) ReqSetBorderBlocks(blocks []gamerules.BorderBlock) {}
+
+func (client nullShardShardClient) ReqLightFire(blockLoc BlockXyz) {}
+
+func (client nullShardShardClient) ReqExplode(explosion gamerules.Explosion) {}
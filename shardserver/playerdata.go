@@ -12,6 +12,8 @@ const (
 	// Assumed values for size of player axis-aligned bounding box (AAB).
 	playerAabH = AbsCoord(0.75) // Each side of player.
 	playerAabY = AbsCoord(2.00) // From player's feet position upwards.
+
+	playerHeadY = AbsCoord(1.62) // From player's feet position to their eyes.
 )
 
 // playerData represents a Chunk's knowledge about a player. Only one Chunk has
@@ -22,19 +24,26 @@ type playerData struct {
 	name       string
 	position   AbsXyz
 	look       LookBytes
-	heldItemId ItemTypeId
-	// TODO Armor data.
+	appearance gamerules.PlayerAppearance
+
+	// The blocks around the player last sent to them, if surroundingsSent.
+	surroundings     gamerules.Surroundings
+	surroundingsSent bool
 }
 
-func (player *playerData) sendSpawn(writer io.Writer) error {
-	return proto.WriteNamedEntitySpawn(
+func (player *playerData) sendSpawn(writer io.Writer) (err error) {
+	err = proto.WriteNamedEntitySpawn(
 		writer,
 		player.entityId, player.name,
 		player.position.ToAbsIntXyz(),
 		&player.look,
-		player.heldItemId,
+		player.appearance.Equipment[gamerules.EquipmentSlotHeld].ItemTypeId,
 	)
-	// TODO Armor packet(s).
+	if err != nil {
+		return
+	}
+
+	return player.appearance.SendSpawn(writer, player.entityId)
 }
 
 func (player *playerData) sendPositionLook(writer io.Writer) error {
@@ -0,0 +1,61 @@
+package shardserver
+
+import (
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdChest  = BlockId(54)
+	testBlockIdPortal = BlockId(90)
+)
+
+func TestMakePortalKeepsTileEntities(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+
+	// The chest is where the portal frame would go.
+	chestLoc := BlockXyz{7, 70, 9}
+	testSetBlock(t, shard, chestLoc, testBlockIdChest)
+	chunk.reqInteractBlock(player, gamerules.Slot{}, &chestLoc, FaceTop)
+
+	chunk.reqMakePortal(player, &AbsXyz{8.5, 70, 8.5}, LookDegrees{})
+
+	if blockId := testBlockIdAt(shard, chestLoc); blockId != testBlockIdChest {
+		t.Errorf("Expected chest to be left in place, got block %d", blockId)
+	}
+	_, subLoc := chestLoc.ToChunkLocal()
+	if index, _ := subLoc.BlockIndex(); chunk.TileEntity(index) == nil {
+		t.Errorf("Expected chest to keep its inventory")
+	}
+	if blockId := testBlockIdAt(shard, BlockXyz{8, 70, 9}); blockId != testBlockIdPortal {
+		t.Errorf("Expected portal to be built, got block %d", blockId)
+	}
+}
+
+func TestMakePortalMovesPlayerToNearbyPortal(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+	player := newTestPlayerClient(1)
+	chunk.reqMakePortal(player, &AbsXyz{8.5, 70, 8.5}, LookDegrees{})
+
+	// Arriving in the next chunk uses the same portal.
+	otherChunk := shard.chunkAt(ChunkXz{1, 0})
+	look := LookDegrees{90, 0}
+	otherChunk.reqMakePortal(player, &AbsXyz{20.5, 72, 10.5}, look)
+
+	event := player.waitFor(func(event interface{}) bool {
+		_, ok := event.(testSetPositionLookEvent)
+		return ok
+	})
+	expected := testSetPositionLookEvent{AbsXyz{9.5, 70, 8.5}, look}
+	if event == nil || event.(testSetPositionLookEvent) != expected {
+		t.Errorf("Expected player to be moved to %v, got %v", expected, event)
+	}
+	if blockId, _, _ := otherChunk.BlockAt(&BlockXyz{20, 72, 11}); blockId == testBlockIdPortal {
+		t.Errorf("Expected no second portal to be built")
+	}
+}
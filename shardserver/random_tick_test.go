@@ -0,0 +1,183 @@
+package shardserver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdSapling  = BlockId(6)
+	testBlockIdLog      = BlockId(17)
+	testBlockIdLeaves   = BlockId(18)
+	testBlockIdCrops    = BlockId(59)
+	testBlockIdFarmland = BlockId(60)
+
+	testTreeBigOak = 3 // Sapling data of a big oak tree.
+)
+
+func TestChunkRandIsReproducible(t *testing.T) {
+	shardA := newTestShards(ShardXz{0, 0})[0]
+	shardB := newTestShards(ShardXz{0, 0})[0]
+
+	a := shardA.chunkAt(ChunkXz{3, 4}).Rand().Int63()
+	b := shardB.chunkAt(ChunkXz{3, 4}).Rand().Int63()
+	if a != b {
+		t.Errorf("Expected the same chunk to give the same random numbers, got %d and %d", a, b)
+	}
+
+	if c := shardA.chunkAt(ChunkXz{4, 3}).Rand().Int63(); c == a {
+		t.Errorf("Expected different chunks to give different random numbers")
+	}
+
+	shardC := newTestShards(ShardXz{0, 0})[0]
+	shardC.seed = 1
+	if c := shardC.chunkAt(ChunkXz{3, 4}).Rand().Int63(); c == a {
+		t.Errorf("Expected worlds with different seeds to give different random numbers")
+	}
+}
+
+func TestChunkRandDiffersWhenReloaded(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	a := shard.chunkAt(chunkLoc).Rand().Int63()
+	tickUntilUnloaded(t, shard, chunkLoc)
+
+	if b := shard.chunkAt(chunkLoc).Rand().Int63(); b == a {
+		t.Errorf("Expected a reloaded chunk not to repeat its random numbers")
+	}
+}
+
+func TestRandomTickGrowsCrops(t *testing.T) {
+	shard := newTestShards(ShardXz{0, 0})[0]
+	digTestTunnel(t, shard, 6, 10)
+	testSetBlock(t, shard, BlockXyz{8, 4, 8}, testBlockIdFarmland)
+	testSetBlock(t, shard, BlockXyz{8, 5, 8}, testBlockIdCrops)
+	chunk := shard.chunkAt(ChunkXz{0, 0})
+
+	cropData := func() byte {
+		_, data, _ := chunk.BlockAt(&BlockXyz{8, 5, 8})
+		return data
+	}
+
+	shard.randomTicks = 0
+	tickTestShards([]*ChunkShard{shard}, 20)
+	if data := cropData(); data != 0 {
+		t.Errorf("Expected crop not to grow without random ticks, got stage %d", data)
+	}
+
+	// Each block is picked about 20 times.
+	shard.randomTicks = 4096
+	for i := 0; i < 20; i++ {
+		chunk.randomTick()
+	}
+	if blockId := testBlockIdAt(shard, BlockXyz{8, 5, 8}); blockId != testBlockIdCrops {
+		t.Fatalf("Expected crop to remain, got block %d", blockId)
+	}
+	if data := cropData(); data == 0 {
+		t.Errorf("Expected crop to grow on random ticks")
+	}
+}
+
+func testLoadedBlockIdAt(t *testing.T, shard *ChunkShard, blockLoc BlockXyz) BlockId {
+	chunk, index, _ := shard.loadedBlock(&blockLoc)
+	if chunk == nil {
+		t.Fatalf("Block %v not loaded in %v", blockLoc, shard)
+	}
+	return index.BlockId(chunk.blocks)
+}
+
+func TestSaplingGrowsAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Plant the sapling on the surface at the edge of shard A.
+	chunk := shardA.chunkAt(ChunkXz{15, 0})
+	shardB.chunkAt(ChunkXz{16, 0})
+	saplingLoc := BlockXyz{255, ChunkSizeY - 1, 8}
+	for testLoadedBlockIdAt(t, shardA, saplingLoc) == BlockIdAir {
+		saplingLoc.Y--
+	}
+	saplingLoc.Y++
+	testSetBlock(t, shardA, saplingLoc, testBlockIdSapling)
+
+	instance, blockType, ok := chunk.blockInstanceAndType(&saplingLoc)
+	if !ok {
+		t.Fatalf("Block %v not available", saplingLoc)
+	}
+	aspect := blockType.Aspect.(gamerules.IRandomTickAspect)
+	for i := 0; i < 1000 && testLoadedBlockIdAt(t, shardA, saplingLoc) == testBlockIdSapling; i++ {
+		aspect.RandomTick(instance)
+	}
+	tickTestShards(shards, 2)
+
+	if blockId := testLoadedBlockIdAt(t, shardA, saplingLoc); blockId != testBlockIdLog {
+		t.Fatalf("Expected sapling to grow into a tree, got block %d", blockId)
+	}
+	leaves := 0
+	for y := saplingLoc.Y; y < ChunkSizeY-1; y++ {
+		if testLoadedBlockIdAt(t, shardB, BlockXyz{256, y, 8}) == testBlockIdLeaves {
+			leaves++
+		}
+	}
+	if leaves == 0 {
+		t.Errorf("Expected tree to grow leaves in shard B")
+	}
+}
+
+func TestSaplingBranchesGrowAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Plant big oak saplings along the edge of shard A. Their branches point
+	// in random directions, but some reach into shard B, where shard A
+	// doesn't know the blocks.
+	var saplings []BlockXyz
+	for z := BlockCoord(8); z < 128; z += 16 {
+		chunk := shardA.chunkAt(ChunkXz{15, ChunkCoord(z / ChunkSizeH)})
+		shardB.chunkAt(ChunkXz{16, ChunkCoord(z / ChunkSizeH)})
+		saplingLoc := BlockXyz{255, ChunkSizeY - 1, z}
+		for testLoadedBlockIdAt(t, shardA, saplingLoc) == BlockIdAir {
+			saplingLoc.Y--
+		}
+		saplingLoc.Y++
+		_, subLoc := saplingLoc.ToChunkLocal()
+		index, _ := subLoc.BlockIndex()
+		chunk.SetBlockByIndex(index, testBlockIdSapling, testTreeBigOak)
+
+		instance, blockType, ok := chunk.blockInstanceAndType(&saplingLoc)
+		if !ok {
+			t.Fatalf("Block %v not available", saplingLoc)
+		}
+		aspect := blockType.Aspect.(gamerules.IRandomTickAspect)
+		for i := 0; i < 1000 && testLoadedBlockIdAt(t, shardA, saplingLoc) == testBlockIdSapling; i++ {
+			aspect.RandomTick(instance)
+		}
+		saplings = append(saplings, saplingLoc)
+	}
+	tickTestShards(shards, 2)
+
+	for _, saplingLoc := range saplings {
+		if blockId := testLoadedBlockIdAt(t, shardA, saplingLoc); blockId != testBlockIdLog {
+			t.Errorf("Expected sapling at %v to grow into a tree, got block %d", saplingLoc, blockId)
+		}
+	}
+	logs := 0
+	for x := BlockCoord(256); x < 260; x++ {
+		for y := BlockYCoord(0); y < ChunkSizeY-1; y++ {
+			for z := BlockCoord(0); z < 128; z++ {
+				if testLoadedBlockIdAt(t, shardB, BlockXyz{x, y, z}) == testBlockIdLog {
+					logs++
+				}
+			}
+		}
+	}
+	if logs == 0 {
+		t.Errorf("Expected trees to grow branches in shard B")
+	}
+}
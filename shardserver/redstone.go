@@ -0,0 +1,156 @@
+package shardserver
+
+import (
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Offsets to the blocks that a block can give redstone power to. These are
+// the blocks touching its faces, and the blocks that wire can step up or down
+// to.
+var redstoneTargetOffsets = [14]struct {
+	dx BlockCoord
+	dy BlockYCoord
+	dz BlockCoord
+}{
+	{-1, 0, 0}, {1, 0, 0},
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+	{-1, -1, 0}, {1, -1, 0}, {0, -1, -1}, {0, -1, 1},
+	{-1, 1, 0}, {1, 1, 0}, {0, 1, -1}, {0, 1, 1},
+}
+
+// redstoneLink identifies the redstone power given by one block to another.
+type redstoneLink struct {
+	from, to BlockXyz
+}
+
+type destRedstoneShard struct {
+	loc    ShardXz
+	powers []gamerules.RedstonePower
+}
+
+// markRedstoneBorder marks the power given to blocks in other shards by the
+// block and its neighbours to be sent by transferRedstonePowers. It is called
+// when the block changes, which can change the power that it and the blocks
+// that it charges give out.
+func (shard *ChunkShard) markRedstoneBorder(blockLoc *BlockXyz) {
+	// Only blocks near the edge of the shard can affect other shards.
+	minX := BlockCoord(shard.originChunkLoc.X) * ChunkSizeH
+	minZ := BlockCoord(shard.originChunkLoc.Z) * ChunkSizeH
+	maxX := minX + ShardSize*ChunkSizeH - 1
+	maxZ := minZ + ShardSize*ChunkSizeH - 1
+	if blockLoc.X-minX >= 2 && maxX-blockLoc.X >= 2 && blockLoc.Z-minZ >= 2 && maxZ-blockLoc.Z >= 2 {
+		return
+	}
+
+	shard.markRedstoneLinks(blockLoc)
+	for _, offset := range neighbourOffsets {
+		if neighbourLoc := blockLoc.AddXyz(offset.dx, offset.dy, offset.dz); neighbourLoc != nil {
+			shard.markRedstoneLinks(neighbourLoc)
+		}
+	}
+}
+
+// markRedstoneLinks marks the power given by the block to blocks in other
+// shards to be sent.
+func (shard *ChunkShard) markRedstoneLinks(blockLoc *BlockXyz) {
+	if _, _, _, ok := shard.chunkIndexAndRelLoc(*blockLoc.ToChunkXz()); !ok {
+		return
+	}
+
+	for _, offset := range redstoneTargetOffsets {
+		targetLoc := blockLoc.AddXyz(offset.dx, offset.dy, offset.dz)
+		if targetLoc == nil {
+			continue
+		}
+
+		if _, _, _, ok := shard.chunkIndexAndRelLoc(*targetLoc.ToChunkXz()); !ok {
+			shard.newRedstoneLinks[redstoneLink{*blockLoc, *targetLoc}] = true
+		}
+	}
+}
+
+// transferRedstonePowers sends the power given to blocks in other shards by
+// the links marked by markRedstoneBorder.
+func (shard *ChunkShard) transferRedstonePowers() {
+	if len(shard.newRedstoneLinks) == 0 {
+		return
+	}
+
+	destShards := make(map[uint64]*destRedstoneShard)
+
+	for link := range shard.newRedstoneLinks {
+		delete(shard.newRedstoneLinks, link)
+
+		chunk, _, _ := shard.loadedBlock(&link.from)
+		if chunk == nil {
+			continue
+		}
+
+		shardLoc := link.to.ToChunkXz().ToShardXz()
+		shardKey := shardLoc.Key()
+		destShard, ok := destShards[shardKey]
+		if !ok {
+			destShard = &destRedstoneShard{loc: shardLoc}
+			destShards[shardKey] = destShard
+		}
+
+		destShard.powers = append(destShard.powers, gamerules.RedstonePower{
+			From:          link.from,
+			To:            link.to,
+			RedstoneInput: gamerules.RedstoneInputFrom(chunk, &link.from, &link.to),
+		})
+	}
+
+	for _, destShard := range destShards {
+		if client := shard.clientForShard(destShard.loc); client != nil {
+			client.ReqSetRedstonePower(destShard.powers)
+		}
+	}
+}
+
+// reqSetRedstonePower records the power given to blocks in this shard by
+// blocks in other shards, and activates the blocks that might be affected by
+// any change in it.
+func (shard *ChunkShard) reqSetRedstonePower(powers []gamerules.RedstonePower) {
+	for i := range powers {
+		power := &powers[i]
+		link := redstoneLink{power.From, power.To}
+
+		if shard.redstoneInputs[link] == power.RedstoneInput {
+			continue
+		}
+
+		if power.RedstoneInput == (gamerules.RedstoneInput{}) {
+			delete(shard.redstoneInputs, link)
+		} else {
+			shard.redstoneInputs[link] = power.RedstoneInput
+		}
+
+		// A block charged by the power might pass it on to other shards.
+		shard.markRedstoneBorder(&power.To)
+
+		for dx := -2; dx <= 2; dx++ {
+			for dy := -2; dy <= 2; dy++ {
+				for dz := -2; dz <= 2; dz++ {
+					if abs(dx)+abs(dy)+abs(dz) > 2 {
+						continue
+					}
+
+					blockLoc := power.To.AddXyz(BlockCoord(dx), BlockYCoord(dy), BlockCoord(dz))
+					if blockLoc != nil {
+						shard.addActiveBlock(blockLoc)
+					}
+				}
+			}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
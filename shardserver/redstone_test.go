@@ -0,0 +1,75 @@
+package shardserver
+
+import (
+	"testing"
+
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const (
+	testBlockIdWire  = BlockId(55)
+	testBlockIdLever = BlockId(69)
+)
+
+func testInteractBlock(t *testing.T, shard *ChunkShard, blockLoc BlockXyz) {
+	chunk := shard.chunkAt(*blockLoc.ToChunkXz())
+	if chunk == nil {
+		t.Fatalf("Chunk for %v not available", blockLoc)
+	}
+	instance, blockType, ok := chunk.blockInstanceAndType(&blockLoc)
+	if !ok {
+		t.Fatalf("Block %v not available", blockLoc)
+	}
+	blockType.Aspect.Interact(instance, nil)
+}
+
+func checkWireLevels(t *testing.T, shards []*ChunkShard, expected map[BlockCoord]byte) {
+	for x, level := range expected {
+		blockLoc := BlockXyz{x, 5, 8}
+		for _, shard := range shards {
+			chunk, index, inShard := shard.loadedBlock(&blockLoc)
+			if !inShard {
+				continue
+			}
+			if blockId, blockData := chunk.blockId(index), index.BlockData(chunk.blockData); blockId != testBlockIdWire || blockData != level {
+				t.Errorf("Expected wire level %d at x=%d, got %d:%d", level, x, blockId, blockData)
+			}
+		}
+	}
+}
+
+func TestRedstoneAcrossShards(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0}, ShardXz{1, 0})
+	shardA, shardB := shards[0], shards[1]
+
+	// Shard B starts at X=256.
+	digTestTunnel(t, shardA, 250, 255)
+	digTestTunnel(t, shardB, 256, 262)
+	testPlaceFluid(t, shardA, BlockXyz{252, 5, 8}, testBlockIdLever, 5)
+	for x := BlockCoord(253); x <= 260; x++ {
+		shard := shardA
+		if x >= 256 {
+			shard = shardB
+		}
+		testSetBlock(t, shard, BlockXyz{x, 5, 8}, testBlockIdWire)
+	}
+	tickTestShards(shards, 5)
+
+	testInteractBlock(t, shardA, BlockXyz{252, 5, 8})
+	tickTestShards(shards, 10)
+	checkWireLevels(t, shards, map[BlockCoord]byte{
+		253: 15,
+		255: 13,
+		256: 12,
+		260: 8,
+	})
+
+	testInteractBlock(t, shardA, BlockXyz{252, 5, 8})
+	tickTestShards(shards, 100)
+	checkWireLevels(t, shards, map[BlockCoord]byte{
+		253: 0,
+		255: 0,
+		256: 0,
+		260: 0,
+	})
+}
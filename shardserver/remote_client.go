@@ -0,0 +1,469 @@
+package shardserver
+
+import (
+	"encoding/gob"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Minimum time between attempts to reconnect to a shard after its connection
+// has failed.
+const remoteReconnectDelay = time.Second
+
+// RemoteShardConnecter implements IShardConnecter by connecting to shards
+// served by a ShardServer over the network.
+// TODO Multiplex connections to the same server over a single TCP connection,
+// rather than making one per client.
+type RemoteShardConnecter struct {
+	addr string
+}
+
+func NewRemoteShardConnecter(addr string) *RemoteShardConnecter {
+	return &RemoteShardConnecter{
+		addr: addr,
+	}
+}
+
+func (rc *RemoteShardConnecter) PlayerShardConnect(entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) gamerules.IPlayerShardClient {
+	return newRemotePlayerShardClient(rc.addr, rc.shardAddr, entityId, player, shardLoc)
+}
+
+func (rc *RemoteShardConnecter) ShardShardConnect(shardLoc ShardXz) gamerules.IShardShardClient {
+	return newRemoteShardShardClient(rc.addr, rc.shardAddr, shardLoc)
+}
+
+// shardAddr returns the address to reconnect to a shard at, which is always
+// the same.
+func (rc *RemoteShardConnecter) shardAddr(loc ShardXz) (string, error) {
+	return rc.addr, nil
+}
+
+// dialShard connects to the ShardServer at addr and sends the hello message.
+// It returns a nil sender if the connection failed.
+func dialShard(addr string, hello *connHello) (conn net.Conn, sender *msgSender) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		log.Printf("Failed to connect to shard %v at %s: %v", hello.ShardLoc, addr, err)
+		return nil, nil
+	}
+
+	sender = newMsgSender(conn)
+	sender.send(hello)
+
+	return
+}
+
+// remoteConn is a connection to a shard served by a ShardServer. The shard is
+// looked up and connected to in the background, so that sending never blocks.
+// If the connection fails, the shard is looked up again and reconnected to, as
+// it might have moved to another host.
+type remoteConn struct {
+	hello connHello
+
+	// lookup finds the address to connect to. The connection is not remade if
+	// it is nil.
+	lookup func(loc ShardXz) (addr string, err error)
+
+	// onConnect is called with each new connection before any messages other
+	// than the hello are sent on it. It is called with lock held.
+	onConnect func(conn net.Conn, sender *msgSender)
+
+	// queue is true if messages sent while not connected are to be sent once
+	// connected, otherwise they are dropped.
+	queue bool
+
+	lock       sync.Mutex
+	sender     *msgSender
+	pending    []interface{} // Messages waiting for a connection.
+	connecting bool          // Is a connection being made in the background?
+	closed     bool
+	retryAt    time.Time // Time of the next allowed attempt to reconnect.
+}
+
+// connect starts making the first connection to the shard at addr. If addr is
+// empty then the shard is looked up first.
+func (rc *remoteConn) connect(addr string) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.connecting = true
+	go rc.dial(addr)
+}
+
+// dial connects to the ShardServer at addr, looking up the shard first if addr
+// is empty. It is run on its own goroutine, so that senders aren't held up by
+// the lookup or connection.
+func (rc *remoteConn) dial(addr string) {
+	var conn net.Conn
+	var sender *msgSender
+
+	if addr == "" && rc.lookup != nil {
+		var err error
+		if addr, err = rc.lookup(rc.hello.ShardLoc); err != nil {
+			log.Printf("Failed to look up shard %v: %v", rc.hello.ShardLoc, err)
+		} else {
+			log.Printf("Connecting to shard %v at %s", rc.hello.ShardLoc, addr)
+		}
+	}
+	if addr != "" {
+		conn, sender = dialShard(addr, &rc.hello)
+	}
+
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.connecting = false
+	rc.retryAt = time.Now().Add(remoteReconnectDelay)
+
+	if sender == nil {
+		return
+	}
+	if rc.closed {
+		sender.close()
+		return
+	}
+
+	rc.sender = sender
+	if rc.onConnect != nil {
+		rc.onConnect(conn, sender)
+	}
+
+	for _, msg := range rc.pending {
+		sender.send(msg)
+	}
+	rc.pending = nil
+}
+
+// reconnect starts replacing a failed connection in the background, unless it
+// was attempted too recently. It must be called with rc.lock held.
+func (rc *remoteConn) reconnect() {
+	if rc.connecting || rc.lookup == nil || time.Now().Before(rc.retryAt) {
+		return
+	}
+
+	if rc.sender != nil {
+		rc.sender.close()
+		rc.sender = nil
+	}
+
+	rc.connecting = true
+	go rc.dial("")
+}
+
+// send sends msg, or queues it until connected. It returns false if msg was
+// dropped.
+func (rc *remoteConn) send(msg interface{}) (accepted bool) {
+	return rc.sendUpdate(msg, nil)
+}
+
+// sendUpdate sends msg, after calling update (if not nil) to change the state
+// that onConnect restores upon reconnection. update is told whether msg is
+// being sent, or if it is to be dropped or queued. The state is changed under
+// the same lock as onConnect is called with, so that it is never restored
+// without the change while msg is dropped. It returns false if msg was
+// dropped.
+func (rc *remoteConn) sendUpdate(msg interface{}, update func(sent bool)) (accepted bool) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if rc.closed {
+		return false
+	}
+
+	connected := rc.sender != nil && !rc.sender.hasFailed()
+	if update != nil {
+		update(connected)
+	}
+
+	if connected {
+		return rc.sender.send(msg)
+	}
+
+	rc.reconnect()
+
+	// Beyond msgQueueSize, messages are dropped as for a connection that
+	// can't keep up.
+	if rc.queue && len(rc.pending) < msgQueueSize {
+		rc.pending = append(rc.pending, msg)
+		return true
+	}
+	return false
+}
+
+func (rc *remoteConn) close() {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.closed = true
+	rc.pending = nil
+	if rc.sender != nil {
+		rc.sender.close()
+	}
+}
+
+// remotePlayerShardClient implements IPlayerShardClient for
+// RemoteShardConnecter. Requests are dropped while the shard can't be
+// connected to. The player's chunk subscriptions and data in the shard are
+// sent again upon reconnecting.
+type remotePlayerShardClient struct {
+	entityId EntityId
+	player   gamerules.IPlayerClient
+	shardLoc ShardXz
+	conn     remoteConn
+
+	// The following are guarded by conn.lock.
+	chunks     map[ChunkXz]bool  // Subscribed chunks, and if notify is unsent.
+	playerData *msgAddPlayerData // The player's data in the shard, if any.
+}
+
+// newRemotePlayerShardClient creates a client connected to the shard at addr.
+// If addr is empty, or the connection fails, the shard is found again with
+// lookup.
+func newRemotePlayerShardClient(addr string, lookup func(ShardXz) (string, error), entityId EntityId, player gamerules.IPlayerClient, shardLoc ShardXz) *remotePlayerShardClient {
+	client := &remotePlayerShardClient{
+		entityId: entityId,
+		player:   player,
+		shardLoc: shardLoc,
+		chunks:   make(map[ChunkXz]bool),
+	}
+
+	client.conn.hello = connHello{
+		IsPlayer: true,
+		EntityId: entityId,
+		Name:     player.GetName(),
+		ShardLoc: shardLoc,
+	}
+	client.conn.lookup = lookup
+	client.conn.onConnect = client.connected
+	client.conn.connect(addr)
+
+	return client
+}
+
+// connected starts receiving from a new connection, and restores the player's
+// state in the shard, which is non-empty if requests were made before
+// connecting or if it is a reconnection.
+func (client *remotePlayerShardClient) connected(conn net.Conn, sender *msgSender) {
+	go client.receive(conn, sender)
+
+	for chunkLoc, notify := range client.chunks {
+		sender.send(&msgSubscribeChunk{chunkLoc, notify})
+		client.chunks[chunkLoc] = false
+	}
+	if client.playerData != nil {
+		playerData := *client.playerData
+		sender.send(&playerData)
+	}
+}
+
+// receive performs calls upon the player that are requested by the shard.
+func (client *remotePlayerShardClient) receive(conn net.Conn, sender *msgSender) {
+	decoder := gob.NewDecoder(conn)
+
+	for {
+		var msg interface{}
+		if err := decoder.Decode(&msg); err != nil {
+			if err != io.EOF && !sender.isClosed() {
+				log.Printf("Error reading from shard %v: %v", client.shardLoc, err)
+			}
+			sender.fail()
+			return
+		}
+
+		switch msg := msg.(type) {
+		case iPlayerClientMsg:
+			msg.perform(client.player)
+		case *msgPositionLook:
+			position, look := client.player.PositionLook()
+			client.send(&msgPositionLookReply{
+				Seq:      msg.Seq,
+				Position: position,
+				Look:     look,
+			})
+		default:
+			log.Printf("Unexpected %T from shard %v", msg, client.shardLoc)
+			sender.fail()
+			return
+		}
+	}
+}
+
+func (client *remotePlayerShardClient) send(msg interface{}) {
+	client.conn.send(msg)
+}
+
+func (client *remotePlayerShardClient) Disconnect() {
+	client.conn.close()
+}
+
+func (client *remotePlayerShardClient) ReqSubscribeChunk(chunkLoc ChunkXz, notify bool) {
+	client.conn.sendUpdate(&msgSubscribeChunk{chunkLoc, notify}, func(sent bool) {
+		client.chunks[chunkLoc] = notify && !sent
+	})
+}
+
+func (client *remotePlayerShardClient) ReqUnsubscribeChunk(chunkLoc ChunkXz) {
+	client.conn.sendUpdate(&msgUnsubscribeChunk{chunkLoc}, func(sent bool) {
+		delete(client.chunks, chunkLoc)
+	})
+}
+
+func (client *remotePlayerShardClient) ReqMulticastPlayers(chunkLoc ChunkXz, exclude EntityId, packet []byte) {
+	client.send(&msgMulticastPlayers{chunkLoc, exclude, packet})
+}
+
+func (client *remotePlayerShardClient) ReqAddPlayerData(chunkLoc ChunkXz, name string, position AbsXyz, look LookBytes, appearance gamerules.PlayerAppearance) {
+	msg := &msgAddPlayerData{chunkLoc, name, position, look, appearance}
+	client.conn.sendUpdate(msg, func(sent bool) {
+		// A copy is kept, as msg might not have been encoded yet when the
+		// copy is changed.
+		playerData := *msg
+		client.playerData = &playerData
+	})
+}
+
+func (client *remotePlayerShardClient) ReqRemovePlayerData(chunkLoc ChunkXz, newChunkLoc ChunkXz, isDisconnect bool) {
+	client.conn.sendUpdate(&msgRemovePlayerData{chunkLoc, newChunkLoc, isDisconnect}, func(sent bool) {
+		client.playerData = nil
+	})
+}
+
+func (client *remotePlayerShardClient) ReqSetPlayerPosition(chunkLoc ChunkXz, position AbsXyz) {
+	client.conn.sendUpdate(&msgSetPlayerPosition{chunkLoc, position}, func(sent bool) {
+		if client.playerData != nil {
+			client.playerData.Position = position
+		}
+	})
+}
+
+func (client *remotePlayerShardClient) ReqSetPlayerLook(chunkLoc ChunkXz, look LookBytes) {
+	client.conn.sendUpdate(&msgSetPlayerLook{chunkLoc, look}, func(sent bool) {
+		if client.playerData != nil {
+			client.playerData.Look = look
+		}
+	})
+}
+
+func (client *remotePlayerShardClient) ReqSetPlayerAppearance(chunkLoc ChunkXz, appearance gamerules.PlayerAppearance) {
+	client.conn.sendUpdate(&msgSetPlayerAppearance{chunkLoc, appearance}, func(sent bool) {
+		if client.playerData != nil {
+			client.playerData.Appearance = appearance
+		}
+	})
+}
+
+func (client *remotePlayerShardClient) ReqHitBlock(held gamerules.Slot, gameType GameType, target BlockXyz, digStatus DigStatus, face Face) {
+	client.send(&msgHitBlock{held, gameType, target, digStatus, face})
+}
+
+func (client *remotePlayerShardClient) ReqInteractBlock(held gamerules.Slot, target BlockXyz, face Face) {
+	client.send(&msgInteractBlock{held, target, face})
+}
+
+func (client *remotePlayerShardClient) ReqHitEntity(chunkLoc ChunkXz, target EntityId, held gamerules.Slot, position AbsXyz, pvp bool) {
+	client.send(&msgHitEntity{chunkLoc, target, held, position, pvp})
+}
+
+func (client *remotePlayerShardClient) ReqPlaceItem(target BlockXyz, slot gamerules.Slot) {
+	client.send(&msgPlaceItem{target, slot})
+}
+
+func (client *remotePlayerShardClient) ReqTakeItem(chunkLoc ChunkXz, entityId EntityId) {
+	client.send(&msgTakeItem{chunkLoc, entityId})
+}
+
+func (client *remotePlayerShardClient) ReqDropItem(content gamerules.Slot, position AbsXyz, velocity AbsVelocity, pickupImmunity Ticks) {
+	client.send(&msgDropItem{content, position, velocity, pickupImmunity})
+}
+
+func (client *remotePlayerShardClient) ReqInventoryClick(block BlockXyz, click gamerules.Click) {
+	client.send(&msgInventoryClick{block, click})
+}
+
+func (client *remotePlayerShardClient) ReqInventoryPutItem(block BlockXyz, item gamerules.Slot) {
+	client.send(&msgInventoryPutItem{block, item})
+}
+
+func (client *remotePlayerShardClient) ReqInventoryUnsubscribed(block BlockXyz) {
+	client.send(&msgInventoryUnsubscribed{block})
+}
+
+func (client *remotePlayerShardClient) ReqMakePortal(position AbsXyz, look LookDegrees) {
+	client.send(&msgMakePortal{position, look})
+}
+
+// remoteShardShardClient implements IShardShardClient for
+// RemoteShardConnecter. Requests are queued while the shard is being connected
+// to.
+type remoteShardShardClient struct {
+	conn remoteConn
+}
+
+// newRemoteShardShardClient creates a client connected to the shard at addr.
+// If addr is empty, or the connection fails, the shard is found again with
+// lookup.
+func newRemoteShardShardClient(addr string, lookup func(ShardXz) (string, error), shardLoc ShardXz) *remoteShardShardClient {
+	client := &remoteShardShardClient{}
+
+	client.conn.hello = connHello{
+		IsPlayer: false,
+		ShardLoc: shardLoc,
+	}
+	client.conn.lookup = lookup
+	client.conn.queue = true
+	client.conn.connect(addr)
+
+	return client
+}
+
+func (client *remoteShardShardClient) Disconnect() {
+	client.conn.close()
+}
+
+func (client *remoteShardShardClient) ReqSetActiveBlocks(blocks []BlockXyz) {
+	client.conn.send(&msgSetActiveBlocks{blocks})
+}
+
+func (client *remoteShardShardClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) bool {
+	msg, err := newMsgTransferEntity(loc, entity)
+	if err != nil {
+		log.Printf("Failed to transfer entity %d: %v", entity.GetEntityId(), err)
+		return false
+	}
+
+	return client.conn.send(msg)
+}
+
+func (client *remoteShardShardClient) ReqUpdateLight(updates []gamerules.LightUpdate) {
+	client.conn.send(&msgUpdateLight{updates})
+}
+
+func (client *remoteShardShardClient) ReqSpreadBlocks(spreads []gamerules.BlockSpread) {
+	client.conn.send(&msgSpreadBlocks{spreads})
+}
+
+func (client *remoteShardShardClient) ReqPlaceBlocks(placements []gamerules.BlockPlacement) {
+	client.conn.send(&msgPlaceBlocks{placements})
+}
+
+func (client *remoteShardShardClient) ReqSetRedstonePower(powers []gamerules.RedstonePower) {
+	client.conn.send(&msgSetRedstonePower{powers})
+}
+
+func (client *remoteShardShardClient) ReqSetBorderBlocks(blocks []gamerules.BorderBlock) {
+	client.conn.send(&msgSetBorderBlocks{blocks})
+}
+
+func (client *remoteShardShardClient) ReqLightFire(blockLoc BlockXyz) {
+	client.conn.send(&msgLightFire{blockLoc})
+}
+
+func (client *remoteShardShardClient) ReqExplode(explosion gamerules.Explosion) {
+	client.conn.send(&msgExplode{explosion})
+}
@@ -0,0 +1,733 @@
+package shardserver
+
+// Defines the messages passed over the network between ShardServer and its
+// remote clients. Messages are gob encoded, each as an interface{} value so
+// that the receiver can dispatch on the concrete message type.
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/nbt"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// Size of the queue of messages waiting to be sent on a connection.
+const msgQueueSize = 256
+
+// connHello is the first message sent by a client on a new connection to a
+// ShardServer. It identifies the kind of connection being made.
+type connHello struct {
+	IsPlayer bool
+	EntityId EntityId // Only set if IsPlayer.
+	Name     string   // Only set if IsPlayer.
+	ShardLoc ShardXz
+}
+
+// iPlayerShardMsg is a message from a player frontend to a shard. It is
+// replayed on the shard server as a call upon an IPlayerShardClient.
+type iPlayerShardMsg interface {
+	perform(client gamerules.IPlayerShardClient)
+}
+
+// iShardShardMsg is a message from one shard to another. It is replayed on the
+// shard server as a call upon an IShardShardClient.
+type iShardShardMsg interface {
+	perform(client gamerules.IShardShardClient)
+}
+
+// iPlayerClientMsg is a message from a shard to a player frontend. It is
+// replayed on the frontend as a call upon an IPlayerClient.
+type iPlayerClientMsg interface {
+	perform(player gamerules.IPlayerClient)
+}
+
+func init() {
+	gob.Register(&connHello{})
+
+	// Player -> shard messages.
+	gob.Register(&msgSubscribeChunk{})
+	gob.Register(&msgUnsubscribeChunk{})
+	gob.Register(&msgMulticastPlayers{})
+	gob.Register(&msgAddPlayerData{})
+	gob.Register(&msgRemovePlayerData{})
+	gob.Register(&msgSetPlayerPosition{})
+	gob.Register(&msgSetPlayerLook{})
+	gob.Register(&msgSetPlayerAppearance{})
+	gob.Register(&msgHitBlock{})
+	gob.Register(&msgInteractBlock{})
+	gob.Register(&msgHitEntity{})
+	gob.Register(&msgPlaceItem{})
+	gob.Register(&msgTakeItem{})
+	gob.Register(&msgDropItem{})
+	gob.Register(&msgInventoryClick{})
+	gob.Register(&msgInventoryPutItem{})
+	gob.Register(&msgInventoryUnsubscribed{})
+	gob.Register(&msgMakePortal{})
+	gob.Register(&msgPositionLookReply{})
+
+	// Shard -> shard messages.
+	gob.Register(&msgSetActiveBlocks{})
+	gob.Register(&msgTransferEntity{})
+	gob.Register(&msgUpdateLight{})
+	gob.Register(&msgSpreadBlocks{})
+	gob.Register(&msgPlaceBlocks{})
+	gob.Register(&msgSetRedstonePower{})
+	gob.Register(&msgSetBorderBlocks{})
+	gob.Register(&msgLightFire{})
+	gob.Register(&msgExplode{})
+
+	// Shard -> player messages.
+	gob.Register(&msgTransmitPacket{})
+	gob.Register(new(msgNotifyChunkLoad))
+	gob.Register(&msgEntitySpawn{})
+	gob.Register(&msgEntityMoved{})
+	gob.Register(&msgEntityDestroy{})
+	gob.Register(&msgInventorySubscribed{})
+	gob.Register(&msgInventorySlotUpdate{})
+	gob.Register(&msgInventoryProgressUpdate{})
+	gob.Register(&msgInventoryCursorUpdate{})
+	gob.Register(&msgInventoryTxState{})
+	gob.Register(&msgInventoryUnsubscribedNotify{})
+	gob.Register(&msgPlaceHeldItem{})
+	gob.Register(&msgWearHeldTool{})
+	gob.Register(&msgOfferItem{})
+	gob.Register(&msgGiveItemAtPosition{})
+	gob.Register(&msgGiveItem{})
+	gob.Register(&msgHurt{})
+	gob.Register(&msgSetSurroundings{})
+	gob.Register(&msgRejectMove{})
+	gob.Register(&msgSetBed{})
+	gob.Register(&msgPositionLook{})
+	gob.Register(&msgSetPositionLook{})
+	gob.Register(&msgSetGameType{})
+	gob.Register(&msgEchoMessage{})
+}
+
+// Player -> shard messages follow.
+
+type msgSubscribeChunk struct {
+	ChunkLoc ChunkXz
+	Notify   bool
+}
+
+func (msg *msgSubscribeChunk) perform(client gamerules.IPlayerShardClient) {
+	client.ReqSubscribeChunk(msg.ChunkLoc, msg.Notify)
+}
+
+type msgUnsubscribeChunk struct {
+	ChunkLoc ChunkXz
+}
+
+func (msg *msgUnsubscribeChunk) perform(client gamerules.IPlayerShardClient) {
+	client.ReqUnsubscribeChunk(msg.ChunkLoc)
+}
+
+type msgMulticastPlayers struct {
+	ChunkLoc ChunkXz
+	Exclude  EntityId
+	Packet   []byte
+}
+
+func (msg *msgMulticastPlayers) perform(client gamerules.IPlayerShardClient) {
+	client.ReqMulticastPlayers(msg.ChunkLoc, msg.Exclude, msg.Packet)
+}
+
+type msgAddPlayerData struct {
+	ChunkLoc   ChunkXz
+	Name       string
+	Position   AbsXyz
+	Look       LookBytes
+	Appearance gamerules.PlayerAppearance
+}
+
+func (msg *msgAddPlayerData) perform(client gamerules.IPlayerShardClient) {
+	client.ReqAddPlayerData(msg.ChunkLoc, msg.Name, msg.Position, msg.Look, msg.Appearance)
+}
+
+type msgRemovePlayerData struct {
+	ChunkLoc     ChunkXz
+	NewChunkLoc  ChunkXz
+	IsDisconnect bool
+}
+
+func (msg *msgRemovePlayerData) perform(client gamerules.IPlayerShardClient) {
+	client.ReqRemovePlayerData(msg.ChunkLoc, msg.NewChunkLoc, msg.IsDisconnect)
+}
+
+type msgSetPlayerPosition struct {
+	ChunkLoc ChunkXz
+	Position AbsXyz
+}
+
+func (msg *msgSetPlayerPosition) perform(client gamerules.IPlayerShardClient) {
+	client.ReqSetPlayerPosition(msg.ChunkLoc, msg.Position)
+}
+
+type msgSetPlayerLook struct {
+	ChunkLoc ChunkXz
+	Look     LookBytes
+}
+
+func (msg *msgSetPlayerLook) perform(client gamerules.IPlayerShardClient) {
+	client.ReqSetPlayerLook(msg.ChunkLoc, msg.Look)
+}
+
+type msgSetPlayerAppearance struct {
+	ChunkLoc   ChunkXz
+	Appearance gamerules.PlayerAppearance
+}
+
+func (msg *msgSetPlayerAppearance) perform(client gamerules.IPlayerShardClient) {
+	client.ReqSetPlayerAppearance(msg.ChunkLoc, msg.Appearance)
+}
+
+type msgHitBlock struct {
+	Held      gamerules.Slot
+	GameType  GameType
+	Target    BlockXyz
+	DigStatus DigStatus
+	Face      Face
+}
+
+func (msg *msgHitBlock) perform(client gamerules.IPlayerShardClient) {
+	client.ReqHitBlock(msg.Held, msg.GameType, msg.Target, msg.DigStatus, msg.Face)
+}
+
+type msgInteractBlock struct {
+	Held   gamerules.Slot
+	Target BlockXyz
+	Face   Face
+}
+
+func (msg *msgInteractBlock) perform(client gamerules.IPlayerShardClient) {
+	client.ReqInteractBlock(msg.Held, msg.Target, msg.Face)
+}
+
+type msgHitEntity struct {
+	ChunkLoc ChunkXz
+	Target   EntityId
+	Held     gamerules.Slot
+	Position AbsXyz
+	Pvp      bool
+}
+
+func (msg *msgHitEntity) perform(client gamerules.IPlayerShardClient) {
+	client.ReqHitEntity(msg.ChunkLoc, msg.Target, msg.Held, msg.Position, msg.Pvp)
+}
+
+type msgPlaceItem struct {
+	Target BlockXyz
+	Slot   gamerules.Slot
+}
+
+func (msg *msgPlaceItem) perform(client gamerules.IPlayerShardClient) {
+	client.ReqPlaceItem(msg.Target, msg.Slot)
+}
+
+type msgTakeItem struct {
+	ChunkLoc ChunkXz
+	EntityId EntityId
+}
+
+func (msg *msgTakeItem) perform(client gamerules.IPlayerShardClient) {
+	client.ReqTakeItem(msg.ChunkLoc, msg.EntityId)
+}
+
+type msgDropItem struct {
+	Content        gamerules.Slot
+	Position       AbsXyz
+	Velocity       AbsVelocity
+	PickupImmunity Ticks
+}
+
+func (msg *msgDropItem) perform(client gamerules.IPlayerShardClient) {
+	client.ReqDropItem(msg.Content, msg.Position, msg.Velocity, msg.PickupImmunity)
+}
+
+type msgInventoryClick struct {
+	Block BlockXyz
+	Click gamerules.Click
+}
+
+func (msg *msgInventoryClick) perform(client gamerules.IPlayerShardClient) {
+	client.ReqInventoryClick(msg.Block, msg.Click)
+}
+
+type msgInventoryPutItem struct {
+	Block BlockXyz
+	Item  gamerules.Slot
+}
+
+func (msg *msgInventoryPutItem) perform(client gamerules.IPlayerShardClient) {
+	client.ReqInventoryPutItem(msg.Block, msg.Item)
+}
+
+type msgInventoryUnsubscribed struct {
+	Block BlockXyz
+}
+
+func (msg *msgInventoryUnsubscribed) perform(client gamerules.IPlayerShardClient) {
+	client.ReqInventoryUnsubscribed(msg.Block)
+}
+
+type msgMakePortal struct {
+	Position AbsXyz
+	Look     LookDegrees
+}
+
+func (msg *msgMakePortal) perform(client gamerules.IPlayerShardClient) {
+	client.ReqMakePortal(msg.Position, msg.Look)
+}
+
+// msgPositionLookReply is the frontend's response to msgPositionLook. It is
+// handled directly by the shard server rather than being performed.
+type msgPositionLookReply struct {
+	Seq      uint32
+	Position AbsXyz
+	Look     LookDegrees
+}
+
+// Shard -> shard messages follow.
+
+type msgSetActiveBlocks struct {
+	Blocks []BlockXyz
+}
+
+func (msg *msgSetActiveBlocks) perform(client gamerules.IShardShardClient) {
+	client.ReqSetActiveBlocks(msg.Blocks)
+}
+
+type msgUpdateLight struct {
+	Updates []gamerules.LightUpdate
+}
+
+func (msg *msgUpdateLight) perform(client gamerules.IShardShardClient) {
+	client.ReqUpdateLight(msg.Updates)
+}
+
+type msgSpreadBlocks struct {
+	Spreads []gamerules.BlockSpread
+}
+
+func (msg *msgSpreadBlocks) perform(client gamerules.IShardShardClient) {
+	client.ReqSpreadBlocks(msg.Spreads)
+}
+
+type msgPlaceBlocks struct {
+	Placements []gamerules.BlockPlacement
+}
+
+func (msg *msgPlaceBlocks) perform(client gamerules.IShardShardClient) {
+	client.ReqPlaceBlocks(msg.Placements)
+}
+
+type msgSetRedstonePower struct {
+	Powers []gamerules.RedstonePower
+}
+
+func (msg *msgSetRedstonePower) perform(client gamerules.IShardShardClient) {
+	client.ReqSetRedstonePower(msg.Powers)
+}
+
+type msgSetBorderBlocks struct {
+	Blocks []gamerules.BorderBlock
+}
+
+func (msg *msgSetBorderBlocks) perform(client gamerules.IShardShardClient) {
+	client.ReqSetBorderBlocks(msg.Blocks)
+}
+
+type msgLightFire struct {
+	Block BlockXyz
+}
+
+func (msg *msgLightFire) perform(client gamerules.IShardShardClient) {
+	client.ReqLightFire(msg.Block)
+}
+
+type msgExplode struct {
+	Explosion gamerules.Explosion
+}
+
+func (msg *msgExplode) perform(client gamerules.IShardShardClient) {
+	client.ReqExplode(msg.Explosion)
+}
+
+// msgTransferEntity carries an entity serialized as NBT.
+type msgTransferEntity struct {
+	ChunkLoc ChunkXz
+	EntityId EntityId
+	Nbt      []byte
+}
+
+func newMsgTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) (msg *msgTransferEntity, err error) {
+	tag := nbt.NewCompound()
+	if err = entity.MarshalNbt(tag); err != nil {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err = nbt.Write(buf, tag); err != nil {
+		return
+	}
+
+	msg = &msgTransferEntity{
+		ChunkLoc: loc,
+		EntityId: entity.GetEntityId(),
+		Nbt:      buf.Bytes(),
+	}
+
+	return
+}
+
+func (msg *msgTransferEntity) perform(client gamerules.IShardShardClient) {
+	tag, err := nbt.Read(bytes.NewBuffer(msg.Nbt))
+	if err != nil {
+		log.Printf("msgTransferEntity: bad entity NBT: %v", err)
+		return
+	}
+
+	typeName, ok := tag.Lookup("id").(*nbt.String)
+	if !ok {
+		log.Printf("msgTransferEntity: missing entity type ID in NBT")
+		return
+	}
+
+	entity := gamerules.NewEntityByTypeName(typeName.Value)
+	if entity == nil {
+		log.Printf("msgTransferEntity: unhandled entity type: %s", typeName.Value)
+		return
+	}
+
+	if err = entity.UnmarshalNbt(tag); err != nil {
+		log.Printf("msgTransferEntity: error unmarshalling entity NBT: %v", err)
+		return
+	}
+
+	// The entity keeps its ID, which can't collide with those allocated here
+	// as each host allocates from its own block of IDs.
+	entity.SetEntityId(msg.EntityId)
+
+	if !client.ReqTransferEntity(msg.ChunkLoc, entity) {
+		log.Printf("msgTransferEntity: lost entity %d, as shard %v has stopped", msg.EntityId, msg.ChunkLoc.ToShardXz())
+	}
+}
+
+// Shard -> player messages follow.
+
+type msgTransmitPacket struct {
+	Packet []byte
+}
+
+func (msg *msgTransmitPacket) perform(player gamerules.IPlayerClient) {
+	player.TransmitPacket(msg.Packet)
+}
+
+// msgNotifyChunkLoad has no content. It is not an empty struct, as gob refuses
+// to encode those.
+type msgNotifyChunkLoad byte
+
+func (msg *msgNotifyChunkLoad) perform(player gamerules.IPlayerClient) {
+	player.NotifyChunkLoad()
+}
+
+type msgEntitySpawn struct {
+	ChunkLoc    ChunkXz
+	EntityId    EntityId
+	SpawnPacket []byte
+}
+
+func (msg *msgEntitySpawn) perform(player gamerules.IPlayerClient) {
+	player.EntitySpawn(msg.ChunkLoc, msg.EntityId, msg.SpawnPacket)
+}
+
+type msgEntityMoved struct {
+	FromChunkLoc ChunkXz
+	ToChunkLoc   ChunkXz
+	EntityId     EntityId
+}
+
+func (msg *msgEntityMoved) perform(player gamerules.IPlayerClient) {
+	player.EntityMoved(msg.FromChunkLoc, msg.ToChunkLoc, msg.EntityId)
+}
+
+type msgEntityDestroy struct {
+	ChunkLoc ChunkXz
+	EntityId EntityId
+}
+
+func (msg *msgEntityDestroy) perform(player gamerules.IPlayerClient) {
+	player.EntityDestroy(msg.ChunkLoc, msg.EntityId)
+}
+
+type msgInventorySubscribed struct {
+	Block     BlockXyz
+	InvTypeId InvTypeId
+	Slots     []proto.WindowSlot
+}
+
+func (msg *msgInventorySubscribed) perform(player gamerules.IPlayerClient) {
+	player.InventorySubscribed(msg.Block, msg.InvTypeId, msg.Slots)
+}
+
+type msgInventorySlotUpdate struct {
+	Block  BlockXyz
+	Slot   gamerules.Slot
+	SlotId SlotId
+}
+
+func (msg *msgInventorySlotUpdate) perform(player gamerules.IPlayerClient) {
+	player.InventorySlotUpdate(msg.Block, msg.Slot, msg.SlotId)
+}
+
+type msgInventoryProgressUpdate struct {
+	Block    BlockXyz
+	PrgBarId PrgBarId
+	Value    PrgBarValue
+}
+
+func (msg *msgInventoryProgressUpdate) perform(player gamerules.IPlayerClient) {
+	player.InventoryProgressUpdate(msg.Block, msg.PrgBarId, msg.Value)
+}
+
+type msgInventoryCursorUpdate struct {
+	Block  BlockXyz
+	Cursor gamerules.Slot
+}
+
+func (msg *msgInventoryCursorUpdate) perform(player gamerules.IPlayerClient) {
+	player.InventoryCursorUpdate(msg.Block, msg.Cursor)
+}
+
+type msgInventoryTxState struct {
+	Block    BlockXyz
+	TxId     TxId
+	Accepted bool
+}
+
+func (msg *msgInventoryTxState) perform(player gamerules.IPlayerClient) {
+	player.InventoryTxState(msg.Block, msg.TxId, msg.Accepted)
+}
+
+type msgInventoryUnsubscribedNotify struct {
+	Block BlockXyz
+}
+
+func (msg *msgInventoryUnsubscribedNotify) perform(player gamerules.IPlayerClient) {
+	player.InventoryUnsubscribed(msg.Block)
+}
+
+type msgPlaceHeldItem struct {
+	Target  BlockXyz
+	WasHeld gamerules.Slot
+}
+
+func (msg *msgPlaceHeldItem) perform(player gamerules.IPlayerClient) {
+	player.PlaceHeldItem(msg.Target, msg.WasHeld)
+}
+
+type msgWearHeldTool struct {
+	WasHeld gamerules.Slot
+	Uses    ItemData
+}
+
+func (msg *msgWearHeldTool) perform(player gamerules.IPlayerClient) {
+	player.WearHeldTool(msg.WasHeld, msg.Uses)
+}
+
+type msgOfferItem struct {
+	FromChunk ChunkXz
+	EntityId  EntityId
+	Item      gamerules.Slot
+}
+
+func (msg *msgOfferItem) perform(player gamerules.IPlayerClient) {
+	player.OfferItem(msg.FromChunk, msg.EntityId, msg.Item)
+}
+
+type msgGiveItemAtPosition struct {
+	AtPosition AbsXyz
+	Item       gamerules.Slot
+}
+
+func (msg *msgGiveItemAtPosition) perform(player gamerules.IPlayerClient) {
+	player.GiveItemAtPosition(msg.AtPosition, msg.Item)
+}
+
+type msgGiveItem struct {
+	Item gamerules.Slot
+}
+
+func (msg *msgGiveItem) perform(player gamerules.IPlayerClient) {
+	player.GiveItem(msg.Item)
+}
+
+type msgHurt struct {
+	Amount Health
+}
+
+func (msg *msgHurt) perform(player gamerules.IPlayerClient) {
+	player.Hurt(msg.Amount)
+}
+
+type msgSetSurroundings struct {
+	Surroundings gamerules.Surroundings
+}
+
+func (msg *msgSetSurroundings) perform(player gamerules.IPlayerClient) {
+	player.SetSurroundings(msg.Surroundings)
+}
+
+type msgRejectMove struct {
+	Position AbsXyz
+}
+
+func (msg *msgRejectMove) perform(player gamerules.IPlayerClient) {
+	player.RejectMove(msg.Position)
+}
+
+type msgSetBed struct {
+	Bed BlockXyz
+}
+
+func (msg *msgSetBed) perform(player gamerules.IPlayerClient) {
+	player.SetBed(msg.Bed)
+}
+
+// msgPositionLook requests a msgPositionLookReply from the frontend. It is
+// handled directly by the frontend rather than being performed.
+type msgPositionLook struct {
+	Seq uint32
+}
+
+type msgSetPositionLook struct {
+	Position AbsXyz
+	Look     LookDegrees
+}
+
+func (msg *msgSetPositionLook) perform(player gamerules.IPlayerClient) {
+	player.SetPositionLook(msg.Position, msg.Look)
+}
+
+type msgSetGameType struct {
+	GameType GameType
+}
+
+func (msg *msgSetGameType) perform(player gamerules.IPlayerClient) {
+	player.SetGameType(msg.GameType)
+}
+
+type msgEchoMessage struct {
+	Msg string
+}
+
+func (msg *msgEchoMessage) perform(player gamerules.IPlayerClient) {
+	player.EchoMessage(msg.Msg)
+}
+
+// msgSender encodes messages onto a connection from its own goroutine, so that
+// callers do not block on network I/O.
+type msgSender struct {
+	conn     net.Conn
+	queue    chan interface{}
+	lock     sync.Mutex // Guards closed, and sending on queue.
+	closed   bool
+	failed   chan struct{} // Closed when the connection fails.
+	failOnce sync.Once
+}
+
+func newMsgSender(conn net.Conn) *msgSender {
+	sender := &msgSender{
+		conn:   conn,
+		queue:  make(chan interface{}, msgQueueSize),
+		failed: make(chan struct{}),
+	}
+
+	go sender.run()
+
+	return sender
+}
+
+func (sender *msgSender) run() {
+	encoder := gob.NewEncoder(sender.conn)
+	var err error
+
+	// Keep draining the queue after an error so that senders never block.
+	for msg := range sender.queue {
+		if err == nil && !sender.hasFailed() {
+			if err = encoder.Encode(&msg); err != nil {
+				if !sender.hasFailed() {
+					log.Printf("msgSender to %v: %v", sender.conn.RemoteAddr(), err)
+				}
+				sender.fail()
+			}
+		}
+	}
+
+	sender.conn.Close()
+}
+
+// send queues the message for sending. It does nothing if the sender has been
+// closed or has failed. It never blocks - if the queue is full then the peer
+// isn't keeping up, and the connection is failed rather than holding up the
+// caller.
+func (sender *msgSender) send(msg interface{}) (queued bool) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	if sender.closed || sender.hasFailed() {
+		return false
+	}
+
+	select {
+	case sender.queue <- msg:
+		return true
+	default:
+		log.Printf("msgSender to %v: queue full, dropping connection", sender.conn.RemoteAddr())
+		sender.fail()
+		return false
+	}
+}
+
+// fail closes the connection after an error. Messages sent afterwards are
+// dropped.
+func (sender *msgSender) fail() {
+	sender.failOnce.Do(func() {
+		close(sender.failed)
+		sender.conn.Close()
+	})
+}
+
+// hasFailed returns true if the connection has failed.
+func (sender *msgSender) hasFailed() bool {
+	select {
+	case <-sender.failed:
+		return true
+	default:
+	}
+	return false
+}
+
+func (sender *msgSender) isClosed() bool {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	return sender.closed
+}
+
+// close closes the connection after all queued messages have been sent.
+func (sender *msgSender) close() {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	if !sender.closed {
+		sender.closed = true
+		close(sender.queue)
+	}
+}
@@ -0,0 +1,342 @@
+package shardserver
+
+import (
+	"encoding/gob"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// ShardServer serves shards hosted by an IShardConnecter (typically a
+// LocalShardManager) to remote clients connecting over the network via
+// RemoteShardConnecter.
+type ShardServer struct {
+	listener  net.Listener
+	connecter gamerules.IShardConnecter
+}
+
+// iShardOwner is implemented by IShardConnecters that serve shards themselves,
+// but might not own all of them, such as LocalShardManager.
+type iShardOwner interface {
+	ownsShard(loc ShardXz) bool
+	confirmShard(loc ShardXz) bool
+}
+
+// iStoppableClient is implemented by shard clients whose shard can stop while
+// connected to, such as when the host loses ownership of it.
+type iStoppableClient interface {
+	// stopped returns a channel that is closed when the shard has stopped.
+	stopped() <-chan bool
+}
+
+func NewShardServer(listener net.Listener, connecter gamerules.IShardConnecter) *ShardServer {
+	return &ShardServer{
+		listener:  listener,
+		connecter: connecter,
+	}
+}
+
+// Serve accepts connections until the listener is closed.
+func (srv *ShardServer) Serve() (err error) {
+	for {
+		var conn net.Conn
+		if conn, err = srv.listener.Accept(); err != nil {
+			return
+		}
+
+		go srv.handleConn(conn)
+	}
+}
+
+func (srv *ShardServer) handleConn(conn net.Conn) {
+	decoder := gob.NewDecoder(conn)
+
+	var msg interface{}
+	if err := decoder.Decode(&msg); err != nil {
+		log.Printf("ShardServer: error reading hello from %v: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+
+	hello, ok := msg.(*connHello)
+	if !ok {
+		log.Printf("ShardServer: expected hello from %v, got %T", conn.RemoteAddr(), msg)
+		conn.Close()
+		return
+	}
+
+	if owner, ok := srv.connecter.(iShardOwner); ok && !owner.confirmShard(hello.ShardLoc) {
+		log.Printf("ShardServer: rejecting connection from %v to shard %v, which is not served here", conn.RemoteAddr(), hello.ShardLoc)
+		conn.Close()
+		return
+	}
+
+	if hello.IsPlayer {
+		srv.servePlayer(conn, decoder, hello)
+	} else {
+		srv.serveShard(conn, decoder, hello)
+	}
+}
+
+func (srv *ShardServer) servePlayer(conn net.Conn, decoder *gob.Decoder, hello *connHello) {
+	player := newRemotePlayerClient(hello.EntityId, hello.Name, newMsgSender(conn))
+	defer player.close()
+
+	client := srv.connecter.PlayerShardConnect(hello.EntityId, player, hello.ShardLoc)
+	defer client.Disconnect()
+
+	finished := make(chan bool)
+	defer close(finished)
+	if !srv.watchShard(conn, hello.ShardLoc, client, finished) {
+		return
+	}
+
+	for {
+		var msg interface{}
+		if err := decoder.Decode(&msg); err != nil {
+			if err != io.EOF {
+				log.Printf("ShardServer: error reading from player %d: %v", hello.EntityId, err)
+			}
+			return
+		}
+
+		switch msg := msg.(type) {
+		case iPlayerShardMsg:
+			msg.perform(client)
+		case *msgPositionLookReply:
+			player.positionLookReply(msg)
+		default:
+			log.Printf("ShardServer: unexpected %T from player %d", msg, hello.EntityId)
+			return
+		}
+	}
+}
+
+func (srv *ShardServer) serveShard(conn net.Conn, decoder *gob.Decoder, hello *connHello) {
+	defer conn.Close()
+
+	client := srv.connecter.ShardShardConnect(hello.ShardLoc)
+	defer client.Disconnect()
+
+	finished := make(chan bool)
+	defer close(finished)
+	if !srv.watchShard(conn, hello.ShardLoc, client, finished) {
+		return
+	}
+
+	for {
+		var msg interface{}
+		if err := decoder.Decode(&msg); err != nil {
+			if err != io.EOF {
+				log.Printf("ShardServer: error reading from shard client %v: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		if msg, ok := msg.(iShardShardMsg); ok {
+			msg.perform(client)
+		} else {
+			log.Printf("ShardServer: unexpected %T from shard client %v", msg, conn.RemoteAddr())
+			return
+		}
+	}
+}
+
+// watchShard closes conn if the shard that client is connected to stops being
+// served before finished is closed, so that the remote end looks up the shard
+// again. It returns false if the shard was not served by the time it was
+// connected to.
+func (srv *ShardServer) watchShard(conn net.Conn, loc ShardXz, client interface{}, finished <-chan bool) bool {
+	if owner, ok := srv.connecter.(iShardOwner); ok && !owner.ownsShard(loc) {
+		log.Printf("ShardServer: closing connection from %v to shard %v, which is no longer served here", conn.RemoteAddr(), loc)
+		return false
+	}
+
+	if stoppable, ok := client.(iStoppableClient); ok {
+		go func() {
+			select {
+			case <-stoppable.stopped():
+				conn.Close()
+			case <-finished:
+			}
+		}()
+	}
+
+	return true
+}
+
+// remotePlayerClient implements IPlayerClient on the shard server, forwarding
+// calls to the player frontend.
+type remotePlayerClient struct {
+	entityId EntityId
+	name     string
+	sender   *msgSender
+
+	// Outstanding PositionLook requests, keyed by sequence number.
+	lock    sync.Mutex
+	seq     uint32
+	pending map[uint32]chan *msgPositionLookReply
+	closed  bool
+}
+
+func newRemotePlayerClient(entityId EntityId, name string, sender *msgSender) *remotePlayerClient {
+	return &remotePlayerClient{
+		entityId: entityId,
+		name:     name,
+		sender:   sender,
+		pending:  make(map[uint32]chan *msgPositionLookReply),
+	}
+}
+
+// close closes the connection to the frontend, and releases any callers
+// waiting upon PositionLook.
+func (p *remotePlayerClient) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	for seq, reply := range p.pending {
+		close(reply)
+		delete(p.pending, seq)
+	}
+
+	p.sender.close()
+}
+
+func (p *remotePlayerClient) positionLookReply(msg *msgPositionLookReply) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if reply, ok := p.pending[msg.Seq]; ok {
+		reply <- msg
+		delete(p.pending, msg.Seq)
+	}
+}
+
+func (p *remotePlayerClient) GetEntityId() EntityId {
+	return p.entityId
+}
+
+func (p *remotePlayerClient) GetName() string {
+	return p.name
+}
+
+func (p *remotePlayerClient) TransmitPacket(packet []byte) {
+	p.sender.send(&msgTransmitPacket{packet})
+}
+
+func (p *remotePlayerClient) NotifyChunkLoad() {
+	p.sender.send(new(msgNotifyChunkLoad))
+}
+
+func (p *remotePlayerClient) EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte) {
+	p.sender.send(&msgEntitySpawn{chunkLoc, entityId, spawnPacket})
+}
+
+func (p *remotePlayerClient) EntityMoved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId) {
+	p.sender.send(&msgEntityMoved{fromChunkLoc, toChunkLoc, entityId})
+}
+
+func (p *remotePlayerClient) EntityDestroy(chunkLoc ChunkXz, entityId EntityId) {
+	p.sender.send(&msgEntityDestroy{chunkLoc, entityId})
+}
+
+func (p *remotePlayerClient) InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots []proto.WindowSlot) {
+	p.sender.send(&msgInventorySubscribed{block, invTypeId, slots})
+}
+
+func (p *remotePlayerClient) InventorySlotUpdate(block BlockXyz, slot gamerules.Slot, slotId SlotId) {
+	p.sender.send(&msgInventorySlotUpdate{block, slot, slotId})
+}
+
+func (p *remotePlayerClient) InventoryProgressUpdate(block BlockXyz, prgBarId PrgBarId, value PrgBarValue) {
+	p.sender.send(&msgInventoryProgressUpdate{block, prgBarId, value})
+}
+
+func (p *remotePlayerClient) InventoryCursorUpdate(block BlockXyz, cursor gamerules.Slot) {
+	p.sender.send(&msgInventoryCursorUpdate{block, cursor})
+}
+
+func (p *remotePlayerClient) InventoryTxState(block BlockXyz, txId TxId, accepted bool) {
+	p.sender.send(&msgInventoryTxState{block, txId, accepted})
+}
+
+func (p *remotePlayerClient) InventoryUnsubscribed(block BlockXyz) {
+	p.sender.send(&msgInventoryUnsubscribedNotify{block})
+}
+
+func (p *remotePlayerClient) PlaceHeldItem(target BlockXyz, wasHeld gamerules.Slot) {
+	p.sender.send(&msgPlaceHeldItem{target, wasHeld})
+}
+
+func (p *remotePlayerClient) WearHeldTool(wasHeld gamerules.Slot, uses ItemData) {
+	p.sender.send(&msgWearHeldTool{wasHeld, uses})
+}
+
+func (p *remotePlayerClient) OfferItem(fromChunk ChunkXz, entityId EntityId, item gamerules.Slot) {
+	p.sender.send(&msgOfferItem{fromChunk, entityId, item})
+}
+
+func (p *remotePlayerClient) GiveItemAtPosition(atPosition AbsXyz, item gamerules.Slot) {
+	p.sender.send(&msgGiveItemAtPosition{atPosition, item})
+}
+
+func (p *remotePlayerClient) GiveItem(item gamerules.Slot) {
+	p.sender.send(&msgGiveItem{item})
+}
+
+func (p *remotePlayerClient) Hurt(amount Health) {
+	p.sender.send(&msgHurt{amount})
+}
+
+func (p *remotePlayerClient) SetSurroundings(surroundings gamerules.Surroundings) {
+	p.sender.send(&msgSetSurroundings{surroundings})
+}
+
+func (p *remotePlayerClient) RejectMove(position AbsXyz) {
+	p.sender.send(&msgRejectMove{position})
+}
+
+func (p *remotePlayerClient) SetBed(bed BlockXyz) {
+	p.sender.send(&msgSetBed{bed})
+}
+
+// PositionLook makes a round trip to the frontend. If the connection is lost
+// before a reply arrives then zero values are returned.
+func (p *remotePlayerClient) PositionLook() (position AbsXyz, look LookDegrees) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return
+	}
+	p.seq++
+	seq := p.seq
+	reply := make(chan *msgPositionLookReply, 1)
+	p.pending[seq] = reply
+	p.lock.Unlock()
+
+	p.sender.send(&msgPositionLook{seq})
+
+	if msg, ok := <-reply; ok {
+		position, look = msg.Position, msg.Look
+	}
+
+	return
+}
+
+func (p *remotePlayerClient) SetPositionLook(position AbsXyz, look LookDegrees) {
+	p.sender.send(&msgSetPositionLook{position, look})
+}
+
+func (p *remotePlayerClient) SetGameType(gameType GameType) {
+	p.sender.send(&msgSetGameType{gameType})
+}
+
+func (p *remotePlayerClient) EchoMessage(msg string) {
+	p.sender.send(&msgEchoMessage{msg})
+}
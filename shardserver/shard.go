@@ -1,6 +1,8 @@
 package shardserver
 
 import (
+	"expvar"
+	"flag"
 	"fmt"
 	"log"
 	"time"
@@ -16,6 +18,59 @@ const chunksPerShard = ShardSize * ShardSize
 // TODO Allow configuration of this.
 const ticksBetweenSaves = TicksPerSecond * 60
 
+// Cached connections to other shards are dropped if they go unused for this
+// long, so that idle shards can be released by their host.
+const ticksBetweenClientExpiry = TicksPerSecond * 10
+
+var (
+	chunkUnloadIdleSecs = flag.Int(
+		"chunk_unload_idle_secs", 60,
+		"Number of seconds that a chunk must be idle before it is saved and "+
+			"unloaded from memory. Zero disables unloading.")
+
+	shardUnloadIdleSecs = flag.Int(
+		"shard_unload_idle_secs", 120,
+		"Number of seconds that a shard must have no loaded chunks before it is "+
+			"stopped. Zero disables unloading.")
+
+	chunkSaveBudgetMs = flag.Int(
+		"chunk_save_budget_ms", 5,
+		"Milliseconds per tick that each shard may spend saving chunks. At "+
+			"least one chunk is saved per tick while any are waiting.")
+
+	randomTicksPerSection = flag.Int(
+		"random_ticks_per_section", 3,
+		"Number of blocks picked at random in each 16 block high section of a "+
+			"chunk to receive a random tick each tick. Zero disables random "+
+			"ticks.")
+
+	itemLifetimeSecs = flag.Int(
+		"item_lifetime_secs", 300,
+		"Number of seconds that a dropped item lasts before it despawns. Zero "+
+			"disables despawning.")
+)
+
+var (
+	expVarChunkSaveBacklog *expvar.Int
+	expVarChunkSaveCount   *expvar.Int
+	expVarChunkSaveTimeNs  *expvar.Int
+)
+
+func init() {
+	expVarChunkSaveBacklog = expvar.NewInt("chunk-save-backlog")
+	expVarChunkSaveCount = expvar.NewInt("chunk-save-count")
+	expVarChunkSaveTimeNs = expvar.NewInt("chunk-save-time-ns")
+}
+
+// iShardHost is implemented by IShardConnecter implementations that host
+// ChunkShards and can release them when they become idle.
+type iShardHost interface {
+	// releaseShard removes the shard from the host if nothing holds a
+	// connection to it and it has no pending requests. Returns true if the
+	// shard was removed, in which case the shard must stop serving.
+	releaseShard(shard *ChunkShard) bool
+}
+
 // chunkXzToChunkIndex assumes that locDelta is offset relative to the shard
 // origin.
 func chunkXzToChunkIndex(locDelta *ChunkXz) int {
@@ -29,18 +84,49 @@ type ChunkShard struct {
 	chunkStore       chunkstore.IChunkStore
 	entityMgr        *entity.EntityManager
 	loc              ShardXz
+	seed             int64 // World seed, mixed into the random numbers of chunks.
 	originChunkLoc   ChunkXz // The lowest X and Z located chunk in the shard.
 	chunks           [chunksPerShard]*Chunk
 	requests         chan iShardRequest
+	ticks            Ticks // Number of ticks that the shard has run for.
 	ticksSinceUpdate Ticks
 	ticksSinceSave   Ticks
 	saveChunks       bool
+	saveQueue        []int         // Indices of chunks waiting to be saved.
+	saveBudget       time.Duration // Time per tick to spend saving chunks.
+	chunkIdleTicks   Ticks         // Idle ticks before a chunk is unloaded (0 = never).
+	shardIdleTicks   Ticks         // Idle ticks before the shard is released (0 = never).
+	randomTicks      int           // Random ticks per chunk section per tick.
+	raining          bool          // Whether it is raining in the world (no weather yet, so never).
+	idleTicks        Ticks         // Number of ticks that the shard has had no chunks.
+	stopped          bool
+	done             chan bool // Closed once the shard has stopped serving.
 
-	newActiveBlocks []BlockXyz
 	newActiveShards map[uint64]*destActiveShard
+	newSpreads      map[uint64]*destSpreadShard
+	newPlacements   map[uint64]*destPlacementShard
 
-	shardClients map[uint64]gamerules.IShardShardClient
-	selfClient   shardSelfClient
+	skyLighting     lighting
+	blockLighting   lighting
+	newLightUpdates map[uint64]*destLightShard
+
+	redstoneInputs   map[redstoneLink]gamerules.RedstoneInput // Power from blocks in other shards.
+	newRedstoneLinks map[redstoneLink]bool                    // Power to blocks in other shards to send.
+
+	borderBlocks    map[BlockXyz]borderBlock // Blocks in other shards that touch this shard.
+	newBorderBlocks map[BlockXyz]bool        // Blocks at the edge of this shard to send.
+
+	deferredWork map[int]*deferredWork // Work for chunks that are not loaded, by chunk index.
+
+	shardClients           map[uint64]*shardClientRef
+	ticksSinceClientExpiry Ticks
+	selfClient             shardSelfClient
+}
+
+// shardClientRef holds a cached connection to another shard.
+type shardClientRef struct {
+	client gamerules.IShardShardClient
+	used   bool // Has the client been used since the last expiry check?
 }
 
 func NewChunkShard(shardConnecter gamerules.IShardConnecter, chunkStore chunkstore.IChunkStore, entityMgr *entity.EntityManager, loc ShardXz) (shard *ChunkShard) {
@@ -51,27 +137,49 @@ func NewChunkShard(shardConnecter gamerules.IShardConnecter, chunkStore chunksto
 		loc:              loc,
 		originChunkLoc:   loc.ToChunkXz(),
 		requests:         make(chan iShardRequest, 256),
+		done:             make(chan bool),
 		ticksSinceUpdate: 0,
 		saveChunks:       chunkStore.SupportsWrite(),
+		saveBudget:       time.Duration(*chunkSaveBudgetMs) * time.Millisecond,
+		chunkIdleTicks:   Ticks(*chunkUnloadIdleSecs) * TicksPerSecond,
+		shardIdleTicks:   Ticks(*shardUnloadIdleSecs) * TicksPerSecond,
+		randomTicks:      *randomTicksPerSection,
 
 		// Offset shard saves.
 		ticksSinceSave: (31 * Ticks(loc.Key())) % ticksBetweenSaves,
 
 		newActiveShards: make(map[uint64]*destActiveShard),
+		newLightUpdates: make(map[uint64]*destLightShard),
+		newSpreads:      make(map[uint64]*destSpreadShard),
+		newPlacements:   make(map[uint64]*destPlacementShard),
+
+		redstoneInputs:   make(map[redstoneLink]gamerules.RedstoneInput),
+		newRedstoneLinks: make(map[redstoneLink]bool),
 
-		shardClients: make(map[uint64]gamerules.IShardShardClient),
+		borderBlocks:    make(map[BlockXyz]borderBlock),
+		newBorderBlocks: make(map[BlockXyz]bool),
+
+		deferredWork: make(map[int]*deferredWork),
+
+		shardClients: make(map[uint64]*shardClientRef),
 	}
 
 	shard.selfClient.shard = shard
+	shard.skyLighting.Init(shard, true)
+	shard.blockLighting.Init(shard, false)
 
 	return
 }
 
-// serve services shard requests in the foreground.
+// serve services shard requests in the foreground. It returns when the shard
+// has been released by its host.
 func (shard *ChunkShard) serve() {
+	defer close(shard.done)
+
 	ticker := time.NewTicker(NanosecondsInSecond / TicksPerSecond)
+	defer ticker.Stop()
 
-	for {
+	for !shard.stopped {
 		select {
 		case <-ticker.C:
 			shard.tick()
@@ -84,6 +192,7 @@ func (shard *ChunkShard) serve() {
 
 // tick runs the shard for a single tick.
 func (shard *ChunkShard) tick() {
+	shard.ticks++
 	shard.ticksSinceUpdate++
 
 	for _, chunk := range shard.chunks {
@@ -101,45 +210,249 @@ func (shard *ChunkShard) tick() {
 		shard.ticksSinceUpdate = 0
 	}
 
-	if shard.saveChunks && shard.chunkStore.SupportsWrite() {
+	if shard.canSave() {
 		shard.ticksSinceSave++
 		if shard.ticksSinceSave > ticksBetweenSaves {
-			log.Printf("%s: Writing chunks.", shard)
-			// TODO Stagger the per-chunk saves over multiple ticks.
-			for _, chunk := range shard.chunks {
-				if chunk != nil {
-					chunk.save(shard.chunkStore)
-				}
-			}
-			shard.ticksSinceSave = 0
+			shard.queueSaves()
 		}
+		shard.saveQueued()
 	}
 
+	shard.transferSpreads()
+	shard.transferPlacements()
+	// Power is sent before the blocks that it wakes up.
+	shard.transferRedstonePowers()
 	shard.transferActiveBlocks()
+	shard.transferLightUpdates()
+	shard.transferBorderBlocks()
+
+	shard.unloadIdleChunks()
+	shard.expireShardClients()
+	shard.releaseIfIdle()
+}
+
+// queueSaves queues all loaded chunks that have changed for saving by
+// saveQueued.
+func (shard *ChunkShard) queueSaves() {
+	shard.ticksSinceSave = 0
+
+	if len(shard.saveQueue) > 0 {
+		// Still saving from last time.
+		return
+	}
+
+	for index, chunk := range shard.chunks {
+		if chunk != nil && chunk.storeDirty {
+			shard.saveQueue = append(shard.saveQueue, index)
+		}
+	}
+
+	if len(shard.saveQueue) > 0 {
+		log.Printf("%s: Writing %d chunks.", shard, len(shard.saveQueue))
+		expVarChunkSaveBacklog.Add(int64(len(shard.saveQueue)))
+	}
+}
+
+// saveQueued saves chunks queued by queueSaves until the shard's save budget
+// for the tick is used up.
+func (shard *ChunkShard) saveQueued() {
+	start := time.Now()
+	saved := false
+
+	for len(shard.saveQueue) > 0 && (!saved || time.Since(start) < shard.saveBudget) {
+		index := shard.saveQueue[0]
+		shard.saveQueue = shard.saveQueue[1:]
+		expVarChunkSaveBacklog.Add(-1)
+
+		// The chunk might have been unloaded (and saved) since being queued.
+		if chunk := shard.chunks[index]; chunk != nil && chunk.storeDirty {
+			shard.saveChunk(chunk)
+			saved = true
+		}
+	}
+}
+
+// saveAll immediately writes all loaded chunks that have changed to the chunk
+// store, regardless of the save budget.
+func (shard *ChunkShard) saveAll() {
+	if !shard.canSave() {
+		return
+	}
+
+	for _, chunk := range shard.chunks {
+		if chunk != nil && chunk.storeDirty {
+			shard.saveChunk(chunk)
+		}
+	}
+
+	expVarChunkSaveBacklog.Add(-int64(len(shard.saveQueue)))
+	shard.saveQueue = nil
+	shard.ticksSinceSave = 0
+}
+
+// saveChunk writes a chunk to the chunk store, and records the time taken.
+func (shard *ChunkShard) saveChunk(chunk *Chunk) {
+	start := time.Now()
+	chunk.save(shard.chunkStore)
+	expVarChunkSaveCount.Add(1)
+	expVarChunkSaveTimeNs.Add(int64(time.Since(start)))
+}
+
+// canSave returns true if chunks can be written to the shard's chunk store.
+func (shard *ChunkShard) canSave() bool {
+	return shard.saveChunks && shard.chunkStore.SupportsWrite()
+}
+
+// unloadIdleChunks saves and removes chunks from memory that have had nothing
+// happening in them for long enough. Such chunks are reloaded by chunkAt when
+// next needed.
+func (shard *ChunkShard) unloadIdleChunks() {
+	if shard.chunkIdleTicks <= 0 {
+		return
+	}
+
+	for index, chunk := range shard.chunks {
+		if chunk == nil {
+			continue
+		}
+
+		if !chunk.isIdle() {
+			chunk.idleTicks = 0
+			continue
+		}
+
+		chunk.idleTicks++
+		if chunk.idleTicks < shard.chunkIdleTicks {
+			continue
+		}
+
+		if chunk.storeDirty {
+			if !shard.canSave() {
+				// Unloading would lose the changes to the chunk.
+				continue
+			}
+			shard.saveChunk(chunk)
+		}
+
+		shard.chunks[index] = nil
+	}
+}
+
+// expireShardClients disconnects cached connections to other shards that
+// have not been used recently.
+func (shard *ChunkShard) expireShardClients() {
+	shard.ticksSinceClientExpiry++
+	if shard.ticksSinceClientExpiry < ticksBetweenClientExpiry {
+		return
+	}
+	shard.ticksSinceClientExpiry = 0
+
+	for shardKey, ref := range shard.shardClients {
+		if ref.used {
+			ref.used = false
+		} else {
+			ref.client.Disconnect()
+			delete(shard.shardClients, shardKey)
+		}
+	}
+}
+
+// releaseIfIdle asks the shard's host to release the shard if it has had no
+// loaded chunks for long enough. The shard stops serving if it is released.
+func (shard *ChunkShard) releaseIfIdle() {
+	if shard.shardIdleTicks <= 0 {
+		return
+	}
+
+	for _, chunk := range shard.chunks {
+		if chunk != nil {
+			shard.idleTicks = 0
+			return
+		}
+	}
+
+	shard.idleTicks++
+	if shard.idleTicks < shard.shardIdleTicks {
+		return
+	}
+
+	if shard.loadDeferredChunks() {
+		// The work would be lost if the shard were released now.
+		shard.idleTicks = 0
+		return
+	}
+
+	host, ok := shard.shardConnecter.(iShardHost)
+	if !ok || !host.releaseShard(shard) {
+		return
+	}
+
+	log.Printf("%s: Released idle shard.", shard)
+
+	// Any chunks still queued for saving were saved when unloaded.
+	shard.stop()
+}
+
+// stop makes the shard stop serving once the current request or tick is
+// done. Requests enqueued after that are dropped. Any changes to chunks that
+// haven't been saved are lost.
+func (shard *ChunkShard) stop() {
+	expVarChunkSaveBacklog.Add(-int64(len(shard.saveQueue)))
+	shard.saveQueue = nil
+
+	for shardKey, ref := range shard.shardClients {
+		ref.client.Disconnect()
+		delete(shard.shardClients, shardKey)
+	}
+
+	shard.stopped = true
 }
 
 // clientForShard is used to get a IShardShardClient for a given shard, reusing
 // IShardShardClient connections for use within the shard. Returns nil if the
 // shard does not exist.
 func (shard *ChunkShard) clientForShard(shardLoc ShardXz) (client gamerules.IShardShardClient) {
-	var ok bool
-
 	if shard.loc.Equals(&shardLoc) {
 		return &shard.selfClient
 	}
 
 	shardKey := shardLoc.Key()
 
-	if client, ok = shard.shardClients[shardKey]; !ok {
-		client = shard.shardConnecter.ShardShardConnect(shardLoc)
-		if client != nil {
-			shard.shardClients[shardKey] = client
+	if ref, ok := shard.shardClients[shardKey]; ok {
+		if !shardClientStopped(ref.client) {
+			ref.used = true
+			return ref.client
+		}
+		// The other shard has stopped while it was connected to, so connect to
+		// wherever it is served now.
+		ref.client.Disconnect()
+		delete(shard.shardClients, shardKey)
+	}
+
+	client = shard.shardConnecter.ShardShardConnect(shardLoc)
+	if client != nil {
+		shard.shardClients[shardKey] = &shardClientRef{
+			client: client,
+			used:   true,
 		}
 	}
 
 	return
 }
 
+// shardClientStopped returns true if client is connected to a shard that has
+// stopped.
+func shardClientStopped(client gamerules.IShardShardClient) bool {
+	if stoppable, ok := client.(iStoppableClient); ok {
+		select {
+		case <-stoppable.stopped():
+			return true
+		default:
+		}
+	}
+	return false
+}
+
 // blockQuery performs a relatively fast query of the BlockId at the given
 // location. known=true if the returned blockTypeId is valid.
 func (shard *ChunkShard) blockQuery(chunkLoc ChunkXz, subLoc *SubChunkXyz) (blockTypeId BlockId, known bool) {
@@ -169,10 +482,6 @@ func (shard *ChunkShard) blockQuery(chunkLoc ChunkXz, subLoc *SubChunkXyz) (bloc
 // transferActiveBlocks takes blocks marked as newly active by addActiveBlock,
 // and informs the chunk in the destination shards.
 func (shard *ChunkShard) transferActiveBlocks() {
-	if len(shard.newActiveBlocks) == 0 {
-		return
-	}
-
 	thisShardKey := shard.loc.Key()
 	for shardKey, activeShard := range shard.newActiveShards {
 		if shardKey == thisShardKey {
@@ -182,6 +491,7 @@ func (shard *ChunkShard) transferActiveBlocks() {
 				client.ReqSetActiveBlocks(activeShard.blocks)
 			}
 		}
+		delete(shard.newActiveShards, shardKey)
 	}
 }
 
@@ -194,6 +504,7 @@ func (shard *ChunkShard) reqSetBlocksActive(blocks []BlockXyz) {
 		if isThisShard {
 			chunk := shard.chunks[chunkIndex]
 			if chunk == nil {
+				shard.deferActiveBlock(chunkIndex, &block)
 				continue
 			}
 			chunk.AddActiveBlock(&block)
@@ -203,13 +514,13 @@ func (shard *ChunkShard) reqSetBlocksActive(blocks []BlockXyz) {
 
 // addActiveBlock sets the given block to be active on the next tick. This
 // works even if the block is not within the shard - it will be made active
-// provided that the chunk that the block is within is loaded.
+// once the chunk that the block is within is loaded.
 func (shard *ChunkShard) addActiveBlock(block *BlockXyz) {
 	chunkXz := block.ToChunkXz()
 	shardXz := chunkXz.ToShardXz()
 	shardKey := shardXz.Key()
 	activeShard, ok := shard.newActiveShards[shardKey]
-	if ok {
+	if !ok {
 		activeShard = &destActiveShard{
 			loc:    shardXz,
 			blocks: []BlockXyz{*block},
@@ -220,6 +531,230 @@ func (shard *ChunkShard) addActiveBlock(block *BlockXyz) {
 	}
 }
 
+// queueSpread queues a block to spread into another block by
+// transferSpreads.
+func (shard *ChunkShard) queueSpread(spread gamerules.BlockSpread) {
+	shardLoc := spread.Block.ToChunkXz().ToShardXz()
+	shardKey := shardLoc.Key()
+
+	spreadShard, ok := shard.newSpreads[shardKey]
+	if !ok {
+		spreadShard = &destSpreadShard{loc: shardLoc}
+		shard.newSpreads[shardKey] = spreadShard
+	}
+	spreadShard.spreads = append(spreadShard.spreads, spread)
+}
+
+// transferSpreads performs the spreads queued by queueSpread, sending them to
+// other shards where necessary.
+func (shard *ChunkShard) transferSpreads() {
+	thisShardKey := shard.loc.Key()
+	for shardKey, spreadShard := range shard.newSpreads {
+		delete(shard.newSpreads, shardKey)
+		if shardKey == thisShardKey {
+			shard.reqSpreadBlocks(spreadShard.spreads)
+		} else if client := shard.clientForShard(spreadShard.loc); client != nil {
+			client.ReqSpreadBlocks(spreadShard.spreads)
+		}
+	}
+}
+
+// reqSpreadBlocks spreads blocks into blocks within the shard.
+func (shard *ChunkShard) reqSpreadBlocks(spreads []gamerules.BlockSpread) {
+	for i := range spreads {
+		spread := &spreads[i]
+
+		chunk := shard.chunkAt(*spread.Block.ToChunkXz())
+		if chunk == nil {
+			continue
+		}
+
+		target, _, ok := chunk.blockInstanceAndType(&spread.Block)
+		if !ok {
+			continue
+		}
+
+		spreadType, ok := gamerules.Blocks.Get(spread.BlockTypeId)
+		if !ok {
+			continue
+		}
+
+		if aspect, ok := spreadType.Aspect.(gamerules.ISpreadingAspect); ok {
+			aspect.SpreadInto(target, spread.BlockData)
+		} else {
+			log.Printf("%v: block type %d does not spread", shard, spread.BlockTypeId)
+		}
+	}
+}
+
+// queuePlacement queues a block to be placed by transferPlacements.
+func (shard *ChunkShard) queuePlacement(placement gamerules.BlockPlacement) {
+	shardLoc := placement.Block.ToChunkXz().ToShardXz()
+	shardKey := shardLoc.Key()
+
+	placementShard, ok := shard.newPlacements[shardKey]
+	if !ok {
+		placementShard = &destPlacementShard{loc: shardLoc}
+		shard.newPlacements[shardKey] = placementShard
+	}
+	placementShard.placements = append(placementShard.placements, placement)
+}
+
+// transferPlacements places the blocks queued by queuePlacement, sending them
+// to other shards where necessary.
+func (shard *ChunkShard) transferPlacements() {
+	thisShardKey := shard.loc.Key()
+	for shardKey, placementShard := range shard.newPlacements {
+		delete(shard.newPlacements, shardKey)
+		if shardKey == thisShardKey {
+			shard.reqPlaceBlocks(placementShard.placements)
+		} else if client := shard.clientForShard(placementShard.loc); client != nil {
+			client.ReqPlaceBlocks(placementShard.placements)
+		}
+	}
+}
+
+// reqPlaceBlocks places blocks within the shard, in order, where the blocks
+// there are replaceable.
+func (shard *ChunkShard) reqPlaceBlocks(placements []gamerules.BlockPlacement) {
+	for i := range placements {
+		placement := &placements[i]
+
+		chunk := shard.chunkAt(*placement.Block.ToChunkXz())
+		if chunk == nil {
+			continue
+		}
+
+		_, subLoc := placement.Block.ToChunkLocal()
+		index, ok := subLoc.BlockIndex()
+		if !ok {
+			continue
+		}
+
+		blockType, _, ok := chunk.blockTypeAndData(index)
+		if !ok || !blockType.Replaceable {
+			continue
+		}
+
+		chunk.SetBlockByIndex(index, placement.BlockTypeId, placement.BlockData)
+	}
+}
+
+// lightFire sets fire to a block, which can be in another shard, if it can
+// burn there. Fire lit inside an obsidian frame lights a portal instead.
+func (shard *ChunkShard) lightFire(blockLoc *BlockXyz) {
+	if shardLoc := blockLoc.ToChunkXz().ToShardXz(); shardLoc != shard.loc {
+		if client := shard.clientForShard(shardLoc); client != nil {
+			client.ReqLightFire(*blockLoc)
+		}
+		return
+	}
+
+	chunk := shard.chunkAt(*blockLoc.ToChunkXz())
+	if chunk == nil {
+		return
+	}
+
+	if instance, _, ok := chunk.blockInstanceAndType(blockLoc); ok {
+		if !gamerules.LightPortal(instance) {
+			gamerules.LightFire(instance)
+		}
+	}
+}
+
+// loadedBlock returns the chunk containing the block and the block's index
+// within it. chunk is nil if the chunk is not loaded, and inShard is false if
+// the block is in another shard. Chunks are not loaded by this.
+func (shard *ChunkShard) loadedBlock(blockLoc *BlockXyz) (chunk *Chunk, index BlockIndex, inShard bool) {
+	chunkLoc, subLoc := blockLoc.ToChunkLocal()
+
+	chunkIndex, _, _, inShard := shard.chunkIndexAndRelLoc(*chunkLoc)
+	if !inShard {
+		return
+	}
+
+	if chunk = shard.chunks[chunkIndex]; chunk == nil {
+		return
+	}
+
+	index, _ = subLoc.BlockIndex()
+
+	return
+}
+
+// blockToUpdate is like loadedBlock, but loads the chunk if it is in the shard
+// and not loaded.
+func (shard *ChunkShard) blockToUpdate(blockLoc *BlockXyz) (chunk *Chunk, index BlockIndex, inShard bool) {
+	chunk, index, inShard = shard.loadedBlock(blockLoc)
+	if !inShard || chunk != nil {
+		return
+	}
+
+	chunkLoc, subLoc := blockLoc.ToChunkLocal()
+	if chunk = shard.chunkAt(*chunkLoc); chunk == nil {
+		return
+	}
+	index, _ = subLoc.BlockIndex()
+
+	return
+}
+
+// queueLightUpdate queues a change in light to be sent to the shard
+// containing the updated block by transferLightUpdates.
+func (shard *ChunkShard) queueLightUpdate(update gamerules.LightUpdate) {
+	shardLoc := update.Block.ToChunkXz().ToShardXz()
+	shardKey := shardLoc.Key()
+
+	lightShard, ok := shard.newLightUpdates[shardKey]
+	if !ok {
+		lightShard = &destLightShard{loc: shardLoc}
+		shard.newLightUpdates[shardKey] = lightShard
+	}
+	lightShard.updates = append(lightShard.updates, update)
+}
+
+// transferLightUpdates sends changes in light queued by queueLightUpdate to
+// their destination shards.
+func (shard *ChunkShard) transferLightUpdates() {
+	for shardKey, lightShard := range shard.newLightUpdates {
+		if client := shard.clientForShard(lightShard.loc); client != nil {
+			client.ReqUpdateLight(lightShard.updates)
+		}
+		delete(shard.newLightUpdates, shardKey)
+	}
+}
+
+// reqUpdateLight propagates changes in light from neighbouring shards into
+// the loaded chunks of this shard.
+func (shard *ChunkShard) reqUpdateLight(updates []gamerules.LightUpdate) {
+	for i := range updates {
+		update := &updates[i]
+
+		chunk, index, inShard := shard.loadedBlock(&update.Block)
+		if !inShard {
+			continue
+		} else if chunk == nil {
+			chunkIndex, _, _, _ := shard.chunkIndexAndRelLoc(*update.Block.ToChunkXz())
+			shard.deferLightUpdate(chunkIndex, update)
+			continue
+		}
+
+		l := &shard.blockLighting
+		if update.Sky {
+			l = &shard.skyLighting
+		}
+
+		if update.Decrease {
+			l.neighbourDecreased(chunk, index, &update.Block, update.Level)
+		} else {
+			l.neighbourIncreased(chunk, index, &update.Block, update.Level)
+		}
+	}
+
+	shard.skyLighting.propagate()
+	shard.blockLighting.propagate()
+}
+
 func (shard *ChunkShard) String() string {
 	return fmt.Sprintf("ChunkShard[%#v/%#v]", shard.loc, shard.originChunkLoc)
 }
@@ -264,10 +799,20 @@ func (shard *ChunkShard) chunkAt(loc ChunkXz) *Chunk {
 	}
 
 	shard.chunks[chunkIndex] = chunk
+	shard.performDeferredWork(chunkIndex, chunk)
 
 	return chunk
 }
 
+// chunkLocForIndex returns the location of the chunk with the given index
+// within the shard.
+func (shard *ChunkShard) chunkLocForIndex(chunkIndex int) ChunkXz {
+	return ChunkXz{
+		X: shard.originChunkLoc.X + ChunkCoord(chunkIndex/ShardSize),
+		Z: shard.originChunkLoc.Z + ChunkCoord(chunkIndex%ShardSize),
+	}
+}
+
 // loadChunk loads the specified chunk from store, and returns it.
 // loc - The absolute world position of the chunk.
 // locDelta - The relative position of the chunk within the shard.
@@ -291,21 +836,28 @@ func (shard *ChunkShard) loadChunk(loc ChunkXz, locDelta ChunkXz) *Chunk {
 
 // enqueueAllChunks runs a given function on all loaded chunks in the shard.
 func (shard *ChunkShard) enqueueAllChunks(fn func(chunk *Chunk)) {
-	shard.requests <- &runOnAllChunks{fn}
+	shard.enqueueRequest(&runOnAllChunks{fn})
 }
 
 // enqueueOnChunk runs a function on the chunk at the given location. If the
 // chunk does not exist, it does nothing.
 func (shard *ChunkShard) enqueueOnChunk(loc ChunkXz, fn func(chunk *Chunk)) {
-	shard.requests <- &runOnChunk{loc, fn}
+	shard.enqueueRequest(&runOnChunk{loc, fn})
 }
 
-func (shard *ChunkShard) enqueue(fn func()) {
-	shard.requests <- &runGeneric{fn}
+func (shard *ChunkShard) enqueue(fn func()) (queued bool) {
+	return shard.enqueueRequest(&runGeneric{fn})
 }
 
-func (shard *ChunkShard) enqueueRequest(req iShardRequest) {
-	shard.requests <- req
+// enqueueRequest queues a request for the shard to perform. The request is
+// dropped if the shard has stopped, in which case false is returned.
+func (shard *ChunkShard) enqueueRequest(req iShardRequest) (queued bool) {
+	select {
+	case shard.requests <- req:
+		return true
+	case <-shard.done:
+		return false
+	}
 }
 
 type destActiveShard struct {
@@ -313,6 +865,21 @@ type destActiveShard struct {
 	blocks []BlockXyz
 }
 
+type destSpreadShard struct {
+	loc     ShardXz
+	spreads []gamerules.BlockSpread
+}
+
+type destPlacementShard struct {
+	loc        ShardXz
+	placements []gamerules.BlockPlacement
+}
+
+type destLightShard struct {
+	loc     ShardXz
+	updates []gamerules.LightUpdate
+}
+
 // shardSelfClient implements IShardShardClient for a shard to efficiently talk
 // to itself.
 type shardSelfClient struct {
@@ -326,9 +893,39 @@ func (client *shardSelfClient) ReqSetActiveBlocks(blocks []BlockXyz) {
 	client.shard.reqSetBlocksActive(blocks)
 }
 
-func (client *shardSelfClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) {
+func (client *shardSelfClient) ReqUpdateLight(updates []gamerules.LightUpdate) {
+	client.shard.reqUpdateLight(updates)
+}
+
+func (client *shardSelfClient) ReqSpreadBlocks(spreads []gamerules.BlockSpread) {
+	client.shard.reqSpreadBlocks(spreads)
+}
+
+func (client *shardSelfClient) ReqPlaceBlocks(placements []gamerules.BlockPlacement) {
+	client.shard.reqPlaceBlocks(placements)
+}
+
+func (client *shardSelfClient) ReqSetRedstonePower(powers []gamerules.RedstonePower) {
+	client.shard.reqSetRedstonePower(powers)
+}
+
+func (client *shardSelfClient) ReqSetBorderBlocks(blocks []gamerules.BorderBlock) {
+	client.shard.reqSetBorderBlocks(blocks)
+}
+
+func (client *shardSelfClient) ReqLightFire(blockLoc BlockXyz) {
+	client.shard.lightFire(&blockLoc)
+}
+
+func (client *shardSelfClient) ReqExplode(explosion gamerules.Explosion) {
+	client.shard.reqExplode(&explosion)
+}
+
+func (client *shardSelfClient) ReqTransferEntity(loc ChunkXz, entity gamerules.INonPlayerEntity) bool {
 	chunk := client.shard.chunkAt(loc)
-	if chunk != nil {
-		chunk.transferEntity(entity)
+	if chunk == nil {
+		return false
 	}
+	chunk.transferEntity(entity)
+	return true
 }
@@ -0,0 +1,343 @@
+package shardserver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/huin/chunkymonkey/chunkstore"
+	"github.com/huin/chunkymonkey/entity"
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/generation"
+	"github.com/huin/chunkymonkey/nbt"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+// newTestSavingStore returns a chunk store that saves chunks in a new
+// directory, and generates chunks that have not been saved. The directory
+// must be removed by the caller.
+func newTestSavingStore(t *testing.T) (store chunkstore.IChunkStore, worldPath string) {
+	worldPath, err := ioutil.TempDir("", "shardserver_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levelData := nbt.NewCompound()
+	data := nbt.NewCompound()
+	data.Set("version", &nbt.Int{19132})
+	levelData.Set("Data", data)
+
+	betaStore, err := chunkstore.ChunkStoreForLevel(worldPath, levelData, DimensionNormal)
+	if err != nil {
+		os.RemoveAll(worldPath)
+		t.Fatal(err)
+	}
+
+	persistantStore := chunkstore.NewChunkService(betaStore)
+	readStores := []chunkstore.IChunkStore{
+		persistantStore,
+		chunkstore.NewChunkService(generation.NewTestGenerator(0)),
+	}
+	for _, readStore := range readStores {
+		go readStore.Serve()
+	}
+
+	store = chunkstore.NewChunkService(chunkstore.NewMultiStore(readStores, persistantStore))
+	go store.Serve()
+
+	return
+}
+
+// newTestSavingShard is like newTestShards, but creates a single shard that
+// saves its chunks in a new directory, which must be removed by the caller.
+func newTestSavingShard(t *testing.T, loc ShardXz) (shard *ChunkShard, worldPath string) {
+	store, worldPath := newTestSavingStore(t)
+
+	entityMgr := new(entity.EntityManager)
+	entityMgr.Init()
+	connecter := make(testShardConnecter)
+
+	shard = NewChunkShard(connecter, store, entityMgr, loc)
+	connecter[loc.Key()] = shard
+
+	return
+}
+
+// tickUntilUnloaded ticks the shard until the chunk is unloaded, failing the
+// test if it stays loaded.
+func tickUntilUnloaded(t *testing.T, shard *ChunkShard, loc ChunkXz) {
+	chunkIndex, _, _, _ := shard.chunkIndexAndRelLoc(loc)
+	for i := 0; i < 500; i++ {
+		if shard.chunks[chunkIndex] == nil {
+			return
+		}
+		shard.tick()
+	}
+	t.Fatalf("Expected chunk %v to be unloaded", loc)
+}
+
+func TestIdleChunkSavedAndReloaded(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	blockLoc := BlockXyz{8, 5, 8}
+	testSetBlock(t, shard, blockLoc, testBlockIdTorch)
+
+	tickUntilUnloaded(t, shard, chunkLoc)
+
+	chunk := shard.chunkAt(chunkLoc)
+	if chunk == nil {
+		t.Fatalf("Expected chunk %v to be reloaded", chunkLoc)
+	}
+	if chunk.storeDirty {
+		t.Errorf("Expected reloaded chunk to be unchanged since it was saved")
+	}
+	if blockId := testLoadedBlockIdAt(t, shard, blockLoc); blockId != testBlockIdTorch {
+		t.Errorf("Expected saved torch at %v, got block %d", blockLoc, blockId)
+	}
+}
+
+func TestChunkNotUnloadedWhileWatched(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	player := newTestPlayerClient(1)
+	shard.chunkAt(chunkLoc).reqSubscribeChunk(1, player, false)
+
+	tickTestShards([]*ChunkShard{shard}, 50)
+
+	if chunk, _, _ := shard.loadedBlock(&BlockXyz{8, 5, 8}); chunk == nil {
+		t.Errorf("Expected watched chunk %v to stay loaded", chunkLoc)
+	}
+}
+
+func TestDirtyChunkNotUnloadedWithoutSaving(t *testing.T) {
+	shards := newTestShards(ShardXz{0, 0})
+	shard := shards[0]
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	testSetBlock(t, shard, BlockXyz{8, 5, 8}, testBlockIdTorch)
+	tickTestShards(shards, 50)
+
+	if chunk, _, _ := shard.loadedBlock(&BlockXyz{8, 5, 8}); chunk == nil {
+		t.Errorf("Expected chunk %v that can't be saved to stay loaded", chunkLoc)
+	}
+}
+
+func TestActiveBlocksDeferredUntilChunkLoaded(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	shard.chunkAt(chunkLoc)
+	tickUntilUnloaded(t, shard, chunkLoc)
+
+	// Blocks made active from another shard or chunk are not dropped.
+	blockLoc := BlockXyz{8, 5, 8}
+	shard.reqSetBlocksActive([]BlockXyz{blockLoc})
+	if chunk, _, _ := shard.loadedBlock(&blockLoc); chunk != nil {
+		t.Fatalf("Expected chunk %v to stay unloaded", chunkLoc)
+	}
+
+	shard.chunkAt(chunkLoc)
+	chunk, index, _ := shard.loadedBlock(&blockLoc)
+	if !chunk.newActiveBlocks[index] {
+		t.Errorf("Expected block %v to be active once loaded", blockLoc)
+	}
+}
+
+func TestLightUpdateDeferredUntilChunkLoaded(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+
+	chunkLoc := ChunkXz{0, 0}
+	digTestTunnel(t, shard, 0, 4)
+	tickUntilUnloaded(t, shard, chunkLoc)
+
+	// A torch lit just outside the shard lights the tunnel.
+	blockLoc := BlockXyz{0, 5, 8}
+	shard.reqUpdateLight([]gamerules.LightUpdate{
+		{Block: blockLoc, Level: 14},
+	})
+	if chunk, _, _ := shard.loadedBlock(&blockLoc); chunk != nil {
+		t.Fatalf("Expected chunk %v to stay unloaded", chunkLoc)
+	}
+
+	shard.chunkAt(chunkLoc)
+	chunk, index, _ := shard.loadedBlock(&blockLoc)
+	if level := chunk.lightLevel(index, false); level != 13 {
+		t.Errorf("Expected light level 13 at %v once loaded, got %d", blockLoc, level)
+	}
+}
+
+func TestIdleShardReleased(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.shardIdleTicks = 5
+
+	host := &testShardHost{release: true}
+	shard.shardConnecter = host
+
+	tickTestShards([]*ChunkShard{shard}, 4)
+	if host.asked {
+		t.Fatal("Expected shard not to ask to be released before it has been idle long enough")
+	}
+
+	tickTestShards([]*ChunkShard{shard}, 1)
+
+	if !host.released {
+		t.Fatal("Expected idle shard to be released")
+	}
+	if !shard.stopped {
+		t.Error("Expected released shard to stop")
+	}
+}
+
+func TestIdleShardPerformsDeferredWorkBeforeRelease(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.chunkIdleTicks = 5
+	shard.shardIdleTicks = 5
+
+	host := &testShardHost{release: true}
+	shard.shardConnecter = host
+
+	blockLoc := BlockXyz{0, 5, 8}
+	shard.reqUpdateLight([]gamerules.LightUpdate{
+		{Block: blockLoc, Level: 14},
+	})
+	tickTestShards([]*ChunkShard{shard}, 6)
+
+	if host.asked {
+		t.Fatal("Expected shard with deferred work not to ask to be released")
+	}
+	if chunk, _, _ := shard.loadedBlock(&blockLoc); chunk == nil {
+		t.Fatal("Expected chunk with deferred work to be loaded")
+	}
+	if len(shard.deferredWork) != 0 {
+		t.Errorf("Expected deferred work to be performed, got %d chunks", len(shard.deferredWork))
+	}
+}
+
+func TestIdleShardKeptIfHostRefuses(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.shardIdleTicks = 5
+
+	host := &testShardHost{release: false}
+	shard.shardConnecter = host
+	tickTestShards([]*ChunkShard{shard}, 20)
+
+	if !host.asked {
+		t.Error("Expected idle shard to ask to be released")
+	}
+	if shard.stopped {
+		t.Error("Expected shard to keep serving if not released")
+	}
+}
+
+// testDirtyChunks returns the number of loaded chunks in the shard that have
+// changed since they were last saved.
+func testDirtyChunks(shard *ChunkShard) (count int) {
+	for _, chunk := range shard.chunks {
+		if chunk != nil && chunk.storeDirty {
+			count++
+		}
+	}
+	return
+}
+
+func TestChunkSavesLimitedBySaveBudget(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+
+	for x := BlockCoord(8); x < 64; x += 16 {
+		testSetBlock(t, shard, BlockXyz{x, 5, 8}, testBlockIdTorch)
+	}
+	dirty := testDirtyChunks(shard)
+	if dirty < 4 {
+		t.Fatalf("Expected at least 4 changed chunks, got %d", dirty)
+	}
+
+	backlog := expVarChunkSaveBacklog.Value()
+	count := expVarChunkSaveCount.Value()
+	timeNs := expVarChunkSaveTimeNs.Value()
+
+	shard.queueSaves()
+	if n := expVarChunkSaveBacklog.Value() - backlog; n != int64(dirty) {
+		t.Errorf("Expected save backlog to grow by %d, got %d", dirty, n)
+	}
+
+	// At least one chunk is saved each tick, however small the budget.
+	shard.saveBudget = 0
+	shard.saveQueued()
+	if n := testDirtyChunks(shard); n != dirty-1 {
+		t.Errorf("Expected one chunk to be saved within the budget, %d of %d still changed", n, dirty)
+	}
+	if n := expVarChunkSaveCount.Value() - count; n != 1 {
+		t.Errorf("Expected save count to grow by 1, got %d", n)
+	}
+	if expVarChunkSaveTimeNs.Value() <= timeNs {
+		t.Errorf("Expected save time to grow")
+	}
+	if n := expVarChunkSaveBacklog.Value() - backlog; n != int64(dirty-1) {
+		t.Errorf("Expected save backlog of %d, got %d", dirty-1, n)
+	}
+
+	shard.saveBudget = time.Hour
+	shard.saveQueued()
+	if n := testDirtyChunks(shard); n != 0 {
+		t.Errorf("Expected all chunks to be saved within a large budget, %d still changed", n)
+	}
+	if n := expVarChunkSaveCount.Value() - count; n != int64(dirty) {
+		t.Errorf("Expected save count to grow by %d, got %d", dirty, n)
+	}
+	if n := expVarChunkSaveBacklog.Value(); n != backlog {
+		t.Errorf("Expected save backlog to return to %d, got %d", backlog, n)
+	}
+}
+
+func TestSaveAllIgnoresSaveBudget(t *testing.T) {
+	shard, worldPath := newTestSavingShard(t, ShardXz{0, 0})
+	defer os.RemoveAll(worldPath)
+	shard.saveBudget = 0
+
+	for x := BlockCoord(8); x < 64; x += 16 {
+		testSetBlock(t, shard, BlockXyz{x, 5, 8}, testBlockIdTorch)
+	}
+	backlog := expVarChunkSaveBacklog.Value()
+	shard.queueSaves()
+
+	shard.saveAll()
+	if n := testDirtyChunks(shard); n != 0 {
+		t.Errorf("Expected all chunks to be saved, %d still changed", n)
+	}
+	if len(shard.saveQueue) != 0 {
+		t.Errorf("Expected save queue to be emptied, got %d chunks", len(shard.saveQueue))
+	}
+	if n := expVarChunkSaveBacklog.Value(); n != backlog {
+		t.Errorf("Expected save backlog to return to %d, got %d", backlog, n)
+	}
+}
+
+// testShardHost is an iShardHost that releases shards if release is set.
+type testShardHost struct {
+	testShardConnecter
+	release  bool
+	asked    bool
+	released bool
+}
+
+func (host *testShardHost) releaseShard(shard *ChunkShard) bool {
+	host.asked = true
+	host.released = host.release
+	return host.release
+}
@@ -0,0 +1,442 @@
+package shardserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/huin/chunkymonkey/chunkstore"
+	"github.com/huin/chunkymonkey/entity"
+	"github.com/huin/chunkymonkey/gamerules"
+	"github.com/huin/chunkymonkey/generation"
+	"github.com/huin/chunkymonkey/proto"
+	. "github.com/huin/chunkymonkey/types"
+)
+
+const testEventTimeout = 5 * time.Second
+
+func init() {
+	if err := gamerules.LoadGameRules("../blocks.json", "../items.json", "../recipes.json", "../furnace.json", "../users.json", "../groups.json"); err != nil {
+		panic(err)
+	}
+}
+
+func newTestShardManager() *LocalShardManager {
+	store := chunkstore.NewChunkService(generation.NewTestGenerator(0))
+	go store.Serve()
+
+	entityMgr := new(entity.EntityManager)
+	entityMgr.Init()
+
+	return NewLocalShardManager(store, entityMgr)
+}
+
+// forEachConnecter runs the test function against shards hosted in-process,
+// and against the same shards served over the loopback interface.
+func forEachConnecter(t *testing.T, test func(t *testing.T, connecter gamerules.IShardConnecter)) {
+	t.Log("Testing LocalShardManager")
+	test(t, newTestShardManager())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	server := NewShardServer(listener, newTestShardManager())
+	go server.Serve()
+
+	t.Log("Testing RemoteShardConnecter")
+	test(t, NewRemoteShardConnecter(listener.Addr().String()))
+}
+
+type testPacketEvent struct {
+	packet []byte
+}
+
+type testNotifyChunkLoadEvent struct{}
+
+type testGiveItemEvent struct {
+	position AbsXyz
+	item     gamerules.Slot
+}
+
+type testHurtEvent struct {
+	amount Health
+}
+
+type testSurroundingsEvent struct {
+	surroundings gamerules.Surroundings
+}
+
+type testRejectMoveEvent struct {
+	position AbsXyz
+}
+
+type testEntityDestroyEvent struct {
+	entityId EntityId
+}
+
+type testInventoryUnsubscribedEvent struct {
+	block BlockXyz
+}
+
+type testSetPositionLookEvent struct {
+	position AbsXyz
+	look     LookDegrees
+}
+
+// testPlayerClient implements IPlayerClient, recording calls upon it as events.
+type testPlayerClient struct {
+	entityId EntityId
+	events   chan interface{}
+}
+
+func newTestPlayerClient(entityId EntityId) *testPlayerClient {
+	return &testPlayerClient{
+		entityId: entityId,
+		events:   make(chan interface{}, 1024),
+	}
+}
+
+// waitFor returns the first event for which match returns true, discarding
+// other events. It returns nil if no such event arrives in time.
+func (p *testPlayerClient) waitFor(match func(event interface{}) bool) interface{} {
+	timeout := time.After(testEventTimeout)
+	for {
+		select {
+		case event := <-p.events:
+			if match(event) {
+				return event
+			}
+		case <-timeout:
+			return nil
+		}
+	}
+}
+
+// waitForPacket waits for a packet starting with the given packet ID.
+func (p *testPlayerClient) waitForPacket(packetId byte) []byte {
+	event := p.waitFor(func(event interface{}) bool {
+		e, ok := event.(testPacketEvent)
+		return ok && len(e.packet) > 0 && e.packet[0] == packetId
+	})
+	if event == nil {
+		return nil
+	}
+	return event.(testPacketEvent).packet
+}
+
+func (p *testPlayerClient) GetEntityId() EntityId {
+	return p.entityId
+}
+
+func (p *testPlayerClient) GetName() string {
+	return ""
+}
+
+func (p *testPlayerClient) TransmitPacket(packet []byte) {
+	p.events <- testPacketEvent{packet}
+}
+
+func (p *testPlayerClient) NotifyChunkLoad() {
+	p.events <- testNotifyChunkLoadEvent{}
+}
+
+// EntitySpawn records the spawn packet as a packet event.
+func (p *testPlayerClient) EntitySpawn(chunkLoc ChunkXz, entityId EntityId, spawnPacket []byte) {
+	p.events <- testPacketEvent{spawnPacket}
+}
+
+func (p *testPlayerClient) EntityMoved(fromChunkLoc, toChunkLoc ChunkXz, entityId EntityId) {
+}
+
+func (p *testPlayerClient) EntityDestroy(chunkLoc ChunkXz, entityId EntityId) {
+	p.events <- testEntityDestroyEvent{entityId}
+}
+
+func (p *testPlayerClient) InventorySubscribed(block BlockXyz, invTypeId InvTypeId, slots []proto.WindowSlot) {
+}
+
+func (p *testPlayerClient) InventorySlotUpdate(block BlockXyz, slot gamerules.Slot, slotId SlotId) {
+}
+
+func (p *testPlayerClient) InventoryProgressUpdate(block BlockXyz, prgBarId PrgBarId, value PrgBarValue) {
+}
+
+func (p *testPlayerClient) InventoryCursorUpdate(block BlockXyz, cursor gamerules.Slot) {
+}
+
+func (p *testPlayerClient) InventoryTxState(block BlockXyz, txId TxId, accepted bool) {
+}
+
+func (p *testPlayerClient) InventoryUnsubscribed(block BlockXyz) {
+	p.events <- testInventoryUnsubscribedEvent{block}
+}
+
+func (p *testPlayerClient) PlaceHeldItem(target BlockXyz, wasHeld gamerules.Slot) {
+}
+
+func (p *testPlayerClient) WearHeldTool(wasHeld gamerules.Slot, uses ItemData) {
+}
+
+func (p *testPlayerClient) OfferItem(fromChunk ChunkXz, entityId EntityId, item gamerules.Slot) {
+}
+
+func (p *testPlayerClient) GiveItemAtPosition(atPosition AbsXyz, item gamerules.Slot) {
+	p.events <- testGiveItemEvent{atPosition, item}
+}
+
+func (p *testPlayerClient) GiveItem(item gamerules.Slot) {
+}
+
+func (p *testPlayerClient) Hurt(amount Health) {
+	p.events <- testHurtEvent{amount}
+}
+
+func (p *testPlayerClient) SetSurroundings(surroundings gamerules.Surroundings) {
+	p.events <- testSurroundingsEvent{surroundings}
+}
+
+func (p *testPlayerClient) RejectMove(position AbsXyz) {
+	p.events <- testRejectMoveEvent{position}
+}
+
+func (p *testPlayerClient) SetBed(bed BlockXyz) {
+}
+
+func (p *testPlayerClient) PositionLook() (AbsXyz, LookDegrees) {
+	return AbsXyz{}, LookDegrees{}
+}
+
+func (p *testPlayerClient) SetPositionLook(position AbsXyz, look LookDegrees) {
+	p.events <- testSetPositionLookEvent{position, look}
+}
+
+func (p *testPlayerClient) SetGameType(gameType GameType) {
+}
+
+func (p *testPlayerClient) EchoMessage(msg string) {
+}
+
+// itemSpawnEntityId extracts the entity ID from an item spawn packet.
+func itemSpawnEntityId(packet []byte) (entityId EntityId) {
+	binary.Read(bytes.NewBuffer(packet[1:]), binary.BigEndian, &entityId)
+	return
+}
+
+func subscribeAndWait(t *testing.T, player *testPlayerClient, client gamerules.IPlayerShardClient, loc ChunkXz) {
+	client.ReqSubscribeChunk(loc, true)
+
+	if player.waitForPacket(proto.PacketIdPreChunk) == nil {
+		t.Fatalf("Expected pre-chunk packet for %+v", loc)
+	}
+	if player.waitForPacket(proto.PacketIdMapChunk) == nil {
+		t.Fatalf("Expected map chunk packet for %+v", loc)
+	}
+	event := player.waitFor(func(event interface{}) bool {
+		_, ok := event.(testNotifyChunkLoadEvent)
+		return ok
+	})
+	if event == nil {
+		t.Fatalf("Expected NotifyChunkLoad for %+v", loc)
+	}
+}
+
+func TestSubscribeChunk(t *testing.T) {
+	forEachConnecter(t, func(t *testing.T, connecter gamerules.IShardConnecter) {
+		loc := ChunkXz{1, 2}
+		player := newTestPlayerClient(1)
+		client := connecter.PlayerShardConnect(1, player, loc.ToShardXz())
+		defer client.Disconnect()
+
+		subscribeAndWait(t, player, client, loc)
+	})
+}
+
+func TestDropAndTakeItem(t *testing.T) {
+	forEachConnecter(t, func(t *testing.T, connecter gamerules.IShardConnecter) {
+		loc := ChunkXz{0, 0}
+		player := newTestPlayerClient(1)
+		client := connecter.PlayerShardConnect(1, player, loc.ToShardXz())
+		defer client.Disconnect()
+
+		subscribeAndWait(t, player, client, loc)
+
+		content := gamerules.Slot{ItemTypeId: 4, Count: 3, Data: 0}
+		client.ReqDropItem(content, AbsXyz{8, 120, 8}, AbsVelocity{}, 0)
+
+		packet := player.waitForPacket(proto.PacketIdItemSpawn)
+		if packet == nil {
+			t.Fatal("Expected item spawn packet")
+		}
+
+		client.ReqTakeItem(loc, itemSpawnEntityId(packet))
+
+		event := player.waitFor(func(event interface{}) bool {
+			_, ok := event.(testGiveItemEvent)
+			return ok
+		})
+		if event == nil {
+			t.Fatal("Expected item to be given")
+		}
+		if given := event.(testGiveItemEvent).item; given != content {
+			t.Errorf("Expected to be given %+v, got %+v", content, given)
+		}
+	})
+}
+
+func TestTransferEntity(t *testing.T) {
+	forEachConnecter(t, func(t *testing.T, connecter gamerules.IShardConnecter) {
+		loc := ChunkXz{3, 4}
+
+		item := gamerules.NewItem(4, 1, 0, &AbsXyz{56, 120, 72}, &AbsVelocity{}, 0)
+		item.SetEntityId(1000)
+
+		shardClient := connecter.ShardShardConnect(loc.ToShardXz())
+		shardClient.ReqTransferEntity(loc, item)
+		defer shardClient.Disconnect()
+
+		player := newTestPlayerClient(1)
+		client := connecter.PlayerShardConnect(1, player, loc.ToShardXz())
+		defer client.Disconnect()
+
+		// Requests made while the client is connecting are dropped, apart from
+		// those that change the player's state in the shard.
+		subscribeAndWait(t, player, client, loc)
+		client.ReqUnsubscribeChunk(loc)
+
+		// The subscription is on a different connection to the transfer, so
+		// retry until the transfer has been seen. Packets between the map chunk
+		// and the unload pre-chunk packet are for the chunk's entities.
+		var packet []byte
+		for i := 0; i < 10 && packet == nil; i++ {
+			client.ReqSubscribeChunk(loc, false)
+			client.ReqUnsubscribeChunk(loc)
+
+			if player.waitForPacket(proto.PacketIdMapChunk) == nil {
+				t.Fatal("Expected map chunk packet")
+			}
+			player.waitFor(func(event interface{}) bool {
+				e, ok := event.(testPacketEvent)
+				if !ok || len(e.packet) == 0 {
+					return false
+				}
+				if e.packet[0] == proto.PacketIdItemSpawn {
+					packet = e.packet
+				}
+				return e.packet[0] == proto.PacketIdPreChunk
+			})
+		}
+
+		if packet == nil {
+			t.Fatal("Expected item spawn packet for transferred entity")
+		}
+		if entityId := itemSpawnEntityId(packet); entityId != 1000 {
+			t.Errorf("Expected entity ID 1000, got %d", entityId)
+		}
+	})
+}
+
+func TestRemoteClientReconnects(t *testing.T) {
+	// The shard's first host has failed, and drops connections straight away.
+	failed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failed.Close()
+	go func() {
+		for {
+			conn, err := failed.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	server := NewShardServer(listener, newTestShardManager())
+	go server.Serve()
+
+	lookup := func(loc ShardXz) (string, error) {
+		return listener.Addr().String(), nil
+	}
+
+	loc := ChunkXz{0, 0}
+	player := newTestPlayerClient(1)
+	client := newRemotePlayerShardClient(failed.Addr().String(), lookup, 1, player, loc.ToShardXz())
+	defer client.Disconnect()
+
+	// The subscription is made again once a request finds that the connection
+	// has failed.
+	client.ReqSubscribeChunk(loc, false)
+	deadline := time.Now().Add(testEventTimeout)
+	for len(player.events) == 0 && time.Now().Before(deadline) {
+		client.ReqMulticastPlayers(loc, 1, []byte{})
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if player.waitForPacket(proto.PacketIdMapChunk) == nil {
+		t.Fatal("Expected chunk to be subscribed to after reconnecting")
+	}
+}
+
+func TestRemoteClientDoesntWaitForLookup(t *testing.T) {
+	release := make(chan bool)
+	defer close(release)
+	lookup := func(loc ShardXz) (string, error) {
+		<-release
+		return "", errors.New("lookup failed")
+	}
+
+	done := make(chan bool)
+	go func() {
+		client := newRemoteShardShardClient("", lookup, ShardXz{0, 0})
+		client.ReqSetActiveBlocks([]BlockXyz{{0, 0, 0}})
+		client.Disconnect()
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(testEventTimeout):
+		t.Fatal("Expected requests not to wait for the shard to be looked up")
+	}
+}
+
+func TestMsgSenderFailsWhenQueueFull(t *testing.T) {
+	// Nothing reads from the other end of the pipe, so the first message is
+	// never written and the queue fills up behind it.
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	sender := newMsgSender(conn)
+	defer sender.close()
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < msgQueueSize+2; i++ {
+			sender.send(&msgEchoMessage{"hello"})
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(testEventTimeout):
+		t.Fatal("Expected send not to block when the queue is full")
+	}
+
+	if !sender.hasFailed() {
+		t.Error("Expected sender to have failed when its queue overflowed")
+	}
+}
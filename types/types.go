@@ -126,6 +126,14 @@ var MobNameByType = map[EntityMobType]string{}
 
 type EntityStatus byte
 
+const (
+	EntityStatusHurt = EntityStatus(2)
+	EntityStatusDead = EntityStatus(3)
+
+	// Sent to a player when they finish eating.
+	EntityStatusEatingAccepted = EntityStatus(9)
+)
+
 type EntityAnimation byte
 
 const (
@@ -286,8 +294,10 @@ type DigStatus byte
 
 const (
 	DigStarted    = DigStatus(0)
+	DigAborted    = DigStatus(1) // Stops digging before the block breaks.
 	DigBlockBroke = DigStatus(2)
 	DigDropItem   = DigStatus(4)
+	DigReleaseUse = DigStatus(5) // Stops eating or drawing a bow.
 )
 
 const (
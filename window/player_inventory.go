@@ -3,7 +3,6 @@ package window
 import (
 	"errors"
 	"fmt"
-	"io"
 
 	"github.com/huin/chunkymonkey/gamerules"
 	"github.com/huin/chunkymonkey/nbt"
@@ -24,6 +23,7 @@ type PlayerInventory struct {
 	main         gamerules.Inventory
 	holding      gamerules.Inventory
 	holdingIndex SlotId
+	gameType     GameType
 }
 
 // Init initializes PlayerInventory.
@@ -48,7 +48,17 @@ func (w *PlayerInventory) Init(entityId EntityId, viewer IWindowViewer) {
 		&w.main,
 		&w.holding,
 	)
+	// Items move from the crafting grid and armor to the main and holding
+	// inventories, and between those two.
+	w.shiftClickTo = [][]int{{2, 3}, {2, 3}, {3}, {2}}
 	w.holdingIndex = 0
+	w.gameType = GameTypeSurvival
+}
+
+// SetGameType sets the game mode of the player who holds the inventory.
+// Creative players have an endless supply of the items that they hold.
+func (w *PlayerInventory) SetGameType(gameType GameType) {
+	w.gameType = gameType
 }
 
 // Resubscribe should be called when another window has potentially been
@@ -96,26 +106,66 @@ func (w *PlayerInventory) HeldItem() (slot gamerules.Slot, slotId SlotId) {
 
 // TakeOneHeldItem takes one item from the stack of items the player is holding
 // and puts it in `into`. It does nothing if the player is holding no items, or
-// if `into` cannot take any items of that type.
+// if `into` cannot take any items of that type. Creative players keep the item.
 func (w *PlayerInventory) TakeOneHeldItem(into *gamerules.Slot) {
+	if w.gameType == GameTypeCreative {
+		held := w.holding.Slot(w.holdingIndex)
+		into.AddOne(&held)
+		return
+	}
 	w.holding.TakeOneItem(w.holdingIndex, into)
 }
 
-// Writes packets for other players to see the equipped items.
-func (w *PlayerInventory) SendFullEquipmentUpdate(writer io.Writer) (err error) {
-	slot, _ := w.HeldItem()
-	err = slot.SendEquipmentUpdate(writer, w.entityId, 0)
-	if err != nil {
+// WearHeldItem wears down the tool that the player is holding by a number of
+// uses. Creative players' tools don't wear.
+func (w *PlayerInventory) WearHeldItem(uses ItemData) {
+	if w.gameType == GameTypeCreative {
 		return
 	}
+	w.holding.WearItem(w.holdingIndex, uses)
+}
+
+// CreativeSetSlot puts an item from the creative inventory into the window
+// slot, replacing whatever was there. Empty or negative counts clear the slot.
+// It returns false if the player isn't in creative mode, or if the slot or
+// item aren't valid.
+func (w *PlayerInventory) CreativeSetSlot(slotId SlotId, slot gamerules.Slot) (ok bool) {
+	if w.gameType != GameTypeCreative {
+		return false
+	}
 
-	numArmor := w.armor.NumSlots()
-	for i := SlotId(0); i < numArmor; i++ {
-		slot := w.armor.Slot(i)
-		err = slot.SendEquipmentUpdate(writer, w.entityId, SlotId(i+1))
-		if err != nil {
-			return
+	if slot.ItemTypeId <= 0 || slot.Count <= 0 {
+		slot.Clear()
+	} else if !slot.IsValidType() {
+		return false
+	} else if maxStack := slot.MaxStack(); slot.Count > maxStack {
+		slot.Count = maxStack
+	}
+
+	for _, view := range w.Window.views {
+		if slotId < view.startSlot || slotId >= view.endSlot {
+			continue
 		}
+		// The crafting grid isn't set directly, as its output depends on it.
+		inv, ok := view.inventory.(*gamerules.Inventory)
+		if !ok {
+			return false
+		}
+		inv.SetSlot(slotId-view.startSlot, &slot)
+		return true
+	}
+
+	return false
+}
+
+// Equipment returns the items that other players see the player holding and
+// wearing.
+func (w *PlayerInventory) Equipment() (equipment [gamerules.NumEquipmentSlots]gamerules.Slot) {
+	equipment[gamerules.EquipmentSlotHeld], _ = w.HeldItem()
+
+	// The armor slots are in order from head to feet.
+	for i := SlotId(0); i < playerInvArmorNum; i++ {
+		equipment[gamerules.EquipmentSlotHelmet-i] = w.armor.Slot(i)
 	}
 	return
 }
@@ -123,8 +173,16 @@ func (w *PlayerInventory) SendFullEquipmentUpdate(writer io.Writer) (err error)
 // PutItem attempts to put the item stack into the player's inventory. The item
 // will be modified as a result.
 func (w *PlayerInventory) PutItem(item *gamerules.Slot) {
-	w.holding.PutItem(item)
-	w.main.PutItem(item)
+	gamerules.InventoryGroup{&w.holding, &w.main}.PutItem(item)
+}
+
+// TakeAllItems empties the inventory, including the crafting grid, and
+// returns all items that were inside it.
+func (w *PlayerInventory) TakeAllItems() (items []gamerules.Slot) {
+	items = append(items, w.crafting.TakeAllItems()...)
+	items = append(items, w.armor.TakeAllItems()...)
+	items = append(items, w.main.TakeAllItems()...)
+	items = append(items, w.holding.TakeAllItems()...)
 	return
 }
 
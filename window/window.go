@@ -14,6 +14,7 @@ import (
 type IInventory interface {
 	NumSlots() SlotId
 	Click(click *gamerules.Click) (txState TxState)
+	ShiftClick(click *gamerules.Click, target gamerules.ITransferTarget) (txState TxState)
 	SetSubscriber(subscriber gamerules.IInventorySubscriber)
 	WriteProtoSlots(slots []proto.WindowSlot)
 }
@@ -83,6 +84,10 @@ type Window struct {
 	views     []inventoryView
 	title     string
 	numSlots  SlotId
+
+	// shiftClickTo holds, for each view, the views that items shift-clicked in
+	// it are moved to.
+	shiftClickTo [][]int
 }
 
 // NewWindow creates a Window as a view onto the given inventories.
@@ -109,6 +114,23 @@ func (w *Window) Init(windowId WindowId, invTypeId InvTypeId, viewer IWindowView
 	}
 	w.numSlots = startSlot
 
+	// Items move between the first inventory and the player's inventory. The
+	// crafting grid doesn't take shift-clicked items, so the player's items
+	// move between the main and holding inventories instead.
+	w.shiftClickTo = make([][]int, len(inventories))
+	for index := range inventories {
+		switch {
+		case index == 0:
+			for other := 1; other < len(inventories); other++ {
+				w.shiftClickTo[index] = append(w.shiftClickTo[index], other)
+			}
+		case invTypeId == InvTypeIdWorkbench && len(inventories) == 3:
+			w.shiftClickTo[index] = []int{3 - index}
+		default:
+			w.shiftClickTo[index] = []int{0}
+		}
+	}
+
 	return
 }
 
@@ -157,13 +179,18 @@ func (w *Window) WriteWindowItems(writer io.Writer) (err error) {
 
 func (w *Window) Click(click *gamerules.Click) TxState {
 	if click.SlotId >= 0 {
-		for _, inventoryView := range w.views {
+		for index, inventoryView := range w.views {
 
 			if click.SlotId >= inventoryView.startSlot && click.SlotId < inventoryView.endSlot {
 				invClick := *click
 				invClick.SlotId = click.SlotId - inventoryView.startSlot
 
-				result := inventoryView.inventory.Click(&invClick)
+				var result TxState
+				if click.ShiftClick {
+					result = inventoryView.inventory.ShiftClick(&invClick, w.transferTarget(w.shiftClickTo[index]))
+				} else {
+					result = inventoryView.inventory.Click(&invClick)
+				}
 
 				click.Cursor = invClick.Cursor
 
@@ -174,3 +201,23 @@ func (w *Window) Click(click *gamerules.Click) TxState {
 
 	return TxStateRejected
 }
+
+// transferTarget returns the target that shift-clicked items are moved to in
+// the given views. Several views are only grouped together if they are all
+// plain inventories.
+func (w *Window) transferTarget(viewIndexes []int) gamerules.ITransferTarget {
+	if len(viewIndexes) == 1 {
+		if target, ok := w.views[viewIndexes[0]].inventory.(gamerules.ITransferTarget); ok {
+			return target
+		}
+		return gamerules.InventoryGroup(nil)
+	}
+
+	group := make(gamerules.InventoryGroup, 0, len(viewIndexes))
+	for _, index := range viewIndexes {
+		if inv, ok := w.views[index].inventory.(*gamerules.Inventory); ok {
+			group = append(group, inv)
+		}
+	}
+	return group
+}
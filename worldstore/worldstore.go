@@ -24,9 +24,11 @@ type WorldStore struct {
 	Seed int64
 	Time Ticks
 
-	LevelData     nbt.ITag
-	ChunkStore    chunkstore.IChunkStore
-	SpawnPosition BlockXyz
+	LevelData        nbt.ITag
+	ChunkStore       chunkstore.IChunkStore
+	NetherChunkStore chunkstore.IChunkStore // nil if the world has no Nether.
+	SpawnPosition    BlockXyz
+	GameType         GameType // Game mode that new players start in.
 }
 
 func LoadWorldStore(worldPath string) (world *WorldStore, err error) {
@@ -56,15 +58,11 @@ func LoadWorldStore(worldPath string) (world *WorldStore, err error) {
 		timeTicks = Ticks(timeTag.Value)
 	}
 
-	var chunkStores []chunkstore.IChunkStore
-	persistantChunkStore, err := chunkstore.ChunkStoreForLevel(worldPath, levelData, DimensionNormal)
-	if err != nil {
-		return nil, err
+	gameType := GameTypeSurvival
+	if gameTypeTag, ok := levelData.Lookup("Data/GameType").(*nbt.Int); ok {
+		gameType = GameType(gameTypeTag.Value)
 	}
 
-	persistantChunkService := chunkstore.NewChunkService(persistantChunkStore)
-	chunkStores = append(chunkStores, persistantChunkService)
-
 	var seed int64
 	if seedNbt, ok := levelData.Lookup("Data/RandomSeed").(*nbt.Long); ok {
 		seed = seedNbt.Value
@@ -72,22 +70,52 @@ func LoadWorldStore(worldPath string) (world *WorldStore, err error) {
 		seed = time.Now().Unix()
 	}
 
-	chunkStores = append(chunkStores, chunkstore.NewChunkService(generation.NewTestGenerator(seed)))
+	chunkStore, err := newDimensionChunkStore(worldPath, levelData, DimensionNormal, generation.NewTestGenerator(seed))
+	if err != nil {
+		return nil, err
+	}
 
-	for _, store := range chunkStores {
-		go store.Serve()
+	// Worlds in older formats don't have the Nether.
+	netherChunkStore, err := newDimensionChunkStore(worldPath, levelData, DimensionNether, generation.NewNetherGenerator(seed))
+	if err != nil {
+		log.Printf("Nether not available: %v", err)
+		netherChunkStore, err = nil, nil
 	}
 
 	world = &WorldStore{
-		WorldPath:     worldPath,
-		Seed:          seed,
-		Time:          timeTicks,
-		LevelData:     levelData,
-		ChunkStore:    chunkstore.NewChunkService(chunkstore.NewMultiStore(chunkStores, persistantChunkService)),
-		SpawnPosition: spawnPosition,
+		WorldPath:        worldPath,
+		Seed:             seed,
+		Time:             timeTicks,
+		LevelData:        levelData,
+		ChunkStore:       chunkStore,
+		NetherChunkStore: netherChunkStore,
+		SpawnPosition:    spawnPosition,
+		GameType:         gameType,
+	}
+
+	return
+}
+
+// newDimensionChunkStore returns a running chunk store for a dimension of the
+// world. Chunks that have not been saved are made by generator.
+func newDimensionChunkStore(worldPath string, levelData nbt.ITag, dimension DimensionId, generator chunkstore.IChunkStoreForeground) (store chunkstore.IChunkStore, err error) {
+	persistantChunkStore, err := chunkstore.ChunkStoreForLevel(worldPath, levelData, dimension)
+	if err != nil {
+		return
+	}
+
+	persistantChunkService := chunkstore.NewChunkService(persistantChunkStore)
+	chunkStores := []chunkstore.IChunkStore{
+		persistantChunkService,
+		chunkstore.NewChunkService(generator),
+	}
+
+	for _, store := range chunkStores {
+		go store.Serve()
 	}
 
-	go world.ChunkStore.Serve()
+	store = chunkstore.NewChunkService(chunkstore.NewMultiStore(chunkStores, persistantChunkService))
+	go store.Serve()
 
 	return
 }
@@ -111,8 +139,9 @@ func loadLevelData(worldPath string) (levelData nbt.ITag, err error) {
 	return
 }
 
-// NOTE: ChunkStoreForDimension shouldn't really be used in the server just
-// yet.
+// ChunkStoreForDimension returns a store of only the chunks saved for the
+// dimension. The server uses ChunkStore and NetherChunkStore instead, which
+// generate chunks that have not been saved.
 func (world *WorldStore) ChunkStoreForDimension(dimension DimensionId) (store chunkstore.IChunkStore, err error) {
 	fgStore, err := chunkstore.ChunkStoreForLevel(world.WorldPath, world.LevelData, dimension)
 	if err != nil {
@@ -123,15 +152,42 @@ func (world *WorldStore) ChunkStoreForDimension(dimension DimensionId) (store ch
 	return
 }
 
+// PlayerData reads the saved data for the named player. If the data file is
+// missing or unreadable, the backup of the previous save is read instead. The
+// result is nil if neither exists.
 func (world *WorldStore) PlayerData(user string) (playerData *nbt.Compound, err error) {
-	file, err := os.Open(path.Join(world.WorldPath, "players", user+".dat"))
+	filename := world.playerDataFilename(user)
+	playerData, err = readPlayerData(filename)
+	if playerData != nil && err == nil {
+		return
+	}
+
+	backupData, backupErr := readPlayerData(filename + ".bak")
+	if backupData != nil && backupErr == nil {
+		if err != nil {
+			log.Printf("Player data for %s could not be read (%v), using backup", user, err)
+		} else {
+			log.Printf("Player data for %s is missing, using backup", user)
+		}
+		return backupData, nil
+	}
+
+	return
+}
+
+func (world *WorldStore) playerDataFilename(user string) string {
+	return path.Join(world.WorldPath, "players", user+".dat")
+}
+
+func readPlayerData(filename string) (playerData *nbt.Compound, err error) {
+	file, err := os.Open(filename)
 	if err != nil {
-		//if errno, ok := util.Errno(err); ok && errno == os.ENOENT {
-		// Player data simply doesn't exist. Not an error, playerData = nil is
-		// the result.
-		//	return nil, nil
-		//}
-		return nil, nil
+		if os.IsNotExist(err) {
+			// Player data simply doesn't exist. Not an error, playerData = nil
+			// is the result.
+			return nil, nil
+		}
+		return
 	}
 	defer file.Close()
 
@@ -146,25 +202,66 @@ func (world *WorldStore) PlayerData(user string) (playerData *nbt.Compound, err
 	return
 }
 
+// WritePlayerData saves the data for the named player. The data is written to
+// a temporary file which then replaces the previous save, so that a crash
+// part way through writing can't lose it. The previous save is kept as a
+// backup if it can be read.
 func (world *WorldStore) WritePlayerData(user string, data *nbt.Compound) (err error) {
 	playerDir := path.Join(world.WorldPath, "players")
 	if err = os.MkdirAll(playerDir, 0777); err != nil {
 		return
 	}
 
-	filename := path.Join(world.WorldPath, "players", user+".dat")
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0666)
+	filename := world.playerDataFilename(user)
+	tmpFilename := filename + ".tmp"
+	if err = writePlayerData(tmpFilename, data); err != nil {
+		os.Remove(tmpFilename)
+		return
+	}
+
+	// A corrupt save mustn't replace the last good backup.
+	if previous, readErr := readPlayerData(filename); previous != nil && readErr == nil {
+		if err = os.Rename(filename, filename+".bak"); err != nil {
+			return
+		}
+	}
+
+	if err = os.Rename(tmpFilename, filename); err != nil {
+		return
+	}
+
+	return syncDir(playerDir)
+}
+
+// syncDir writes changes to the entries of a directory, such as renames, to
+// disk.
+func syncDir(dirname string) (err error) {
+	dir, err := os.Open(dirname)
 	if err != nil {
-		return err
+		return
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+func writePlayerData(filename string, data *nbt.Compound) (err error) {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return
 	}
 	defer file.Close()
 
 	gzipWriter := gzip.NewWriter(file)
+	if err = nbt.Write(gzipWriter, data); err != nil {
+		gzipWriter.Close()
+		return
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return
+	}
 
-	err = nbt.Write(gzipWriter, data)
-	gzipWriter.Close()
-
-	return
+	return file.Sync()
 }
 
 // Creates a new world at 'worldPath'
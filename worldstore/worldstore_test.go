@@ -0,0 +1,109 @@
+package worldstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/huin/chunkymonkey/nbt"
+)
+
+func newTestWorldStore(t *testing.T) *WorldStore {
+	worldPath, err := ioutil.TempDir("", "worldstore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &WorldStore{WorldPath: worldPath}
+}
+
+func checkPlayerHealth(t *testing.T, world *WorldStore, expected int16) {
+	data, err := world.PlayerData("steve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data == nil {
+		t.Fatalf("Expected player data with health %d, got none", expected)
+	}
+	if health, ok := data.Lookup("Health").(*nbt.Short); !ok || health.Value != expected {
+		t.Errorf("Expected player data with health %d, got %v", expected, data.Lookup("Health"))
+	}
+}
+
+func TestWritePlayerDataKeepsBackup(t *testing.T) {
+	world := newTestWorldStore(t)
+	defer os.RemoveAll(world.WorldPath)
+
+	if data, err := world.PlayerData("steve"); data != nil || err != nil {
+		t.Fatalf("Expected no data for a new player, got %v, %v", data, err)
+	}
+
+	for _, health := range []int16{20, 15, 10} {
+		data := nbt.NewCompound()
+		data.Set("Health", &nbt.Short{health})
+		if err := world.WritePlayerData("steve", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkPlayerHealth(t, world, 10)
+
+	filename := path.Join(world.WorldPath, "players", "steve.dat")
+	if _, err := os.Stat(filename + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary file to be removed, got %v", err)
+	}
+
+	// The previous save is used if the latest is lost.
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	checkPlayerHealth(t, world, 15)
+}
+
+func TestPlayerDataCorruptUsesBackup(t *testing.T) {
+	world := newTestWorldStore(t)
+	defer os.RemoveAll(world.WorldPath)
+
+	for _, health := range []int16{20, 15} {
+		data := nbt.NewCompound()
+		data.Set("Health", &nbt.Short{health})
+		if err := world.WritePlayerData("steve", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filename := path.Join(world.WorldPath, "players", "steve.dat")
+	if err := ioutil.WriteFile(filename, []byte("not gzipped"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	checkPlayerHealth(t, world, 20)
+}
+
+func TestWritePlayerDataKeepsBackupOverCorruptSave(t *testing.T) {
+	world := newTestWorldStore(t)
+	defer os.RemoveAll(world.WorldPath)
+
+	data := nbt.NewCompound()
+	data.Set("Health", &nbt.Short{20})
+	if err := world.WritePlayerData("steve", data); err != nil {
+		t.Fatal(err)
+	}
+	data.Set("Health", &nbt.Short{15})
+	if err := world.WritePlayerData("steve", data); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := path.Join(world.WorldPath, "players", "steve.dat")
+	if err := ioutil.WriteFile(filename, []byte("not gzipped"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	data.Set("Health", &nbt.Short{10})
+	if err := world.WritePlayerData("steve", data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The corrupt save was dropped rather than replacing the good backup.
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	checkPlayerHealth(t, world, 20)
+}